@@ -0,0 +1,33 @@
+package astits
+
+import (
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/descriptor/ext"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// CapabilitySet lists what the current build parses and serializes, so an
+// embedding application can degrade gracefully and report it to its users.
+type CapabilitySet struct {
+	// Tables are the PSI/SI table ids with a typed parser and writer.
+	Tables []psi.TableID `json:"tables"`
+	// Descriptors are the descriptor tags with a typed parser; others are
+	// carried verbatim as UserDefined or Unknown.
+	Descriptors []descriptor.Tag `json:"descriptors"`
+	// ExtensionDescriptors are the DVB extension_descriptor_tag values with a
+	// typed parser.
+	ExtensionDescriptors []ext.Tag `json:"extension_descriptors"`
+	// PacketSizes are the packet sizes the reader accepts and autodetects.
+	PacketSizes []int `json:"packet_sizes"`
+}
+
+// Capabilities reports the capability set of the current build.
+func Capabilities() CapabilitySet {
+	return CapabilitySet{
+		Tables:               psi.SupportedTableIDs(),
+		Descriptors:          descriptor.SupportedTags(),
+		ExtensionDescriptors: ext.SupportedTags(),
+		PacketSizes:          []int{ts.PacketSize, ts.M2TSPacketSize, ts.RSPacketSize},
+	}
+}
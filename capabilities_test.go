@@ -0,0 +1,22 @@
+package astits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/descriptor/ext"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+func TestCapabilities(t *testing.T) {
+	c := Capabilities()
+	assert.Contains(t, c.Tables, psi.TableIDPMT)
+	assert.Contains(t, c.Tables, psi.TableIDEITStart)
+	assert.NotContains(t, c.Tables, psi.TableIDNull)
+	assert.Contains(t, c.Descriptors, descriptor.TagHierarchy)
+	assert.Contains(t, c.ExtensionDescriptors, ext.TagMessage)
+	assert.IsIncreasing(t, c.Descriptors)
+	assert.Equal(t, []int{188, 192, 204}, c.PacketSizes)
+}
@@ -2,6 +2,7 @@ package demux
 
 import (
 	"encoding/binary"
+	"time"
 
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
 	"github.com/k-danil/go-astits/v2/internal/pidmap"
@@ -29,6 +30,11 @@ type pidSlot struct {
 	afIdx uint8
 	hasAF bool
 	cc    uint8 // CC of the unit's first packet
+	// TP_extra_header of the unit's first packet (192-byte M2TS)
+	extraHeader    uint32
+	hasExtraHeader bool
+	captureTime    time.Time // of the unit's first packet
+	offset, index  int64     // of the unit's first packet
 
 	lastCC         uint8
 	lastHadPayload bool
@@ -38,6 +44,9 @@ type pidSlot struct {
 	started bool
 	isPSI   bool
 	stats   uint32
+
+	scrambledPackets uint32 // payload packets skipped as scrambled
+	scrambled        bool   // the last payload packet was
 }
 
 // accumulator replaces the per-PID packet lists: it owns per-PID slots and
@@ -45,7 +54,15 @@ type pidSlot struct {
 type accumulator struct {
 	slots      pidmap.Map[pidSlot]
 	programMap *pidmap.Map[uint16]
+	parsers    *pidmap.Map[[]sectionParser]
 	dvbTables  bool
+	acceptTEI  bool // TEIParse
+	sniff      bool // PSI or PES by the unit's first bytes, for the read-ahead
+	onCCError  func(CCError)
+	onScramble func(ScramblingChange)
+	ca         *pidmap.Map[caPID]  // WithCASections: CA PIDs, and the CAT
+	cues       *pidmap.Map[cuePID] // WithSCTE35: SCTE 35 PIDs
+	policies   *pidmap.Map[pidPolicy]
 
 	keysArr [packetPoolPreallocPIDs]uint16
 	valsArr [packetPoolPreallocPIDs]pidSlot
@@ -53,63 +70,108 @@ type accumulator struct {
 
 const packetPoolPreallocPIDs = 8
 
-func (a *accumulator) init(programMap *pidmap.Map[uint16], dvbTables bool) {
+func (a *accumulator) init(programMap *pidmap.Map[uint16], parsers *pidmap.Map[[]sectionParser], dvbTables bool) {
 	a.slots = pidmap.Map[pidSlot]{Keys: a.keysArr[:0], Vals: a.valsArr[:0]}
 	a.programMap = programMap
+	a.parsers = parsers
 	a.dvbTables = dvbTables
 }
 
 // unit is a flushed payload unit handed to the parse stage. buf ownership
 // moves to the receiver.
 type unit struct {
-	buf   *dataPayload
-	af    *ts.PacketAdaptationField
-	cc    uint8
-	pid   uint16
-	isPSI bool
+	captureTime    time.Time
+	offset, index  int64
+	buf            *dataPayload
+	af             *ts.PacketAdaptationField
+	extraHeader    uint32
+	cc             uint8
+	pid            uint16
+	isPSI          bool
+	hasExtraHeader bool
+	drained        bool // flushed unfinished, at EOF or by Flush
 }
 
 func (a *accumulator) isPSIPID(pid uint16) bool {
 	return pid == ts.PIDPAT ||
 		a.programMap.Has(pid) ||
+		a.parsers.Has(pid) ||
+		(a.ca != nil && (pid == ts.PIDCAT || a.ca.Has(pid))) ||
+		(a.cues != nil && a.cues.Has(pid)) ||
 		(a.dvbTables && (pid == ts.PIDCAT || pid == ts.PIDTSDT || (pid >= 0x10 && pid <= 0x14) || (pid >= 0x1e && pid <= 0x1f)))
 }
 
+// classify tells whether the unit p starts is PSI: by its PID, or by its first
+// bytes for the read-ahead of WithPipeline, which has no table state.
+func (a *accumulator) classify(p *ts.Packet) bool {
+	if a.sniff {
+		return !isPESPayload(p.Payload)
+	}
+	return a.isPSIPID(p.Header.PID)
+}
+
 // add consumes the packet's payload and appends completed units (zero, one,
 // or — for a torn PSI flushed by the same packet that completes the next
 // section — two) to out. Buffer ownership moves with the units.
 func (a *accumulator) add(p *ts.Packet, out []unit) []unit {
-	if p.Header.TransportErrorIndicator || !p.Header.HasPayload {
+	if (p.Header.TransportErrorIndicator && !a.acceptTEI) || !p.Header.HasPayload {
 		return out
 	}
 
 	slot := a.slots.GetOrAdd(p.Header.PID)
 	slot.stats++
 
+	scrambled := p.Header.TransportScramblingControl != ts.ScramblingControlNotScrambled
+	if scrambled != slot.scrambled {
+		slot.scrambled = scrambled
+		if a.onScramble != nil {
+			a.onScramble(ScramblingChange{PID: p.Header.PID, Control: p.Header.TransportScramblingControl, Scrambled: scrambled, Offset: p.Offset})
+		}
+	}
+
 	// Same packet repeated (retransmission)
 	if slot.seenPacket && p.Header.ContinuityCounter == slot.lastCC && slot.lastHadPayload {
 		return out
 	}
 	// Discontinuity drops the unfinished unit
-	if slot.started && a.discontinuity(slot, p) {
-		slot.release()
+	if slot.seenPacket && a.discontinuity(slot, p) {
+		signalled := p.Header.HasAdaptationField && p.AdaptationField.DiscontinuityIndicator
+		if a.onCCError != nil && !signalled {
+			a.onCCError(CCError{PID: p.Header.PID, Expected: (slot.lastCC + 1) % 16, Got: p.Header.ContinuityCounter, Offset: p.Offset})
+		}
+		if signalled || !a.tolerateCC(p.Header.PID) {
+			slot.release()
+		}
 	}
 	slot.lastCC = p.Header.ContinuityCounter
 	slot.lastHadPayload = p.Header.HasPayload
 	slot.seenPacket = true
 
+	// A payload left scrambled (no descrambler) is not demuxed: it completes
+	// the unit before it, and drops the unit it belongs to
+	if scrambled {
+		slot.scrambledPackets++
+		if p.Header.PayloadUnitStartIndicator && slot.started {
+			if u, ok := slot.flush(p.Header.PID); ok {
+				out = append(out, u)
+			}
+		}
+		slot.release()
+		return out
+	}
+
 	if p.Header.PayloadUnitStartIndicator {
 		if slot.started {
 			if u, ok := slot.flush(p.Header.PID); ok {
 				out = append(out, u)
 			}
 		}
-		slot.start(p, a.isPSIPID(p.Header.PID))
+		slot.start(p, a.classify(p))
 	} else if !slot.started {
 		// A headless prefix (stream picked up mid-unit) accumulates too and
 		// flushes on the next PayloadUnitStartIndicator, matching the packet
 		// list behavior; the parse stage rejects it if it is garbage.
-		slot.start(p, a.isPSIPID(p.Header.PID))
+		slot.start(p, a.classify(p))
 	}
 
 	slot.append(p.Payload)
@@ -124,6 +186,16 @@ func (a *accumulator) add(p *ts.Packet, out []unit) []unit {
 	return out
 }
 
+// tolerateCC tells whether the policy of pid keeps its unit across a
+// continuity counter error.
+func (a *accumulator) tolerateCC(pid uint16) bool {
+	if a.policies == nil {
+		return false
+	}
+	p := a.policies.Get(pid)
+	return p != nil && p.TolerateCC
+}
+
 func (a *accumulator) discontinuity(slot *pidSlot, p *ts.Packet) bool {
 	if p.Header.HasAdaptationField && p.AdaptationField.DiscontinuityIndicator {
 		return true
@@ -139,6 +211,12 @@ func (s *pidSlot) start(p *ts.Packet, isPSI bool) {
 	s.started = true
 	s.isPSI = isPSI
 	s.cc = p.Header.ContinuityCounter
+	s.captureTime = p.CaptureTime
+	s.offset, s.index = p.Offset, p.Index
+	s.extraHeader, s.hasExtraHeader = 0, len(p.Prefix) == 4
+	if s.hasExtraHeader {
+		s.extraHeader = binary.BigEndian.Uint32(p.Prefix)
+	}
 	if p.Header.HasAdaptationField {
 		s.afIdx ^= 1
 		s.af[s.afIdx].CopyFrom(p.AdaptationField)
@@ -157,6 +235,14 @@ func (s *pidSlot) start(p *ts.Packet, isPSI bool) {
 // carry the unit length, sticky-max with a floor otherwise.
 func (s *pidSlot) classFor(payload []byte, isPSI bool) uint8 {
 	if isPSI {
+		// The first section's length, up to 4096 bytes for private sections
+		// and EIT: sized once instead of grown over the packets it spans
+		if len(payload) > 0 {
+			if h := 1 + int(payload[0]); len(payload) >= h+3 {
+				n := h + 3 + int(binary.BigEndian.Uint16(payload[h+1:h+3])&0x0fff)
+				return maxClass(classOf(n), maxClass(s.sticky, defaultFloorClass))
+			}
+		}
 		return maxClass(s.sticky, defaultFloorClass)
 	}
 	if len(payload) >= 6 && payload[0] == 0 && payload[1] == 0 && payload[2] == 1 {
@@ -190,7 +276,7 @@ func (s *pidSlot) flush(pid uint16) (u unit, ok bool) {
 		return
 	}
 	s.sticky = maxClass(s.sticky, classOf(len(s.buf.bs)))
-	u = unit{buf: s.buf, cc: s.cc, pid: pid, isPSI: s.isPSI}
+	u = unit{buf: s.buf, cc: s.cc, pid: pid, isPSI: s.isPSI, extraHeader: s.extraHeader, hasExtraHeader: s.hasExtraHeader, captureTime: s.captureTime, offset: s.offset, index: s.index}
 	if s.hasAF {
 		u.af = &s.af[s.afIdx]
 	}
@@ -25,7 +25,7 @@ func accPacket(pid uint16, cc uint8, pusi bool, payload []byte) *ts.Packet {
 func TestAccumulatorFlushOnUnitStart(t *testing.T) {
 	var a accumulator
 	pm := pidmap.Map[uint16]{}
-	a.init(&pm, false)
+	a.init(&pm, &pidmap.Map[[]sectionParser]{}, false)
 
 	var units []unit
 	units = a.add(accPacket(1, 0, true, []byte("abc")), units[:0])
@@ -56,7 +56,7 @@ func TestAccumulatorFlushOnUnitStart(t *testing.T) {
 func TestAccumulatorPSICompletes(t *testing.T) {
 	var a accumulator
 	pm := pidmap.Map[uint16]{}
-	a.init(&pm, false)
+	a.init(&pm, &pidmap.Map[[]sectionParser]{}, false)
 
 	// PAT PID with a complete single section: flushes without waiting for
 	// the next unit start
@@ -72,7 +72,7 @@ func TestAccumulatorPSICompletes(t *testing.T) {
 func TestAccumulatorDrainAscendingPIDs(t *testing.T) {
 	var a accumulator
 	pm := pidmap.Map[uint16]{}
-	a.init(&pm, false)
+	a.init(&pm, &pidmap.Map[[]sectionParser]{}, false)
 
 	_ = a.add(accPacket(0x300, 0, true, []byte("high")), nil)
 	_ = a.add(accPacket(0x100, 0, true, []byte("low")), nil)
@@ -93,7 +93,7 @@ func TestAccumulatorDrainAscendingPIDs(t *testing.T) {
 func TestIsPSIPID(t *testing.T) {
 	var a accumulator
 	pm := pidmap.Map[uint16]{}
-	a.init(&pm, true)
+	a.init(&pm, &pidmap.Map[[]sectionParser]{}, true)
 	var pids []int
 	for i := 0; i <= 255; i++ {
 		if a.isPSIPID(uint16(i)) {
@@ -105,7 +105,7 @@ func TestIsPSIPID(t *testing.T) {
 	assert.True(t, a.isPSIPID(uint16(1)))
 
 	// DVB ranges are ignored without the option
-	a.init(&pm, false)
+	a.init(&pm, &pidmap.Map[[]sectionParser]{}, false)
 	assert.False(t, a.isPSIPID(uint16(0x12)))
 	assert.True(t, a.isPSIPID(ts.PIDPAT))
 	assert.True(t, a.isPSIPID(uint16(1)))
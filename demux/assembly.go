@@ -0,0 +1,180 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// eitSegmentSize is the number of sections of an EIT schedule segment
+// (EN 300 468 §5.2.4): sections are numbered per segment, with gaps between
+// segments shorter than eight sections.
+const eitSegmentSize = 8
+
+type tableKey struct {
+	pid     uint16
+	ext     uint16
+	tableID psi.TableID
+}
+
+// tableAssembly is the partial state of one table: the parsed sections by
+// section_number.
+type tableAssembly struct {
+	sections []psi.SectionSyntaxData
+	segLast  [256 / eitSegmentSize]int16 // segment_last_section_number per EIT segment, -1 until seen
+	version  uint8
+	done     bool
+}
+
+func (a *tableAssembly) reset(version, lastSectionNumber uint8) {
+	a.sections = append(a.sections[:0], make([]psi.SectionSyntaxData, int(lastSectionNumber)+1)...)
+	for i := range a.segLast {
+		a.segLast[i] = -1
+	}
+	a.version = version
+	a.done = false
+}
+
+func (a *tableAssembly) complete() bool {
+	if _, eit := a.sections[a.firstSeen()].(*psi.EIT); !eit {
+		for _, s := range a.sections {
+			if s == nil {
+				return false
+			}
+		}
+		return true
+	}
+	for seg := 0; seg*eitSegmentSize < len(a.sections); seg++ {
+		last := int(a.segLast[seg])
+		if last < 0 {
+			return false
+		}
+		for n := seg * eitSegmentSize; n <= last && n < len(a.sections); n++ {
+			if a.sections[n] == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *tableAssembly) firstSeen() int {
+	for i, s := range a.sections {
+		if s != nil {
+			return i
+		}
+	}
+	return 0
+}
+
+// assemble adds a section to its table. It returns the merged table once the
+// table is complete, and whether the section was consumed: an unconsumed one
+// is emitted on its own.
+func (dmx *Demuxer) assemble(pid uint16, s *psi.Section) (merged psi.SectionSyntaxData, consumed bool) {
+	h := &s.Syntax.Header
+	if !s.Header.SectionSyntaxIndicator || !mergeable(s.Syntax.Data) {
+		return nil, false
+	}
+	if !h.CurrentNextIndicator || h.SectionNumber > h.LastSectionNumber {
+		return nil, true
+	}
+
+	if dmx.tables == nil {
+		dmx.tables = make(map[tableKey]*tableAssembly)
+	}
+	k := tableKey{pid: pid, ext: h.TableIDExtension, tableID: s.Header.TableID}
+	a := dmx.tables[k]
+	if a == nil {
+		a = &tableAssembly{}
+		a.reset(h.VersionNumber, h.LastSectionNumber)
+		dmx.tables[k] = a
+	} else if a.version != h.VersionNumber || len(a.sections) != int(h.LastSectionNumber)+1 {
+		a.reset(h.VersionNumber, h.LastSectionNumber)
+	}
+	if a.done {
+		return nil, true
+	}
+
+	a.sections[h.SectionNumber] = s.Syntax.Data
+	if eit, ok := s.Syntax.Data.(*psi.EIT); ok {
+		a.segLast[h.SectionNumber/eitSegmentSize] = int16(eit.SegmentLastSectionNumber)
+	}
+	if !a.complete() {
+		return nil, true
+	}
+	a.done = true
+	return mergeSections(a.sections), true
+}
+
+func mergeable(d psi.SectionSyntaxData) bool {
+	switch d.(type) {
+	case *psi.PAT, *psi.PMT, *psi.CAT, *psi.TSDT, *psi.NIT, *psi.BAT, *psi.SDT, *psi.EIT:
+		return true
+	}
+	return false
+}
+
+// mergeSections concatenates the loops of the sections of a table, in
+// section_number order. The other fields come from the first section.
+func mergeSections(sections []psi.SectionSyntaxData) psi.SectionSyntaxData {
+	var merged psi.SectionSyntaxData
+	for _, s := range sections {
+		if s == nil {
+			continue
+		}
+		switch d := s.(type) {
+		case *psi.PAT:
+			if merged == nil {
+				merged = &psi.PAT{TransportStreamID: d.TransportStreamID}
+			}
+			m := merged.(*psi.PAT)
+			m.Programs = append(m.Programs, d.Programs...)
+		case *psi.PMT:
+			if merged == nil {
+				merged = &psi.PMT{ProgramNumber: d.ProgramNumber, PCRPID: d.PCRPID}
+			}
+			m := merged.(*psi.PMT)
+			m.ProgramDescriptors = append(m.ProgramDescriptors, d.ProgramDescriptors...)
+			m.ElementaryStreams = append(m.ElementaryStreams, d.ElementaryStreams...)
+		case *psi.CAT:
+			if merged == nil {
+				merged = &psi.CAT{}
+			}
+			m := merged.(*psi.CAT)
+			m.Descriptors = append(m.Descriptors, d.Descriptors...)
+		case *psi.TSDT:
+			if merged == nil {
+				merged = &psi.TSDT{}
+			}
+			m := merged.(*psi.TSDT)
+			m.Descriptors = append(m.Descriptors, d.Descriptors...)
+		case *psi.NIT:
+			if merged == nil {
+				merged = &psi.NIT{NetworkID: d.NetworkID}
+			}
+			m := merged.(*psi.NIT)
+			m.NetworkDescriptors = append(m.NetworkDescriptors, d.NetworkDescriptors...)
+			m.TransportStreams = append(m.TransportStreams, d.TransportStreams...)
+		case *psi.BAT:
+			if merged == nil {
+				merged = &psi.BAT{BouquetID: d.BouquetID}
+			}
+			m := merged.(*psi.BAT)
+			m.BouquetDescriptors = append(m.BouquetDescriptors, d.BouquetDescriptors...)
+			m.TransportStreams = append(m.TransportStreams, d.TransportStreams...)
+		case *psi.SDT:
+			if merged == nil {
+				merged = &psi.SDT{TransportStreamID: d.TransportStreamID, OriginalNetworkID: d.OriginalNetworkID}
+			}
+			m := merged.(*psi.SDT)
+			m.Services = append(m.Services, d.Services...)
+		case *psi.EIT:
+			if merged == nil {
+				c := *d
+				c.Events = nil
+				merged = &c
+			}
+			m := merged.(*psi.EIT)
+			m.Events = append(m.Events, d.Events...)
+		}
+	}
+	return merged
+}
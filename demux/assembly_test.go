@@ -0,0 +1,59 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerTableAssembly(t *testing.T) {
+	const pid = 0x11
+	sdtSection := func(version, number uint8, serviceID uint16) *psi.Data {
+		return &psi.Data{Sections: []psi.Section{{
+			Header: psi.SectionHeader{TableID: psi.TableIDSDTVariant1, SectionSyntaxIndicator: true},
+			Syntax: &psi.SectionSyntax{
+				Header: psi.SectionSyntaxHeader{
+					TableIDExtension:     7,
+					VersionNumber:        version,
+					CurrentNextIndicator: true,
+					SectionNumber:        number,
+					LastSectionNumber:    1,
+				},
+				Data: &psi.SDT{TransportStreamID: 7, OriginalNetworkID: 1, Services: []psi.SDTService{{ServiceID: serviceID}}},
+			},
+		}}}
+	}
+
+	var stream []byte
+	for cc, d := range []*psi.Data{
+		sdtSection(0, 1, 2),
+		sdtSection(0, 0, 1),
+		sdtSection(0, 1, 2), // repeat of an assembled version
+		sdtSection(1, 0, 3),
+		sdtSection(1, 1, 4),
+	} {
+		p := dataPacket(t, pid, d)
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithDVBTables(), WithTableAssembly())
+	defer dmx.Close()
+
+	for _, ids := range [][2]uint16{{1, 2}, {3, 4}} {
+		ev, err := dmx.Next()
+		require.NoError(t, err)
+		assert.Equal(t, EventSDT, ev)
+		_, data := dmx.Section()
+		assert.Equal(t, &psi.SDT{TransportStreamID: 7, OriginalNetworkID: 1, Services: []psi.SDTService{{ServiceID: ids[0]}, {ServiceID: ids[1]}}}, data)
+	}
+
+	_, err := dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
@@ -0,0 +1,94 @@
+package demux
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// CASection is a section of an ECM or EMM PID, the result of EventECM and
+// EventEMM under WithCASections.
+type CASection struct {
+	// Section runs from table_id through the end of the section (the CRC32,
+	// if any); owned.
+	Section  []byte
+	SystemID uint16 // CA_system_ID of the CA descriptor naming the PID
+	TableID  psi.TableID
+}
+
+// caPID is a PID named by a CA descriptor.
+type caPID struct {
+	ev       Event // EventECM or EventEMM
+	systemID uint16
+}
+
+// WithCASections discovers the ECM PIDs named by the CA descriptors of the PMTs
+// and the EMM PIDs named by those of the CAT, and emits their sections as
+// EventECM and EventEMM (see Section, which returns a *CASection), so a CAS
+// integration needs no section parser of its own. It implies parsing the CAT.
+// Identical repeats of a section are dropped like those of a table.
+func WithCASections() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optCASections = true
+	}
+}
+
+// discoverCA records the CA PIDs a PMT or the CAT names.
+func (dmx *Demuxer) discoverCA(data psi.SectionSyntaxData) {
+	switch d := data.(type) {
+	case *psi.PMT:
+		dmx.addCAPIDs(d.ProgramDescriptors, EventECM)
+		for _, es := range d.ElementaryStreams {
+			dmx.addCAPIDs(es.ElementaryStreamDescriptors, EventECM)
+		}
+	case *psi.CAT:
+		dmx.addCAPIDs(d.Descriptors, EventEMM)
+	}
+}
+
+func (dmx *Demuxer) addCAPIDs(ds []descriptor.Descriptor, ev Event) {
+	for _, d := range ds {
+		if ca, ok := d.(*descriptor.CA); ok && ca.PID != ts.PIDNull {
+			dmx.caPIDs.Set(ca.PID, caPID{ev: ev, systemID: ca.SystemID})
+		}
+	}
+}
+
+// processCASections splits a PSI unit of a CA PID into sections, each emitted
+// as a CASection.
+func (dmx *Demuxer) processCASections(u unit, ca caPID) {
+	cache := dmx.psiPrev.GetOrAdd(u.pid)
+	cache.raw = append(cache.raw[:0], u.buf.bs...)
+	cache.events = cache.events[:0]
+	defer poolOfPayload.put(u.buf)
+
+	bs := u.buf.bs
+	if len(bs) == 0 {
+		return
+	}
+	for off := 1 + int(bs[0]); off+3 <= len(bs); {
+		tableID := psi.TableID(bs[off])
+		if tableID == psi.TableIDNull {
+			return
+		}
+		end := off + 3 + int(binary.BigEndian.Uint16(bs[off+1:])&0xfff)
+		if end > len(bs) {
+			dmx.reportSectionError(u.pid, fmt.Errorf("astits: section of table 0x%02x overruns its unit: %w", uint8(tableID), ts.ErrInvalidData))
+			return
+		}
+		section := bs[off:end]
+		off = end
+
+		if !dmx.verifySection(u.pid, section) {
+			continue
+		}
+		dmx.queueTable(u.pid, &CASection{
+			Section:  append([]byte(nil), section...),
+			SystemID: ca.systemID,
+			TableID:  tableID,
+		}, ca.ev, cache)
+	}
+}
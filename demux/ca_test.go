@@ -0,0 +1,71 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func caDescriptor(systemID, pid uint16) descriptor.Descriptor {
+	return &descriptor.CA{Header: descriptor.Header{Tag: descriptor.TagCA, Length: 4}, SystemID: systemID, PID: pid}
+}
+
+func TestDemuxerCASections(t *testing.T) {
+	ecm := []byte{0x80, 0x70, 0x03, 0x01, 0x02, 0x03}
+	emm := []byte{0x82, 0x70, 0x02, 0xaa, 0xbb}
+
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ElementaryStreams: []psi.ElementaryStream{{
+			ElementaryPID:               0x100,
+			StreamType:                  psi.StreamTypeH264Video,
+			ElementaryStreamDescriptors: []descriptor.Descriptor{caDescriptor(0x0b00, 0x200)},
+		}},
+	})...)
+	stream = append(stream, psiPacket(t, ts.PIDCAT, psi.TableIDCAT, 0xffff, &psi.CAT{
+		Descriptors: []descriptor.Descriptor{caDescriptor(0x0b00, 0x300)},
+	})...)
+	stream = append(stream, payloadPacket(0x200, append([]byte{0}, ecm...))...)
+	stream = append(stream, payloadPacket(0x300, append([]byte{0}, emm...))...)
+	// A repeated ECM is dropped
+	p := payloadPacket(0x200, append([]byte{0}, ecm...))
+	ts.SetContinuityCounter(p, 1)
+	stream = append(stream, p...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithCASections())
+	defer dmx.Close()
+	var got []Event
+	var sections []*CASection
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, ev)
+		if ev == EventECM || ev == EventEMM {
+			_, data := dmx.Section()
+			sections = append(sections, data.(*CASection))
+		}
+	}
+	assert.Equal(t, []Event{EventPAT, EventPMT, EventCAT, EventECM, EventEMM}, got)
+	assert.Equal(t, []*CASection{
+		{Section: ecm, SystemID: 0x0b00, TableID: 0x80},
+		{Section: emm, SystemID: 0x0b00, TableID: 0x82},
+	}, sections)
+	assert.Equal(t, "ECM", EventECM.String())
+}
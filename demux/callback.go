@@ -0,0 +1,106 @@
+package demux
+
+import (
+	"context"
+	"errors"
+
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// handlers are the callbacks Run dispatches events to.
+type handlers struct {
+	tables map[Event]func(pid uint16, data psi.SectionSyntaxData)
+	pes    pidmap.Map[func(*PES)]
+	err    func(*ts.RecoverableError)
+}
+
+// OnTable registers fn for the table events ev dispatched by Run, replacing
+// any previous one. data is borrowed for the duration of the call.
+func (dmx *Demuxer) OnTable(ev Event, fn func(pid uint16, data psi.SectionSyntaxData)) {
+	if dmx.handlers.tables == nil {
+		dmx.handlers.tables = make(map[Event]func(uint16, psi.SectionSyntaxData))
+	}
+	dmx.handlers.tables[ev] = fn
+}
+
+// OnPAT registers fn for the PATs dispatched by Run.
+func (dmx *Demuxer) OnPAT(fn func(pat *psi.PAT)) {
+	dmx.OnTable(EventPAT, func(_ uint16, data psi.SectionSyntaxData) { fn(data.(*psi.PAT)) })
+}
+
+// OnPMT registers fn for the PMTs dispatched by Run.
+func (dmx *Demuxer) OnPMT(fn func(pid uint16, pmt *psi.PMT)) {
+	dmx.OnTable(EventPMT, func(pid uint16, data psi.SectionSyntaxData) { fn(pid, data.(*psi.PMT)) })
+}
+
+// OnNIT registers fn for the NITs dispatched by Run (see WithDVBTables).
+func (dmx *Demuxer) OnNIT(fn func(nit *psi.NIT)) {
+	dmx.OnTable(EventNIT, func(_ uint16, data psi.SectionSyntaxData) { fn(data.(*psi.NIT)) })
+}
+
+// OnSDT registers fn for the SDTs dispatched by Run (see WithDVBTables).
+func (dmx *Demuxer) OnSDT(fn func(sdt *psi.SDT)) {
+	dmx.OnTable(EventSDT, func(_ uint16, data psi.SectionSyntaxData) { fn(data.(*psi.SDT)) })
+}
+
+// OnEIT registers fn for the EITs dispatched by Run (see WithDVBTables).
+func (dmx *Demuxer) OnEIT(fn func(eit *psi.EIT)) {
+	dmx.OnTable(EventEIT, func(_ uint16, data psi.SectionSyntaxData) { fn(data.(*psi.EIT)) })
+}
+
+// OnPES registers fn for the PES units of pid dispatched by Run. The unit is
+// borrowed for the duration of the call; fn claims it with Demuxer.PES to keep
+// it.
+func (dmx *Demuxer) OnPES(pid uint16, fn func(d *PES)) {
+	*dmx.handlers.pes.GetOrAdd(pid) = fn
+}
+
+// OnError registers fn for the recoverable errors dispatched by Run (see
+// WithRecoverableErrors).
+func (dmx *Demuxer) OnError(fn func(err *ts.RecoverableError)) {
+	dmx.handlers.err = fn
+}
+
+// Run demuxes until the packets are exhausted, dispatching every event to the
+// callbacks registered with the On methods; events without a callback are
+// skipped. It returns nil at the end of the stream, ctx.Err() once ctx is
+// done, or the first fatal error.
+func (dmx *Demuxer) Run(ctx context.Context) error {
+	done := ctx.Done()
+	for {
+		if done != nil {
+			select {
+			case <-done:
+				return ctx.Err()
+			default:
+			}
+		}
+
+		ev, err := dmx.Next()
+		if err != nil {
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return nil
+			}
+			var rerr *ts.RecoverableError
+			if !errors.As(err, &rerr) {
+				return err
+			}
+			if dmx.handlers.err != nil {
+				dmx.handlers.err(rerr)
+			}
+			continue
+		}
+
+		if ev == EventPES {
+			if fn := dmx.handlers.pes.Get(dmx.pending.PID); fn != nil {
+				(*fn)(dmx.pending)
+			}
+			continue
+		}
+		if fn := dmx.handlers.tables[ev]; fn != nil {
+			fn(dmx.cur.pid, dmx.cur.data)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerRun(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber:     1,
+		PCRPID:            0x100,
+		ElementaryStreams: []psi.ElementaryStream{{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x11, psi.TableIDSDTVariant1, 7, &psi.SDT{TransportStreamID: 7})...)
+	// Unbounded video PES, flushed at the end of the stream
+	stream = append(stream, payloadPacket(0x100, []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00, 0xaa, 0xbb})...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithDVBTables())
+	defer dmx.Close()
+
+	var got []string
+	dmx.OnPAT(func(pat *psi.PAT) {
+		got = append(got, "PAT")
+		assert.Len(t, pat.Programs, 1)
+	})
+	dmx.OnPMT(func(pid uint16, pmt *psi.PMT) {
+		got = append(got, "PMT")
+		assert.Equal(t, uint16(0x1000), pid)
+		assert.Equal(t, uint16(0x100), pmt.PCRPID)
+	})
+	dmx.OnPES(0x100, func(d *PES) {
+		got = append(got, "PES")
+		assert.Equal(t, []byte{0xaa, 0xbb}, d.Data.Data[:2])
+	})
+	require.NoError(t, dmx.Run(context.Background()))
+	// No SDT callback: the event is skipped
+	assert.Equal(t, []string{"PAT", "PMT", "PES"}, got)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, New(context.Background(), bytes.NewReader(stream)).Run(ctx), context.Canceled)
+}
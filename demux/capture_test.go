@@ -0,0 +1,58 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerCaptureTime(t *testing.T) {
+	var stream []byte
+	for i := range 4 {
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), uint64(i)*3600, false)...)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var reads int
+	clock := func() time.Time {
+		reads++
+		return base.Add(time.Duration(reads) * time.Millisecond)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithCaptureTime(clock))
+	defer dmx.Close()
+	var got []time.Time
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		if ev == EventPES {
+			d := dmx.PES()
+			got = append(got, d.CaptureTime)
+			d.Close()
+		}
+	}
+	// Each PES carries the time of its (single) packet
+	require.Len(t, got, 4)
+	for i, c := range got {
+		assert.Equal(t, base.Add(time.Duration(i+1)*time.Millisecond), c)
+	}
+
+	// Packets read directly are stamped too, by time.Now by default
+	before := time.Now()
+	dmx = New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithCaptureTime(nil))
+	defer dmx.Close()
+	p, err := dmx.NextPacket()
+	require.NoError(t, err)
+	assert.False(t, p.CaptureTime.Before(before))
+	assert.False(t, p.CaptureTime.After(time.Now()))
+	p.Close()
+}
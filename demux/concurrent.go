@@ -0,0 +1,39 @@
+package demux
+
+import "sync"
+
+// WithConcurrentQueries makes GetStats, PAT, PMT and Services safe to call
+// from other goroutines while one goroutine drives the demuxer (Next, Run,
+// NextPES, Rewind, Seek). The driving goroutine holds a lock only while it
+// updates that state, never while it waits on the reader, so a snapshot never
+// waits on a stalled input. The hooks the demuxer calls under that lock —
+// descramblers and the CC error hook — must not query it.
+func WithConcurrentQueries() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.mu = &sync.RWMutex{}
+	}
+}
+
+func (dmx *Demuxer) lock() {
+	if dmx.mu != nil {
+		dmx.mu.Lock()
+	}
+}
+
+func (dmx *Demuxer) unlock() {
+	if dmx.mu != nil {
+		dmx.mu.Unlock()
+	}
+}
+
+func (dmx *Demuxer) rlock() {
+	if dmx.mu != nil {
+		dmx.mu.RLock()
+	}
+}
+
+func (dmx *Demuxer) runlock() {
+	if dmx.mu != nil {
+		dmx.mu.RUnlock()
+	}
+}
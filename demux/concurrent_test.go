@@ -0,0 +1,63 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerConcurrentQueries(t *testing.T) {
+	var stream []byte
+	for i := range 50 {
+		p := psiPacket(t, ts.PIDPAT, psi.TableIDPAT, uint16(i), &psi.PAT{
+			TransportStreamID: uint16(i),
+			Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+		})
+		ts.SetContinuityCounter(p, uint8(i))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithConcurrentQueries())
+	defer dmx.Close()
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			_ = dmx.GetStats()
+			if pat := dmx.PAT(); pat != nil {
+				assert.Len(t, pat.Programs, 1)
+			}
+			_ = dmx.Services()
+		}
+	})
+
+	var pats int
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		if ev == EventPAT {
+			pats++
+		}
+	}
+	close(done)
+	wg.Wait()
+	assert.Equal(t, 50, pats)
+	assert.Equal(t, map[uint64]uint{uint64(ts.PIDPAT): 50 * ts.PacketSize}, dmx.GetStats())
+}
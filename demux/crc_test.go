@@ -0,0 +1,51 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerCRCMode(t *testing.T) {
+	run := func(opts ...func(*Demuxer)) (evs []Event, errs []error) {
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithRecoverableErrors())
+		dmx := New(context.Background(), bytes.NewReader(corruptCRCPATPacket()), opts...)
+		defer dmx.Close()
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return
+			}
+			if ev == EventError {
+				errs = append(errs, err)
+				continue
+			}
+			require.NoError(t, err)
+			evs = append(evs, ev)
+		}
+	}
+
+	// Dropped by default
+	evs, errs := run()
+	assert.Empty(t, evs)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], psi.ErrCRC32Mismatch)
+
+	// Kept silently
+	evs, errs = run(WithSkipCRCCheck())
+	assert.Equal(t, []Event{EventPAT}, evs)
+	assert.Empty(t, errs)
+
+	// Kept and reported
+	evs, errs = run(WithLenientCRC())
+	assert.Equal(t, []Event{EventPAT}, evs)
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], psi.ErrCRC32Mismatch)
+}
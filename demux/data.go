@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/k-danil/go-astits/v2/pes"
 	"github.com/k-danil/go-astits/v2/psi"
@@ -19,6 +20,33 @@ type PES struct {
 	PID               uint16
 	ContinuityCounter uint8
 
+	// ArrivalTimeStamp (27 MHz) and CopyPermission are decoded from the M2TS
+	// TP_extra_header of the unit's first packet, as by
+	// ts.Packet.ArrivalTimeStamp; HasArrivalTimeStamp is false for other
+	// packet sizes.
+	ArrivalTimeStamp    uint32
+	CopyPermission      uint8
+	HasArrivalTimeStamp bool
+
+	// PTS64 and DTS64 are the unit's PTS and DTS bases (90 kHz) unwrapped past
+	// the 33-bit wrap, with WithTimestampUnwrap; DTS64 equals PTS64 when the
+	// header carries no DTS, both are 0 without a PTS.
+	PTS64 uint64
+	DTS64 uint64
+
+	// Offset and Index are the ts.Packet.Offset and ts.Packet.Index of the
+	// unit's first packet: its position in the source.
+	Offset int64
+	Index  int64
+
+	// Truncated is set on a unit cut short at EOF or by Flush, delivered with
+	// WithTruncatedPES: Data holds the bytes received.
+	Truncated bool
+
+	// CaptureTime is the ts.Packet.CaptureTime of the unit's first packet,
+	// with WithCaptureTime.
+	CaptureTime time.Time
+
 	af  ts.PacketAdaptationField
 	buf *dataPayload
 }
@@ -47,6 +75,10 @@ type tableEvent struct {
 	pid     uint16
 	ev      Event
 	changed bool
+	change  VersionChange // EventVersionChange only
+	disc    Discontinuity // EventDiscontinuity only
+	pcr     PCR           // EventPCR only
+	pmtDiff PMTDiff       // EventPMTDiff only
 }
 
 // psiCache holds the last accepted section of a PID: the raw bytes for the
@@ -86,6 +118,8 @@ func tableEventKind(d psi.SectionSyntaxData) (ev Event, ok bool) {
 		return EventST, true
 	case *psi.TSDT:
 		return EventTSDT, true
+	case *Cue:
+		return EventSCTE35, true
 	}
 	return 0, false
 }
@@ -102,17 +136,41 @@ func (dmx *Demuxer) processUnit(u unit) (emitted *PES, err error) {
 		d, _ := poolOfPES.Get().(*PES)
 		d.PID = u.pid
 		d.ContinuityCounter = u.cc
+		d.ArrivalTimeStamp = u.extraHeader & 0x3fffffff
+		d.CopyPermission = uint8(u.extraHeader >> 30)
+		d.HasArrivalTimeStamp = u.hasExtraHeader
+		d.PTS64, d.DTS64 = 0, 0
+		d.CaptureTime = u.captureTime
+		d.Offset, d.Index = u.offset, u.index
 		d.buf = u.buf
 
-		if perr := d.Data.Parse(u.buf.bs); perr != nil {
+		var perr error
+		if u.drained && dmx.optTruncatedPES {
+			d.Truncated, perr = d.Data.ParseTruncated(u.buf.bs)
+		} else if j := dmx.parsed(&u); j != nil {
+			// The read-ahead parsed the same bytes in its own buffer
+			d.Truncated, d.Data, perr = false, j.pes, j.err
+			poolOfPayload.put(u.buf)
+			d.buf = j.buf
+		} else {
+			d.Truncated = false
+			perr = d.Data.Parse(u.buf.bs)
+		}
+		if perr != nil {
 			d.Close()
-			if dmx.optRecoverable {
-				dmx.pendingErrs = append(dmx.pendingErrs, &ts.RecoverableError{
+			if dmx.reportsErrors() {
+				dmx.reportRecoverable(ts.RecoverableError{
 					Kind: ts.ErrorKindPES, PID: u.pid, Offset: dmx.pkt.Offset, Err: perr,
 				})
 			}
 			return nil, perr
 		}
+		if dmx.timeline != nil {
+			dmx.observePTS(d)
+		}
+		if dmx.unwrappers != nil {
+			dmx.unwrapTimestamps(d)
+		}
 
 		if u.af != nil {
 			d.af.CopyFrom(u.af)
@@ -135,7 +193,7 @@ func (dmx *Demuxer) reportPSIError(pid uint16, err error) {
 	if errors.Is(err, psi.ErrCRC32Mismatch) {
 		kind = ts.ErrorKindCRC
 	}
-	dmx.pendingErrs = append(dmx.pendingErrs, &ts.RecoverableError{
+	dmx.reportRecoverable(ts.RecoverableError{
 		Kind: kind, PID: pid, Offset: dmx.pkt.Offset, Err: err,
 	})
 }
@@ -145,7 +203,7 @@ func (dmx *Demuxer) processPSI(u unit) {
 	// is not re-parsed. Without WithPSIRepeats it is not emitted either.
 	if cache := dmx.psiPrev.Get(u.pid); cache != nil && bytes.Equal(cache.raw, u.buf.bs) {
 		poolOfPayload.put(u.buf)
-		if dmx.optPSIRepeats {
+		if dmx.optPSIRepeats && !dmx.optVersionTracking && !dmx.optSectionDedup {
 			for _, e := range cache.events {
 				e.changed = false
 				dmx.tblQueue = append(dmx.tblQueue, e)
@@ -154,9 +212,25 @@ func (dmx *Demuxer) processPSI(u unit) {
 		return
 	}
 
-	psiData, err := psi.Parse(u.buf.bs)
+	if ca := dmx.caPIDs.Get(u.pid); ca != nil {
+		dmx.processCASections(u, *ca)
+		return
+	}
+	if parsers := dmx.sectionParsers.Get(u.pid); parsers != nil {
+		dmx.processSections(u, *parsers)
+		return
+	}
+
+	var psiData *psi.Data
+	var err error
+	if j := dmx.parsed(&u); j != nil {
+		psiData, err = j.psi, j.err
+		poolOfPayload.put(j.buf)
+	} else {
+		psiData, err = psi.ParseWith(u.buf.bs, dmx.optParse)
+	}
 	if err != nil {
-		if dmx.optRecoverable {
+		if dmx.reportsErrors() {
 			dmx.reportPSIError(u.pid, err)
 		}
 		poolOfPayload.put(u.buf)
@@ -169,29 +243,74 @@ func (dmx *Demuxer) processPSI(u unit) {
 	poolOfPayload.put(u.buf)
 
 	for _, s := range psiData.Sections {
-		if s.Syntax == nil || s.Syntax.Data == nil {
-			continue
-		}
-		ev, ok := tableEventKind(s.Syntax.Data)
-		if !ok {
-			continue
+		dmx.reportCRCMismatch(u.pid, &s)
+		dmx.emitSection(u.pid, &s, cache)
+	}
+}
+
+// emitSection updates the table state from a parsed section and queues its
+// table event.
+func (dmx *Demuxer) emitSection(pid uint16, s *psi.Section, cache *psiCache) {
+	if s.Syntax == nil || s.Syntax.Data == nil {
+		return
+	}
+	if dmx.optSectionDedup && !dmx.newSection(pid, s) {
+		return
+	}
+	if dmx.optValidateDescs && dmx.reportsErrors() {
+		dmx.validateDescriptors(pid, s)
+	}
+	data := s.Syntax.Data
+	if dmx.optTableAssembly {
+		merged, consumed := dmx.assemble(pid, s)
+		if merged != nil {
+			data = merged
+		} else if consumed {
+			return
 		}
-		switch data := s.Syntax.Data.(type) {
-		case *psi.PAT:
-			dmx.pat = data
-			for _, pgm := range data.Programs {
-				// Program number 0 is reserved to NIT
-				if pgm.ProgramNumber > 0 {
-					dmx.programMap.Set(pgm.ProgramMapID, pgm.ProgramNumber)
-				}
+	}
+	if len(dmx.remap.Keys) > 0 {
+		dmx.remapTable(data)
+	}
+	if si, ok := data.(*psi.SpliceInfo); ok && dmx.optSCTE35 {
+		data = dmx.cue(pid, si)
+	}
+	ev, ok := tableEventKind(data)
+	if !ok {
+		return
+	}
+	if dmx.optVersionTracking && !dmx.trackVersion(pid, s, data) {
+		return
+	}
+	switch data := data.(type) {
+	case *psi.PAT:
+		dmx.pat = data
+		for _, pgm := range data.Programs {
+			// Program number 0 is reserved to NIT
+			if pgm.ProgramNumber > 0 {
+				dmx.programMap.Set(pgm.ProgramMapID, pgm.ProgramNumber)
 			}
-		case *psi.PMT:
-			dmx.pmt = data
 		}
-		e := tableEvent{pid: u.pid, data: s.Syntax.Data, ev: ev, changed: true}
-		cache.events = append(cache.events, e)
-		dmx.tblQueue = append(dmx.tblQueue, e)
+	case *psi.PMT:
+		if dmx.optPMTDiffs {
+			dmx.diffPMT(pid, s, data)
+		}
+		dmx.pmt = data
+		if dmx.optSCTE35 {
+			dmx.discoverCues(data)
+		}
+	}
+	dmx.recordService(s, data)
+	if dmx.optCASections {
+		dmx.discoverCA(data)
 	}
+	dmx.queueTable(pid, data, ev, cache)
+}
+
+func (dmx *Demuxer) queueTable(pid uint16, data psi.SectionSyntaxData, ev Event, cache *psiCache) {
+	e := tableEvent{pid: pid, data: data, ev: ev, changed: true}
+	cache.events = append(cache.events, e)
+	dmx.tblQueue = append(dmx.tblQueue, e)
 }
 
 // isPESPayload checks whether the payload is a PES one
@@ -0,0 +1,84 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// datagramReader returns one datagram per Read, as a UDP socket does.
+type datagramReader struct{ dgrams [][]byte }
+
+func (r *datagramReader) Read(p []byte) (int, error) {
+	if len(r.dgrams) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.dgrams[0])
+	r.dgrams = r.dgrams[1:]
+	return n, nil
+}
+
+func TestDemuxerDatagrams(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber:     1,
+		PCRPID:            0x100,
+		ElementaryStreams: []psi.ElementaryStream{{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}},
+	})...)
+	for i := range 12 {
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), uint64(i)*3600, false)...)
+	}
+
+	run := func(r io.Reader, opts ...func(*Demuxer)) (log []string) {
+		dmx := New(context.Background(), r, append(opts, WithRecoverableErrors())...)
+		defer dmx.Close()
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return
+			}
+			if ev == EventError {
+				log = append(log, fmt.Sprintf("error %v", err))
+				continue
+			}
+			require.NoError(t, err)
+			line := ev.String()
+			if ev == EventPES {
+				d := dmx.PES()
+				line += fmt.Sprint(" ", d.Data.Header.OptionalHeader.PTS.Base())
+				d.Close()
+			}
+			log = append(log, line)
+		}
+	}
+	want := run(bytes.NewReader(stream))
+	require.Len(t, want, 2+12)
+
+	// Datagrams of 7, 5 and 2 packets, plus a torn one that is dropped
+	ps := ts.PacketSize
+	r := &datagramReader{dgrams: [][]byte{
+		stream[:7*ps],
+		stream[7*ps : 12*ps],
+		stream[12*ps+100 : 13*ps],
+		stream[12*ps : 14*ps],
+	}}
+	got := run(r, WithDatagrams())
+	require.Len(t, got, len(want)+1)
+	// The torn datagram is reported as a sync loss at its offset, ahead of
+	// the PES its next packet completes
+	assert.Contains(t, got[12], fmt.Sprintf("sync-loss error at offset %d", 12*ps))
+	assert.Equal(t, want, append(got[:12:12], got[13:]...))
+}
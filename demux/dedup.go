@@ -0,0 +1,43 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// sectionKey identifies one section of one table version.
+type sectionKey struct {
+	tableKey
+	version uint8
+	number  uint8
+}
+
+// WithSectionDedup emits a section only when it is new or changed: sections
+// are keyed on PID, table id, table_id_extension, version_number and
+// section_number, and one whose CRC32 matches the last seen under its key is
+// dropped. Unlike the default repeat check, which compares a PID's unit with
+// the previous one only, it catches repeats of tables interleaved on a PID
+// (SDT actual and other, an EIT schedule carousel). Sections without the
+// section syntax always pass. It takes precedence over WithPSIRepeats.
+func WithSectionDedup() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optSectionDedup = true
+	}
+}
+
+// newSection reports whether a section is new or changed under
+// WithSectionDedup, and records its CRC32.
+func (dmx *Demuxer) newSection(pid uint16, s *psi.Section) bool {
+	if !s.Header.SectionSyntaxIndicator {
+		return true
+	}
+	h := &s.Syntax.Header
+	if dmx.sectionCRCs == nil {
+		dmx.sectionCRCs = make(map[sectionKey]uint32)
+	}
+	k := sectionKey{tableKey: tableKey{pid: pid, ext: h.TableIDExtension, tableID: s.Header.TableID}, version: h.VersionNumber, number: h.SectionNumber}
+	if crc, ok := dmx.sectionCRCs[k]; ok && crc == s.CRC32 {
+		return false
+	}
+	dmx.sectionCRCs[k] = s.CRC32
+	return true
+}
@@ -0,0 +1,61 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerSectionDedup(t *testing.T) {
+	sdt := func(tsID uint16, services ...uint16) *psi.SDT {
+		d := &psi.SDT{TransportStreamID: tsID, OriginalNetworkID: 1}
+		for _, s := range services {
+			d.Services = append(d.Services, psi.SDTService{ServiceID: s})
+		}
+		return d
+	}
+
+	// SDT actual and other interleaved on one PID: no unit repeats the
+	// previous one, every section but the last repeats an earlier one
+	var stream []byte
+	for cc, s := range []struct {
+		id   psi.TableID
+		data *psi.SDT
+	}{
+		{psi.TableIDSDTVariant1, sdt(7, 1)},
+		{psi.TableIDSDTVariant2, sdt(8, 2)},
+		{psi.TableIDSDTVariant1, sdt(7, 1)},
+		{psi.TableIDSDTVariant2, sdt(8, 2)},
+		{psi.TableIDSDTVariant1, sdt(7, 1, 3)}, // changed, same version
+	} {
+		p := psiPacket(t, 0x11, s.id, s.data.TransportStreamID, s.data)
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = append(stream, p...)
+	}
+
+	services := func(opts ...func(*Demuxer)) (got [][]uint16) {
+		dmx := New(context.Background(), bytes.NewReader(stream), append(opts, WithDVBTables())...)
+		defer dmx.Close()
+		for ev, err := range dmx.Events() {
+			require.NoError(t, err)
+			require.Equal(t, EventSDT, ev)
+			_, data := dmx.Section()
+			var ids []uint16
+			for _, s := range data.(*psi.SDT).Services {
+				ids = append(ids, s.ServiceID)
+			}
+			got = append(got, ids)
+		}
+		return got
+	}
+
+	assert.Len(t, services(), 5)
+	assert.Equal(t, [][]uint16{{1}, {2}, {1, 3}}, services(WithSectionDedup()))
+	assert.Equal(t, [][]uint16{{1}, {2}, {1, 3}}, services(WithSectionDedup(), WithPSIRepeats()))
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"sync"
+	"time"
 
 	"github.com/k-danil/go-astits/v2/internal/pidmap"
 	"github.com/k-danil/go-astits/v2/psi"
@@ -13,7 +15,8 @@ import (
 )
 
 // Event is what a Next call advanced to. Every table event carries its PSI
-// type; the payload is behind Section() (and PAT()/PMT() for those two).
+// type; the payload is behind Section() (and PAT()/PMT() for those two). Kind
+// tells which accessor holds the payload of an event.
 type Event uint8
 
 const (
@@ -37,6 +40,29 @@ const (
 	// (a *ts.RecoverableError) and iteration continues on the following call.
 	// Emitted only under WithRecoverableErrors.
 	EventError
+	// EventSection: a section parsed by a parser registered with
+	// RegisterSectionParser; its result is behind Section().
+	EventSection
+	// EventVersionChange: a table changed version, described by
+	// VersionChange(); its table event follows. Emitted only under
+	// WithVersionTracking.
+	EventVersionChange
+	// EventECM, EventEMM: a section of an ECM or EMM PID; Section() returns a
+	// *CASection. Emitted only under WithCASections.
+	EventECM
+	EventEMM
+	// EventDiscontinuity: the stream timeline jumped, described by
+	// Discontinuity(). Emitted only under WithDiscontinuities.
+	EventDiscontinuity
+	// EventSCTE35: an SCTE 35 cue; Section() returns a *Cue. Emitted only
+	// under WithSCTE35.
+	EventSCTE35
+	// EventPCR: a packet carried a PCR, described by PCR(). Emitted only
+	// under WithPCREvents.
+	EventPCR
+	// EventPMTDiff: the PMT of a program changed, described by PMTDiff(); its
+	// EventPMT follows. Emitted only under WithPMTDiffs.
+	EventPMTDiff
 )
 
 // Demuxer represents a demuxer
@@ -50,22 +76,67 @@ type Demuxer struct {
 	done <-chan struct{}
 	r    io.Reader
 
-	optPacketSize    uint
-	optSkipErrLimit  uint
-	optResyncLimit   uint
-	optPacketSkipper ts.PacketSkipper
-	optKeepPIDs      *ts.PIDSet
-	optZeroCopyBatch uint
-	optSyncLock      bool
-	optDVBTables     bool
-	optPSIRepeats    bool
-	optRecoverable   bool
-	optPacketHook    func(*ts.Packet)
+	optPacketSize      uint
+	optSkipErrLimit    uint
+	optResyncLimit     uint
+	optPacketSkipper   ts.PacketSkipper
+	optKeepPIDs        *ts.PIDSet
+	optZeroCopyBatch   uint
+	optPipelineDepth   int
+	optSyncLock        bool
+	optRedetect        bool
+	optPCREvents       bool
+	optPCRFilter       func() ts.PCRFilter
+	optTEIPolicy       TEIPolicy
+	optTruncatedPES    bool
+	optDatagram        bool
+	optDVBTables       bool
+	optPSIRepeats      bool
+	optTableAssembly   bool
+	optVersionTracking bool
+	optSectionDedup    bool
+	optValidateDescs   bool
+	optPMTDiffs        bool
+	optCASections      bool
+	optParse           psi.ParseConfig
+	optSCTE35          bool
+	optRecoverable     bool
+	optPacketHook      func(*ts.Packet)
+	optClock           func() time.Time
+	optCCErrorHook     func(CCError)
+	optScramblingHook  func(ScramblingChange)
 
 	packetBuffer *ts.PacketBuffer
+	packetSize   uint          // of packetBuffer, for GetStats
+	readSize     uint          // packetSize of the last read, reader side
+	pipe         *pipeline     // WithPipeline state
+	mu           *sync.RWMutex // WithConcurrentQueries state
+	startOffset  int64         // reader position of the next packet buffer, set by Seek
+	startIndex   int64         // packet index at startOffset
+	index        *TimeIndex
+	keyframes    *pidmap.Map[[]Keyframe]
+	unwrappers   *pidmap.Map[tsUnwrapper] // WithTimestampUnwrap state
+	timeline     *timeline                // WithDiscontinuities state
+	monitors     []Monitor
+	descramblers pidmap.Map[Descrambler]
+	caPIDs       pidmap.Map[caPID]              // WithCASections state
+	cuePIDs      pidmap.Map[cuePID]             // WithSCTE35 state
+	pcrs         pidmap.Map[ts.ClockReference]  // WithSCTE35: last PCR per PID
+	pcrFilters   pidmap.Map[ts.PCROffsetFilter] // WithPCRFilter state
+	policies     pidmap.Map[pidPolicy]          // WithPIDPolicy state
+	remap        pidmap.Map[uint16]             // WithPIDRemap: new PID by PID read
 	acc          accumulator
 	programMap   pidmap.Map[uint16]
 	psiPrev      pidmap.Map[psiCache]
+	curPAT       *psi.PAT                   // for CurrentPAT
+	pmts         pidmap.Map[*psi.PMT]       // by program number, for Services
+	sdtServices  map[sdtKey]*psi.SDTService // for Services
+
+	sectionParsers pidmap.Map[[]sectionParser]
+	handlers       handlers                    // Run callbacks
+	tables         map[tableKey]*tableAssembly // WithTableAssembly state
+	versions       map[tableKey]*tableVersion  // WithVersionTracking state
+	sectionCRCs    map[sectionKey]uint32       // WithSectionDedup state
 
 	// Result of the last Next
 	pat         *psi.PAT
@@ -78,6 +149,7 @@ type Demuxer struct {
 	pendingFatal error
 	pending      *PES
 	claimed      bool
+	flushing     bool // Flush drains the unfinished units
 
 	pkt ts.Packet
 
@@ -108,22 +180,33 @@ func New(ctx context.Context, r io.Reader, opts ...func(*Demuxer)) (d *Demuxer)
 		opt(d)
 	}
 
-	d.acc.init(&d.programMap, d.optDVBTables)
+	d.acc.init(&d.programMap, &d.sectionParsers, d.optDVBTables)
+	d.acc.acceptTEI = d.optTEIPolicy == TEIParse
+	d.acc.onCCError = d.optCCErrorHook
+	d.acc.onScramble = d.optScramblingHook
+	if len(d.policies.Keys) > 0 {
+		d.acc.onCCError = d.onCCError
+		d.acc.policies = &d.policies
+	}
+	if d.optCASections {
+		d.acc.ca = &d.caPIDs
+	}
+	if d.optSCTE35 {
+		d.acc.cues = &d.cuePIDs
+	}
 
 	return
 }
 
 // GetStats returns the number of stream bytes seen per PID, keyed by PID.
 func (dmx *Demuxer) GetStats() (ret map[uint64]uint) {
-	var packetSize uint
-	if dmx.packetBuffer != nil {
-		packetSize = dmx.packetBuffer.PacketSize()
-	}
+	dmx.rlock()
+	defer dmx.runlock()
 
 	ret = make(map[uint64]uint, len(dmx.acc.slots.Vals))
 	for i := range dmx.acc.slots.Vals {
 		if n := dmx.acc.slots.Vals[i].stats; n > 0 {
-			ret[uint64(dmx.acc.slots.Keys[i])] = uint(n) * packetSize
+			ret[uint64(dmx.acc.slots.Keys[i])] = uint(n) * dmx.packetSize
 		}
 	}
 
@@ -158,6 +241,17 @@ func WithKeepPIDs(keep *ts.PIDSet) func(*Demuxer) {
 	}
 }
 
+// WithDropPIDs sets an inline PID deny-list: the complement of drop is
+// installed as the WithKeepPIDs allow-list, so dropped packets are discarded in
+// the packet parse hot path just the same. drop is not retained.
+func WithDropPIDs(drop *ts.PIDSet) func(*Demuxer) {
+	return func(d *Demuxer) {
+		keep := *drop
+		keep.Invert()
+		d.optKeepPIDs = &keep
+	}
+}
+
 // SetKeepPIDs installs the inline PID allow-list after construction (see
 // WithKeepPIDs for the PAT/PMT caveat). It takes effect on the next packet
 // buffer, so set it before the pass that should filter (e.g. after Rewind).
@@ -195,6 +289,29 @@ func WithResyncLimit(windows int) func(*Demuxer) {
 	}
 }
 
+// WithPacketSizeRedetect re-runs packet size detection when resync finds no
+// boundary at the current size, for concatenated captures that switch between
+// 188, 192 and 204 byte packets; Packet.Prefix and Packet.Suffix follow the
+// format in effect. It implies WithSyncLock.
+func WithPacketSizeRedetect() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optSyncLock = true
+		d.optRedetect = true
+	}
+}
+
+// WithDatagrams reads the input as datagrams of 1 to 7 (or more) whole packets,
+// one per Read, as returned by a UDP socket: the packets are sliced out of
+// each datagram, whose packet size follows from its length and sync bytes
+// (or WithPacketSize), instead of the peek-based size detection that can
+// straddle datagram boundaries. A datagram that holds no whole packets is
+// dropped as a sync loss. It replaces WithSyncLock.
+func WithDatagrams() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optDatagram = true
+	}
+}
+
 // WithZeroCopyPackets makes packet reads batched: packets are views into the
 // internal buffer, valid until the refill triggered by a later read. The
 // accumulator copies payloads out immediately, so Next works in this mode.
@@ -212,6 +329,34 @@ func WithDVBTables() func(*Demuxer) {
 	}
 }
 
+// WithSkipCRCCheck does not compute the CRC32 of the PSI sections: for a
+// trusted input it is wasted work.
+func WithSkipCRCCheck() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optParse.CRC = psi.CRCSkip
+	}
+}
+
+// WithLenientCRC keeps the PSI sections whose CRC32 does not match instead of
+// dropping them, for corrupt but still useful captures. Under
+// WithRecoverableErrors each mismatch is still reported as an EventError
+// matching psi.ErrCRC32Mismatch, ahead of the table event of the section.
+func WithLenientCRC() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optParse.CRC = psi.CRCLenient
+	}
+}
+
+// WithRawDescriptors keeps the wire bytes of every descriptor of the tables
+// (descriptor.Header.Raw), so a table written back through psi, e.g. by a
+// muxer passing it through, carries its descriptors byte for byte, reserved
+// bits and trailing bytes included.
+func WithRawDescriptors() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optParse.KeepRawDescriptors = true
+	}
+}
+
 // WithPSIRepeats emits a table event for every occurrence of a section,
 // including byte-identical repeats (TableChanged reports false for those).
 // Repeats reuse the cached parse — no re-parse, no allocation. Useful for
@@ -222,6 +367,43 @@ func WithPSIRepeats() func(*Demuxer) {
 	}
 }
 
+// WithTableAssembly collects the sections of a table (same PID, table id,
+// table_id_extension and version) and emits a single table event merging them
+// once section 0 through last_section_number have all been seen — for an EIT,
+// through segment_last_section_number of every segment. Repeats of an
+// assembled version are dropped, sections with current_next_indicator unset
+// are ignored. Tables whose sections cannot be merged are emitted one section
+// at a time as without the option.
+func WithTableAssembly() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optTableAssembly = true
+	}
+}
+
+// WithVersionTracking emits a table only when its version_number changes, per
+// PID, table id and table_id_extension: each section of a version is emitted
+// once, repeats are dropped and sections announcing the next version are
+// ignored. Every change is announced by an EventVersionChange ahead of the
+// table event. It takes precedence over WithPSIRepeats.
+func WithVersionTracking() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optVersionTracking = true
+	}
+}
+
+// WithCaptureTime stamps each packet read with clock (time.Now when nil) into
+// ts.Packet.CaptureTime, and each PES with the capture time of its first
+// packet, for latency measurements and PCR-vs-wallclock comparisons. With
+// WithPipeline the time is taken on the reader goroutine.
+func WithCaptureTime(clock func() time.Time) func(*Demuxer) {
+	return func(d *Demuxer) {
+		if clock == nil {
+			clock = time.Now
+		}
+		d.optClock = clock
+	}
+}
+
 // WithPacketHook runs fn on every raw packet as it is read, before unit
 // assembly, letting one traversal serve both packet- and unit-level work. The
 // packet is valid only for the duration of the call.
@@ -231,6 +413,26 @@ func WithPacketHook(fn func(*ts.Packet)) func(*Demuxer) {
 	}
 }
 
+// CCError describes a continuity counter error: a payload packet whose
+// continuity_counter does not follow the previous one of its PID, without a
+// discontinuity_indicator. The packets in between were lost, so the unit they
+// belonged to is dropped.
+type CCError struct {
+	Offset   int64 // byte offset of the packet
+	PID      uint16
+	Expected uint8
+	Got      uint8
+}
+
+// WithCCErrorHook runs fn on every continuity counter error, e.g. to log and
+// count packet loss. Repeated packets and signalled discontinuities are not
+// errors.
+func WithCCErrorHook(fn func(CCError)) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optCCErrorHook = fn
+	}
+}
+
 // WithRecoverableErrors surfaces non-fatal parse failures the demuxer would
 // otherwise skip silently: a PSI CRC32 mismatch, a torn PSI section, a bad PES
 // unit, a lost sync byte or a dropped corrupt packet. Next then returns
@@ -244,16 +446,23 @@ func WithRecoverableErrors() func(*Demuxer) {
 }
 
 func (dmx *Demuxer) reportRecoverable(e ts.RecoverableError) {
-	dmx.pendingErrs = append(dmx.pendingErrs, &e)
+	if e.PID != ts.PIDUnset && len(dmx.policies.Keys) > 0 {
+		dmx.countPIDError(e.PID, e.Offset)
+	}
+	for _, m := range dmx.monitors {
+		m.ObserveError(&e)
+	}
+	if dmx.optRecoverable {
+		dmx.pendingErrs = append(dmx.pendingErrs, &e)
+	}
 }
 
-func (dmx *Demuxer) nextPacket(p *ts.Packet) (err error) {
+// nextPacket reads the next packet into p. Unless owned is set, p may view
+// buffers of the demuxer until the next read.
+func (dmx *Demuxer) nextPacket(p *ts.Packet, owned bool) (err error) {
 	if dmx.packetBuffer == nil {
 		var onRecover func(ts.RecoverableError)
-		if dmx.optRecoverable {
-			onRecover = dmx.reportRecoverable
-		}
-		if dmx.packetBuffer, err = ts.NewPacketBuffer(dmx.r, ts.PacketBufferConfig{
+		cfg := ts.PacketBufferConfig{
 			PacketSize:    dmx.optPacketSize,
 			SkipErrLimit:  dmx.optSkipErrLimit,
 			Skipper:       dmx.optPacketSkipper,
@@ -261,22 +470,57 @@ func (dmx *Demuxer) nextPacket(p *ts.Packet) (err error) {
 			ZeroCopyBatch: dmx.optZeroCopyBatch,
 			SyncLock:      dmx.optSyncLock,
 			ResyncLimit:   dmx.optResyncLimit,
-			OnRecover:     onRecover,
-		}); err != nil {
+			Redetect:      dmx.optRedetect,
+			StartOffset:   dmx.startOffset,
+			StartIndex:    dmx.startIndex,
+			Clock:         dmx.optClock,
+			Datagram:      dmx.optDatagram,
+		}
+		var pl *pipeline
+		if dmx.optPipelineDepth > 0 {
+			pl = newPipeline(dmx.optPipelineDepth, dmx.optParse)
+			cfg.ZeroCopyBatch = 0
+			onRecover = pl.onRecover
+		} else if dmx.reportsErrors() {
+			onRecover = dmx.reportRecoverable
+		}
+		cfg.OnRecover = onRecover
+		if dmx.packetBuffer, err = ts.NewPacketBuffer(dmx.r, cfg); err != nil {
 			err = fmt.Errorf("astits: creating packet buffer failed: %w", err)
 			return
 		}
+		if pl != nil {
+			dmx.pipe = pl
+			go pl.run(dmx.packetBuffer)
+		}
 	}
 
-	if err = dmx.packetBuffer.Next(p); err != nil {
+	if dmx.pipe != nil {
+		err = dmx.nextPipelined(p, owned)
+	} else if err = dmx.packetBuffer.Next(p); err == nil {
+		dmx.readSize = dmx.packetBuffer.PacketSize()
+	}
+	if err != nil {
 		if !errors.Is(err, ts.ErrNoMorePackets) {
 			err = fmt.Errorf("astits: fetching next packet from buffer failed: %w", err)
 		}
 		return
 	}
+	if len(dmx.remap.Keys) > 0 {
+		dmx.remapPacket(p)
+	}
+	if dmx.index != nil {
+		dmx.index.add(p)
+	}
+	if dmx.keyframes != nil {
+		dmx.indexKeyframe(p)
+	}
 	if dmx.optPacketHook != nil {
 		dmx.optPacketHook(p)
 	}
+	for _, m := range dmx.monitors {
+		m.ObservePacket(p)
+	}
 	return
 }
 
@@ -293,7 +537,7 @@ func (dmx *Demuxer) NextPacket() (p *ts.Packet, err error) {
 		}
 	}
 
-	if err = dmx.nextPacket(p); err != nil {
+	if err = dmx.nextPacket(p, true); err != nil {
 		p.Close()
 		return nil, err
 	}
@@ -310,7 +554,7 @@ func (dmx *Demuxer) NextPacketTo(p *ts.Packet) (err error) {
 		default:
 		}
 	}
-	return dmx.nextPacket(p)
+	return dmx.nextPacket(p, true)
 }
 
 // Next advances the demuxer to the next event. On EventPES claim the unit via
@@ -364,7 +608,18 @@ func (dmx *Demuxer) Next() (ev Event, err error) {
 		}
 
 		var units []unit
-		if err = dmx.nextPacket(&dmx.pkt); err != nil {
+		if dmx.flushing {
+			// Flush: drain the unfinished units as at EOF, then read on
+			dmx.lock()
+			u, ok := dmx.acc.drain()
+			if !ok {
+				dmx.flushing = false
+				dmx.unlock()
+				continue
+			}
+			u.drained = true
+			units = append(dmx.unitsArr[:0], u)
+		} else if err = dmx.nextPacket(&dmx.pkt, false); err != nil {
 			if !errors.Is(err, ts.ErrNoMorePackets) {
 				werr := fmt.Errorf("astits: fetching next packet failed: %w", err)
 				// Flush recoverable errors reported during this failed read before
@@ -377,16 +632,45 @@ func (dmx *Demuxer) Next() (ev Event, err error) {
 			}
 			// EOF: drain the unfinished units, lowest PID first. The reader is
 			// retried on the next call — it may grow.
+			dmx.lock()
 			u, ok := dmx.acc.drain()
 			if !ok {
+				dmx.unlock()
 				// Flush any errors the final read reported before ending.
 				if len(dmx.pendingErrs) > 0 {
 					continue
 				}
 				return 0, ts.ErrNoMorePackets
 			}
+			u.drained = true
 			units = append(dmx.unitsArr[:0], u)
 		} else {
+			dmx.lock()
+			dmx.packetSize = dmx.readSize
+			if af := dmx.pkt.AdaptationField; dmx.pkt.Header.HasAdaptationField && af != nil && af.HasPCR &&
+				(dmx.timeline != nil || dmx.optPCREvents) {
+				pcr := dmx.filterPCR(&dmx.pkt)
+				if dmx.timeline != nil {
+					dmx.observePCR(&dmx.pkt, pcr)
+				}
+				if dmx.optPCREvents {
+					dmx.queuePCR(&dmx.pkt, pcr)
+				}
+			}
+			if dmx.optSCTE35 {
+				dmx.observeCuePCR(&dmx.pkt)
+			}
+			if dmx.pkt.Header.TransportErrorIndicator && dmx.optTEIPolicy == TEIReport && dmx.reportsErrors() {
+				dmx.reportTEI(&dmx.pkt)
+			}
+			if len(dmx.policies.Keys) > 0 && dmx.dropped(dmx.pkt.Header.PID) {
+				dmx.unlock()
+				continue
+			}
+			if len(dmx.descramblers.Keys) > 0 && !dmx.descramble(&dmx.pkt) {
+				dmx.unlock()
+				continue
+			}
 			units = dmx.acc.add(&dmx.pkt, dmx.unitsArr[:0])
 		}
 
@@ -401,12 +685,31 @@ func (dmx *Demuxer) Next() (ev Event, err error) {
 				dmx.claimed = false
 			}
 		}
+		dmx.unlock()
 		if dmx.pending != nil {
 			return EventPES, nil
 		}
 	}
 }
 
+// WithTruncatedPES delivers a PES unit cut short at EOF or by Flush — its
+// PES_packet_length running past the bytes received, as for a live capture
+// stopped mid-PES — with the data received and PES.Truncated set, instead of
+// dropping it as a short unit.
+func WithTruncatedPES() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optTruncatedPES = true
+	}
+}
+
+// Flush makes the following Next calls emit the unfinished units first, in
+// ascending PID order as at EOF, then carry on reading: for a live source
+// that pauses or stops without EOF. Units cut short are dropped unless
+// WithTruncatedPES is set.
+func (dmx *Demuxer) Flush() {
+	dmx.flushing = true
+}
+
 // PES claims the unit of the last EventPES: the caller owns it until Close.
 // An unclaimed unit is released by the next Next call.
 func (dmx *Demuxer) PES() *PES {
@@ -416,6 +719,23 @@ func (dmx *Demuxer) PES() *PES {
 	return dmx.pending
 }
 
+// NextPES advances to the next PES unit of pid and claims it: the caller owns
+// it until Close. Tables and the units of other PIDs are skipped over (table
+// state such as PAT() and PMT() still updates). A recoverable error (see
+// WithRecoverableErrors) is returned with a nil unit; the next call carries on
+// past it. EOF is ts.ErrNoMorePackets.
+func (dmx *Demuxer) NextPES(pid uint16) (d *PES, err error) {
+	for {
+		var ev Event
+		if ev, err = dmx.Next(); err != nil {
+			return nil, err
+		}
+		if ev == EventPES && dmx.pending.PID == pid {
+			return dmx.PES(), nil
+		}
+	}
+}
+
 // Section is the section behind the last table event, valid until the next
 // Next call.
 func (dmx *Demuxer) Section() (pid uint16, s psi.SectionSyntaxData) {
@@ -432,11 +752,15 @@ func (dmx *Demuxer) TableChanged() bool {
 
 // PAT is the last parsed program association table; nil until one is seen.
 func (dmx *Demuxer) PAT() *psi.PAT {
+	dmx.rlock()
+	defer dmx.runlock()
 	return dmx.pat
 }
 
 // PMT is the last parsed program map table; nil until one is seen.
 func (dmx *Demuxer) PMT() *psi.PMT {
+	dmx.rlock()
+	defer dmx.runlock()
 	return dmx.pmt
 }
 
@@ -471,6 +795,7 @@ func (dmx *Demuxer) Events() iter.Seq2[Event, error] {
 // the pending unit. The demuxer must not be used after Close. Mandatory for
 // demuxers abandoned before the end of the stream.
 func (dmx *Demuxer) Close() {
+	dmx.stopPipeline(false)
 	if dmx.pending != nil && !dmx.claimed {
 		dmx.pending.Close()
 	}
@@ -481,16 +806,76 @@ func (dmx *Demuxer) Close() {
 // Rewind rewinds the demuxer reader. The table state survives, the emission
 // dedup does not: tables are re-emitted on the second pass.
 func (dmx *Demuxer) Rewind() (n int64, err error) {
+	dmx.reset()
+	dmx.startOffset, dmx.startIndex = 0, 0
+	if n, err = ts.Rewind(dmx.r); err != nil {
+		err = fmt.Errorf("astits: rewinding reader failed: %w", err)
+		return
+	}
+	return
+}
+
+// Seek moves the demuxer to the first unit boundary at or after offset in an
+// io.Seeker reader, returning that boundary. whence is io.SeekStart,
+// io.SeekEnd, or io.SeekCurrent relative to the offset of the last packet
+// read. Partially assembled units are dropped; like Rewind, the table state
+// survives and the emission dedup does not. Packet offsets stay relative to the
+// start of the reader; packet indexes resume at the offset over the packet size,
+// exact for a stream of constant packet size.
+func (dmx *Demuxer) Seek(offset int64, whence int) (n int64, err error) {
+	s, ok := dmx.r.(io.Seeker)
+	if !ok {
+		return 0, ts.ErrNotSeekable
+	}
+	dmx.stopPipeline(true)
+	switch whence {
+	case io.SeekCurrent:
+		offset += dmx.pkt.Offset
+	case io.SeekEnd:
+		if offset, err = s.Seek(offset, io.SeekEnd); err != nil {
+			return 0, fmt.Errorf("astits: seeking reader failed: %w", err)
+		}
+	}
+
+	packetSize := dmx.optPacketSize
+	if dmx.packetBuffer != nil {
+		packetSize = dmx.packetBuffer.PacketSize()
+	}
+	dmx.reset()
+	if n, err = ts.SeekSync(dmx.r, offset, packetSize); err != nil {
+		if !errors.Is(err, ts.ErrNoMorePackets) {
+			err = fmt.Errorf("astits: seeking reader failed: %w", err)
+		}
+		return
+	}
+	dmx.startOffset = n
+	if packetSize == 0 {
+		packetSize = ts.PacketSize
+	}
+	dmx.startIndex = n / int64(packetSize)
+	return
+}
+
+// reset drops the read state ahead of a reader reposition.
+func (dmx *Demuxer) reset() {
+	dmx.stopPipeline(true)
 	dmx.Close()
+	dmx.lock()
+	defer dmx.unlock()
 	dmx.packetBuffer = nil
+	dmx.packetSize = 0
+	dmx.readSize = 0
 	dmx.tblQueue = dmx.tblArr[:0]
 	dmx.pendingErrs = dmx.errArr[:0]
 	dmx.pendingFatal = nil
+	dmx.flushing = false
 	dmx.psiPrev = pidmap.Map[psiCache]{Keys: dmx.psiKeysArr[:0], Vals: dmx.psiValsArr[:0]}
-	dmx.acc.init(&dmx.programMap, dmx.optDVBTables)
-	if n, err = ts.Rewind(dmx.r); err != nil {
-		err = fmt.Errorf("astits: rewinding reader failed: %w", err)
-		return
+	dmx.tables = nil
+	dmx.versions = nil
+	dmx.sectionCRCs = nil
+	if dmx.timeline != nil {
+		*dmx.timeline = timeline{rebase: dmx.timeline.rebase}
 	}
-	return
+	dmx.pcrFilters = pidmap.Map[ts.PCROffsetFilter]{}
+	dmx.acc.init(&dmx.programMap, &dmx.sectionParsers, dmx.optDVBTables)
 }
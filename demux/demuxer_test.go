@@ -3,9 +3,11 @@ package demux
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 	"unicode"
@@ -180,6 +182,98 @@ func TestDemuxerNextUnknownDataPackets(t *testing.T) {
 	assert.EqualError(t, err, ts.ErrNoMorePackets.Error())
 }
 
+func TestDemuxerDropPIDs(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, 0x11, psi.TableIDSDTVariant1, 7, &psi.SDT{TransportStreamID: 7})...)
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{TransportStreamID: 7})...)
+
+	drop := ts.NewPIDSet()
+	drop.AddRange(0x10, 0x1f)
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithDVBTables(), WithDropPIDs(&drop))
+	defer dmx.Close()
+
+	ev, err := dmx.Next()
+	require.NoError(t, err)
+	assert.Equal(t, EventPAT, ev)
+	_, err = dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
+
+func TestDemuxerCCErrorHook(t *testing.T) {
+	var stream []byte
+	for _, cc := range []uint8{14, 15, 15, 0, 3} { // repeat, then two packets lost
+		p := payloadPacket(0x100, []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00})
+		ts.SetContinuityCounter(p, cc)
+		stream = append(stream, p...)
+	}
+	p := videoPacket(t, 0x100, 9, 0, false) // signalled discontinuity
+	p[5] |= 0x80
+	stream = append(stream, p...)
+
+	var got []CCError
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize),
+		WithCCErrorHook(func(e CCError) { got = append(got, e) }))
+	defer dmx.Close()
+	for {
+		if _, err := dmx.Next(); errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+	}
+	assert.Equal(t, []CCError{{PID: 0x100, Expected: 1, Got: 3, Offset: 4 * ts.PacketSize}}, got)
+}
+
+func TestDemuxerArrivalTimeStamp(t *testing.T) {
+	// M2TS: copy_permission 2 and arrival_time_stamp 1000, 1001 ahead of the packets
+	var stream []byte
+	for cc, start := range []bool{true, false} {
+		p := payloadPacket(0x100, []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00})
+		if !start {
+			p[1] &^= 0x40
+		}
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = binary.BigEndian.AppendUint32(stream, 2<<30|uint32(1000+cc))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.M2TSPacketSize))
+	defer dmx.Close()
+	ev, err := dmx.Next()
+	require.NoError(t, err)
+	require.Equal(t, EventPES, ev)
+	d := dmx.PES()
+	defer d.Close()
+	assert.True(t, d.HasArrivalTimeStamp)
+	assert.Equal(t, uint32(1000), d.ArrivalTimeStamp)
+	assert.Equal(t, uint8(2), d.CopyPermission)
+}
+
+func TestDemuxerNextPESByPID(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 1, &psi.PAT{TransportStreamID: 1})...)
+	for i, pid := range []uint16{0x100, 0x101, 0x100, 0x101} {
+		p := payloadPacket(pid, []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00, byte(i)})
+		ts.SetContinuityCounter(p, uint8(i/2))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize))
+	defer dmx.Close()
+	var got []byte
+	for {
+		d, err := dmx.NextPES(0x101)
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, uint16(0x101), d.PID)
+		require.NotEmpty(t, d.Data.Data)
+		got = append(got, d.Data.Data[0])
+		d.Close()
+	}
+	assert.Equal(t, []byte{1, 3}, got)
+	assert.NotNil(t, dmx.PAT(), "tables are still processed")
+}
+
 func TestDemuxerNextPATPMT(t *testing.T) {
 	pat := hexToBytes(`474000100000b00d0001c100000001f0002ab104b2ffffffffffffffff
 		ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
@@ -388,6 +482,46 @@ func FuzzDemuxerView(f *testing.F) {
 	})
 }
 
+func TestDemuxerSeek(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{TransportStreamID: 7})...)
+	stream = append(stream, psiPacket(t, 0x11, psi.TableIDSDTVariant1, 7, &psi.SDT{TransportStreamID: 7})...)
+	next := psiPacket(t, 0x11, psi.TableIDSDTVariant1, 8, &psi.SDT{TransportStreamID: 8})
+	ts.SetContinuityCounter(next, 1)
+	stream = append(stream, next...)
+
+	var offsets []int64
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithDVBTables(),
+		WithPacketHook(func(p *ts.Packet) { offsets = append(offsets, p.Offset) }))
+	defer dmx.Close()
+
+	n, err := dmx.Seek(ts.PacketSize+1, io.SeekStart)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*ts.PacketSize), n)
+	ev, err := dmx.Next()
+	require.NoError(t, err)
+	assert.Equal(t, EventSDT, ev)
+	_, data := dmx.Section()
+	assert.Equal(t, uint16(8), data.(*psi.SDT).TransportStreamID)
+
+	n, err = dmx.Seek(-ts.PacketSize, io.SeekCurrent)
+	require.NoError(t, err)
+	assert.Equal(t, int64(ts.PacketSize), n)
+	ev, err = dmx.Next()
+	require.NoError(t, err)
+	assert.Equal(t, EventSDT, ev)
+	_, data = dmx.Section()
+	assert.Equal(t, uint16(7), data.(*psi.SDT).TransportStreamID)
+	assert.Equal(t, []int64{2 * ts.PacketSize, ts.PacketSize}, offsets)
+
+	_, err = New(context.Background(), nonSeekable{bytes.NewReader(stream)}).Seek(0, io.SeekStart)
+	assert.ErrorIs(t, err, ts.ErrNotSeekable)
+}
+
+type nonSeekable struct{ r io.Reader }
+
+func (n nonSeekable) Read(p []byte) (int, error) { return n.r.Read(p) }
+
 func TestDemuxerPSIRepeats(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := bitstest.NewWriter(buf)
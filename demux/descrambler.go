@@ -0,0 +1,62 @@
+package demux
+
+import (
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// Descrambler returns the clear payload of scrambled packets, e.g. a DVB-CSA,
+// BISS or AES integration holding the control words. See WithDescrambler.
+type Descrambler interface {
+	// Descramble returns the clear payload of a packet of pid scrambled with
+	// sc. It may decrypt payload in place and return it; the packet is valid
+	// only for the duration of the call.
+	Descramble(pid uint16, sc ts.ScramblingControl, payload []byte) ([]byte, error)
+}
+
+// WithDescrambler registers d for the scrambled packets of pid, run before
+// unit assembly, so PES units and sections come out clear; the packet hook and
+// monitors still see the packets as read. A failing packet is dropped and, with
+// WithRecoverableErrors, reported as a packet drop.
+func WithDescrambler(pid uint16, d Descrambler) func(*Demuxer) {
+	return func(dmx *Demuxer) {
+		dmx.SetDescrambler(pid, d)
+	}
+}
+
+// SetDescrambler registers d for pid after construction, e.g. once its PMT
+// lists the stream; a nil d removes the descrambler of pid.
+func (dmx *Demuxer) SetDescrambler(pid uint16, d Descrambler) {
+	if d == nil {
+		dmx.descramblers.Remove(pid)
+		return
+	}
+	dmx.descramblers.Set(pid, d)
+}
+
+// descramble clears the payload of p in place when its PID has a descrambler,
+// and reports whether p is to be demuxed.
+func (dmx *Demuxer) descramble(p *ts.Packet) bool {
+	sc := p.Header.TransportScramblingControl
+	if sc == ts.ScramblingControlNotScrambled || !p.Header.HasPayload {
+		return true
+	}
+	d := dmx.descramblers.Get(p.Header.PID)
+	if d == nil {
+		return true
+	}
+	payload, err := (*d).Descramble(p.Header.PID, sc, p.Payload)
+	if err != nil {
+		if dmx.reportsErrors() {
+			dmx.reportRecoverable(ts.RecoverableError{
+				Kind: ts.ErrorKindPacketDrop, PID: p.Header.PID, Offset: p.Offset,
+				Err: fmt.Errorf("astits: descrambling failed: %w", err),
+			})
+		}
+		return false
+	}
+	p.Payload = payload
+	p.Header.TransportScramblingControl = ts.ScramblingControlNotScrambled
+	return true
+}
@@ -0,0 +1,64 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// xorDescrambler clears payloads scrambled with the even key by XOR.
+type xorDescrambler byte
+
+var errOddKey = errors.New("odd key")
+
+func (x xorDescrambler) Descramble(_ uint16, sc ts.ScramblingControl, payload []byte) ([]byte, error) {
+	if sc != ts.ScramblingControlScrambledWithEvenKey {
+		return nil, errOddKey
+	}
+	for i := range payload {
+		payload[i] ^= byte(x)
+	}
+	return payload, nil
+}
+
+func TestDemuxerDescrambler(t *testing.T) {
+	scrambled := func(cc uint8, sc ts.ScramblingControl) []byte {
+		p := payloadPacket(0x100, []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00, 0xaa})
+		for i := ts.HeaderSize; i < len(p); i++ {
+			p[i] ^= 0x5a
+		}
+		p[3] |= byte(sc) << 6
+		ts.SetContinuityCounter(p, cc)
+		return p
+	}
+	stream := append(scrambled(0, ts.ScramblingControlScrambledWithEvenKey), scrambled(1, ts.ScramblingControlScrambledWithOddKey)...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize),
+		WithDescrambler(0x100, xorDescrambler(0x5a)), WithRecoverableErrors())
+	defer dmx.Close()
+
+	ev, err := dmx.Next()
+	require.Equal(t, EventError, ev)
+	assert.ErrorIs(t, err, errOddKey)
+	// The dropped packet flushes nothing: the first unit ends at EOF
+	ev, err = dmx.Next()
+	require.NoError(t, err)
+	require.Equal(t, EventPES, ev)
+	d := dmx.PES()
+	defer d.Close()
+	assert.Equal(t, byte(0xaa), d.Data.Data[0])
+
+	dmx = New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize))
+	defer dmx.Close()
+	dmx.SetDescrambler(0x100, xorDescrambler(0x5a))
+	dmx.SetDescrambler(0x100, nil)
+	// Still scrambled: no PES start code
+	_, err = dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
@@ -1,12 +1,21 @@
 // Package demux turns an MPEG-TS byte stream into events. [New] builds a
 // [Demuxer]; [Demuxer.Next] — or the [Demuxer.Events] iterator — advances to
-// the next [EventPES] or typed table event (EventPAT, EventPMT, …). Claim a
+// the next [EventPES] or typed table event (EventPAT, EventPMT, …), whose
+// [Event.Kind] tells which accessor holds the payload. Claim a
 // completed unit with [Demuxer.PES], and read table state with
-// [Demuxer.Section], [Demuxer.PAT] and [Demuxer.PMT].
+// [Demuxer.Section], [Demuxer.PAT] and [Demuxer.PMT], or merged per program
+// with [Demuxer.Services]. Alternatively, [Demuxer.Run] dispatches the events
+// to callbacks registered with [Demuxer.OnPAT], [Demuxer.OnPES] and the other
+// On methods, and [Demuxer.NextPES] pulls the units of a single elementary
+// stream.
 //
 // Results are borrowed until the next Next call: a claimed [PES] must be
 // [PES.Close]d, an abandoned demuxer released with [Demuxer.Close], and
 // anything kept from Section/PAT/PMT copied out. DVB tables are parsed only
-// with [WithDVBTables]; [WithZeroCopyPackets] enables the view read mode. See
-// the module documentation for the full ownership and view-mode contracts.
+// with [WithDVBTables]; private tables are handed to parsers registered with
+// [Demuxer.RegisterSectionParser], [WithCASections] delivers the ECM and EMM
+// sections of the CA PIDs, [WithSCTE35] the SCTE 35 cues of the programs, and
+// [WithTableAssembly] merges multi-section tables into one event.
+// [WithZeroCopyPackets] enables the view read mode. See the module
+// documentation for the full ownership and view-mode contracts.
 package demux
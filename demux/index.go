@@ -0,0 +1,149 @@
+package demux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// ErrNoTimeIndex reports a SeekToTime without an indexed time to seek to.
+var ErrNoTimeIndex = errors.New("astits: no time index")
+
+const (
+	// timeIndexInterval is the minimum stream time between index entries.
+	timeIndexInterval = 100 * time.Millisecond
+	// pcrTicksPerSecond is the 27 MHz system clock rate.
+	pcrTicksPerSecond = 27000000
+	// pcrWrap is the PCR period in 27 MHz ticks: the 33-bit base times 300.
+	pcrWrap = (1 << 33) * 300
+)
+
+// TimeIndexEntry locates the packet carrying a PCR: its byte offset and the
+// stream time of the PCR since the first indexed one.
+type TimeIndexEntry struct {
+	Offset int64
+	Time   time.Duration
+}
+
+// TimeIndex maps stream time to byte offsets, from the PCRs of the first PID
+// seen carrying one. PCR wraps are unwrapped; entries are at least 100ms
+// apart, in offset order.
+type TimeIndex struct {
+	Entries []TimeIndexEntry
+
+	pid     uint16
+	first   int64 // unwrapped ticks of the first PCR
+	last    int64 // ticks of the last PCR, before unwrapping
+	wraps   int64
+	started bool
+}
+
+// add indexes the PCR of p, if any. Packets at or before the last entry (read
+// again after a seek back) are ignored.
+func (x *TimeIndex) add(p *ts.Packet) {
+	af := p.AdaptationField
+	if af == nil || !af.HasPCR || (x.started && p.Header.PID != x.pid) {
+		return
+	}
+	if n := len(x.Entries); n > 0 && p.Offset <= x.Entries[n-1].Offset {
+		return
+	}
+
+	ticks := int64(af.PCR.Ticks())
+	if !x.started {
+		x.pid, x.first, x.last, x.started = p.Header.PID, ticks, ticks, true
+	}
+	if ticks < x.last-pcrWrap/2 {
+		x.wraps++
+	}
+	x.last = ticks
+	ticks += x.wraps*pcrWrap - x.first
+
+	t := time.Duration(ticks/pcrTicksPerSecond)*time.Second + time.Duration(ticks%pcrTicksPerSecond)*time.Second/pcrTicksPerSecond
+	if n := len(x.Entries); n > 0 && t-x.Entries[n-1].Time < timeIndexInterval {
+		return
+	}
+	x.Entries = append(x.Entries, TimeIndexEntry{Offset: p.Offset, Time: t})
+}
+
+// Lookup returns the last entry at or before t, clamped to the first one.
+func (x *TimeIndex) Lookup(t time.Duration) (e TimeIndexEntry, ok bool) {
+	if len(x.Entries) == 0 {
+		return
+	}
+	i := sort.Search(len(x.Entries), func(i int) bool { return x.Entries[i].Time > t })
+	return x.Entries[max(i-1, 0)], true
+}
+
+// WithTimeIndex builds the time index of the packets read as demuxing goes,
+// for SeekToTime within the part of the stream already read. See Index for a
+// full pass.
+func WithTimeIndex() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.index = &TimeIndex{}
+	}
+}
+
+// TimeIndex returns the time index built so far; nil without WithTimeIndex or
+// Index.
+func (dmx *Demuxer) TimeIndex() *TimeIndex {
+	return dmx.index
+}
+
+// Index reads the whole seekable reader once to build its time index, then
+// rewinds it (see Rewind). The index is kept for SeekToTime.
+func (dmx *Demuxer) Index() (x *TimeIndex, err error) {
+	if _, ok := dmx.r.(io.Seeker); !ok {
+		return nil, ts.ErrNotSeekable
+	}
+	if _, err = dmx.Rewind(); err != nil {
+		return
+	}
+
+	var pb *ts.PacketBuffer
+	if pb, err = ts.NewPacketBuffer(dmx.r, ts.PacketBufferConfig{
+		PacketSize:   dmx.optPacketSize,
+		SkipErrLimit: dmx.optSkipErrLimit,
+		SyncLock:     dmx.optSyncLock,
+		ResyncLimit:  dmx.optResyncLimit,
+		Redetect:     dmx.optRedetect,
+	}); err != nil {
+		return nil, fmt.Errorf("astits: creating packet buffer failed: %w", err)
+	}
+	x = &TimeIndex{}
+	p := ts.NewPacket()
+	defer p.Close()
+	for {
+		if err = pb.Next(p); err != nil {
+			if !errors.Is(err, ts.ErrNoMorePackets) {
+				return nil, fmt.Errorf("astits: fetching next packet from buffer failed: %w", err)
+			}
+			break
+		}
+		x.add(p)
+	}
+
+	if _, err = dmx.Rewind(); err != nil {
+		return nil, err
+	}
+	dmx.index = x
+	return
+}
+
+// SeekToTime seeks to the indexed packet at or before stream time t, counted
+// from the first PCR (see Seek). It returns ErrNoTimeIndex while the index is
+// empty.
+func (dmx *Demuxer) SeekToTime(t time.Duration) (n int64, err error) {
+	if dmx.index == nil {
+		return 0, ErrNoTimeIndex
+	}
+	e, ok := dmx.index.Lookup(t)
+	if !ok {
+		return 0, ErrNoTimeIndex
+	}
+	return dmx.Seek(e.Offset, io.SeekStart)
+}
@@ -0,0 +1,70 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// pcrPacket builds an adaptation-field-only packet on pid carrying pcrBase.
+func pcrPacket(t *testing.T, pid uint16, pcrBase uint64) []byte {
+	p := ts.Packet{
+		Header: ts.PacketHeader{PID: pid, HasAdaptationField: true},
+		AdaptationField: &ts.PacketAdaptationField{
+			HasPCR:         true,
+			PCR:            ts.NewClockReference(pcrBase&(1<<33-1), 0),
+			StuffingLength: ts.PacketSize - ts.HeaderSize - 2 - ts.PCRSize,
+		},
+	}
+	bs := make([]byte, ts.PacketSize)
+	_, err := p.Put(bs)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestDemuxerTimeIndex(t *testing.T) {
+	// Ten PCRs 0.5s apart, wrapping after the third
+	var stream []byte
+	for i := range uint64(10) {
+		stream = append(stream, pcrPacket(t, 0x100, 1<<33-3*45000+i*45000)...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithTimeIndex())
+	defer dmx.Close()
+	_, err := dmx.SeekToTime(time.Second)
+	assert.ErrorIs(t, err, ErrNoTimeIndex)
+	for {
+		if _, err = dmx.Next(); errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	require.Len(t, dmx.TimeIndex().Entries, 10)
+
+	x, err := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize)).Index()
+	require.NoError(t, err)
+	assert.Equal(t, dmx.TimeIndex().Entries, x.Entries)
+	for i, e := range x.Entries {
+		assert.Equal(t, TimeIndexEntry{Offset: int64(i * ts.PacketSize), Time: time.Duration(i) * 500 * time.Millisecond}, e)
+	}
+
+	var offsets []int64
+	dmx = New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize),
+		WithPacketHook(func(p *ts.Packet) { offsets = append(offsets, p.Offset) }))
+	defer dmx.Close()
+	_, err = dmx.Index()
+	require.NoError(t, err)
+	n, err := dmx.SeekToTime(4200 * time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8*ts.PacketSize), n)
+	_, err = dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+	assert.Equal(t, []int64{8 * ts.PacketSize, 9 * ts.PacketSize}, offsets)
+}
@@ -0,0 +1,68 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// Keyframe locates a decodable point of a video PID: the packet starting a PES
+// unit with random_access_indicator set.
+type Keyframe struct {
+	Offset int64
+	PTS    ts.ClockReference
+	HasPTS bool
+}
+
+// WithKeyframeIndex records the keyframes of the video PIDs in the packets read,
+// for Keyframes. A video PID is one whose PES units carry a video stream_id.
+func WithKeyframeIndex() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.keyframes = &pidmap.Map[[]Keyframe]{}
+	}
+}
+
+// Keyframes returns the keyframes of pid recorded so far, in offset order; nil
+// without WithKeyframeIndex.
+func (dmx *Demuxer) Keyframes(pid uint16) []Keyframe {
+	if dmx.keyframes == nil {
+		return nil
+	}
+	if ks := dmx.keyframes.Get(pid); ks != nil {
+		return *ks
+	}
+	return nil
+}
+
+// indexKeyframe records p when it starts a video PES unit at a random access
+// point. Packets at or before the last keyframe of the PID (read again after a
+// seek back) are ignored.
+func (dmx *Demuxer) indexKeyframe(p *ts.Packet) {
+	if af := p.AdaptationField; af == nil || !af.RandomAccessIndicator || !p.Header.PayloadUnitStartIndicator {
+		return
+	}
+	bs := p.Payload
+	if len(bs) < pes.HeaderSize || bs[0] != 0 || bs[1] != 0 || bs[2] != 1 || !isVideoStreamID(pes.StreamID(bs[3])) {
+		return
+	}
+	k := Keyframe{Offset: p.Offset}
+	// PTS_DTS_flags sit in the second flag byte of the optional header, the
+	// PTS right after header_data_length.
+	if len(bs) >= pes.HeaderSize+3+ts.PTSDTSSize && bs[pes.HeaderSize+1]&0x80 != 0 {
+		if _, err := k.PTS.ParsePTSDTS(bs[pes.HeaderSize+3:]); err == nil {
+			k.HasPTS = true
+		}
+	}
+
+	ks := dmx.keyframes.GetOrAdd(p.Header.PID)
+	if n := len(*ks); n > 0 && k.Offset <= (*ks)[n-1].Offset {
+		return
+	}
+	*ks = append(*ks, k)
+}
+
+// isVideoStreamID reports an MPEG video stream_id (0xe0-0xef) or the extended
+// stream_id used by VC-1 and other video formats.
+func isVideoStreamID(id pes.StreamID) bool {
+	return id&0xf0 == 0xe0 || id == 0xfd
+}
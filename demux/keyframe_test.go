@@ -0,0 +1,59 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// videoPacket builds a packet starting a video PES unit with a PTS on pid,
+// flagged as a random access point when rai.
+func videoPacket(t *testing.T, pid uint16, cc uint8, pts uint64, rai bool) []byte {
+	payload := []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x80, 0x05, 0, 0, 0, 0, 0, 0xaa}
+	cr := ts.NewClockReference(pts, 0)
+	cr.PutPTSDTS(payload[9:], 0x2)
+	p := ts.Packet{
+		Header: ts.PacketHeader{
+			PID: pid, ContinuityCounter: cc, HasPayload: true, HasAdaptationField: true, PayloadUnitStartIndicator: true,
+		},
+		AdaptationField: &ts.PacketAdaptationField{
+			RandomAccessIndicator: rai,
+			StuffingLength:        uint8(ts.PacketSize - ts.HeaderSize - 2 - len(payload)),
+		},
+		Payload: payload,
+	}
+	bs := make([]byte, ts.PacketSize)
+	_, err := p.Put(bs)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestDemuxerKeyframeIndex(t *testing.T) {
+	var stream []byte
+	stream = append(stream, videoPacket(t, 0x100, 0, 90000, true)...)
+	stream = append(stream, videoPacket(t, 0x100, 1, 93600, false)...)
+	stream = append(stream, payloadPacket(0x101, []byte{0x00, 0x00, 0x01, 0xc0, 0x00, 0x00, 0x80, 0x00, 0x00})...) // audio
+	stream = append(stream, videoPacket(t, 0x100, 2, 180000, true)...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithKeyframeIndex())
+	defer dmx.Close()
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []Keyframe{
+		{Offset: 0, PTS: ts.NewClockReference(90000, 0), HasPTS: true},
+		{Offset: 3 * ts.PacketSize, PTS: ts.NewClockReference(180000, 0), HasPTS: true},
+	}, dmx.Keyframes(0x100))
+	assert.Nil(t, dmx.Keyframes(0x101))
+}
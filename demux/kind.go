@@ -0,0 +1,56 @@
+package demux
+
+// EventKind groups the events by the accessor their payload is read with, so
+// a consumer switches on a handful of kinds rather than on every event.
+type EventKind uint8
+
+const (
+	// KindPES: the payload is a *PES, claimed via Demuxer.PES().
+	KindPES EventKind = iota
+	// KindTable: the payload is behind Demuxer.Section() — a PSI table, a
+	// registered section parser's result, a *CASection or a *Cue.
+	KindTable
+	// KindError: the payload is the *ts.RecoverableError Next returned.
+	KindError
+	// KindVersionChange: the payload is Demuxer.VersionChange().
+	KindVersionChange
+	// KindDiscontinuity: the payload is Demuxer.Discontinuity().
+	KindDiscontinuity
+	// KindPCR: the payload is Demuxer.PCR().
+	KindPCR
+	// KindPMTDiff: the payload is Demuxer.PMTDiff().
+	KindPMTDiff
+)
+
+var eventKindNames = map[EventKind]string{
+	KindPES:           "PES",
+	KindTable:         "Table",
+	KindError:         "Error",
+	KindVersionChange: "VersionChange",
+	KindDiscontinuity: "Discontinuity",
+	KindPCR:           "PCR",
+	KindPMTDiff:       "PMTDiff",
+}
+
+func (k EventKind) String() string {
+	return eventKindNames[k]
+}
+
+// Kind tells which payload the event carries.
+func (e Event) Kind() EventKind {
+	switch e {
+	case EventPES:
+		return KindPES
+	case EventError:
+		return KindError
+	case EventVersionChange:
+		return KindVersionChange
+	case EventDiscontinuity:
+		return KindDiscontinuity
+	case EventPCR:
+		return KindPCR
+	case EventPMTDiff:
+		return KindPMTDiff
+	}
+	return KindTable
+}
@@ -0,0 +1,26 @@
+package demux
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventKind(t *testing.T) {
+	for ev, want := range map[Event]EventKind{
+		EventPES:           KindPES,
+		EventPAT:           KindTable,
+		EventEIT:           KindTable,
+		EventSection:       KindTable,
+		EventECM:           KindTable,
+		EventSCTE35:        KindTable,
+		EventError:         KindError,
+		EventVersionChange: KindVersionChange,
+		EventDiscontinuity: KindDiscontinuity,
+		EventPCR:           KindPCR,
+		EventPMTDiff:       KindPMTDiff,
+	} {
+		assert.Equal(t, want, ev.Kind(), ev.String())
+	}
+	assert.Equal(t, "Table", EventSCTE35.Kind().String())
+}
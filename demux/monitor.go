@@ -0,0 +1,28 @@
+package demux
+
+import "github.com/k-danil/go-astits/v2/ts"
+
+// Monitor observes the raw packet flow of a Demuxer alongside demuxing, e.g. a
+// stream analyzer such as tr101290.Monitor. See WithMonitor.
+type Monitor interface {
+	// ObservePacket is called on every packet read, after WithPacketHook. The
+	// packet is valid only for the duration of the call.
+	ObservePacket(p *ts.Packet)
+	// ObserveError is called on every recoverable error the demuxer detects
+	// (see WithRecoverableErrors), whether or not Next reports it.
+	ObserveError(e *ts.RecoverableError)
+}
+
+// WithMonitor attaches m to the demuxer. Several monitors may be attached; they
+// are called in the order of their options.
+func WithMonitor(m Monitor) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.monitors = append(d.monitors, m)
+	}
+}
+
+// reportsErrors tells whether recoverable errors have a consumer: Next under
+// WithRecoverableErrors, a monitor or a PID policy.
+func (dmx *Demuxer) reportsErrors() bool {
+	return dmx.optRecoverable || len(dmx.monitors) > 0 || len(dmx.policies.Keys) > 0
+}
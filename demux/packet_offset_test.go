@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,12 +38,14 @@ func TestPacketOffset(t *testing.T) {
 		name        string
 		skipper     ts.PacketSkipper
 		wantOffsets []int64
+		wantIndexes []int64
 	}{
-		{"noSkipper", nil, []int64{0, 188, 376, 564, 752, 940}},
+		{"noSkipper", nil, []int64{0, 188, 376, 564, 752, 940}, []int64{0, 1, 2, 3, 4, 5}},
 		{
 			"skipperAdvancesOffset",
 			func(p *ts.Packet) bool { return p.Header.PID == skipPID },
 			[]int64{0, 564, 940},
+			[]int64{0, 3, 5},
 		},
 	}
 	for _, tc := range tests {
@@ -55,15 +58,55 @@ func TestPacketOffset(t *testing.T) {
 
 			p := ts.NewPacket()
 			defer p.Close()
-			var offsets []int64
+			var offsets, indexes []int64
 			for {
 				if err := dmx.NextPacketTo(p); err != nil {
 					require.True(t, errors.Is(err, ts.ErrNoMorePackets))
 					break
 				}
 				offsets = append(offsets, p.Offset)
+				indexes = append(indexes, p.Index)
 			}
 			assert.Equal(t, tc.wantOffsets, offsets)
+			assert.Equal(t, tc.wantIndexes, indexes)
 		})
 	}
 }
+
+func TestPESOffset(t *testing.T) {
+	var stream []byte
+	for i := range 3 {
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), uint64(i)*3600, i == 0)...)
+		// An audio packet between the video ones
+		stream = append(stream, payloadPacket(0x101, []byte{0x00, 0x00, 0x01, 0xc0, 0x00, 0x00, 0x80, 0x00, 0x00})...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize))
+	defer dmx.Close()
+	var video []int64
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		if ev != EventPES {
+			continue
+		}
+		d := dmx.PES()
+		assert.Equal(t, d.Index*ts.PacketSize, d.Offset)
+		if d.PID == 0x100 {
+			video = append(video, d.Index)
+		}
+		d.Close()
+	}
+	assert.Equal(t, []int64{0, 2, 4}, video)
+
+	// Indexes resume from the seek position
+	_, err := dmx.Seek(3*ts.PacketSize, io.SeekStart)
+	require.NoError(t, err)
+	p, err := dmx.NextPacket()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), p.Index)
+	p.Close()
+}
@@ -0,0 +1,61 @@
+package demux
+
+import "github.com/k-danil/go-astits/v2/ts"
+
+// PCR is a program clock reference sample, the payload of EventPCR.
+type PCR struct {
+	PCR           ts.ClockReference
+	Offset        int64 // byte offset of the packet
+	PID           uint16
+	Discontinuity bool // the packet's discontinuity_indicator
+}
+
+// WithPCREvents emits an EventPCR for every packet carrying a PCR, including
+// the adaptation-field-only packets that produce no unit, for clock recovery
+// and latency measurements. The event follows the PES unit the same packet
+// completes, if any.
+func WithPCREvents() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optPCREvents = true
+	}
+}
+
+// WithPCRFilter passes the PCRs of each PID through a filter from fn, e.g.
+// ts.NewPCRMedianFilter, before the timeline (WithDiscontinuities) and the
+// EventPCR samples see them. The filter's arrival clock is the byte offset of
+// the packets (ts.PCROffsetFilter). Packets keep their PCRs as read.
+func WithPCRFilter(fn func() ts.PCRFilter) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optPCRFilter = fn
+	}
+}
+
+// filterPCR returns the PCR of p, which carries one, through the WithPCRFilter
+// filter of its PID.
+func (dmx *Demuxer) filterPCR(p *ts.Packet) ts.ClockReference {
+	af := p.AdaptationField
+	if dmx.optPCRFilter == nil {
+		return af.PCR
+	}
+	f := dmx.pcrFilters.Get(p.Header.PID)
+	if f == nil {
+		f = dmx.pcrFilters.GetOrAdd(p.Header.PID)
+		*f = *ts.NewPCROffsetFilter(dmx.optPCRFilter())
+	}
+	return f.Filter(af.PCR, p.Offset, af.DiscontinuityIndicator)
+}
+
+// queuePCR queues the EventPCR of p, which carries pcr.
+func (dmx *Demuxer) queuePCR(p *ts.Packet, pcr ts.ClockReference) {
+	dmx.tblQueue = append(dmx.tblQueue, tableEvent{pid: p.Header.PID, ev: EventPCR, pcr: PCR{
+		PCR:           pcr,
+		Offset:        p.Offset,
+		PID:           p.Header.PID,
+		Discontinuity: p.AdaptationField.DiscontinuityIndicator,
+	}})
+}
+
+// PCR is the sample behind the last EventPCR.
+func (dmx *Demuxer) PCR() PCR {
+	return dmx.cur.pcr
+}
@@ -0,0 +1,80 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerPCREvents(t *testing.T) {
+	var stream []byte
+	stream = append(stream, pcrPacket(t, 0x100, 90000)...)
+	stream = append(stream, videoPacket(t, 0x101, 0, 3600, true)...)
+	stream = append(stream, pcrPacket(t, 0x100, 93600)...)
+	stream = append(stream, videoPacket(t, 0x101, 1, 7200, false)...)
+
+	run := func(opts ...func(*Demuxer)) (log []string) {
+		dmx := New(context.Background(), bytes.NewReader(stream), append(opts, WithPacketSize(ts.PacketSize))...)
+		defer dmx.Close()
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return
+			}
+			require.NoError(t, err)
+			switch ev.Kind() {
+			case KindPCR:
+				c := dmx.PCR()
+				log = append(log, fmt.Sprintf("PCR %x %d %d", c.PID, c.PCR.Base(), c.Offset))
+			case KindPES:
+				log = append(log, fmt.Sprintf("PES %x", dmx.PES().PID))
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"PES 101", "PES 101"}, run())
+	assert.Equal(t, []string{
+		"PCR 100 90000 0",
+		"PCR 100 93600 376",
+		"PES 101",
+		"PES 101",
+	}, run(WithPCREvents()))
+}
+
+func TestDemuxerPCRFilter(t *testing.T) {
+	var stream []byte
+	for n := range 8 {
+		base := uint64(90000 + 3600*n)
+		if n == 4 {
+			base += 270 // 3 ms outlier
+		}
+		stream = append(stream, pcrPacket(t, 0x100, base)...)
+	}
+
+	run := func(opts ...func(*Demuxer)) (bases []int64) {
+		dmx := New(context.Background(), bytes.NewReader(stream), append(opts, WithPacketSize(ts.PacketSize), WithPCREvents())...)
+		defer dmx.Close()
+		for ev, err := range dmx.Events() {
+			require.NoError(t, err)
+			if ev.Kind() == KindPCR {
+				c := dmx.PCR()
+				bases = append(bases, int64(c.PCR.Base())-90000)
+			}
+		}
+		return
+	}
+
+	assert.Equal(t, []int64{0, 3600, 7200, 10800, 14670, 18000, 21600, 25200}, run())
+	got := run(WithPCRFilter(func() ts.PCRFilter { return ts.NewPCRMedianFilter(5) }))
+	require.Len(t, got, 8)
+	for n, base := range got {
+		assert.InDelta(t, 3600*n, base, 90, "PCR %d", n) // within 1 ms
+	}
+}
@@ -0,0 +1,297 @@
+package demux
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+const (
+	// defaultPipelineDepth is the read-ahead of WithPipeline in packets.
+	defaultPipelineDepth = 256
+	// workerQueue is the number of units a parse worker may have queued
+	// before the reader waits for it.
+	workerQueue = 16
+)
+
+// WithPipeline moves packet reading — the reader calls, sync and framing, and
+// the packet header and adaptation field parsing — to a goroutine that runs
+// up to depth packets (256 when depth <= 0) ahead of the demuxer, and parses
+// the units of the packets read ahead on a worker goroutine per PID, so a
+// high-bitrate stream spreads over several cores: the PES and PSI of a unit
+// are parsed while the demuxer is still on the packets before it.
+//
+// The demuxer still assembles the units and applies them in stream order,
+// taking the parse of the read-ahead for a unit of the same bytes; one it
+// assembles differently (a continuity error, Flush, a descrambled PID, a unit
+// drained at EOF) is parsed in place. Events, errors and offsets come out
+// exactly as without it. The read-ahead copies the payloads once more, and
+// holds at most depth parsed units for the demuxer.
+//
+// The reader goroutine starts with the first read; Close stops it (a read in
+// progress completes in the background), as do Rewind and Seek, which wait for
+// it. A PacketSkipper runs on the reader goroutine. The zero-copy batch mode of
+// WithZeroCopyPackets is not used: packets are copied out of the read buffer.
+func WithPipeline(depth int) func(*Demuxer) {
+	return func(d *Demuxer) {
+		if depth <= 0 {
+			depth = defaultPipelineDepth
+		}
+		d.optPipelineDepth = depth
+	}
+}
+
+// pipeItem is a packet read ahead, with the recoverable errors reported while
+// reading it; err ends the read.
+type pipeItem struct {
+	pkt  ts.Packet
+	errs []ts.RecoverableError
+	err  error
+	size uint // packet size at the read
+}
+
+// parseJob is a unit of the read-ahead, parsed on the worker of its PID: done
+// is closed once pes or psi and err are set.
+type parseJob struct {
+	buf    *dataPayload
+	offset int64 // of the unit's first packet
+	end    int64 // of the packet completing it
+	isPSI  bool
+	pes    pes.Data
+	psi    *psi.Data
+	err    error
+	done   chan struct{}
+}
+
+// pipeline is the reader goroutine of WithPipeline: items cycle from free
+// through the reader to items and back. The reader assembles the units of the
+// packets it reads in spec and hands them to the workers; jobs holds them, in
+// the order of the packets completing them, until the demuxer claims them.
+type pipeline struct {
+	items  chan *pipeItem
+	free   chan *pipeItem
+	resume chan struct{} // the read after an error
+	stop   chan struct{}
+	done   chan struct{}
+	held   *pipeItem // the item behind the last packet handed out
+	cur    *pipeItem // the item being read, owned by the reader goroutine
+
+	parse   psi.ParseConfig
+	spec    accumulator                // reader side
+	units   []unit                     // reader side, spec.add scratch
+	workers pidmap.Map[chan *parseJob] // reader side, by PID
+
+	mu      sync.Mutex
+	jobs    []*parseJob
+	maxJobs int
+	stale   []*parseJob // demuxer side, claim scratch
+	claimed int         // demuxer side, units taken parsed
+}
+
+func newPipeline(depth int, parse psi.ParseConfig) *pipeline {
+	pl := &pipeline{
+		items:   make(chan *pipeItem, depth),
+		free:    make(chan *pipeItem, depth),
+		resume:  make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		parse:   parse,
+		maxJobs: depth,
+	}
+	pl.spec.init(nil, nil, false)
+	pl.spec.sniff = true
+	for range depth {
+		pl.free <- &pipeItem{}
+	}
+	return pl
+}
+
+// onRecover collects the recoverable errors of the packet being read.
+func (pl *pipeline) onRecover(e ts.RecoverableError) {
+	pl.cur.errs = append(pl.cur.errs, e)
+}
+
+// run reads packets into free items until stopped. After an error it waits
+// for the demuxer to ask again: the reader may have grown.
+func (pl *pipeline) run(pb *ts.PacketBuffer) {
+	defer close(pl.done)
+	defer pl.spec.close()
+	defer func() {
+		for _, c := range pl.workers.Vals {
+			close(c)
+		}
+	}()
+	for {
+		var it *pipeItem
+		select {
+		case it = <-pl.free:
+		case <-pl.stop:
+			return
+		}
+		it.errs = it.errs[:0]
+		pl.cur = it
+		it.err = pb.Next(&it.pkt)
+		it.size = pb.PacketSize()
+		if it.err == nil && !pl.speculate(&it.pkt) {
+			return
+		}
+		select {
+		case pl.items <- it:
+		case <-pl.stop:
+			return
+		}
+		if it.err != nil {
+			select {
+			case <-pl.resume:
+			case <-pl.stop:
+				return
+			}
+		}
+	}
+}
+
+// speculate assembles p into the units of the read-ahead and queues the ones
+// it completes to the workers. It reports false when the pipeline stops.
+func (pl *pipeline) speculate(p *ts.Packet) bool {
+	pl.units = pl.spec.add(p, pl.units[:0])
+	for _, u := range pl.units {
+		pl.mu.Lock()
+		var j *parseJob
+		if len(pl.jobs) < pl.maxJobs {
+			j = &parseJob{buf: u.buf, offset: u.offset, end: p.Offset, isPSI: u.isPSI, done: make(chan struct{})}
+			pl.jobs = append(pl.jobs, j)
+		}
+		pl.mu.Unlock()
+		if j == nil {
+			// The demuxer is far behind: it parses this one itself
+			poolOfPayload.put(u.buf)
+			continue
+		}
+		c := pl.workers.GetOrAdd(u.pid)
+		if *c == nil {
+			*c = make(chan *parseJob, workerQueue)
+			go pl.work(*c)
+		}
+		select {
+		case *c <- j:
+		case <-pl.stop:
+			return false
+		}
+	}
+	return true
+}
+
+// work parses the units of one PID in order.
+func (pl *pipeline) work(jobs <-chan *parseJob) {
+	for j := range jobs {
+		if j.isPSI {
+			j.psi, j.err = psi.ParseWith(j.buf.bs, pl.parse)
+		} else if isPESPayload(j.buf.bs) {
+			j.err = j.pes.Parse(j.buf.bs)
+		}
+		close(j.done)
+	}
+}
+
+// claim takes the job of the unit starting at offset completed by the packet
+// at end, and drops the jobs completed before that packet: the demuxer
+// assembled their units otherwise, or not at all.
+func (pl *pipeline) claim(offset, end int64) (j *parseJob) {
+	pl.mu.Lock()
+	n := 0
+	for n < len(pl.jobs) && pl.jobs[n].end < end {
+		n++
+	}
+	pl.stale = append(pl.stale[:0], pl.jobs[:n]...)
+	pl.jobs = pl.jobs[n:]
+	for i, c := range pl.jobs {
+		if c.end != end {
+			break
+		}
+		if c.offset == offset {
+			j = c
+			pl.jobs = slices.Delete(pl.jobs, i, i+1)
+			break
+		}
+	}
+	pl.mu.Unlock()
+
+	for _, s := range pl.stale {
+		<-s.done
+		poolOfPayload.put(s.buf)
+	}
+	clear(pl.stale)
+	return
+}
+
+// parsed returns the parse of the read-ahead for u, nil unless WithPipeline
+// made one of the same bytes. The job's buffer moves to the caller.
+func (dmx *Demuxer) parsed(u *unit) *parseJob {
+	if dmx.pipe == nil || u.drained {
+		return nil
+	}
+	j := dmx.pipe.claim(u.offset, dmx.pkt.Offset)
+	if j == nil {
+		return nil
+	}
+	<-j.done
+	if j.isPSI != u.isPSI || !bytes.Equal(j.buf.bs, u.buf.bs) {
+		poolOfPayload.put(j.buf)
+		return nil
+	}
+	dmx.pipe.claimed++
+	return j
+}
+
+// next hands out the next packet read ahead, valid until the following call:
+// p views the item it was read into.
+func (dmx *Demuxer) nextPipelined(p *ts.Packet, owned bool) (err error) {
+	pl := dmx.pipe
+	if it := pl.held; it != nil {
+		pl.held = nil
+		if it.err != nil {
+			pl.resume <- struct{}{}
+		}
+		pl.free <- it
+	}
+
+	var it *pipeItem
+	select {
+	case it = <-pl.items:
+	case <-dmx.done:
+		return dmx.ctx.Err()
+	}
+	pl.held = it
+	for _, e := range it.errs {
+		dmx.reportRecoverable(e)
+	}
+	if it.err != nil {
+		return it.err
+	}
+	dmx.readSize = it.size
+	if owned {
+		p.CopyFrom(&it.pkt)
+	} else {
+		*p = it.pkt
+	}
+	return nil
+}
+
+// stopPipeline stops the reader goroutine, waiting for it to return when wait
+// is set.
+func (dmx *Demuxer) stopPipeline(wait bool) {
+	pl := dmx.pipe
+	if pl == nil {
+		return
+	}
+	dmx.pipe = nil
+	close(pl.stop)
+	if wait {
+		<-pl.done
+	}
+}
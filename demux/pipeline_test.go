@@ -0,0 +1,156 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerPipeline(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber:     1,
+		PCRPID:            0x100,
+		ElementaryStreams: []psi.ElementaryStream{{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}},
+	})...)
+	for i := range 20 {
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), uint64(i)*3600, i%5 == 0)...)
+		if i == 7 {
+			stream = append(stream, 0x00, 0x11, 0x22) // sync loss
+		}
+		if i == 12 {
+			stream = append(stream, corruptAlignedPacket()...)
+		}
+	}
+
+	// run records every event, reading the stream twice around a Rewind.
+	run := func(opts ...func(*Demuxer)) (log []string) {
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithSyncLock(), WithRecoverableErrors())
+		dmx := New(context.Background(), bytes.NewReader(stream), opts...)
+		defer dmx.Close()
+		for pass := range 2 {
+			for {
+				ev, err := dmx.Next()
+				if errors.Is(err, ts.ErrNoMorePackets) {
+					break
+				}
+				if ev == EventError {
+					log = append(log, fmt.Sprintf("error %v", err))
+					continue
+				}
+				require.NoError(t, err)
+				line := fmt.Sprintf("%d %s", pass, ev)
+				if ev == EventPES {
+					d := dmx.PES()
+					line += fmt.Sprintf(" %d %d", d.PID, d.Data.Header.OptionalHeader.PTS.Base())
+					d.Close()
+				}
+				log = append(log, line)
+			}
+			log = append(log, fmt.Sprint(dmx.GetStats()))
+			_, err := dmx.Rewind()
+			require.NoError(t, err)
+		}
+		return
+	}
+
+	want := run()
+	require.Len(t, want, 2*(2+20+1)+4)
+	assert.Equal(t, want, run(WithPipeline(4)))
+	assert.Equal(t, want, run(WithPipeline(0)))
+
+	// Packets read through NextPacket are owned copies
+	dmx := New(context.Background(), bytes.NewReader(stream[:10*ts.PacketSize]), WithPacketSize(ts.PacketSize), WithPipeline(2))
+	defer dmx.Close()
+	var pkts []*ts.Packet
+	for {
+		p, err := dmx.NextPacket()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		pkts = append(pkts, p)
+	}
+	require.Len(t, pkts, 10)
+	for i, p := range pkts {
+		assert.Equal(t, stream[i*ts.PacketSize:(i+1)*ts.PacketSize], p.Raw())
+		assert.Equal(t, int64(i*ts.PacketSize), p.Offset)
+		p.Close()
+	}
+}
+
+func TestDemuxerPipelineParse(t *testing.T) {
+	var stream []byte
+	pmt := func(version uint8) []byte {
+		return psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+			ProgramNumber: 1,
+			PCRPID:        0x100,
+			ElementaryStreams: []psi.ElementaryStream{
+				{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video},
+				{ElementaryPID: 0x101, StreamType: psi.StreamTypeH264Video},
+			},
+		})
+	}
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	for i := range 40 {
+		if i%10 == 0 {
+			stream = append(stream, pmt(0)...) // repeats
+			stream = append(stream, psiPacket(t, 0x11, psi.TableIDSDTVariant1, 7, &psi.SDT{TransportStreamID: 7, OriginalNetworkID: uint16(i)})...)
+		}
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), uint64(i)*3600, i%5 == 0)...)
+		if i != 17 { // a lost packet
+			stream = append(stream, videoPacket(t, 0x101, uint8(i), uint64(i)*3600+1, false)...)
+		}
+	}
+
+	run := func(opts ...func(*Demuxer)) (log []string, dmx *Demuxer) {
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithDVBTables(), WithRecoverableErrors(),
+			WithPIDRemap(map[uint16]uint16{0x101: 0x201}))
+		dmx = New(context.Background(), bytes.NewReader(stream), opts...)
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				break
+			}
+			require.NoError(t, err)
+			line := ev.String()
+			switch ev {
+			case EventPES:
+				d := dmx.PES()
+				line += fmt.Sprintf(" %d %d %d", d.PID, d.Offset, d.Data.Header.OptionalHeader.PTS.Base())
+				if d.PID == 0x100 && d.Data.Header.OptionalHeader.PTS.Base() == 20*3600 {
+					dmx.Flush() // the read-ahead does not see it
+				}
+				d.Close()
+			case EventSDT:
+				_, s := dmx.Section()
+				line += fmt.Sprint(" ", s.(*psi.SDT).OriginalNetworkID)
+			}
+			log = append(log, line)
+		}
+		return
+	}
+
+	want, dmx := run()
+	dmx.Close()
+	got, dmx := run(WithPipeline(8))
+	defer dmx.Close()
+	assert.Equal(t, want, got)
+	// the units not cut short by the Flush or the lost packet come parsed
+	assert.Greater(t, dmx.pipe.claimed, 60)
+}
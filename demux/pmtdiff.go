@@ -0,0 +1,115 @@
+package demux
+
+import (
+	"bytes"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// PMTDiff describes how the PMT in force of a program changed, the payload of
+// EventPMTDiff. Streams are matched by elementary PID; the tables are shared
+// with the demuxer state: read-only.
+type PMTDiff struct {
+	Previous *psi.PMT
+	Current  *psi.PMT
+	Added    []psi.ElementaryStream // streams of Current only
+	Removed  []psi.ElementaryStream // streams of Previous only
+	Changed  []StreamChange         // streams of both whose type or descriptors changed
+
+	PID                uint16 // PMT PID
+	ProgramNumber      uint16
+	PCRPIDChanged      bool
+	DescriptorsChanged bool // program descriptors
+}
+
+// StreamChange is an elementary stream kept across a PMT change with another
+// stream type or other descriptors.
+type StreamChange struct {
+	Previous           psi.ElementaryStream
+	Current            psi.ElementaryStream
+	StreamTypeChanged  bool
+	DescriptorsChanged bool
+}
+
+// WithPMTDiffs emits an EventPMTDiff ahead of the table event of a PMT that
+// replaces the one in force of its program with other content: streams added,
+// removed or changed, a new PCR PID or new program descriptors. A repeat or a
+// version bump alone emits none, nor does the first PMT of a program.
+func WithPMTDiffs() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optPMTDiffs = true
+	}
+}
+
+// diffPMT queues the EventPMTDiff of a current PMT against the one it
+// replaces; it runs before recordService records it.
+func (dmx *Demuxer) diffPMT(pid uint16, s *psi.Section, pmt *psi.PMT) {
+	if !isCurrent(s) {
+		return
+	}
+	prev := dmx.pmts.Get(pmt.ProgramNumber)
+	if prev == nil || *prev == pmt {
+		return
+	}
+	if d, ok := comparePMTs(*prev, pmt); ok {
+		d.PID = pid
+		dmx.tblQueue = append(dmx.tblQueue, tableEvent{pid: pid, data: pmt, ev: EventPMTDiff, changed: true, pmtDiff: d})
+	}
+}
+
+// comparePMTs diffs two PMTs of a program; ok is false when nothing changed.
+func comparePMTs(prev, cur *psi.PMT) (d PMTDiff, ok bool) {
+	d = PMTDiff{
+		Previous:           prev,
+		Current:            cur,
+		ProgramNumber:      cur.ProgramNumber,
+		PCRPIDChanged:      prev.PCRPID != cur.PCRPID,
+		DescriptorsChanged: !sameDescriptors(prev.ProgramDescriptors, cur.ProgramDescriptors),
+	}
+	for _, es := range cur.ElementaryStreams {
+		old := findStream(prev.ElementaryStreams, es.ElementaryPID)
+		if old == nil {
+			d.Added = append(d.Added, es)
+			continue
+		}
+		c := StreamChange{
+			Previous:           *old,
+			Current:            es,
+			StreamTypeChanged:  old.StreamType != es.StreamType,
+			DescriptorsChanged: !sameDescriptors(old.ElementaryStreamDescriptors, es.ElementaryStreamDescriptors),
+		}
+		if c.StreamTypeChanged || c.DescriptorsChanged {
+			d.Changed = append(d.Changed, c)
+		}
+	}
+	for _, es := range prev.ElementaryStreams {
+		if findStream(cur.ElementaryStreams, es.ElementaryPID) == nil {
+			d.Removed = append(d.Removed, es)
+		}
+	}
+	ok = d.PCRPIDChanged || d.DescriptorsChanged || len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+	return
+}
+
+func findStream(ss []psi.ElementaryStream, pid uint16) *psi.ElementaryStream {
+	for i := range ss {
+		if ss[i].ElementaryPID == pid {
+			return &ss[i]
+		}
+	}
+	return nil
+}
+
+// sameDescriptors compares two descriptor loops by their serialized form.
+func sameDescriptors(a, b []descriptor.Descriptor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return bytes.Equal(descriptor.Append(nil, a), descriptor.Append(nil, b))
+}
+
+// PMTDiff is the change behind the last EventPMTDiff.
+func (dmx *Demuxer) PMTDiff() PMTDiff {
+	return dmx.cur.pmtDiff
+}
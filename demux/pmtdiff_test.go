@@ -0,0 +1,85 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerPMTDiff(t *testing.T) {
+	lang := func(code string) []descriptor.Descriptor {
+		item := descriptor.ISO639Item{}
+		copy(item.Language[:], code)
+		return []descriptor.Descriptor{&descriptor.ISO639LanguageAndAudioType{
+			Header: descriptor.Header{Tag: descriptor.TagISO639LanguageAndAudioType, Length: 4},
+			Items:  []descriptor.ISO639Item{item},
+		}}
+	}
+	video := psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}
+	audio := psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio, ElementaryStreamDescriptors: lang("eng")}
+	dubbed := psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio, ElementaryStreamDescriptors: lang("fra")}
+	subs := psi.ElementaryStream{ElementaryPID: 0x102, StreamType: psi.StreamTypePrivateData}
+
+	pmt := func(version uint8, pcrPID uint16, streams ...psi.ElementaryStream) []byte {
+		return dataPacket(t, 0x1000, &psi.Data{Sections: []psi.Section{{
+			Header: psi.SectionHeader{TableID: psi.TableIDPMT, SectionSyntaxIndicator: true},
+			Syntax: &psi.SectionSyntax{
+				Header: psi.SectionSyntaxHeader{TableIDExtension: 1, VersionNumber: version, CurrentNextIndicator: true},
+				Data:   &psi.PMT{ProgramNumber: 1, PCRPID: pcrPID, ElementaryStreams: streams},
+			},
+		}}})
+	}
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	for cc, p := range [][]byte{
+		pmt(0, 0x100, video, audio),
+		pmt(1, 0x100, video, dubbed, subs), // audio language changed, subtitles added
+		pmt(2, 0x101, dubbed, subs),        // video removed, PCR moved
+		pmt(3, 0x101, dubbed, subs),        // version bump only
+	} {
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPMTDiffs())
+	defer dmx.Close()
+	var events []Event
+	var diffs []PMTDiff
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		events = append(events, ev)
+		if ev == EventPMTDiff {
+			diffs = append(diffs, dmx.PMTDiff())
+		}
+	}
+	assert.Equal(t, []Event{EventPAT, EventPMT, EventPMTDiff, EventPMT, EventPMTDiff, EventPMT, EventPMT}, events)
+	require.Len(t, diffs, 2)
+
+	d := diffs[0]
+	assert.Equal(t, uint16(0x1000), d.PID)
+	assert.Equal(t, uint16(1), d.ProgramNumber)
+	assert.Equal(t, []psi.ElementaryStream{subs}, d.Added)
+	assert.Empty(t, d.Removed)
+	require.Len(t, d.Changed, 1)
+	assert.Equal(t, StreamChange{Previous: audio, Current: dubbed, DescriptorsChanged: true}, d.Changed[0])
+	assert.False(t, d.PCRPIDChanged)
+	assert.False(t, d.DescriptorsChanged)
+	assert.Len(t, d.Previous.ElementaryStreams, 2)
+	assert.Len(t, d.Current.ElementaryStreams, 3)
+
+	d = diffs[1]
+	assert.Empty(t, d.Added)
+	assert.Equal(t, []psi.ElementaryStream{video}, d.Removed)
+	assert.Empty(t, d.Changed)
+	assert.True(t, d.PCRPIDChanged)
+}
@@ -0,0 +1,90 @@
+package demux
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// ErrPIDDropped is the error reported when a PID is dropped under its
+// PIDPolicy.
+var ErrPIDDropped = errors.New("astits: PID dropped after too many errors")
+
+// PIDPolicy is the error tolerance of a PID, set with WithPIDPolicy. The
+// packet-level WithSkipErrLimit stays global: an unparseable packet has no
+// PID.
+type PIDPolicy struct {
+	// TolerateCC keeps assembling the unit across a continuity counter error
+	// instead of dropping it, for a flaky PID whose consumer copes with
+	// missing bytes; the error is still counted and hooked.
+	TolerateCC bool
+	// DropAfter drops the PID at its DropAfter-th error — continuity counter
+	// errors and the PES, PSI and descrambling failures reported for it: its
+	// unfinished unit is discarded and its packets no longer reach unit
+	// assembly. 0 never drops.
+	DropAfter uint
+}
+
+// pidPolicy is the state of a PID under a PIDPolicy.
+type pidPolicy struct {
+	PIDPolicy
+	errors  uint
+	dropped bool
+}
+
+// WithPIDPolicy sets the error tolerance of pid; the other PIDs keep the
+// default one (a continuity counter error drops the unit, errors never drop
+// the PID). Setting it implies the error detection of WithRecoverableErrors,
+// whether or not Next reports the errors.
+func WithPIDPolicy(pid uint16, p PIDPolicy) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.policies.GetOrAdd(pid).PIDPolicy = p
+	}
+}
+
+// PIDErrors returns the number of errors counted for pid, which must have a
+// PIDPolicy, and whether it was dropped.
+func (dmx *Demuxer) PIDErrors(pid uint16) (n uint, dropped bool) {
+	dmx.rlock()
+	defer dmx.runlock()
+	if p := dmx.policies.Get(pid); p != nil {
+		return p.errors, p.dropped
+	}
+	return 0, false
+}
+
+// dropped tells whether the packets of pid are dropped under its policy.
+func (dmx *Demuxer) dropped(pid uint16) bool {
+	p := dmx.policies.Get(pid)
+	return p != nil && p.dropped
+}
+
+// countPIDError counts an error of pid against its policy, dropping it at the
+// limit.
+func (dmx *Demuxer) countPIDError(pid uint16, offset int64) {
+	p := dmx.policies.Get(pid)
+	if p == nil || p.dropped {
+		return
+	}
+	p.errors++
+	if p.DropAfter == 0 || p.errors < p.DropAfter {
+		return
+	}
+	p.dropped = true
+	if s := dmx.acc.slots.Get(pid); s != nil {
+		s.release()
+	}
+	dmx.reportRecoverable(ts.RecoverableError{
+		Kind: ts.ErrorKindPacketDrop, PID: pid, Offset: offset,
+		Err: fmt.Errorf("astits: PID 0x%x after %d errors: %w", pid, p.errors, ErrPIDDropped),
+	})
+}
+
+// onCCError counts a continuity counter error before the hook sees it.
+func (dmx *Demuxer) onCCError(e CCError) {
+	dmx.countPIDError(e.PID, e.Offset)
+	if dmx.optCCErrorHook != nil {
+		dmx.optCCErrorHook(e)
+	}
+}
@@ -0,0 +1,81 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// policyPacket is a payload packet of pid; a unit start carries the header of
+// an unbounded PES.
+func policyPacket(pid uint16, cc uint8, start bool) []byte {
+	bs := make([]byte, ts.PacketSize)
+	h := ts.PacketHeader{PID: pid, ContinuityCounter: cc, HasPayload: true, PayloadUnitStartIndicator: start}
+	h.Put(bs)
+	for i := ts.HeaderSize; i < len(bs); i++ {
+		bs[i] = 0xab
+	}
+	if start {
+		copy(bs[ts.HeaderSize:], []byte{0x00, 0x00, 0x01, 0xe0, 0x00, 0x00, 0x80, 0x00, 0x00})
+	}
+	return bs
+}
+
+func TestDemuxerPIDPolicy(t *testing.T) {
+	// Two units per PID, the first with a lost packet
+	var stream []byte
+	for _, pid := range []uint16{0x100, 0x200} {
+		stream = append(stream, policyPacket(pid, 0, true)...)
+		stream = append(stream, policyPacket(pid, 2, false)...)
+		stream = append(stream, policyPacket(pid, 3, true)...)
+	}
+
+	run := func(opts ...func(*Demuxer)) (log []string, dmx *Demuxer) {
+		var ccErrs int
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithRecoverableErrors(), WithCCErrorHook(func(CCError) { ccErrs++ }))
+		dmx = New(context.Background(), bytes.NewReader(stream), opts...)
+		t.Cleanup(dmx.Close)
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				break
+			}
+			if ev == EventError {
+				assert.ErrorIs(t, err, ErrPIDDropped)
+				log = append(log, "dropped")
+				continue
+			}
+			require.NoError(t, err)
+			d := dmx.PES()
+			log = append(log, fmt.Sprintf("%x %d", d.PID, len(d.Data.Data)))
+			d.Close()
+		}
+		assert.Equal(t, 2, ccErrs)
+		return
+	}
+
+	// The torn units are dropped by default
+	log, _ := run()
+	assert.Equal(t, []string{"100 175", "200 175"}, log)
+
+	// Kept on the tolerant PID
+	log, dmx := run(WithPIDPolicy(0x200, PIDPolicy{TolerateCC: true}))
+	assert.Equal(t, []string{"200 359", "100 175", "200 175"}, log)
+	n, dropped := dmx.PIDErrors(0x200)
+	assert.Equal(t, uint(1), n)
+	assert.False(t, dropped)
+
+	// The strict PID is dropped at its first error
+	log, dmx = run(WithPIDPolicy(0x100, PIDPolicy{DropAfter: 1}))
+	assert.Equal(t, []string{"dropped", "200 175"}, log)
+	n, dropped = dmx.PIDErrors(0x100)
+	assert.Equal(t, uint(1), n)
+	assert.True(t, dropped)
+}
@@ -0,0 +1,69 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WithPIDRemap renumbers PIDs as packets are read: a packet of a PID in m gets
+// the PID it maps to, in its header and raw bytes, and the PAT, PMT and CAT
+// carry the new numbers (program map, PCR and elementary PIDs, CA descriptor
+// PIDs), so the packets, units and tables downstream — e.g. a remuxer — see
+// one consistent numbering. Everything keyed by PID after the read (the table
+// state, descramblers, policies, hooks and monitors) uses the new numbers;
+// WithKeepPIDs and a PacketSkipper filter on the PIDs as read.
+func WithPIDRemap(m map[uint16]uint16) func(*Demuxer) {
+	return func(d *Demuxer) {
+		for from, to := range m {
+			d.remap.Set(from, to)
+		}
+	}
+}
+
+// remapPacket renumbers the PID of p.
+func (dmx *Demuxer) remapPacket(p *ts.Packet) {
+	if to := dmx.remap.Get(p.Header.PID); to != nil {
+		p.Header.PID = *to
+		p.UpdateHeader()
+	}
+}
+
+// remapPID returns the new number of pid.
+func (dmx *Demuxer) remapPID(pid uint16) uint16 {
+	if to := dmx.remap.Get(pid); to != nil {
+		return *to
+	}
+	return pid
+}
+
+// remapTable renumbers the PID references of a freshly parsed table.
+func (dmx *Demuxer) remapTable(data psi.SectionSyntaxData) {
+	switch d := data.(type) {
+	case *psi.PAT:
+		for i := range d.Programs {
+			d.Programs[i].ProgramMapID = dmx.remapPID(d.Programs[i].ProgramMapID)
+		}
+	case *psi.PMT:
+		d.PCRPID = dmx.remapPID(d.PCRPID)
+		dmx.remapDescriptors(d.ProgramDescriptors)
+		for i := range d.ElementaryStreams {
+			es := &d.ElementaryStreams[i]
+			es.ElementaryPID = dmx.remapPID(es.ElementaryPID)
+			dmx.remapDescriptors(es.ElementaryStreamDescriptors)
+		}
+	case *psi.CAT:
+		dmx.remapDescriptors(d.Descriptors)
+	}
+}
+
+// remapDescriptors renumbers the CA descriptor PIDs of a loop. A renumbered
+// descriptor no longer matches its kept wire bytes (WithRawDescriptors) and
+// is written from its fields.
+func (dmx *Demuxer) remapDescriptors(ds []descriptor.Descriptor) {
+	for _, d := range ds {
+		if ca, ok := d.(*descriptor.CA); ok {
+			ca.PID = dmx.remapPID(ca.PID)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerPIDRemap(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ProgramDescriptors: []descriptor.Descriptor{&descriptor.CA{
+			Header:   descriptor.Header{Tag: descriptor.TagCA},
+			SystemID: 0x500,
+			PID:      0x300,
+		}},
+		ElementaryStreams: []psi.ElementaryStream{
+			{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video},
+			{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio},
+		},
+	})...)
+	stream = append(stream, videoPacket(t, 0x100, 0, 3600, true)...)
+	remap := map[uint16]uint16{0x1000: 0x20, 0x100: 0x200, 0x300: 0x30}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithPIDRemap(remap))
+	defer dmx.Close()
+	var pesPIDs []uint16
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		if ev == EventPES {
+			d := dmx.PES()
+			pesPIDs = append(pesPIDs, d.PID)
+			d.Close()
+		}
+	}
+	require.NotNil(t, dmx.PAT())
+	assert.Equal(t, uint16(0x20), dmx.PAT().Programs[0].ProgramMapID)
+	pmt := dmx.PMT()
+	require.NotNil(t, pmt)
+	assert.Equal(t, uint16(0x200), pmt.PCRPID)
+	assert.Equal(t, uint16(0x200), pmt.ElementaryStreams[0].ElementaryPID)
+	assert.Equal(t, uint16(0x101), pmt.ElementaryStreams[1].ElementaryPID)
+	assert.Equal(t, uint16(0x30), pmt.ProgramDescriptors[0].(*descriptor.CA).PID)
+	assert.Equal(t, []uint16{0x200}, pesPIDs)
+
+	// Packets carry the new PID in their raw bytes too
+	_, err := dmx.Rewind()
+	require.NoError(t, err)
+	var pids []uint16
+	for {
+		p, err := dmx.NextPacket()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		var h ts.PacketHeader
+		_, err = h.Parse(p.Raw())
+		require.NoError(t, err)
+		assert.Equal(t, p.Header.PID, h.PID)
+		pids = append(pids, p.Header.PID)
+		p.Close()
+	}
+	assert.Equal(t, []uint16{ts.PIDPAT, 0x20, 0x200}, pids)
+}
+
+func TestDemuxerPIDRemapRawDescriptors(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ProgramDescriptors: []descriptor.Descriptor{&descriptor.CA{
+			Header:   descriptor.Header{Tag: descriptor.TagCA},
+			SystemID: 0x500,
+			PID:      0x300,
+			Private:  []byte{0xaa},
+		}},
+		ElementaryStreams: []psi.ElementaryStream{{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}},
+	})...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize),
+		WithPIDRemap(map[uint16]uint16{0x300: 0x30}), WithRawDescriptors())
+	defer dmx.Close()
+	for _, err := range dmx.Events() {
+		require.NoError(t, err)
+	}
+	pmt := dmx.PMT()
+	require.NotNil(t, pmt)
+	ds := pmt.ProgramDescriptors
+	require.NotNil(t, ds[0].(*descriptor.CA).Header.Raw)
+	assert.Equal(t, []byte{0x09, 0x05, 0x05, 0x00, 0xe0, 0x30, 0xaa}, descriptor.Append(nil, ds))
+
+	got, _, err := descriptor.ParseKeepRaw(descriptor.AppendWithLength(nil, ds))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x30), got[0].(*descriptor.CA).PID)
+}
@@ -0,0 +1,39 @@
+package demux
+
+import "github.com/k-danil/go-astits/v2/ts"
+
+// ScramblingChange describes a PID whose payload packets turned scrambled or
+// clear, as seen after descrambling: a PID with a Descrambler stays clear.
+type ScramblingChange struct {
+	Offset    int64 // byte offset of the first packet in the new state
+	PID       uint16
+	Control   ts.ScramblingControl
+	Scrambled bool
+}
+
+// WithScramblingHook runs fn whenever the payload packets of a PID turn
+// scrambled, or clear again, e.g. to log entitlement loss. PIDs start clear:
+// a PID scrambled from its first packet is reported on it.
+func WithScramblingHook(fn func(ScramblingChange)) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optScramblingHook = fn
+	}
+}
+
+// ScrambledPackets returns the number of payload packets left scrambled per
+// PID, keyed by PID. Those packets are not demuxed: a scrambled payload would
+// only parse into garbage, so it is skipped with the unit it belongs to
+// instead. A Descrambler registered for the PID clears them before this
+// check.
+func (dmx *Demuxer) ScrambledPackets() (ret map[uint16]uint) {
+	dmx.rlock()
+	defer dmx.runlock()
+
+	ret = make(map[uint16]uint)
+	for i := range dmx.acc.slots.Vals {
+		if n := dmx.acc.slots.Vals[i].scrambledPackets; n > 0 {
+			ret[dmx.acc.slots.Keys[i]] = uint(n)
+		}
+	}
+	return
+}
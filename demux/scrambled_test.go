@@ -0,0 +1,49 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerScrambled(t *testing.T) {
+	scrambled := func(cc uint8) []byte {
+		p := videoPacket(t, 0x100, cc, 0, false)
+		p[3] |= byte(ts.ScramblingControlScrambledWithOddKey) << 6
+		return p
+	}
+	var stream []byte
+	for _, p := range [][]byte{
+		videoPacket(t, 0x100, 0, 3600, false),
+		scrambled(1), // completes the clear unit before it
+		scrambled(2),
+		videoPacket(t, 0x100, 3, 7200, false),
+		videoPacket(t, 0x100, 4, 10800, false),
+	} {
+		stream = append(stream, p...)
+	}
+
+	var changes []ScramblingChange
+	dmx := New(context.Background(), bytes.NewReader(stream), WithRecoverableErrors(),
+		WithScramblingHook(func(c ScramblingChange) { changes = append(changes, c) }))
+	defer dmx.Close()
+	var pts []uint64
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		require.Equal(t, EventPES, ev)
+		d := dmx.PES()
+		pts = append(pts, d.Data.Header.OptionalHeader.PTS.Base())
+		d.Close()
+	}
+	assert.Equal(t, []uint64{3600, 7200, 10800}, pts)
+	assert.Equal(t, []ScramblingChange{
+		{Offset: ts.PacketSize, PID: 0x100, Control: ts.ScramblingControlScrambledWithOddKey, Scrambled: true},
+		{Offset: 3 * ts.PacketSize, PID: 0x100},
+	}, changes)
+	assert.Equal(t, map[uint16]uint{0x100: 2}, dmx.ScrambledPackets())
+}
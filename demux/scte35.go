@@ -0,0 +1,97 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// formatIdentifierCUEI is the registration format_identifier of SCTE 35.
+const formatIdentifierCUEI = 0x43554549
+
+// Cue is an SCTE 35 splice_info_section with the stream context it was read
+// in, the result of EventSCTE35 under WithSCTE35.
+type Cue struct {
+	*psi.SpliceInfo
+	// PCR is the last PCR read on the PCR PID of the cue's program; HasPCR is
+	// false until one is.
+	PCR           ts.ClockReference
+	PID           uint16
+	ProgramNumber uint16
+	HasPCR        bool
+}
+
+// SpliceTime returns the PTS (90 kHz) a program-level splice_insert or a
+// time_signal takes effect at, pts_adjustment applied; ok is false for an
+// immediate or component splice and for other commands.
+func (c *Cue) SpliceTime() (pts uint64, ok bool) {
+	var t *psi.SpliceTime
+	switch {
+	case c.TimeSignal != nil:
+		t = c.TimeSignal
+	case c.SpliceInsert != nil:
+		t = c.SpliceInsert.SpliceTime
+	}
+	if t == nil || !t.TimeSpecified {
+		return 0, false
+	}
+	return (t.PTSTime + c.PTSAdjustment) & (ptsWrap - 1), true
+}
+
+// cuePID is an SCTE 35 PID and the program it belongs to.
+type cuePID struct {
+	program uint16
+	pcrPID  uint16
+}
+
+// WithSCTE35 discovers the SCTE 35 PIDs of the PMTs — stream type 0x86, or a
+// CUEI registration descriptor — and emits their cues as EventSCTE35 (see
+// Section, which returns a *Cue), with the PCR of the program they were read
+// at. Identical repeats of a cue are dropped like those of a table.
+func WithSCTE35() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optSCTE35 = true
+	}
+}
+
+// discoverCues records the SCTE 35 PIDs of a PMT.
+func (dmx *Demuxer) discoverCues(pmt *psi.PMT) {
+	for _, es := range pmt.ElementaryStreams {
+		if es.StreamType == psi.StreamTypeSCTE35 || hasCUEI(es.ElementaryStreamDescriptors) {
+			dmx.cuePIDs.Set(es.ElementaryPID, cuePID{program: pmt.ProgramNumber, pcrPID: pmt.PCRPID})
+		}
+	}
+}
+
+func hasCUEI(ds []descriptor.Descriptor) bool {
+	for _, d := range ds {
+		if r, ok := d.(*descriptor.Registration); ok && r.FormatIdentifier == formatIdentifierCUEI {
+			return true
+		}
+	}
+	return false
+}
+
+// cue wraps a splice_info_section read on pid into its Cue.
+func (dmx *Demuxer) cue(pid uint16, si *psi.SpliceInfo) *Cue {
+	c := &Cue{SpliceInfo: si, PID: pid}
+	if p := dmx.cuePIDs.Get(pid); p != nil {
+		c.ProgramNumber = p.program
+		if pcr := dmx.pcrs.Get(p.pcrPID); pcr != nil {
+			c.PCR, c.HasPCR = *pcr, true
+		}
+	}
+	return c
+}
+
+// observeCuePCR keeps the last PCR of every PID carrying one.
+func (dmx *Demuxer) observeCuePCR(p *ts.Packet) {
+	if p.Header.HasAdaptationField && p.AdaptationField != nil && p.AdaptationField.HasPCR {
+		dmx.pcrs.Set(p.Header.PID, p.AdaptationField.PCR)
+	}
+}
+
+// OnSCTE35 registers fn for the cues dispatched by Run (see WithSCTE35).
+func (dmx *Demuxer) OnSCTE35(fn func(c *Cue)) {
+	dmx.OnTable(EventSCTE35, func(_ uint16, data psi.SectionSyntaxData) { fn(data.(*Cue)) })
+}
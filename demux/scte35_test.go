@@ -0,0 +1,78 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerSCTE35(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ElementaryStreams: []psi.ElementaryStream{
+			{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video},
+			{ElementaryPID: 0x1f0, StreamType: psi.StreamTypeSCTE35},
+			{
+				ElementaryPID: 0x1f1,
+				StreamType:    psi.StreamTypePrivateSection,
+				ElementaryStreamDescriptors: []descriptor.Descriptor{&descriptor.Registration{
+					Header:           descriptor.Header{Tag: descriptor.TagRegistration, Length: 4},
+					FormatIdentifier: formatIdentifierCUEI,
+				}},
+			},
+		},
+	})...)
+	insert := psi.NewSpliceInsert(1, 1<<33-100, 0, true)
+	insert.PTSAdjustment = 300
+	stream = append(stream, dataPacket(t, 0x1f0, insert.Data())...)
+	stream = append(stream, pcrPacket(t, 0x100, 90000)...)
+	stream = append(stream, dataPacket(t, 0x1f1, psi.NewTimeSignal(180000).Data())...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithSCTE35())
+	defer dmx.Close()
+	var got []Event
+	var cues []*Cue
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		got = append(got, ev)
+		if ev == EventSCTE35 {
+			_, data := dmx.Section()
+			cues = append(cues, data.(*Cue))
+		}
+	}
+	assert.Equal(t, []Event{EventPAT, EventPMT, EventSCTE35, EventSCTE35}, got)
+	require.Len(t, cues, 2)
+
+	assert.Equal(t, uint16(0x1f0), cues[0].PID)
+	assert.Equal(t, uint16(1), cues[0].ProgramNumber)
+	assert.False(t, cues[0].HasPCR)
+	pts, ok := cues[0].SpliceTime()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(200), pts, "wraps with the adjustment")
+
+	assert.Equal(t, uint16(0x1f1), cues[1].PID)
+	assert.True(t, cues[1].HasPCR)
+	assert.Equal(t, uint64(90000), cues[1].PCR.Base())
+	pts, ok = cues[1].SpliceTime()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(180000), pts)
+	assert.Equal(t, "SCTE35", EventSCTE35.String())
+}
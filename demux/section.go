@@ -0,0 +1,131 @@
+package demux
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// SectionParser parses one section registered with RegisterSectionParser.
+// section runs from table_id through the CRC32 and is valid only during the
+// call. A non-nil result is emitted as EventSection and returned by Section;
+// an error is reported like a built-in table's (see WithRecoverableErrors).
+type SectionParser func(pid uint16, section []byte) (psi.SectionSyntaxData, error)
+
+type sectionParser struct {
+	fn      SectionParser
+	tableID psi.TableID
+}
+
+// RegisterSectionParser routes the sections of table tableID on pid to fn,
+// replacing any parser registered for the same pair. The PID is reassembled
+// as PSI from then on: sections go through the usual repeat check, and their
+// CRC32 is verified when section_syntax_indicator is set. Other table ids on
+// the PID keep their built-in parsing.
+func (dmx *Demuxer) RegisterSectionParser(pid uint16, tableID psi.TableID, fn SectionParser) {
+	ps := dmx.sectionParsers.GetOrAdd(pid)
+	for i := range *ps {
+		if (*ps)[i].tableID == tableID {
+			(*ps)[i].fn = fn
+			return
+		}
+	}
+	*ps = append(*ps, sectionParser{fn: fn, tableID: tableID})
+}
+
+// processSections splits a PSI unit of a PID with registered parsers into
+// sections: registered table ids go to their parser, the others to the
+// built-in one.
+func (dmx *Demuxer) processSections(u unit, parsers []sectionParser) {
+	cache := dmx.psiPrev.GetOrAdd(u.pid)
+	cache.raw = append(cache.raw[:0], u.buf.bs...)
+	cache.events = cache.events[:0]
+	defer poolOfPayload.put(u.buf)
+
+	bs := u.buf.bs
+	if len(bs) == 0 {
+		return
+	}
+	for off := 1 + int(bs[0]); off+3 <= len(bs); {
+		tableID := psi.TableID(bs[off])
+		if tableID == psi.TableIDNull {
+			return
+		}
+		end := off + 3 + int(binary.BigEndian.Uint16(bs[off+1:])&0xfff)
+		if end > len(bs) {
+			dmx.reportSectionError(u.pid, fmt.Errorf("astits: section of table 0x%02x overruns its unit: %w", uint8(tableID), ts.ErrInvalidData))
+			return
+		}
+		section := bs[off:end]
+		off = end
+
+		var fn SectionParser
+		for i := range parsers {
+			if parsers[i].tableID == tableID {
+				fn = parsers[i].fn
+				break
+			}
+		}
+		if fn == nil {
+			s, err := psi.ParseSectionWith(section, dmx.optParse)
+			if err != nil {
+				dmx.reportSectionError(u.pid, err)
+				continue
+			}
+			dmx.reportCRCMismatch(u.pid, &s)
+			dmx.emitSection(u.pid, &s, cache)
+			continue
+		}
+
+		if !dmx.verifySection(u.pid, section) {
+			continue
+		}
+
+		data, err := fn(u.pid, section)
+		if err != nil {
+			dmx.reportSectionError(u.pid, err)
+			continue
+		}
+		if data != nil {
+			dmx.queueTable(u.pid, data, EventSection, cache)
+		}
+	}
+}
+
+// verifySection checks the CRC32 of a section with section_syntax_indicator
+// set under the CRC mode of the demuxer, reporting a failure; it tells whether
+// the section is kept.
+func (dmx *Demuxer) verifySection(pid uint16, section []byte) bool {
+	if section[1]&0x80 == 0 {
+		return true
+	}
+	if len(section) < 7 {
+		dmx.reportSectionError(pid, fmt.Errorf("astits: section length %d is too short: %w", len(section)-3, ts.ErrInvalidData))
+		return false
+	}
+	if dmx.optParse.CRC == psi.CRCSkip {
+		return true
+	}
+	crcData := section[:len(section)-4]
+	if c, want := ts.ComputeCRC32(crcData), binary.BigEndian.Uint32(section[len(crcData):]); c != want {
+		dmx.reportSectionError(pid, fmt.Errorf("astits: table CRC32 %x != computed CRC32 %x: %w", want, c, psi.ErrCRC32Mismatch))
+		return dmx.optParse.CRC == psi.CRCLenient
+	}
+	return true
+}
+
+// reportCRCMismatch reports a section kept by WithLenientCRC although its
+// CRC32 does not match.
+func (dmx *Demuxer) reportCRCMismatch(pid uint16, s *psi.Section) {
+	if s.CRC32Mismatch {
+		dmx.reportSectionError(pid, fmt.Errorf("astits: table 0x%02x CRC32 %x mismatch: %w", uint8(s.Header.TableID), s.CRC32, psi.ErrCRC32Mismatch))
+	}
+}
+
+func (dmx *Demuxer) reportSectionError(pid uint16, err error) {
+	if dmx.reportsErrors() {
+		dmx.reportPSIError(pid, err)
+	}
+}
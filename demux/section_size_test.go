@@ -0,0 +1,118 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// maxEITSection builds an EIT schedule section of exactly
+// psi.MaxPrivateSectionLength bytes of section_length.
+func maxEITSection(section uint8) psi.Section {
+	// 5 syntax header + 6 EIT fixed fields + 4 CRC around the event loop.
+	loop := psi.MaxPrivateSectionLength - 15
+	eit := &psi.EIT{ServiceID: 1, TransportStreamID: 7, LastTableID: 0x50}
+	for loop > 0 {
+		n := min(loop, 12+descriptor.MaxLength+2)
+		if rest := loop - n; rest > 0 && rest < 14 {
+			n -= 14 - rest
+		}
+		eit.Events = append(eit.Events, psi.EITEvent{
+			EventID:     uint16(section)<<8 | uint16(len(eit.Events)),
+			StartTime:   time.Date(2026, 10, 16, len(eit.Events), 0, 0, 0, time.UTC),
+			Duration:    time.Hour,
+			Descriptors: []descriptor.Descriptor{&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80, Length: uint8(n - 14)}, Data: bytes.Repeat([]byte{section}, n-14)}},
+		})
+		loop -= n
+	}
+	return psi.Section{
+		Header: psi.SectionHeader{TableID: 0x50, SectionSyntaxIndicator: true},
+		Syntax: &psi.SectionSyntax{
+			Header: psi.SectionSyntaxHeader{TableIDExtension: 1, CurrentNextIndicator: true, SectionNumber: section, LastSectionNumber: 1},
+			Data:   eit,
+		},
+	}
+}
+
+// sectionPackets splits payload over as many packets of pid as it takes, the
+// first one with PayloadUnitStartIndicator, the last one stuffed with 0xff.
+func sectionPackets(pid uint16, payload []byte) (out []byte) {
+	for cc := uint8(0); len(payload) > 0; cc++ {
+		bs := make([]byte, ts.PacketSize)
+		h := ts.PacketHeader{PID: pid, HasPayload: true, PayloadUnitStartIndicator: cc == 0, ContinuityCounter: cc % 16}
+		h.Put(bs)
+		n := copy(bs[ts.HeaderSize:], payload)
+		for i := ts.HeaderSize + n; i < len(bs); i++ {
+			bs[i] = 0xff
+		}
+		payload = payload[n:]
+		out = append(out, bs...)
+	}
+	return out
+}
+
+// Two back-to-back 4096-byte EIT schedule sections span 45 packets and come
+// out whole.
+func TestDemuxerMaxSizeEIT(t *testing.T) {
+	d := &psi.Data{Sections: []psi.Section{maxEITSection(0), maxEITSection(1)}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+	require.Len(t, payload, 1+2*(3+psi.MaxPrivateSectionLength))
+	stream := sectionPackets(ts.PIDEIT, payload)
+	require.Len(t, stream, 45*ts.PacketSize)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithDVBTables())
+	defer dmx.Close()
+	for _, s := range d.Sections {
+		ev, err := dmx.Next()
+		require.NoError(t, err)
+		require.Equal(t, EventEIT, ev)
+		_, data := dmx.Section()
+		assert.Equal(t, s.Syntax.Data, data)
+	}
+	_, err = dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
+
+// A maximum-size section completes on its last packet, without waiting for the
+// next PayloadUnitStartIndicator.
+func TestAccumulatorMaxSizeSection(t *testing.T) {
+	d := &psi.Data{Sections: []psi.Section{maxEITSection(0)}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+
+	var a accumulator
+	a.init(&pidmap.Map[uint16]{}, &pidmap.Map[[]sectionParser]{}, true)
+	defer a.close()
+
+	var units []unit
+	for cc := uint8(0); len(payload) > 0; cc++ {
+		require.Empty(t, units)
+		n := min(len(payload), ts.PacketSize-ts.HeaderSize)
+		units = a.add(accPacket(ts.PIDEIT, cc%16, cc == 0, payload[:n]), units)
+		payload = payload[n:]
+	}
+	require.Len(t, units, 1)
+	assert.Len(t, units[0].buf.bs, 1+3+psi.MaxPrivateSectionLength)
+	poolOfPayload.put(units[0].buf)
+}
+
+// The buffer of a maximum-size section is sized from its first packet.
+func TestAccumulatorPSISizeHint(t *testing.T) {
+	d := &psi.Data{Sections: []psi.Section{maxEITSection(0)}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+
+	var s pidSlot
+	assert.Equal(t, classOf(len(payload)), s.classFor(payload[:ts.PacketSize-ts.HeaderSize], true))
+	assert.Equal(t, uint8(defaultFloorClass), s.classFor([]byte{0}, true))
+}
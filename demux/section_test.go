@@ -0,0 +1,68 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// privateSection builds a long-form private section of table tableID carrying body.
+func privateSection(tableID psi.TableID, body []byte) []byte {
+	l := 5 + len(body) + 4
+	bs := []byte{uint8(tableID), 0xb0 | byte(l>>8), byte(l), 0x00, 0x01, 0xc1, 0x00, 0x00}
+	bs = append(bs, body...)
+	return binary.BigEndian.AppendUint32(bs, ts.ComputeCRC32(bs))
+}
+
+func TestDemuxerRegisterSectionParser(t *testing.T) {
+	const pid = 0x200
+	good := privateSection(0x90, []byte("hello"))
+	bad := privateSection(0x90, []byte("world"))
+	bad[len(bad)-1] ^= 0xff
+	tdt := psiPacket(t, pid, psi.TableIDTDT, 0, &psi.TDT{})[ts.HeaderSize+1:][:8]
+
+	var stream []byte
+	stream = append(stream, payloadPacket(pid, append(append([]byte{0x00}, good...), tdt...))...)
+	next := payloadPacket(pid, append([]byte{0x00}, bad...))
+	ts.SetContinuityCounter(next, 1)
+	stream = append(stream, next...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithRecoverableErrors())
+	defer dmx.Close()
+
+	var got [][]byte
+	dmx.RegisterSectionParser(pid, 0x90, func(p uint16, section []byte) (psi.SectionSyntaxData, error) {
+		assert.Equal(t, uint16(pid), p)
+		got = append(got, bytes.Clone(section))
+		return string(section[8 : len(section)-4]), nil
+	})
+
+	ev, err := dmx.Next()
+	require.NoError(t, err)
+	assert.Equal(t, EventSection, ev)
+	p, data := dmx.Section()
+	assert.Equal(t, uint16(pid), p)
+	assert.Equal(t, "hello", data)
+	assert.Equal(t, [][]byte{good}, got)
+
+	// Table ids without a registered parser keep the built-in one.
+	ev, err = dmx.Next()
+	require.NoError(t, err)
+	assert.Equal(t, EventTDT, ev)
+
+	ev, err = dmx.Next()
+	assert.Equal(t, EventError, ev)
+	assert.True(t, errors.Is(err, psi.ErrCRC32Mismatch))
+	assert.Len(t, got, 1)
+
+	_, err = dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
@@ -0,0 +1,120 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// Service is the merged view of a program of the PAT: its PMT and its entry
+// in the SDT of the actual transport stream.
+type Service struct {
+	// Streams and Descriptors (the program descriptors) are those of the
+	// PMT, shared with the table state: read-only.
+	Streams       []psi.ElementaryStream
+	Descriptors   []descriptor.Descriptor
+	Name          string // service name of the SDT, decoded
+	Provider      string // service provider name of the SDT, decoded
+	ProgramNumber uint16
+	PMTPID        uint16
+	PCRPID        uint16
+	Type          descriptor.ServiceType
+	RunningStatus psi.RunningStatus
+	HasPMT        bool
+	HasSDT        bool // the SDT fields are set; it needs WithDVBTables
+	FreeCAMode    bool
+}
+
+// sdtKey identifies a service of an SDT.
+type sdtKey struct {
+	tsID, serviceID uint16
+}
+
+// recordService keeps the current tables Services, CurrentPAT and CurrentPMT
+// read: a table announcing its next version (current_next_indicator unset) is
+// not applicable yet and is skipped.
+func (dmx *Demuxer) recordService(s *psi.Section, data psi.SectionSyntaxData) {
+	if !isCurrent(s) {
+		return
+	}
+	switch d := data.(type) {
+	case *psi.PAT:
+		dmx.curPAT = d
+	case *psi.PMT:
+		dmx.pmts.Set(d.ProgramNumber, d)
+	case *psi.SDT:
+		if dmx.sdtServices == nil {
+			dmx.sdtServices = make(map[sdtKey]*psi.SDTService)
+		}
+		for i := range d.Services {
+			dmx.sdtServices[sdtKey{tsID: d.TransportStreamID, serviceID: d.Services[i].ServiceID}] = &d.Services[i]
+		}
+	}
+}
+
+// isCurrent tells whether a section is applicable: current_next_indicator is
+// set, or the section has no syntax header to carry it.
+func isCurrent(s *psi.Section) bool {
+	return !s.Header.SectionSyntaxIndicator || s.Syntax.Header.CurrentNextIndicator
+}
+
+// CurrentPAT returns the PAT in force: the last one read with
+// current_next_indicator set, nil until one is seen. Unlike PAT, which
+// follows the table events, it answers at any point, so a consumer joining
+// mid-stream need not keep the tables itself. The table is shared with the
+// demuxer state: read-only.
+func (dmx *Demuxer) CurrentPAT() *psi.PAT {
+	dmx.rlock()
+	defer dmx.runlock()
+	return dmx.curPAT
+}
+
+// CurrentPMT returns the PMT in force of a program, as CurrentPAT: nil until
+// one is seen.
+func (dmx *Demuxer) CurrentPMT(programNumber uint16) *psi.PMT {
+	dmx.rlock()
+	defer dmx.runlock()
+	if pmt := dmx.pmts.Get(programNumber); pmt != nil {
+		return *pmt
+	}
+	return nil
+}
+
+// Services returns the programs of the last PAT in its order, merged with the
+// last PMT of each and, under WithDVBTables, with their SDT entry; nil until a
+// PAT is seen. The snapshot is rebuilt on every call, so it follows the table
+// updates read so far.
+func (dmx *Demuxer) Services() (ss []Service) {
+	dmx.rlock()
+	defer dmx.runlock()
+	if dmx.pat == nil {
+		return nil
+	}
+	for _, p := range dmx.pat.Programs {
+		// Program number 0 is reserved to NIT
+		if p.ProgramNumber == 0 {
+			continue
+		}
+		s := Service{ProgramNumber: p.ProgramNumber, PMTPID: p.ProgramMapID}
+		if pmt := dmx.pmts.Get(p.ProgramNumber); pmt != nil {
+			s.Streams = (*pmt).ElementaryStreams
+			s.Descriptors = (*pmt).ProgramDescriptors
+			s.PCRPID = (*pmt).PCRPID
+			s.HasPMT = true
+		}
+		if e := dmx.sdtServices[sdtKey{tsID: dmx.pat.TransportStreamID, serviceID: p.ProgramNumber}]; e != nil {
+			s.RunningStatus = e.RunningStatus
+			s.FreeCAMode = e.HasFreeCSAMode
+			s.HasSDT = true
+			for _, d := range e.Descriptors {
+				if d, ok := d.(*descriptor.Service); ok {
+					s.Name = descriptor.DecodeText(d.Name)
+					s.Provider = descriptor.DecodeText(d.Provider)
+					s.Type = d.Type
+					break
+				}
+			}
+		}
+		ss = append(ss, s)
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerServices(t *testing.T) {
+	pmt := &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ElementaryStreams: []psi.ElementaryStream{
+			{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video},
+			{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio},
+		},
+	}
+	sdt := func(tsID uint16, name string) []byte {
+		return psiPacket(t, 0x11, psi.TableIDSDTVariant1, tsID, &psi.SDT{
+			TransportStreamID: tsID,
+			OriginalNetworkID: 1,
+			Services: []psi.SDTService{{
+				ServiceID:     1,
+				RunningStatus: 4,
+				Descriptors: []descriptor.Descriptor{&descriptor.Service{
+					Header:   descriptor.Header{Tag: descriptor.TagService},
+					Type:     descriptor.ServiceTypeDigitalTelevisionService,
+					Name:     []byte(name),
+					Provider: []byte("\x15Prov"),
+				}},
+			}},
+		})
+	}
+
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs: []psi.PATProgram{
+			{ProgramNumber: 0, ProgramMapID: ts.PIDNIT},
+			{ProgramNumber: 1, ProgramMapID: 0x1000},
+			{ProgramNumber: 2, ProgramMapID: 0x1001},
+		},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, pmt)...)
+	stream = append(stream, sdt(7, "One")...)
+	// The SDT of another transport stream
+	stream = append(stream, sdt(8, "Other")...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithDVBTables())
+	defer dmx.Close()
+	assert.Nil(t, dmx.Services())
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []Service{
+		{
+			Streams:       pmt.ElementaryStreams,
+			Name:          "One",
+			Provider:      "Prov",
+			ProgramNumber: 1,
+			PMTPID:        0x1000,
+			PCRPID:        0x100,
+			Type:          descriptor.ServiceTypeDigitalTelevisionService,
+			RunningStatus: 4,
+			HasPMT:        true,
+			HasSDT:        true,
+		},
+		{ProgramNumber: 2, PMTPID: 0x1001},
+	}, dmx.Services())
+}
+
+func TestDemuxerCurrentTables(t *testing.T) {
+	pat := func(version uint8, currentNext bool, programs ...uint16) []byte {
+		d := &psi.PAT{TransportStreamID: 7}
+		for _, p := range programs {
+			d.Programs = append(d.Programs, psi.PATProgram{ProgramNumber: p, ProgramMapID: 0x1000 + p})
+		}
+		return dataPacket(t, ts.PIDPAT, &psi.Data{Sections: []psi.Section{{
+			Header: psi.SectionHeader{TableID: psi.TableIDPAT, SectionSyntaxIndicator: true},
+			Syntax: &psi.SectionSyntax{
+				Header: psi.SectionSyntaxHeader{TableIDExtension: 7, VersionNumber: version, CurrentNextIndicator: currentNext},
+				Data:   d,
+			},
+		}}})
+	}
+	pmt := &psi.PMT{ProgramNumber: 1, PCRPID: 0x100, ElementaryStreams: []psi.ElementaryStream{{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}}}
+
+	var stream []byte
+	for cc, p := range [][]byte{
+		pat(1, true, 1),
+		psiPacket(t, 0x1001, psi.TableIDPMT, 1, pmt),
+		pat(2, false, 1, 2), // next version announced
+	} {
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream))
+	defer dmx.Close()
+	assert.Nil(t, dmx.CurrentPAT())
+	assert.Nil(t, dmx.CurrentPMT(1))
+	for {
+		if _, err := dmx.Next(); errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+	}
+
+	require.NotNil(t, dmx.CurrentPAT())
+	assert.Len(t, dmx.CurrentPAT().Programs, 1)
+	assert.Len(t, dmx.PAT().Programs, 2)
+	assert.Equal(t, pmt, dmx.CurrentPMT(1))
+	assert.Nil(t, dmx.CurrentPMT(2))
+}
@@ -0,0 +1,176 @@
+package demux
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+var eventNames = map[Event]string{
+	EventPES:           "PES",
+	EventPAT:           "PAT",
+	EventPMT:           "PMT",
+	EventNIT:           "NIT",
+	EventSDT:           "SDT",
+	EventTOT:           "TOT",
+	EventEIT:           "EIT",
+	EventTDT:           "TDT",
+	EventCAT:           "CAT",
+	EventBAT:           "BAT",
+	EventRST:           "RST",
+	EventDIT:           "DIT",
+	EventSIT:           "SIT",
+	EventST:            "ST",
+	EventTSDT:          "TSDT",
+	EventError:         "Error",
+	EventSection:       "Section",
+	EventVersionChange: "VersionChange",
+	EventECM:           "ECM",
+	EventEMM:           "EMM",
+	EventDiscontinuity: "Discontinuity",
+	EventSCTE35:        "SCTE35",
+	EventPCR:           "PCR",
+	EventPMTDiff:       "PMTDiff",
+}
+
+func (e Event) String() (s string) {
+	var ok bool
+	if s, ok = eventNames[e]; !ok {
+		s = fmt.Sprintf("0x%02x", uint8(e))
+	}
+	return
+}
+
+// Structure is the stream layout discovered while demuxing: the programs of
+// the PAT with their PMTs, and the tables seen on every PSI PID. Feed it every
+// event with Observe, then render it with WriteDOT (Graphviz) or WriteMermaid:
+// programs → PIDs → codecs → descriptors, plus the SI PIDs and their tables.
+type Structure struct {
+	PAT    *psi.PAT
+	PMTs   map[uint16]*psi.PMT // by program number
+	Tables map[uint16][]Event  // table events seen per PID, in first-seen order
+}
+
+// Observe records the table behind ev, the event the last Next returned.
+func (s *Structure) Observe(dmx *Demuxer, ev Event) {
+	if ev.Kind() != KindTable {
+		return
+	}
+	pid, data := dmx.Section()
+	if s.Tables == nil {
+		s.Tables = make(map[uint16][]Event)
+	}
+	if !slices.Contains(s.Tables[pid], ev) {
+		s.Tables[pid] = append(s.Tables[pid], ev)
+	}
+	switch d := data.(type) {
+	case *psi.PAT:
+		s.PAT = d
+	case *psi.PMT:
+		if s.PMTs == nil {
+			s.PMTs = make(map[uint16]*psi.PMT)
+		}
+		s.PMTs[d.ProgramNumber] = d
+	}
+}
+
+type graphNode struct {
+	id, label string
+}
+
+type graphEdge struct {
+	from, to string
+}
+
+// graph flattens the structure into nodes and edges in a stable order, so the
+// rendered output of the same stream is byte-identical across runs.
+func (s *Structure) graph() (nodes []graphNode, edges []graphEdge) {
+	root := graphNode{id: "ts", label: "TS"}
+	if s.PAT != nil {
+		root.label = fmt.Sprintf("TS 0x%04x", s.PAT.TransportStreamID)
+	}
+	nodes = append(nodes, root)
+	link := func(from string, n graphNode) {
+		nodes = append(nodes, n)
+		edges = append(edges, graphEdge{from: from, to: n.id})
+	}
+
+	pmtPIDs := map[uint16]bool{}
+	if s.PAT != nil {
+		for _, p := range s.PAT.Programs {
+			if p.ProgramNumber == 0 {
+				continue
+			}
+			pmtPIDs[p.ProgramMapID] = true
+			prog := graphNode{id: fmt.Sprintf("prog_%d", p.ProgramNumber),
+				label: fmt.Sprintf("Program %d (PMT PID 0x%04x)", p.ProgramNumber, p.ProgramMapID)}
+			link(root.id, prog)
+			pmt := s.PMTs[p.ProgramNumber]
+			if pmt == nil {
+				continue
+			}
+			for i, d := range pmt.ProgramDescriptors {
+				link(prog.id, graphNode{id: fmt.Sprintf("%s_d%d", prog.id, i), label: d.Tag().String()})
+			}
+			for _, es := range pmt.ElementaryStreams {
+				label := fmt.Sprintf("PID 0x%04x: %s", es.ElementaryPID, es.StreamType)
+				if es.ElementaryPID == pmt.PCRPID {
+					label += " (PCR)"
+				}
+				esNode := graphNode{id: fmt.Sprintf("%s_pid_%04x", prog.id, es.ElementaryPID), label: label}
+				link(prog.id, esNode)
+				for i, d := range es.ElementaryStreamDescriptors {
+					link(esNode.id, graphNode{id: fmt.Sprintf("%s_d%d", esNode.id, i), label: d.Tag().String()})
+				}
+			}
+		}
+	}
+
+	pids := make([]uint16, 0, len(s.Tables))
+	for pid := range s.Tables {
+		if !pmtPIDs[pid] {
+			pids = append(pids, pid)
+		}
+	}
+	slices.Sort(pids)
+	for _, pid := range pids {
+		pidNode := graphNode{id: fmt.Sprintf("si_%04x", pid), label: fmt.Sprintf("PID 0x%04x", pid)}
+		link(root.id, pidNode)
+		for _, ev := range s.Tables[pid] {
+			link(pidNode.id, graphNode{id: fmt.Sprintf("%s_%s", pidNode.id, ev), label: ev.String()})
+		}
+	}
+	return
+}
+
+// WriteDOT writes the structure as a Graphviz digraph.
+func (s *Structure) WriteDOT(w io.Writer) (err error) {
+	nodes, edges := s.graph()
+	b := []byte("digraph ts {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for _, n := range nodes {
+		b = fmt.Appendf(b, "\t%s [label=%q];\n", n.id, n.label)
+	}
+	for _, e := range edges {
+		b = fmt.Appendf(b, "\t%s -> %s;\n", e.from, e.to)
+	}
+	b = append(b, "}\n"...)
+	_, err = w.Write(b)
+	return
+}
+
+// WriteMermaid writes the structure as a Mermaid flowchart.
+func (s *Structure) WriteMermaid(w io.Writer) (err error) {
+	nodes, edges := s.graph()
+	b := []byte("graph LR\n")
+	for _, n := range nodes {
+		b = fmt.Appendf(b, "\t%s[\"%s\"]\n", n.id, strings.ReplaceAll(n.label, `"`, "#quot;"))
+	}
+	for _, e := range edges {
+		b = fmt.Appendf(b, "\t%s --> %s\n", e.from, e.to)
+	}
+	_, err = w.Write(b)
+	return
+}
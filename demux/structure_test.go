@@ -0,0 +1,98 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// psiPacket wraps a single-section table into one packet on pid.
+func psiPacket(t *testing.T, pid uint16, id psi.TableID, ext uint16, data psi.SectionSyntaxData) []byte {
+	d := &psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: id, SectionSyntaxIndicator: true},
+		Syntax: &psi.SectionSyntax{Header: psi.SectionSyntaxHeader{TableIDExtension: ext, CurrentNextIndicator: true}, Data: data},
+	}}}
+	return dataPacket(t, pid, d)
+}
+
+// dataPacket wraps PSI data into one packet on pid, stuffed with 0xff.
+func dataPacket(t *testing.T, pid uint16, d *psi.Data) []byte {
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+	return payloadPacket(pid, payload)
+}
+
+// payloadPacket builds a single unit-start packet carrying payload, stuffed with 0xff.
+func payloadPacket(pid uint16, payload []byte) []byte {
+	bs := make([]byte, ts.PacketSize)
+	h := ts.PacketHeader{PID: pid, HasPayload: true, PayloadUnitStartIndicator: true}
+	h.Put(bs)
+	copy(bs[ts.HeaderSize:], payload)
+	for i := ts.HeaderSize + len(payload); i < len(bs); i++ {
+		bs[i] = 0xff
+	}
+	return bs
+}
+
+func TestStructure(t *testing.T) {
+	var stream []byte
+	stream = append(stream, psiPacket(t, ts.PIDPAT, psi.TableIDPAT, 7, &psi.PAT{
+		TransportStreamID: 7,
+		Programs:          []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x1000, psi.TableIDPMT, 1, &psi.PMT{
+		ProgramNumber: 1,
+		PCRPID:        0x100,
+		ElementaryStreams: []psi.ElementaryStream{{
+			ElementaryPID: 0x100,
+			StreamType:    psi.StreamTypeH264Video,
+			ElementaryStreamDescriptors: []descriptor.Descriptor{
+				&descriptor.StreamIdentifier{Header: descriptor.Header{Tag: descriptor.TagStreamIdentifier, Length: 1}, ComponentTag: 1},
+			},
+		}},
+	})...)
+	stream = append(stream, psiPacket(t, 0x11, psi.TableIDSDTVariant1, 7, &psi.SDT{TransportStreamID: 7})...)
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithDVBTables())
+	defer dmx.Close()
+	var s Structure
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		s.Observe(dmx, ev)
+	}
+
+	var dot, mermaid strings.Builder
+	require.NoError(t, s.WriteDOT(&dot))
+	require.NoError(t, s.WriteMermaid(&mermaid))
+
+	assert.Equal(t, `digraph ts {
+	rankdir=LR;
+	node [shape=box];
+	ts [label="TS 0x0007"];
+	prog_1 [label="Program 1 (PMT PID 0x1000)"];
+	prog_1_pid_0100 [label="PID 0x0100: AVC video (PCR)"];
+	prog_1_pid_0100_d0 [label="stream_identifier_descriptor"];
+	si_0000 [label="PID 0x0000"];
+	si_0000_PAT [label="PAT"];
+	si_0011 [label="PID 0x0011"];
+	si_0011_SDT [label="SDT"];
+	ts -> prog_1;
+	prog_1 -> prog_1_pid_0100;
+	prog_1_pid_0100 -> prog_1_pid_0100_d0;
+	ts -> si_0000;
+	si_0000 -> si_0000_PAT;
+	ts -> si_0011;
+	si_0011 -> si_0011_SDT;
+}
+`, dot.String())
+	assert.Contains(t, mermaid.String(), "graph LR\n\tts[\"TS 0x0007\"]\n")
+	assert.Contains(t, mermaid.String(), "\tprog_1_pid_0100 --> prog_1_pid_0100_d0\n")
+}
@@ -0,0 +1,82 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// nextTable demuxes stream and returns the first table event with its section.
+func nextTable(t *testing.T, stream []byte, opts ...func(*Demuxer)) (Event, uint16, psi.SectionSyntaxData) {
+	dmx := New(context.Background(), bytes.NewReader(stream), append(opts, WithPacketSize(ts.PacketSize))...)
+	defer dmx.Close()
+	ev, err := dmx.Next()
+	require.NoError(t, err)
+	pid, data := dmx.Section()
+	return ev, pid, data
+}
+
+func TestDemuxerTSDT(t *testing.T) {
+	tsdt := &psi.TSDT{Descriptors: []descriptor.Descriptor{
+		&descriptor.TransportStream{Header: descriptor.Header{Tag: descriptor.TagTransportStream, Length: 3}, Data: []byte("DVB")},
+	}}
+	ev, pid, data := nextTable(t, psiPacket(t, ts.PIDTSDT, psi.TableIDTSDT, 0xffff, tsdt), WithDVBTables())
+	assert.Equal(t, EventTSDT, ev)
+	assert.Equal(t, ts.PIDTSDT, pid)
+	assert.Equal(t, tsdt, data)
+}
+
+func TestDemuxerBAT(t *testing.T) {
+	bat := &psi.BAT{
+		BouquetID: 0x1234,
+		BouquetDescriptors: []descriptor.Descriptor{
+			&descriptor.BouquetName{Header: descriptor.Header{Tag: descriptor.TagBouquetName, Length: 7}, Name: []byte("bouquet")},
+		},
+		TransportStreams: []psi.BATTransportStream{{
+			TransportStreamID: 7,
+			OriginalNetworkID: 0x233a,
+			TransportDescriptors: []descriptor.Descriptor{
+				&descriptor.ServiceList{Header: descriptor.Header{Tag: descriptor.TagServiceList, Length: 3},
+					Items: []descriptor.ServiceListItem{{ServiceID: 1, ServiceType: uint8(descriptor.ServiceTypeDigitalTelevisionService)}}},
+			},
+		}},
+	}
+	ev, pid, data := nextTable(t, psiPacket(t, 0x11, psi.TableIDBAT, bat.BouquetID, bat), WithDVBTables())
+	assert.Equal(t, EventBAT, ev)
+	assert.Equal(t, uint16(0x11), pid)
+	assert.Equal(t, bat, data)
+}
+
+func TestDemuxerTDT(t *testing.T) {
+	tdt := &psi.TDT{UTCTime: time.Date(2026, 10, 16, 12, 45, 30, 0, time.UTC)}
+	d := &psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: psi.TableIDTDT},
+		Syntax: &psi.SectionSyntax{Data: tdt},
+	}}}
+	ev, pid, data := nextTable(t, dataPacket(t, 0x14, d), WithDVBTables())
+	assert.Equal(t, EventTDT, ev)
+	assert.Equal(t, uint16(0x14), pid)
+	assert.Equal(t, tdt, data)
+}
+
+func TestDemuxerSIT(t *testing.T) {
+	sit := &psi.SIT{
+		TransmissionInfoDescriptors: []descriptor.Descriptor{
+			&descriptor.PartialTransportStream{Header: descriptor.Header{Tag: descriptor.TagPartialTransportStream, Length: 8},
+				PeakRate: 0x1234, MinimumOverallSmoothingRate: 0x3fffff, MaximumOverallSmoothingBuffer: 0x3fff},
+		},
+		Services: []psi.SITService{{ServiceID: 5, RunningStatus: psi.RunningStatusRunning}},
+	}
+	ev, pid, data := nextTable(t, psiPacket(t, 0x1f, psi.TableIDSIT, 0xffff, sit), WithDVBTables())
+	assert.Equal(t, EventSIT, ev)
+	assert.Equal(t, uint16(0x1f), pid)
+	assert.Equal(t, sit, data)
+}
@@ -0,0 +1,35 @@
+package demux
+
+import "github.com/k-danil/go-astits/v2/ts"
+
+// TEIPolicy is the handling of packets with transport_error_indicator set,
+// flagged as corrupt by the demodulator. See WithTEIPolicy.
+type TEIPolicy uint8
+
+const (
+	// TEIDrop leaves the payload of a flagged packet out of unit assembly,
+	// silently. The default.
+	TEIDrop TEIPolicy = iota
+	// TEIReport drops the payload like TEIDrop and reports each flagged packet
+	// as a recoverable packet drop matching ts.ErrTransportError, for Next
+	// under WithRecoverableErrors, OnError and the monitors.
+	TEIReport
+	// TEIParse assembles the payload of a flagged packet like any other, for
+	// error concealment downstream.
+	TEIParse
+)
+
+// WithTEIPolicy sets the handling of packets with transport_error_indicator
+// set. The packet hook, monitors and NextPacket see them under every policy.
+func WithTEIPolicy(p TEIPolicy) func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optTEIPolicy = p
+	}
+}
+
+// reportTEI reports a packet flagged with transport_error_indicator.
+func (dmx *Demuxer) reportTEI(p *ts.Packet) {
+	dmx.reportRecoverable(ts.RecoverableError{
+		Kind: ts.ErrorKindPacketDrop, PID: p.Header.PID, Offset: p.Offset, Err: ts.ErrTransportError,
+	})
+}
@@ -0,0 +1,48 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerTEIPolicy(t *testing.T) {
+	var stream []byte
+	stream = append(stream, videoPacket(t, 0x100, 0, 3600, true)...)
+	flagged := videoPacket(t, 0x100, 1, 7200, false)
+	flagged[1] |= 0x80 // transport_error_indicator
+	stream = append(stream, flagged...)
+	stream = append(stream, videoPacket(t, 0x100, 2, 10800, false)...)
+
+	run := func(opts ...func(*Demuxer)) (log []string) {
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithRecoverableErrors())
+		dmx := New(context.Background(), bytes.NewReader(stream), opts...)
+		defer dmx.Close()
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return
+			}
+			if ev == EventError {
+				assert.ErrorIs(t, err, ts.ErrTransportError)
+				assert.ErrorIs(t, err, ts.ErrInvalidData)
+				log = append(log, "TEI")
+				continue
+			}
+			require.NoError(t, err)
+			log = append(log, fmt.Sprint(dmx.PES().Data.Header.OptionalHeader.PTS.Base()))
+		}
+	}
+
+	// The continuity counter gap left by the flagged packet drops its unit
+	assert.Equal(t, []string{"10800"}, run())
+	assert.Equal(t, []string{"TEI", "10800"}, run(WithTEIPolicy(TEIReport)))
+	assert.Equal(t, []string{"3600", "7200", "10800"}, run(WithTEIPolicy(TEIParse)))
+}
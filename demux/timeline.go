@@ -0,0 +1,180 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// timelineGap is the largest PCR step, and the largest PTS step beyond the
+// elapsed PCR time, still taken as continuous: 1s of 90 kHz ticks.
+const timelineGap = 90000
+
+// DiscontinuityKind tells which clock revealed a Discontinuity.
+type DiscontinuityKind uint8
+
+const (
+	// DiscontinuityPCR: the PCR of the timeline PID jumped, or was flagged by
+	// discontinuity_indicator.
+	DiscontinuityPCR DiscontinuityKind = iota
+	// DiscontinuityPTS: the PTS of a PID jumped further than the PCR explains.
+	DiscontinuityPTS
+)
+
+// Discontinuity describes the timeline jump behind an EventDiscontinuity.
+// Before and After are the unwrapped 90 kHz clock values around the jump: the
+// PCR base for DiscontinuityPCR, the PTS otherwise.
+type Discontinuity struct {
+	Offset int64 // byte offset of the packet the jump was read at
+	Before uint64
+	After  uint64
+	// Shift is the 90 kHz offset WithTimelineRebase adds to the timestamps
+	// from here on, cumulative over the jumps; 0 without it.
+	Shift   int64
+	PID     uint16
+	Kind    DiscontinuityKind
+	Flagged bool // signalled by discontinuity_indicator
+}
+
+// WithDiscontinuities emits an EventDiscontinuity for every jump of the
+// stream timeline: a PCR step backwards or over 1s on the PID of the first PCR
+// seen, a PCR flagged by discontinuity_indicator, or a PTS moving more than 1s
+// off the elapsed PCR time (without a PCR, only backwards).
+func WithDiscontinuities() func(*Demuxer) {
+	return func(d *Demuxer) {
+		if d.timeline == nil {
+			d.timeline = &timeline{}
+		}
+	}
+}
+
+// WithTimelineRebase extends WithDiscontinuities and WithTimestampUnwrap:
+// PES.PTS64 and PES.DTS64 are shifted past each PCR jump so they continue
+// from the timestamps before it, one PCR interval on. PTS-only jumps are
+// reported, not rebased.
+func WithTimelineRebase() func(*Demuxer) {
+	return func(d *Demuxer) {
+		WithDiscontinuities()(d)
+		d.timeline.rebase = true
+		if d.unwrappers == nil {
+			d.unwrappers = &pidmap.Map[tsUnwrapper]{}
+		}
+	}
+}
+
+// timeline tracks the PCR of one PID and the PTS of every PES PID.
+type timeline struct {
+	pcr       tsUnwrapper
+	last      uint64 // last PCR base, unwrapped
+	interval  uint64 // ticks between the last two PCRs
+	shift     int64
+	prevShift int64 // in effect for units still flushing from before a jump
+	discs     uint32
+	pid       uint16
+	rebase    bool
+	pts       pidmap.Map[ptsTrack]
+}
+
+// ptsTrack is the PTS state of one PID: the PCR at its last PTS, the PCR
+// discontinuity count at its last two and its last rebased PTS.
+type ptsTrack struct {
+	tsUnwrapper
+	pcr        uint64
+	rebased    int64
+	discs      [2]uint32
+	hasPCR     bool
+	hasRebased bool
+}
+
+// observePCR checks pcr, carried by p, against the timeline.
+func (dmx *Demuxer) observePCR(p *ts.Packet, pcr ts.ClockReference) {
+	af := p.AdaptationField
+	tl := dmx.timeline
+	if tl.pcr.started && p.Header.PID != tl.pid {
+		return
+	}
+	if !tl.pcr.started {
+		tl.pid = p.Header.PID
+		tl.last = tl.pcr.unwrap(pcr.Base())
+		return
+	}
+
+	base := tl.pcr.unwrap(pcr.Base())
+	d := int64(base - tl.last)
+	jump := d < 0 || d > timelineGap
+	if jump || af.DiscontinuityIndicator {
+		if jump {
+			tl.discs++
+			if tl.rebase {
+				tl.prevShift = tl.shift
+				tl.shift += int64(tl.last+tl.interval) - int64(base)
+			}
+		}
+		dmx.queueDiscontinuity(Discontinuity{
+			Offset: p.Offset, PID: tl.pid, Kind: DiscontinuityPCR, Flagged: af.DiscontinuityIndicator,
+			Before: tl.last, After: base,
+		})
+	}
+	if !jump {
+		tl.interval = uint64(d)
+	}
+	tl.last = base
+}
+
+// observePTS checks the PTS of d against its previous one on the PID. A PCR
+// discontinuity since the PTS before that accounts for any jump: the unit
+// flushed by the packet carrying a PCR jump still holds the old PTS.
+func (dmx *Demuxer) observePTS(d *PES) {
+	oh := d.Data.Header.OptionalHeader
+	if oh == nil || oh.PTSDTSIndicator&0x2 == 0 {
+		return
+	}
+	tl := dmx.timeline
+	t := tl.pts.GetOrAdd(d.PID)
+	before, checked := t.last, t.started && t.discs[0] == tl.discs
+	pts := t.unwrap(oh.PTS.Base())
+
+	if checked {
+		var elapsed int64
+		if t.hasPCR {
+			elapsed = int64(tl.last - t.pcr)
+		}
+		if step := int64(pts-before) - elapsed; step < -timelineGap || (t.hasPCR && step > timelineGap) {
+			dmx.queueDiscontinuity(Discontinuity{
+				Offset: dmx.pkt.Offset, PID: d.PID, Kind: DiscontinuityPTS, Before: before, After: pts,
+			})
+		}
+	}
+	t.pcr, t.hasPCR = tl.last, tl.pcr.started
+	t.discs[0], t.discs[1] = t.discs[1], tl.discs
+}
+
+func (dmx *Demuxer) queueDiscontinuity(c Discontinuity) {
+	c.Shift = dmx.timeline.shift
+	dmx.tblQueue = append(dmx.tblQueue, tableEvent{pid: c.PID, ev: EventDiscontinuity, disc: c})
+}
+
+// shiftFor returns the rebase shift of a unit of pid with the unwrapped pts.
+// A unit flushed right after a jump may predate it: until the PID has had a
+// unit past the jump, of the shifts before and after it the one landing nearer
+// the previous rebased PTS of the PID is taken.
+func (tl *timeline) shiftFor(pid uint16, pts uint64) int64 {
+	t := tl.pts.GetOrAdd(pid)
+	s := tl.shift
+	if t.hasRebased && t.discs[0] != tl.discs && abs(int64(pts)+tl.prevShift-t.rebased) < abs(int64(pts)+tl.shift-t.rebased) {
+		s = tl.prevShift
+	}
+	t.rebased, t.hasRebased = int64(pts)+s, true
+	return s
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// Discontinuity is the jump behind the last EventDiscontinuity.
+func (dmx *Demuxer) Discontinuity() Discontinuity {
+	return dmx.cur.disc
+}
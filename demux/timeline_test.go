@@ -0,0 +1,65 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerDiscontinuities(t *testing.T) {
+	// 0.4s steps, video 1s ahead of the PCR: the PCR splices back at step 3,
+	// the video PTS alone jumps 10s at step 6
+	var stream []byte
+	for i := range uint64(7) {
+		pcr, pts := i*36000, 90000+i*36000
+		if i >= 3 {
+			pcr, pts = (i-3)*36000+5000, 95000+(i-3)*36000
+		}
+		if i == 6 {
+			pts += 900000
+		}
+		stream = append(stream, pcrPacket(t, 0x1ff, pcr)...)
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), pts, false)...)
+	}
+
+	for _, rebase := range []bool{false, true} {
+		opt := WithDiscontinuities()
+		if rebase {
+			opt = WithTimelineRebase()
+		}
+		dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), opt)
+		var got []Discontinuity
+		var pts []uint64
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				break
+			}
+			require.NoError(t, err)
+			switch ev {
+			case EventDiscontinuity:
+				got = append(got, dmx.Discontinuity())
+			case EventPES:
+				pts = append(pts, dmx.PES().PTS64)
+			}
+		}
+		dmx.Close()
+
+		var shift int64
+		if rebase {
+			shift = 72000 + 36000 - 5000
+			assert.Equal(t, []uint64{90000, 126000, 162000, 198000, 234000, 270000, 1206000}, pts,
+				"continuous across the splice, units flushed after it included")
+		}
+		assert.Equal(t, []Discontinuity{
+			{Offset: 6 * ts.PacketSize, PID: 0x1ff, Kind: DiscontinuityPCR, Before: 72000, After: 5000, Shift: shift},
+			{Offset: 13 * ts.PacketSize, PID: 0x100, Kind: DiscontinuityPTS, Before: 167000, After: 1103000, Shift: shift},
+		}, got)
+	}
+}
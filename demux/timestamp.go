@@ -0,0 +1,66 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/pes"
+)
+
+// ptsWrap is the PTS/DTS period: 33 bits of 90 kHz ticks, about 26.5 hours.
+const ptsWrap = 1 << 33
+
+// WithTimestampUnwrap sets PES.PTS64 and PES.DTS64: the PTS and DTS of each
+// unit unwrapped per PID onto a continuous 90 kHz timeline, so a recording
+// running past the 33-bit wrap keeps increasing timestamps.
+func WithTimestampUnwrap() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.unwrappers = &pidmap.Map[tsUnwrapper]{}
+	}
+}
+
+// tsUnwrapper maps the 33-bit timestamps of one PID onto 64 bits, picking for
+// each the value nearest to the last one: DTS lagging PTS across the wrap and
+// reordered B-frame PTSs land on the right side of it.
+type tsUnwrapper struct {
+	last    uint64
+	started bool
+}
+
+func (u *tsUnwrapper) unwrap(base uint64) uint64 {
+	if !u.started {
+		u.last, u.started = base, true
+		return base
+	}
+	v := u.last&^(ptsWrap-1) | base
+	if v+ptsWrap/2 < u.last {
+		v += ptsWrap
+	} else if v > u.last+ptsWrap/2 && v >= ptsWrap {
+		v -= ptsWrap
+	}
+	u.last = v
+	return v
+}
+
+// unwrapTimestamps fills the 64-bit timestamps of d from its header, rebased
+// under WithTimelineRebase.
+func (dmx *Demuxer) unwrapTimestamps(d *PES) {
+	oh := d.Data.Header.OptionalHeader
+	if oh == nil {
+		return
+	}
+	switch oh.PTSDTSIndicator {
+	case pes.PTSDTSIndicatorBothPresent:
+		u := dmx.unwrappers.GetOrAdd(d.PID)
+		d.DTS64 = u.unwrap(oh.DTS.Base())
+		d.PTS64 = u.unwrap(oh.PTS.Base())
+	case pes.PTSDTSIndicatorOnlyPTS:
+		d.PTS64 = dmx.unwrappers.GetOrAdd(d.PID).unwrap(oh.PTS.Base())
+		d.DTS64 = d.PTS64
+	default:
+		return
+	}
+	if tl := dmx.timeline; tl != nil && tl.rebase {
+		s := tl.shiftFor(d.PID, d.PTS64)
+		d.PTS64 = uint64(max(int64(d.PTS64)+s, 0))
+		d.DTS64 = uint64(max(int64(d.DTS64)+s, 0))
+	}
+}
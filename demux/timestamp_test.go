@@ -0,0 +1,42 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerTimestampUnwrap(t *testing.T) {
+	// Across the wrap, then a reordered PTS just before it
+	var stream []byte
+	for i, pts := range []uint64{ptsWrap - 3600, 100, ptsWrap - 100, 3700} {
+		stream = append(stream, videoPacket(t, 0x100, uint8(i), pts, false)...)
+	}
+	stream = append(stream, videoPacket(t, 0x101, 0, 1000, false)...) // unwrapped apart
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithTimestampUnwrap())
+	defer dmx.Close()
+	got := map[uint16][]uint64{}
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		if ev == EventPES {
+			d := dmx.PES()
+			assert.Equal(t, d.PTS64, d.DTS64, "DTS defaults to the PTS")
+			got[d.PID] = append(got[d.PID], d.PTS64)
+		}
+	}
+	assert.Equal(t, map[uint16][]uint64{
+		0x100: {ptsWrap - 3600, ptsWrap + 100, ptsWrap - 100, ptsWrap + 3700},
+		0x101: {1000},
+	}, got)
+}
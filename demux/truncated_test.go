@@ -0,0 +1,53 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerTruncatedPES(t *testing.T) {
+	// An audio PES of 1000 bytes cut after its first packet
+	audio := payloadPacket(0x101, []byte{0x00, 0x00, 0x01, 0xc0, 0x03, 0xe8, 0x80, 0x00, 0x00})
+	var stream []byte
+	stream = append(stream, videoPacket(t, 0x100, 0, 3600, true)...)
+	stream = append(stream, audio...)
+	stream = append(stream, videoPacket(t, 0x100, 1, 7200, false)...)
+
+	run := func(flushAt int64, opts ...func(*Demuxer)) (log []string) {
+		var dmx *Demuxer
+		opts = append(opts, WithPacketSize(ts.PacketSize), WithPacketHook(func(p *ts.Packet) {
+			if p.Index == flushAt {
+				dmx.Flush()
+			}
+		}))
+		dmx = New(context.Background(), bytes.NewReader(stream), opts...)
+		defer dmx.Close()
+		for {
+			ev, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return
+			}
+			require.NoError(t, err)
+			if ev == EventPES {
+				d := dmx.PES()
+				log = append(log, fmt.Sprintf("%d %d %v", d.PID, len(d.Data.Data), d.Truncated))
+				d.Close()
+			}
+		}
+	}
+
+	// Dropped by default
+	assert.Equal(t, []string{"256 1 false", "256 1 false"}, run(-1))
+	// Delivered at EOF, after the video tail of the lower PID
+	assert.Equal(t, []string{"256 1 false", "256 1 false", "257 175 true"}, run(-1, WithTruncatedPES()))
+	// Delivered by Flush, before the next packet is read
+	assert.Equal(t, []string{"256 1 false", "257 175 true", "256 1 false"}, run(1, WithTruncatedPES()))
+}
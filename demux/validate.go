@@ -0,0 +1,27 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WithDescriptorValidation checks the descriptor loops of every table section
+// emitted (psi.Section.ValidateDescriptors) and reports each descriptor whose
+// descriptor_length disagrees with its content as a recoverable error of kind
+// ts.ErrorKindDescriptor, wrapping a psi.DescriptorWarning that matches
+// psi.ErrDescriptorLength. The reports reach the monitors, and Next under
+// WithRecoverableErrors ahead of the table event; the table is emitted as is.
+func WithDescriptorValidation() func(*Demuxer) {
+	return func(d *Demuxer) {
+		d.optValidateDescs = true
+	}
+}
+
+// validateDescriptors reports the descriptor warnings of a section.
+func (dmx *Demuxer) validateDescriptors(pid uint16, s *psi.Section) {
+	for _, w := range s.ValidateDescriptors() {
+		dmx.reportRecoverable(ts.RecoverableError{
+			Kind: ts.ErrorKindDescriptor, PID: pid, Offset: dmx.pkt.Offset, Err: w,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// badDescriptorPMTPacket carries the PMT of validPATPacket's program, on PID
+// 0x1000, with a stream_identifier_descriptor declaring a byte too many. The
+// CRC32 is left blank.
+func badDescriptorPMTPacket() []byte {
+	p := hexToBytes(`47500010 00 02b016 0001c10000 e100 f000
+		1b e100 f004 5202 0700 00000000`)
+	for len(p) < ts.PacketSize {
+		p = append(p, 0xff)
+	}
+	return p
+}
+
+func TestWithDescriptorValidation(t *testing.T) {
+	stream := append(validPATPacket(), badDescriptorPMTPacket()...)
+
+	t.Run("silent by default", func(t *testing.T) {
+		dmx := New(context.Background(), bytes.NewReader(stream),
+			WithPacketSize(ts.PacketSize), WithSkipCRCCheck(), WithRecoverableErrors())
+		for _, err := range dmx.Events() {
+			require.NoError(t, err)
+		}
+		require.NotNil(t, dmx.PMT())
+	})
+
+	t.Run("reported ahead of the table", func(t *testing.T) {
+		dmx := New(context.Background(), bytes.NewReader(stream),
+			WithPacketSize(ts.PacketSize), WithSkipCRCCheck(), WithRecoverableErrors(), WithDescriptorValidation())
+		var evs []Event
+		for ev, err := range dmx.Events() {
+			evs = append(evs, ev)
+			if ev != EventError {
+				require.NoError(t, err)
+				continue
+			}
+			var re *ts.RecoverableError
+			require.ErrorAs(t, err, &re)
+			assert.Equal(t, ts.ErrorKindDescriptor, re.Kind)
+			assert.Equal(t, uint16(0x1000), re.PID)
+			assert.ErrorIs(t, err, psi.ErrDescriptorLength)
+			assert.ErrorIs(t, err, ts.ErrInvalidData)
+			var w psi.DescriptorWarning
+			require.ErrorAs(t, err, &w)
+			assert.Equal(t, psi.DescriptorWarning{
+				Warning: descriptor.Warning{Tag: descriptor.TagStreamIdentifier, Length: 2, Parsed: 1},
+				Loop:    1,
+			}, w)
+		}
+		assert.Equal(t, []Event{EventPAT, EventError, EventPMT}, evs)
+	})
+}
+
+func TestWithRawDescriptors(t *testing.T) {
+	stream := append(validPATPacket(), badDescriptorPMTPacket()...)
+	for _, keep := range []bool{false, true} {
+		opts := []func(*Demuxer){WithPacketSize(ts.PacketSize), WithSkipCRCCheck()}
+		if keep {
+			opts = append(opts, WithRawDescriptors())
+		}
+		dmx := New(context.Background(), bytes.NewReader(stream), opts...)
+		for _, err := range dmx.Events() {
+			require.NoError(t, err)
+		}
+		require.NotNil(t, dmx.PMT())
+		ds := dmx.PMT().ElementaryStreams[0].ElementaryStreamDescriptors
+		require.Len(t, ds, 1)
+		if keep {
+			assert.Equal(t, []byte{0x52, 0x02, 0x07, 0x00}, descriptor.Append(nil, ds))
+		} else {
+			assert.Equal(t, []byte{0x52, 0x01, 0x07}, descriptor.Append(nil, ds))
+		}
+	}
+}
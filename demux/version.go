@@ -0,0 +1,64 @@
+package demux
+
+import (
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// VersionChange describes the table version behind an EventVersionChange.
+type VersionChange struct {
+	PID              uint16
+	TableIDExtension uint16
+	TableID          psi.TableID
+	Previous         uint8 // meaningless when First
+	Current          uint8
+	First            bool // first version seen of the table
+}
+
+// tableVersion is the tracked state of one table: its current version and the
+// section numbers already emitted at that version.
+type tableVersion struct {
+	sections [256 / 64]uint64
+	version  uint8
+}
+
+// trackVersion reports whether a section is new under WithVersionTracking: its
+// table changed version, or the section was not emitted at this version yet.
+// A version change queues the EventVersionChange announcing it.
+func (dmx *Demuxer) trackVersion(pid uint16, s *psi.Section, data psi.SectionSyntaxData) bool {
+	if !s.Header.SectionSyntaxIndicator {
+		return true
+	}
+	h := &s.Syntax.Header
+	if !h.CurrentNextIndicator {
+		return false
+	}
+
+	if dmx.versions == nil {
+		dmx.versions = make(map[tableKey]*tableVersion)
+	}
+	k := tableKey{pid: pid, ext: h.TableIDExtension, tableID: s.Header.TableID}
+	v := dmx.versions[k]
+	if v == nil || v.version != h.VersionNumber {
+		c := VersionChange{PID: pid, TableIDExtension: h.TableIDExtension, TableID: s.Header.TableID, Current: h.VersionNumber, First: v == nil}
+		if v == nil {
+			v = &tableVersion{}
+			dmx.versions[k] = v
+		} else {
+			c.Previous = v.version
+		}
+		*v = tableVersion{version: h.VersionNumber}
+		dmx.tblQueue = append(dmx.tblQueue, tableEvent{pid: pid, data: data, ev: EventVersionChange, changed: true, change: c})
+	}
+
+	n := h.SectionNumber
+	if v.sections[n/64]&(1<<(n%64)) != 0 {
+		return false
+	}
+	v.sections[n/64] |= 1 << (n % 64)
+	return true
+}
+
+// VersionChange is the change behind the last EventVersionChange.
+func (dmx *Demuxer) VersionChange() VersionChange {
+	return dmx.cur.change
+}
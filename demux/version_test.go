@@ -0,0 +1,62 @@
+package demux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestDemuxerVersionTracking(t *testing.T) {
+	patSection := func(version uint8, currentNext bool, programs ...uint16) *psi.Data {
+		pat := &psi.PAT{TransportStreamID: 7}
+		for _, p := range programs {
+			pat.Programs = append(pat.Programs, psi.PATProgram{ProgramNumber: p, ProgramMapID: 0x1000 + p})
+		}
+		return &psi.Data{Sections: []psi.Section{{
+			Header: psi.SectionHeader{TableID: psi.TableIDPAT, SectionSyntaxIndicator: true},
+			Syntax: &psi.SectionSyntax{
+				Header: psi.SectionSyntaxHeader{TableIDExtension: 7, VersionNumber: version, CurrentNextIndicator: currentNext},
+				Data:   pat,
+			},
+		}}}
+	}
+
+	var stream []byte
+	for cc, d := range []*psi.Data{
+		patSection(3, true, 1),
+		patSection(4, false, 1, 2), // next version announced
+		patSection(3, true, 1),     // same version, other bytes in between
+		patSection(4, true, 1, 2),
+	} {
+		p := dataPacket(t, ts.PIDPAT, d)
+		ts.SetContinuityCounter(p, uint8(cc))
+		stream = append(stream, p...)
+	}
+
+	dmx := New(context.Background(), bytes.NewReader(stream), WithPacketSize(ts.PacketSize), WithVersionTracking())
+	defer dmx.Close()
+
+	for _, c := range []VersionChange{
+		{PID: ts.PIDPAT, TableIDExtension: 7, TableID: psi.TableIDPAT, Current: 3, First: true},
+		{PID: ts.PIDPAT, TableIDExtension: 7, TableID: psi.TableIDPAT, Previous: 3, Current: 4},
+	} {
+		ev, err := dmx.Next()
+		require.NoError(t, err)
+		assert.Equal(t, EventVersionChange, ev)
+		assert.Equal(t, c, dmx.VersionChange())
+
+		ev, err = dmx.Next()
+		require.NoError(t, err)
+		assert.Equal(t, EventPAT, ev)
+		assert.Len(t, dmx.PAT().Programs, int(c.Current-2))
+	}
+
+	_, err := dmx.Next()
+	assert.ErrorIs(t, err, ts.ErrNoMorePackets)
+}
@@ -1,9 +1,14 @@
 package descriptor
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
 	"github.com/k-danil/go-astits/v2/internal/util"
@@ -241,11 +246,29 @@ func (t *Tag) UnmarshalJSON(b []byte) (err error) {
 	return
 }
 
+// SupportedTags returns the descriptor tags parsed into a dedicated type, in
+// ascending order. Any other tag is carried as UserDefined or Unknown.
+func SupportedTags() []Tag {
+	return slices.Sorted(maps.Keys(tagNames))
+}
+
 // Parse parses a length-prefixed descriptor list; n is the number of bytes
 // consumed (2-byte length prefix plus the descriptors).
 func Parse(bs []byte) (ds []Descriptor, n int, err error) {
+	return parse(bs, false)
+}
+
+// ParseKeepRaw is Parse keeping the wire bytes of every descriptor in its
+// Header.Raw, for a byte-exact passthrough of descriptors whose parsed form
+// does not re-serialize identically (reserved bits, trailing garbage). The
+// wire bytes are written only while the parsed fields are left unmodified.
+func ParseKeepRaw(bs []byte) (ds []Descriptor, n int, err error) {
+	return parse(bs, true)
+}
+
+func parse(bs []byte, keepRaw bool) (ds []Descriptor, n int, err error) {
 	i := bytesiter.New(bs)
-	if ds, err = parseDescriptors(i); err != nil {
+	if ds, err = parseDescriptors(i, keepRaw); err != nil {
 		return
 	}
 	return ds, i.Offset(), nil
@@ -255,14 +278,23 @@ func Parse(bs []byte) (ds []Descriptor, n int, err error) {
 // without a leading 2-byte length prefix — the form a CAT uses, where the loop
 // is bounded by the section length instead.
 func ParseN(bs []byte, length int) (ds []Descriptor, n int, err error) {
+	return parseN(bs, length, false)
+}
+
+// ParseNKeepRaw is ParseN keeping the wire bytes, as ParseKeepRaw does.
+func ParseNKeepRaw(bs []byte, length int) (ds []Descriptor, n int, err error) {
+	return parseN(bs, length, true)
+}
+
+func parseN(bs []byte, length int, keepRaw bool) (ds []Descriptor, n int, err error) {
 	i := bytesiter.New(bs)
-	if ds, err = parseDescriptorsN(i, length); err != nil {
+	if ds, err = parseDescriptorsN(i, length, keepRaw); err != nil {
 		return
 	}
 	return ds, i.Offset(), nil
 }
 
-func parseDescriptors(i *bytesiter.Iterator) (o []Descriptor, err error) {
+func parseDescriptors(i *bytesiter.Iterator, keepRaw bool) (o []Descriptor, err error) {
 	var bs []byte
 	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
 		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
@@ -270,10 +302,10 @@ func parseDescriptors(i *bytesiter.Iterator) (o []Descriptor, err error) {
 	}
 
 	length := int(binary.BigEndian.Uint16(bs) & 0xfff)
-	return parseDescriptorsN(i, length)
+	return parseDescriptorsN(i, length, keepRaw)
 }
 
-func parseDescriptorsN(i *bytesiter.Iterator, length int) (o []Descriptor, err error) {
+func parseDescriptorsN(i *bytesiter.Iterator, length int, keepRaw bool) (o []Descriptor, err error) {
 	if length > 0 {
 		curOffset := i.Offset()
 		offsetEnd := i.Offset() + length
@@ -293,6 +325,13 @@ func parseDescriptorsN(i *bytesiter.Iterator, length int) (o []Descriptor, err e
 
 		i.Seek(curOffset)
 
+		// One copy backs the raw bytes of the whole loop: the input is usually
+		// a pooled demuxer buffer.
+		var raw, parsed []byte
+		if keepRaw {
+			raw = slices.Clone(i.Bytes()[:min(length, len(i.Bytes()))])
+		}
+
 		o = make([]Descriptor, descrCount)
 
 		for idx := range o {
@@ -305,6 +344,11 @@ func parseDescriptorsN(i *bytesiter.Iterator, length int) (o []Descriptor, err e
 				Tag:    Tag(bs[0]),
 				Length: bs[1],
 			}
+			if raw != nil {
+				start := i.Offset() - 2 - curOffset
+				end := min(start+2+int(h.Length), len(raw))
+				h.Raw = raw[start:end:end]
+			}
 
 			if h.Length > 0 {
 				// Unfortunately there's no way to be sure the real descriptor length is the same as the one indicated
@@ -324,16 +368,31 @@ func parseDescriptorsN(i *bytesiter.Iterator, length int) (o []Descriptor, err e
 			} else {
 				o[idx] = &Unknown{Header: h}
 			}
+
+			// The parsed form as read: a descriptor serializing otherwise
+			// has been modified and no longer matches its wire bytes.
+			if raw != nil {
+				if hd, ok := o[idx].(interface{ header() *Header }); ok {
+					start := len(parsed)
+					parsed = o[idx].Append(parsed)
+					hd.header().parsed = parsed[start:len(parsed):len(parsed)]
+				}
+			}
 		}
 	}
 	return
 }
 
 // Append appends the serialized descriptors with no length prefix; the caller
-// bounds them by the section length, as CAT and TSDT do.
+// bounds them by the section length, as CAT and TSDT do. A descriptor parsed
+// with its wire bytes (ParseKeepRaw) and not modified since is written as read.
 func Append(dst []byte, ds []Descriptor) []byte {
 	for _, d := range ds {
+		start := len(dst)
 		dst = d.Append(dst)
+		if raw := unmodifiedRaw(d, dst[start:]); raw != nil {
+			dst = append(dst[:start], raw...)
+		}
 	}
 	return dst
 }
@@ -346,10 +405,53 @@ func AppendWithLength(dst []byte, ds []Descriptor) []byte {
 	return Append(dst, ds)
 }
 
+// MaxLength is the largest descriptor body: descriptor_length is one byte.
+const MaxLength = 0xff
+
+// ErrLengthOverflow reports a descriptor whose body does not fit the one-byte
+// descriptor_length field.
+var ErrLengthOverflow = errors.New("astits: descriptor body exceeds 255 bytes")
+
+// CheckLength reports the first descriptor in ds whose body exceeds MaxLength.
+// Append writes descriptor_length as a single byte and cannot fail, so writers
+// taking descriptors from callers validate them here first.
+func CheckLength(ds []Descriptor) error {
+	for _, d := range ds {
+		if l := d.CalcLength(); l > MaxLength {
+			return fmt.Errorf("astits: descriptor %s length %d: %w", d.Tag(), l, ErrLengthOverflow)
+		}
+	}
+	return nil
+}
+
+// Sort orders a descriptor loop by tag, keeping equal tags in their original
+// order. A private_data_specifier scopes the descriptors that follow it, so it
+// stays in place and only the runs between specifiers are sorted.
+func Sort(ds []Descriptor) {
+	start := 0
+	for i, d := range ds {
+		if d.Tag() == TagPrivateDataSpecifier {
+			sortRun(ds[start:i])
+			start = i + 1
+		}
+	}
+	sortRun(ds[start:])
+}
+
+func sortRun(ds []Descriptor) {
+	slices.SortStableFunc(ds, func(a, b Descriptor) int {
+		return cmp.Compare(a.Tag(), b.Tag())
+	})
+}
+
 // CalcLength returns the total serialized size of a descriptor list,
-// including the 2-byte tag+length prefix of each entry.
+// including the 2-byte tag+length prefix of each entry, as Append writes it.
 func CalcLength(ds []Descriptor) (length int) {
 	for _, d := range ds {
+		if raw := rawBytes(d); raw != nil {
+			length += len(raw)
+			continue
+		}
 		length += 2 // tag and length
 		length += d.CalcLength()
 	}
@@ -371,6 +473,43 @@ type Descriptor interface {
 type Header struct {
 	Tag    Tag   `json:"descriptor_tag"` // the tag defines the structure of the contained data following the descriptor length.
 	Length uint8 `json:"descriptor_length"`
+	// Raw is the descriptor as read from the wire (tag, length, body); set
+	// only by ParseKeepRaw and ParseNKeepRaw. Append and CalcLength of a loop
+	// use it over the parsed fields as long as those still serialize as they
+	// did when read: a modified descriptor is written from its fields.
+	Raw []byte `json:"-"`
+
+	parsed []byte // the fields serialized when Raw was read
+}
+
+// RawBytes returns the wire bytes d was parsed from, or its serialized form
+// when they were not kept or d was modified since.
+func RawBytes(d Descriptor) []byte {
+	if raw := rawBytes(d); raw != nil {
+		return raw
+	}
+	return d.Append(nil)
+}
+
+// rawBytes returns the wire bytes of d, while its fields are unmodified.
+func rawBytes(d Descriptor) []byte {
+	if h, ok := d.(interface{ header() *Header }); !ok || h.header().Raw == nil {
+		return nil
+	}
+	return unmodifiedRaw(d, d.Append(nil))
+}
+
+// unmodifiedRaw returns the wire bytes of d if serialized, the current form of
+// its fields, is the one they had when read.
+func unmodifiedRaw(d Descriptor, serialized []byte) []byte {
+	h, ok := d.(interface{ header() *Header })
+	if !ok {
+		return nil
+	}
+	if hd := h.header(); hd.Raw != nil && bytes.Equal(serialized, hd.parsed) {
+		return hd.Raw
+	}
+	return nil
 }
 
 // userDefinedTagsStart is the bottom of the user-defined tag range
@@ -694,3 +833,107 @@ func (d *UserDefined) Tag() Tag                { return d.Header.Tag }
 func (*VBIData) Tag() Tag                      { return TagVBIData }
 func (*VideoStream) Tag() Tag                  { return TagVideoStream }
 func (*VideoWindow) Tag() Tag                  { return TagVideoWindow }
+
+func (d *AAC) header() *Header                          { return &d.Header }
+func (d *AC3) header() *Header                          { return &d.Header }
+func (d *AVCTimingAndHRD) header() *Header              { return &d.Header }
+func (d *AVCVideo) header() *Header                     { return &d.Header }
+func (d *AdaptationFieldData) header() *Header          { return &d.Header }
+func (d *AncillaryData) header() *Header                { return &d.Header }
+func (d *AnnouncementSupport) header() *Header          { return &d.Header }
+func (d *AudioStream) header() *Header                  { return &d.Header }
+func (d *AuxiliaryVideoStream) header() *Header         { return &d.Header }
+func (d *BouquetName) header() *Header                  { return &d.Header }
+func (d *CA) header() *Header                           { return &d.Header }
+func (d *CAIdentifier) header() *Header                 { return &d.Header }
+func (d *CableDeliverySystem) header() *Header          { return &d.Header }
+func (d *CellFrequencyLink) header() *Header            { return &d.Header }
+func (d *CellList) header() *Header                     { return &d.Header }
+func (d *Component) header() *Header                    { return &d.Header }
+func (d *Content) header() *Header                      { return &d.Header }
+func (d *ContentLabeling) header() *Header              { return &d.Header }
+func (d *Copyright) header() *Header                    { return &d.Header }
+func (d *CountryAvailability) header() *Header          { return &d.Header }
+func (d *DSNG) header() *Header                         { return &d.Header }
+func (d *DTS) header() *Header                          { return &d.Header }
+func (d *DataBroadcast) header() *Header                { return &d.Header }
+func (d *DataBroadcastID) header() *Header              { return &d.Header }
+func (d *DataStreamAlignment) header() *Header          { return &d.Header }
+func (d *EnhancedAC3) header() *Header                  { return &d.Header }
+func (d *ExtendedEvent) header() *Header                { return &d.Header }
+func (d *Extension) header() *Header                    { return &d.Header }
+func (d *ExternalESID) header() *Header                 { return &d.Header }
+func (d *FMC) header() *Header                          { return &d.Header }
+func (d *FTAContentManagement) header() *Header         { return &d.Header }
+func (d *FlexMuxTiming) header() *Header                { return &d.Header }
+func (d *FmxBufferSize) header() *Header                { return &d.Header }
+func (d *FrequencyList) header() *Header                { return &d.Header }
+func (d *HEVCVideo) header() *Header                    { return &d.Header }
+func (d *Hierarchy) header() *Header                    { return &d.Header }
+func (d *IBP) header() *Header                          { return &d.Header }
+func (d *IOD) header() *Header                          { return &d.Header }
+func (d *ISO639LanguageAndAudioType) header() *Header   { return &d.Header }
+func (d *J2KVideo) header() *Header                     { return &d.Header }
+func (d *Linkage) header() *Header                      { return &d.Header }
+func (d *LocalTimeOffset) header() *Header              { return &d.Header }
+func (d *MPEG2AACAudio) header() *Header                { return &d.Header }
+func (d *MPEG2StereoscopicVideoFormat) header() *Header { return &d.Header }
+func (d *MPEG4Audio) header() *Header                   { return &d.Header }
+func (d *MPEG4AudioExtension) header() *Header          { return &d.Header }
+func (d *MPEG4Text) header() *Header                    { return &d.Header }
+func (d *MPEG4Video) header() *Header                   { return &d.Header }
+func (d *MPEGExtension) header() *Header                { return &d.Header }
+func (d *MVCExtension) header() *Header                 { return &d.Header }
+func (d *MVCOperationPoint) header() *Header            { return &d.Header }
+func (d *MaximumBitrate) header() *Header               { return &d.Header }
+func (d *Metadata) header() *Header                     { return &d.Header }
+func (d *MetadataPointer) header() *Header              { return &d.Header }
+func (d *MetadataSTD) header() *Header                  { return &d.Header }
+func (d *Mosaic) header() *Header                       { return &d.Header }
+func (d *MultilingualBouquetName) header() *Header      { return &d.Header }
+func (d *MultilingualComponent) header() *Header        { return &d.Header }
+func (d *MultilingualNetworkName) header() *Header      { return &d.Header }
+func (d *MultilingualServiceName) header() *Header      { return &d.Header }
+func (d *MultiplexBuffer) header() *Header              { return &d.Header }
+func (d *MultiplexBufferUtilization) header() *Header   { return &d.Header }
+func (d *MuxCode) header() *Header                      { return &d.Header }
+func (d *NVODReference) header() *Header                { return &d.Header }
+func (d *NetworkName) header() *Header                  { return &d.Header }
+func (d *PDC) header() *Header                          { return &d.Header }
+func (d *ParentalRating) header() *Header               { return &d.Header }
+func (d *PartialTransportStream) header() *Header       { return &d.Header }
+func (d *PrivateDataIndicator) header() *Header         { return &d.Header }
+func (d *PrivateDataSpecifier) header() *Header         { return &d.Header }
+func (d *Registration) header() *Header                 { return &d.Header }
+func (d *S2SatelliteDeliverySystem) header() *Header    { return &d.Header }
+func (d *SL) header() *Header                           { return &d.Header }
+func (d *STD) header() *Header                          { return &d.Header }
+func (d *SVCExtension) header() *Header                 { return &d.Header }
+func (d *SatelliteDeliverySystem) header() *Header      { return &d.Header }
+func (d *Scrambling) header() *Header                   { return &d.Header }
+func (d *Service) header() *Header                      { return &d.Header }
+func (d *ServiceAvailability) header() *Header          { return &d.Header }
+func (d *ServiceList) header() *Header                  { return &d.Header }
+func (d *ServiceMove) header() *Header                  { return &d.Header }
+func (d *ShortEvent) header() *Header                   { return &d.Header }
+func (d *ShortSmoothingBuffer) header() *Header         { return &d.Header }
+func (d *SmoothingBuffer) header() *Header              { return &d.Header }
+func (d *StereoscopicProgramInfo) header() *Header      { return &d.Header }
+func (d *StereoscopicVideoInfo) header() *Header        { return &d.Header }
+func (d *StreamIdentifier) header() *Header             { return &d.Header }
+func (d *Stuffing) header() *Header                     { return &d.Header }
+func (d *Subtitling) header() *Header                   { return &d.Header }
+func (d *SystemClock) header() *Header                  { return &d.Header }
+func (d *TargetBackgroundGrid) header() *Header         { return &d.Header }
+func (d *Telephone) header() *Header                    { return &d.Header }
+func (d *Teletext) header() *Header                     { return &d.Header }
+func (d *TerrestrialDeliverySystem) header() *Header    { return &d.Header }
+func (d *TimeShiftedEvent) header() *Header             { return &d.Header }
+func (d *TimeShiftedService) header() *Header           { return &d.Header }
+func (d *TransportProfile) header() *Header             { return &d.Header }
+func (d *TransportStream) header() *Header              { return &d.Header }
+func (d *Unknown) header() *Header                      { return &d.Header }
+func (d *UserDefined) header() *Header                  { return &d.Header }
+func (d *VBIData) header() *Header                      { return &d.Header }
+func (d *VideoStream) header() *Header                  { return &d.Header }
+func (d *VideoWindow) header() *Header                  { return &d.Header }
@@ -516,6 +516,168 @@ var descriptorTestTable = []descriptorTest{
 			},
 			Type: 2,
 		}},
+	{
+		"AudioStream",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagAudioStream)) // Tag
+			_ = w.Write(uint8(1))              // Length
+			_ = w.Write("1")                   // Free format flag
+			_ = w.Write("1")                   // ID
+			_ = w.Write("10")                  // Layer
+			_ = w.Write("0")                   // Variable rate audio indicator
+			_ = w.Write("111")                 // Reserved
+		},
+		&AudioStream{
+			Header: Header{
+				Tag:    TagAudioStream,
+				Length: 1,
+			},
+			FreeFormatFlag: true,
+			ID:             true,
+			Layer:          2,
+		}},
+	{
+		"Hierarchy",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagHierarchy)) // Tag
+			_ = w.Write(uint8(4))            // Length
+			_ = w.Write("1")                 // No view scalability flag
+			_ = w.Write("0")                 // No temporal scalability flag
+			_ = w.Write("1")                 // No spatial scalability flag
+			_ = w.Write("0")                 // No quality scalability flag
+			_ = w.Write("0011")              // Hierarchy type
+			_ = w.Write("11")                // Reserved
+			_ = w.Write("000010")            // Hierarchy layer index
+			_ = w.Write("1")                 // TREF present flag
+			_ = w.Write("1")                 // Reserved
+			_ = w.Write("000001")            // Hierarchy embedded layer index
+			_ = w.Write("11")                // Reserved
+			_ = w.Write("000101")            // Hierarchy channel
+		},
+		&Hierarchy{
+			Header: Header{
+				Tag:    TagHierarchy,
+				Length: 4,
+			},
+			HierarchyType:               HierarchyTypeTemporalScalability,
+			HierarchyLayerIndex:         2,
+			HierarchyEmbeddedLayerIndex: 1,
+			HierarchyChannel:            5,
+			NoViewScalabilityFlag:       true,
+			NoSpatialScalabilityFlag:    true,
+			TREFPresentFlag:             true,
+		}},
+	{
+		"HEVCTimingAndHRD",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagMPEGExtension))          // Tag
+			_ = w.Write(uint8(15))                        // Length
+			_ = w.Write(MPEGExtensionTagHEVCTimingAndHRD) // Extension descriptor tag
+			_ = w.Write("1")                              // HRD management valid flag
+			_ = w.Write("0")                              // Target schedule idx not present flag
+			_ = w.Write("10110")                          // Target schedule idx
+			_ = w.Write("1")                              // Picture and timing info present flag
+			_ = w.Write("0")                              // 90kHz flag
+			_ = w.Write("1111111")                        // Reserved
+			_ = w.Write(uint32(27000000))                 // N
+			_ = w.Write(uint32(90000))                    // K
+			_ = w.Write(uint32(1001))                     // Num units in tick
+		},
+		&MPEGExtension{
+			Header: Header{
+				Tag:    TagMPEGExtension,
+				Length: 15,
+			},
+			Extension: MPEGExtensionTagHEVCTimingAndHRD,
+			HEVCTimingAndHRD: &HEVCTimingAndHRD{
+				N:                           27000000,
+				K:                           90000,
+				NumUnitsInTick:              1001,
+				TargetScheduleIdx:           22,
+				HRDManagementValid:          true,
+				PictureAndTimingInfoPresent: true,
+			},
+		}},
+	{
+		"TransportStream",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagTransportStream)) // Tag
+			_ = w.Write(uint8(3))                  // Length
+			_ = w.Write([]byte("DVB"))             // Bytes
+		},
+		&TransportStream{
+			Header: Header{
+				Tag:    TagTransportStream,
+				Length: 3,
+			},
+			Data: []byte("DVB"),
+		}},
+	{
+		"ExtensionMessage",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagExtension))   // Tag
+			_ = w.Write(uint8(10))             // Length
+			_ = w.Write(uint8(ext.TagMessage)) // Extension tag
+			_ = w.Write(uint8(7))              // Message ID
+			_ = w.Write([]byte("eng"))         // ISO 639 language code
+			_ = w.Write([]byte("hello"))       // Text
+		},
+		&Extension{
+			Header: Header{
+				Tag:    TagExtension,
+				Length: 10,
+			},
+			Body: &ext.Message{
+				MessageID: 7,
+				Language:  [3]byte{'e', 'n', 'g'},
+				Text:      []byte("hello"),
+			},
+		}},
+	{
+		"ExtensionURILinkage",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagExtension))              // Tag
+			_ = w.Write(uint8(17))                        // Length
+			_ = w.Write(uint8(ext.TagURILinkage))         // Extension tag
+			_ = w.Write(uint8(ext.URILinkageTypeIPTVSDS)) // URI linkage type
+			_ = w.Write(uint8(10))                        // URI length
+			_ = w.Write([]byte("http://a/b"))             // URI
+			_ = w.Write(uint16(60))                       // Min polling interval
+			_ = w.Write([]byte("pd"))                     // Private data
+		},
+		&Extension{
+			Header: Header{
+				Tag:    TagExtension,
+				Length: 17,
+			},
+			Body: &ext.URILinkage{
+				URILinkageType:     ext.URILinkageTypeIPTVSDS,
+				URI:                []byte("http://a/b"),
+				MinPollingInterval: 60,
+				PrivateData:        []byte("pd"),
+			},
+		}},
+	{
+		"ExtensionServiceRelocated",
+		func(w *bitstest.Writer) {
+			_ = w.Write(uint8(TagExtension))            // Tag
+			_ = w.Write(uint8(7))                       // Length
+			_ = w.Write(uint8(ext.TagServiceRelocated)) // Extension tag
+			_ = w.Write(uint16(0x233a))                 // Old original network ID
+			_ = w.Write(uint16(0x1004))                 // Old transport stream ID
+			_ = w.Write(uint16(0x10bf))                 // Old service ID
+		},
+		&Extension{
+			Header: Header{
+				Tag:    TagExtension,
+				Length: 7,
+			},
+			Body: &ext.ServiceRelocated{
+				OldOriginalNetworkID: 0x233a,
+				OldTransportStreamID: 0x1004,
+				OldServiceID:         0x10bf,
+			},
+		}},
 	{
 		"PrivateDataIndicator",
 		func(w *bitstest.Writer) {
@@ -737,3 +899,125 @@ func TestParseOwnsInput(t *testing.T) {
 
 	assert.Equal(t, reference, parsed)
 }
+
+func TestRawBytes(t *testing.T) {
+	// The audio stream descriptor has its reserved bits cleared: Append would
+	// set them.
+	wire := []byte{byte(TagStreamIdentifier), 1, 0x02, byte(TagAudioStream), 1, 0xd0}
+	src := append([]byte{0x00, byte(len(wire))}, wire...)
+
+	ds, _, err := Parse(src)
+	require.NoError(t, err)
+	assert.Nil(t, ds[1].(*AudioStream).Header.Raw)
+	assert.Equal(t, []byte{byte(TagAudioStream), 1, 0xd7}, RawBytes(ds[1]))
+
+	ds, _, err = ParseKeepRaw(src)
+	require.NoError(t, err)
+	for i := range src {
+		src[i] = 0xa5
+	}
+	assert.Equal(t, wire[:3], RawBytes(ds[0]))
+	assert.Equal(t, wire[3:], ds[1].(*AudioStream).Header.Raw)
+	assert.Equal(t, wire, Append(nil, ds))
+	assert.Equal(t, len(wire), CalcLength(ds))
+
+	// a raw descriptor with trailing garbage keeps it, its loop length too
+	wire = []byte{byte(TagStreamIdentifier), 2, 0x07, 0x00}
+	ds, _, err = ParseNKeepRaw(wire, len(wire))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0xf0, 4}, wire...), AppendWithLength(nil, ds))
+
+	// a modified descriptor is written from its fields, its raw bytes ignored
+	ds[0].(*StreamIdentifier).ComponentTag = 0x08
+	assert.Equal(t, []byte{byte(TagStreamIdentifier), 1, 0x08}, RawBytes(ds[0]))
+	assert.Equal(t, []byte{0xf0, 3, byte(TagStreamIdentifier), 1, 0x08}, AppendWithLength(nil, ds))
+}
+
+func TestValidate(t *testing.T) {
+	buf := bytes.Buffer{}
+	buf.Write([]byte{0x00, 0x00})
+	w := bitstest.NewWriter(&buf)
+	for _, tc := range descriptorTestTable {
+		tc.bytesFunc(w)
+	}
+	// A stream identifier declaring a byte more than its component tag.
+	_ = w.Write(uint8(TagStreamIdentifier))
+	_ = w.Write(uint8(2))
+	_ = w.Write(uint16(0x0200))
+	src := buf.Bytes()
+	src[0], src[1] = byte((len(src)-2)>>8), byte(len(src)-2)
+
+	ds, _, err := Parse(src)
+	require.NoError(t, err)
+	assert.Equal(t, []Warning{{Index: len(descriptorTestTable), Tag: TagStreamIdentifier, Length: 2, Parsed: 1}}, Validate(ds))
+}
+
+func TestLabel(t *testing.T) {
+	ds, err := NewLabel(0x41535449, 0xa0, []byte("build-1234"))
+	require.NoError(t, err)
+
+	// A foreign private descriptor with the same tag must not match.
+	loop := append([]Descriptor{
+		&PrivateDataSpecifier{Header: Header{Tag: TagPrivateDataSpecifier, Length: 4}, Specifier: 0x1},
+		&UserDefined{Header: Header{Tag: 0xa0, Length: 3}, Data: []byte("foo")},
+	}, ds...)
+
+	parsed, _, err := Parse(AppendWithLength(nil, loop))
+	require.NoError(t, err)
+	data, ok := FindLabel(parsed, 0x41535449, 0xa0)
+	require.True(t, ok)
+	assert.Equal(t, []byte("build-1234"), data)
+
+	_, ok = FindLabel(parsed, 0x41535449, 0xa1)
+	assert.False(t, ok)
+
+	_, err = NewLabel(0x1, TagService, nil)
+	assert.ErrorIs(t, err, ErrInvalidLabel)
+	_, err = NewLabel(0x1, 0xa0, make([]byte, 256))
+	assert.ErrorIs(t, err, ErrInvalidLabel)
+}
+
+func TestCheckLength(t *testing.T) {
+	ud := &UserDefined{Header: Header{Tag: 0x80}, Data: make([]byte, MaxLength)}
+	assert.NoError(t, CheckLength([]Descriptor{ud}))
+	bs := ud.Append(nil)
+	assert.Equal(t, byte(MaxLength), bs[1])
+	ds, _, err := ParseN(bs, len(bs))
+	require.NoError(t, err)
+	assert.Equal(t, ud.Data, ds[0].(*UserDefined).Data)
+
+	ud.Data = append(ud.Data, 0)
+	assert.ErrorIs(t, CheckLength([]Descriptor{ud}), ErrLengthOverflow)
+}
+
+func TestSort(t *testing.T) {
+	ds := []Descriptor{
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{1}},
+		&StreamIdentifier{Header: Header{Tag: TagStreamIdentifier}},
+		&PrivateDataSpecifier{Header: Header{Tag: TagPrivateDataSpecifier}, Specifier: 1},
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{2}},
+		&UserDefined{Header: Header{Tag: 0x80}},
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{3}},
+	}
+	Sort(ds)
+	assert.Equal(t, []Descriptor{
+		&StreamIdentifier{Header: Header{Tag: TagStreamIdentifier}},
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{1}},
+		&PrivateDataSpecifier{Header: Header{Tag: TagPrivateDataSpecifier}, Specifier: 1},
+		&UserDefined{Header: Header{Tag: 0x80}},
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{2}},
+		&UserDefined{Header: Header{Tag: 0x90}, Data: []byte{3}},
+	}, ds)
+}
+
+// Byte layouts from Apple's "Timed Metadata for HTTP Live Streaming".
+func TestID3Metadata(t *testing.T) {
+	pointer := []byte{0x25, 0x0f, 0xff, 0xff, 'I', 'D', '3', ' ', 0xff, 'I', 'D', '3', ' ', 0x00, 0x1f, 0x00, 0x01}
+	assert.Equal(t, pointer, NewID3MetadataPointer(1).Append(nil))
+	metadata := []byte{0x26, 0x0d, 0xff, 0xff, 'I', 'D', '3', ' ', 0xff, 'I', 'D', '3', ' ', 0x00, 0x0f}
+	assert.Equal(t, metadata, NewID3Metadata().Append(nil))
+
+	ds, _, err := ParseN(append(pointer, metadata...), len(pointer)+len(metadata))
+	require.NoError(t, err)
+	assert.Equal(t, []Descriptor{NewID3MetadataPointer(1), NewID3Metadata()}, ds)
+}
@@ -7,6 +7,8 @@ package ext
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
 	"github.com/k-danil/go-astits/v2/internal/util"
@@ -87,6 +89,12 @@ func (t *Tag) UnmarshalJSON(b []byte) (err error) {
 	return
 }
 
+// SupportedTags returns the extension_descriptor_tag values parsed into a
+// dedicated type, in ascending order. Any other tag is carried as Unknown.
+func SupportedTags() []Tag {
+	return slices.Sorted(maps.Keys(tagNames))
+}
+
 func (*SupplementaryAudio) Tag() Tag     { return TagSupplementaryAudio }
 func (*CIAncillaryData) Tag() Tag        { return TagCIAncillaryData }
 func (*CP) Tag() Tag                     { return TagCP }
@@ -0,0 +1,99 @@
+package descriptor
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/internal/util"
+)
+
+// MPEGExtensionTagHEVCTimingAndHRD is the extension_descriptor_tag of the
+// HEVC timing and HRD descriptor (ISO/IEC 13818-1 Table 2-103bis).
+const MPEGExtensionTagHEVCTimingAndHRD uint8 = 0x03
+
+// HEVCTimingAndHRD is the MPEG-2 systems HEVC_timing_and_HRD_descriptor
+// (ISO/IEC 13818-1), carried in an MPEGExtension. Without the 90 kHz flag the
+// time base is N * 27 MHz / K, K at least N (N 1 and K 300 for 90 kHz).
+type HEVCTimingAndHRD struct {
+	N                           uint32 `json:"N"`
+	K                           uint32 `json:"K"`
+	NumUnitsInTick              uint32 `json:"num_units_in_tick"`
+	TargetScheduleIdx           uint8  `json:"target_schedule_idx"` // 5 bits, when TargetScheduleIdxNotPresent is unset
+	HRDManagementValid          bool   `json:"hrd_management_valid_flag"`
+	TargetScheduleIdxNotPresent bool   `json:"target_schedule_idx_not_present_flag"`
+	PictureAndTimingInfoPresent bool   `json:"picture_and_timing_info_present_flag"`
+	Is90kHz                     bool   `json:"90kHz_flag"`
+}
+
+func parseHEVCTimingAndHRD(i *bytesiter.Iterator) (d *HEVCTimingAndHRD, err error) {
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+
+	d = &HEVCTimingAndHRD{
+		HRDManagementValid:          b&0x80 > 0,
+		TargetScheduleIdxNotPresent: b&0x40 > 0,
+		PictureAndTimingInfoPresent: b&0x01 > 0,
+	}
+	if !d.TargetScheduleIdxNotPresent {
+		d.TargetScheduleIdx = b >> 1 & 0x1f
+	}
+
+	if d.PictureAndTimingInfoPresent {
+		if b, err = i.NextByte(); err != nil {
+			err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+			return
+		}
+		d.Is90kHz = b&0x80 > 0
+
+		var bs []byte
+		if !d.Is90kHz {
+			if bs, err = i.NextBytesNoCopy(8); err != nil || len(bs) < 8 {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+			d.N = binary.BigEndian.Uint32(bs)
+			d.K = binary.BigEndian.Uint32(bs[4:])
+		}
+
+		if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		d.NumUnitsInTick = binary.BigEndian.Uint32(bs)
+	}
+	return
+}
+
+func (d *HEVCTimingAndHRD) calcLength() int {
+	ret := 1
+	if d.PictureAndTimingInfoPresent {
+		ret += 5
+		if !d.Is90kHz {
+			ret += 8
+		}
+	}
+	return ret
+}
+
+func (d *HEVCTimingAndHRD) append(dst []byte) []byte {
+	idx := byte(0x1f) // reserved
+	if !d.TargetScheduleIdxNotPresent {
+		idx = d.TargetScheduleIdx & 0x1f
+	}
+	dst = append(dst, util.B2U(d.HRDManagementValid)<<7|util.B2U(d.TargetScheduleIdxNotPresent)<<6|idx<<1|util.B2U(d.PictureAndTimingInfoPresent))
+
+	if d.PictureAndTimingInfoPresent {
+		dst = append(dst, util.B2U(d.Is90kHz)<<7|0x7f)
+		if !d.Is90kHz {
+			dst = append(dst,
+				byte(d.N>>24), byte(d.N>>16), byte(d.N>>8), byte(d.N),
+				byte(d.K>>24), byte(d.K>>16), byte(d.K>>8), byte(d.K))
+		}
+		dst = append(dst, byte(d.NumUnitsInTick>>24), byte(d.NumUnitsInTick>>16), byte(d.NumUnitsInTick>>8), byte(d.NumUnitsInTick))
+	}
+	return dst
+}
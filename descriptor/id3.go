@@ -0,0 +1,36 @@
+package descriptor
+
+// MetadataFormatIdentifierID3 is the "ID3 " format identifier of Apple's HLS
+// timed metadata: ID3v2 tags carried in a metadata stream (stream_type 0x15).
+const MetadataFormatIdentifierID3 uint32 = 0x49443320
+
+// NewID3MetadataPointer returns the metadata_pointer_descriptor that announces
+// an ID3 timed metadata stream in the program loop of the PMT of
+// programNumber, as laid out in Apple's "Timed Metadata for HTTP Live
+// Streaming".
+func NewID3MetadataPointer(programNumber uint16) *MetadataPointer {
+	d := &MetadataPointer{
+		Header:                              Header{Tag: TagMetadataPointer},
+		MetadataApplicationFormat:           metadataApplicationFormatIdentifierGate,
+		MetadataApplicationFormatIdentifier: MetadataFormatIdentifierID3,
+		MetadataFormat:                      metadataFormatIdentifierGate,
+		MetadataFormatIdentifier:            MetadataFormatIdentifierID3,
+		ProgramNumber:                       programNumber,
+	}
+	d.Header.Length = uint8(d.CalcLength())
+	return d
+}
+
+// NewID3Metadata returns the metadata_descriptor that describes an ID3 timed
+// metadata stream in its elementary stream loop; see NewID3MetadataPointer.
+func NewID3Metadata() *Metadata {
+	d := &Metadata{
+		Header:                              Header{Tag: TagMetadata},
+		MetadataApplicationFormat:           metadataApplicationFormatEscape,
+		MetadataApplicationFormatIdentifier: MetadataFormatIdentifierID3,
+		MetadataFormat:                      metadataFormatEscape,
+		MetadataFormatIdentifier:            MetadataFormatIdentifierID3,
+	}
+	d.Header.Length = uint8(d.CalcLength())
+	return d
+}
@@ -0,0 +1,48 @@
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidLabel reports a label that cannot be carried in a user-defined
+// descriptor: a tag outside 0x80-0xfe or data longer than 255 bytes.
+var ErrInvalidLabel = errors.New("astits: invalid label")
+
+// NewLabel returns the private_data_specifier + user-defined descriptor pair
+// carrying an application-defined label (build id, source id, ...). Put the
+// pair in a PMT program or elementary stream loop to trace a stream through a
+// processing chain; [FindLabel] reads it back. The specifier scopes the
+// user-defined tag, so unrelated private descriptors with the same tag are not
+// mistaken for the label.
+func NewLabel(specifier uint32, tag Tag, data []byte) ([]Descriptor, error) {
+	if tag < userDefinedTagsStart || tag == 0xff {
+		return nil, fmt.Errorf("astits: label tag %s is not user-defined: %w", tag, ErrInvalidLabel)
+	}
+	if len(data) > MaxLength {
+		return nil, fmt.Errorf("astits: label length %d exceeds %d: %w", len(data), MaxLength, ErrInvalidLabel)
+	}
+	return []Descriptor{
+		&PrivateDataSpecifier{Header: Header{Tag: TagPrivateDataSpecifier, Length: 4}, Specifier: specifier},
+		&UserDefined{Header: Header{Tag: tag, Length: uint8(len(data))}, Data: append([]byte(nil), data...)},
+	}, nil
+}
+
+// FindLabel returns the data of the first user-defined descriptor with the
+// given tag that sits in the scope of a private_data_specifier equal to
+// specifier. A private_data_specifier applies to the descriptors that follow
+// it in the same loop, until the next one.
+func FindLabel(ds []Descriptor, specifier uint32, tag Tag) (data []byte, ok bool) {
+	var inScope bool
+	for _, d := range ds {
+		switch d := d.(type) {
+		case *PrivateDataSpecifier:
+			inScope = d.Specifier == specifier
+		case *UserDefined:
+			if inScope && d.Header.Tag == tag {
+				return d.Data, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -7,10 +7,14 @@ import (
 )
 
 // MPEGExtension is the MPEG-2 systems extension_descriptor (ISO/IEC 13818-1).
+// Sub-descriptors with a typed parser are decoded into their field (e.g.
+// HEVCTimingAndHRD); Body holds the remaining bytes verbatim — the whole body
+// for any other extension_descriptor_tag.
 type MPEGExtension struct {
-	Body      []byte `json:"_body"`
-	Header    Header `json:"_header"`
-	Extension uint8  `json:"extension_descriptor_tag"`
+	HEVCTimingAndHRD *HEVCTimingAndHRD `json:"HEVC_timing_and_HRD,omitempty"`
+	Body             []byte            `json:"_body"`
+	Header           Header            `json:"_header"`
+	Extension        uint8             `json:"extension_descriptor_tag"`
 }
 
 func newDescriptorMPEGExtension(i *bytesiter.Iterator, h Header, offsetEnd int) (dd Descriptor, err error) {
@@ -26,6 +30,13 @@ func newDescriptorMPEGExtension(i *bytesiter.Iterator, h Header, offsetEnd int)
 	}
 	dd = d
 
+	if d.Extension == MPEGExtensionTagHEVCTimingAndHRD {
+		if d.HEVCTimingAndHRD, err = parseHEVCTimingAndHRD(i); err != nil {
+			err = fmt.Errorf("astits: parsing HEVC timing and HRD failed: %w", err)
+			return
+		}
+	}
+
 	if i.Offset() < offsetEnd {
 		if d.Body, err = i.NextBytes(offsetEnd - i.Offset()); err != nil {
 			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
@@ -36,11 +47,18 @@ func newDescriptorMPEGExtension(i *bytesiter.Iterator, h Header, offsetEnd int)
 }
 
 func (d *MPEGExtension) CalcLength() int {
-	return 1 + len(d.Body)
+	ret := 1 + len(d.Body)
+	if d.HEVCTimingAndHRD != nil {
+		ret += d.HEVCTimingAndHRD.calcLength()
+	}
+	return ret
 }
 
 func (d *MPEGExtension) Append(dst []byte) []byte {
 	dst = append(dst, uint8(d.Header.Tag), uint8(d.CalcLength()))
 	dst = append(dst, d.Extension)
+	if d.HEVCTimingAndHRD != nil {
+		dst = d.HEVCTimingAndHRD.append(dst)
+	}
 	return append(dst, d.Body...)
 }
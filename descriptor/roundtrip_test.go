@@ -1091,12 +1091,38 @@ var roundtripGenerators = map[string]func(r *rand.Rand) Descriptor{
 		return d
 	},
 	"MPEGExtension": func(r *rand.Rand) Descriptor {
+		ext := uint8(r.UintN(256))
+		if ext == MPEGExtensionTagHEVCTimingAndHRD {
+			ext++
+		}
 		return &MPEGExtension{
 			Header:    Header{Tag: TagMPEGExtension},
-			Extension: uint8(r.UintN(256)),
+			Extension: ext,
 			Body:      randBytes(r, int(r.UintN(20))),
 		}
 	},
+	"HEVCTimingAndHRD": func(r *rand.Rand) Descriptor {
+		h := &HEVCTimingAndHRD{
+			HRDManagementValid:          r.UintN(2) == 1,
+			TargetScheduleIdxNotPresent: r.UintN(2) == 1,
+			PictureAndTimingInfoPresent: r.UintN(2) == 1,
+		}
+		if !h.TargetScheduleIdxNotPresent {
+			h.TargetScheduleIdx = uint8(r.UintN(32))
+		}
+		if h.PictureAndTimingInfoPresent {
+			h.Is90kHz = r.UintN(2) == 1
+			if !h.Is90kHz {
+				h.N, h.K = r.Uint32(), r.Uint32()
+			}
+			h.NumUnitsInTick = r.Uint32()
+		}
+		return &MPEGExtension{
+			Header:           Header{Tag: TagMPEGExtension},
+			Extension:        MPEGExtensionTagHEVCTimingAndHRD,
+			HEVCTimingAndHRD: h,
+		}
+	},
 }
 
 func TestRoundtripDescriptors(t *testing.T) {
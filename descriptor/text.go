@@ -0,0 +1,242 @@
+package descriptor
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DecodeText decodes a DVB text field (service and event names, event text)
+// to a string, the character table being selected by its first byte (EN 300
+// 468 Annex A): ISO/IEC 6937 by default, ISO/IEC 8859-1, 2, 5, 7, 9 and 15,
+// UCS-2 (0x11) and UTF-8 (0x15). The CR/LF control code becomes a newline and
+// the emphasis codes are dropped. Bytes of the other tables decode as
+// U+FFFD, ASCII aside.
+func DecodeText(bs []byte) string {
+	if len(bs) == 0 {
+		return ""
+	}
+	var t textTable
+	switch b := bs[0]; {
+	case b >= 0x20:
+		t = tableISO6937
+	case b >= 0x01 && b <= 0x0b:
+		t, bs = iso8859Table(int(b)+4), bs[1:]
+	case b == 0x10:
+		if len(bs) < 3 {
+			return ""
+		}
+		t, bs = iso8859Table(int(bs[1])<<8|int(bs[2])), bs[3:]
+	case b == 0x11:
+		return decodeUCS2(bs[1:])
+	case b == 0x15:
+		return decodeUTF8(bs[1:])
+	case b == 0x1f:
+		if len(bs) < 2 {
+			return ""
+		}
+		bs = bs[2:] // encoding_type_id
+	default:
+		bs = bs[1:]
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(bs))
+	for i := 0; i < len(bs); i++ {
+		b := bs[i]
+		switch {
+		case b == 0x8a:
+			sb.WriteByte('\n')
+		case b < 0x20, b >= 0x7f && b < 0xa0:
+			// Control codes, emphasis among them
+		case b < 0x7f:
+			sb.WriteByte(b)
+		case t == tableISO6937 && b >= 0xc1 && b <= 0xcf:
+			// A non-spacing diacritical mark precedes its letter
+			var base byte
+			if i+1 < len(bs) {
+				i++
+				base = bs[i]
+			}
+			sb.WriteString(compose6937(b, base))
+		default:
+			sb.WriteRune(t.rune(b))
+		}
+	}
+	return sb.String()
+}
+
+// textTable is a single-byte character table.
+type textTable uint8
+
+const (
+	tableUnsupported textTable = iota
+	tableISO6937
+	tableISO8859_1
+	tableISO8859_2
+	tableISO8859_5
+	tableISO8859_7
+	tableISO8859_9
+	tableISO8859_15
+)
+
+func iso8859Table(part int) textTable {
+	switch part {
+	case 1:
+		return tableISO8859_1
+	case 2:
+		return tableISO8859_2
+	case 5:
+		return tableISO8859_5
+	case 7:
+		return tableISO8859_7
+	case 9:
+		return tableISO8859_9
+	case 15:
+		return tableISO8859_15
+	}
+	return tableUnsupported
+}
+
+// rune maps a byte of the upper half (0xa0-0xff) of the table.
+func (t textTable) rune(b byte) rune {
+	switch t {
+	case tableISO6937:
+		return iso6937[b-0xa0]
+	case tableISO8859_1:
+		return rune(b)
+	case tableISO8859_2:
+		return iso8859_2[b-0xa0]
+	case tableISO8859_5:
+		switch b {
+		case 0xa0, 0xad:
+			return rune(b)
+		case 0xf0:
+			return '№'
+		case 0xfd:
+			return '§'
+		}
+		return rune(b) + 0x360
+	case tableISO8859_7:
+		switch {
+		case b == 0xa1:
+			return '‘'
+		case b == 0xa2:
+			return '’'
+		case b == 0xaf:
+			return '―'
+		case b >= 0xb4 && b != 0xb7 && b != 0xbb && b != 0xbd && b != 0xff:
+			return rune(b) + 0x2d0
+		}
+		return rune(b)
+	case tableISO8859_9:
+		if r, ok := iso8859_9[b]; ok {
+			return r
+		}
+		return rune(b)
+	case tableISO8859_15:
+		if r, ok := iso8859_15[b]; ok {
+			return r
+		}
+		return rune(b)
+	}
+	return utf8.RuneError
+}
+
+// iso6937 is the upper half of ISO/IEC 6937 (EN 300 468 Figure A.1), the
+// diacritical marks 0xc1-0xcf aside; unassigned positions are U+FFFD.
+var iso6937 = [96]rune([]rune(
+	"\u00a0¡¢£$¥#§¤‘“«←↑→↓" +
+		"°±²³×µ¶·÷’”»¼½¾¿" +
+		strings.Repeat("\ufffd", 16) +
+		"―¹®©™♪¬¦\ufffd\ufffd\ufffd\ufffd⅛⅜⅝⅞" +
+		"ΩÆĐªĦ\ufffdĲĿŁØŒºÞŦŊŉ" +
+		"ĸæđðħıĳŀłøœßþŧŋ\u00ad"))
+
+// iso6937Marks are the combining forms of the diacritical marks 0xc1-0xcf,
+// with the letters they precompose with.
+var iso6937Marks = [15]struct {
+	combining       rune
+	bases, composed string
+}{
+	{'\u0300', "AEIOUaeiou", "ÀÈÌÒÙàèìòù"},
+	{'\u0301', "ACEILNORSUYZacegilnorsuyz", "ÁĆÉÍĹŃÓŔŚÚÝŹáćéǵíĺńóŕśúýź"},
+	{'\u0302', "ACEGHIJOSUWYaceghijosuwy", "ÂĈÊĜĤÎĴÔŜÛŴŶâĉêĝĥîĵôŝûŵŷ"},
+	{'\u0303', "AINOUainou", "ÃĨÑÕŨãĩñõũ"},
+	{'\u0304', "AEIOUaeiou", "ĀĒĪŌŪāēīōū"},
+	{'\u0306', "AGUagu", "ĂĞŬăğŭ"},
+	{'\u0307', "CEGIZcegz", "ĊĖĠİŻċėġż"},
+	{'\u0308', "AEIOUYaeiouy", "ÄËÏÖÜŸäëïöüÿ"},
+	{},
+	{'\u030a', "AUau", "ÅŮåů"},
+	{'\u0327', "CGKLNRSTcklnrst", "ÇĢĶĻŅŖŞŢçķļņŗşţ"},
+	{},
+	{'\u030b', "OUou", "ŐŰőű"},
+	{'\u0328', "AEIUaeiu", "ĄĘĮŲąęįų"},
+	{'\u030c', "CDELNRSTZcdelnrstz", "ČĎĚĽŇŘŠŤŽčďěľňřšťž"},
+}
+
+// compose6937 renders letter base carrying the diacritical mark, precomposed
+// when Unicode has the character and with a combining mark otherwise.
+func compose6937(mark, base byte) string {
+	m := iso6937Marks[mark-0xc1]
+	if m.combining == 0 {
+		return string(utf8.RuneError)
+	}
+	if i := strings.IndexByte(m.bases, base); i >= 0 {
+		return string([]rune(m.composed)[i])
+	}
+	if base < 0x20 || base >= 0x7f {
+		return string(m.combining)
+	}
+	return string([]rune{rune(base), m.combining})
+}
+
+// iso8859_2 is the upper half of ISO/IEC 8859-2.
+var iso8859_2 = [96]rune([]rune(
+	"\u00a0Ą˘Ł¤ĽŚ§¨ŠŞŤŹ\u00adŽŻ" +
+		"°ą˛ł´ľśˇ¸šşťź˝žż" +
+		"ŔÁÂĂÄĹĆÇČÉĘËĚÍÎĎ" +
+		"ĐŃŇÓÔŐÖ×ŘŮÚŰÜÝŢß" +
+		"ŕáâăäĺćçčéęëěíîď" +
+		"đńňóôőö÷řůúűüýţ˙"))
+
+// iso8859_9 and iso8859_15 are where ISO/IEC 8859-9 and 8859-15 differ from
+// 8859-1.
+var (
+	iso8859_9  = map[byte]rune{0xd0: 'Ğ', 0xdd: 'İ', 0xde: 'Ş', 0xf0: 'ğ', 0xfd: 'ı', 0xfe: 'ş'}
+	iso8859_15 = map[byte]rune{0xa4: '€', 0xa6: 'Š', 0xa8: 'š', 0xb4: 'Ž', 0xb8: 'ž', 0xbc: 'Œ', 0xbd: 'œ', 0xbe: 'Ÿ'}
+)
+
+// decodeUCS2 decodes big-endian UCS-2, the control codes in 0xe080-0xe09f.
+func decodeUCS2(bs []byte) string {
+	var sb strings.Builder
+	for ; len(bs) >= 2; bs = bs[2:] {
+		r := rune(bs[0])<<8 | rune(bs[1])
+		switch {
+		case r == 0xe08a:
+			sb.WriteByte('\n')
+		case r >= 0xe080 && r <= 0xe09f, r < 0x20:
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// decodeUTF8 decodes UTF-8, the control codes being U+0080-U+009F.
+func decodeUTF8(bs []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(bs))
+	for len(bs) > 0 {
+		r, n := utf8.DecodeRune(bs)
+		bs = bs[n:]
+		switch {
+		case r == 0x8a:
+			sb.WriteByte('\n')
+		case r < 0x20, r >= 0x7f && r < 0xa0:
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package descriptor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeText(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		bs   []byte
+		want string
+	}{
+		{"empty", nil, ""},
+		{"ISO 6937", []byte("Caf\xc2e \xa3\x865\x87\x8a\xc8u\xcfs\xc2"), "Café £5\nüš́"},
+		{"ISO 6937 uncomposed", []byte("q\xc3x"), "qx̂"},
+		{"ISO 8859-5", []byte{0x01, 0xbf, 0xe0, 0xd8, 0xd2, 0xd5, 0xe2, 0xf0}, "Привет№"},
+		{"ISO 8859-7", []byte{0x03, 0xc1, 0xe2}, "Αβ"},
+		{"ISO 8859-9", []byte{0x05, 0xdd, 0x73, 0x74, 0x61, 0x6e, 0x62, 0x75, 0x6c}, "İstanbul"},
+		{"ISO 8859-2", []byte{0x10, 0x00, 0x02, 0xa3, 0xf3, 0x64, 0xbc}, "Łódź"},
+		{"ISO 8859-15", []byte{0x10, 0x00, 0x0f, 0x35, 0xa4}, "5€"},
+		{"UCS-2", []byte{0x11, 0x00, 0x41, 0xe0, 0x8a, 0x04, 0x14}, "A\nД"},
+		{"UTF-8", append([]byte{0x15}, "Grüße\u0086!"...), "Grüße!"},
+		{"unsupported", []byte{0x13, 'a', 0xb0}, "a�"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, DecodeText(c.bs))
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package descriptor
+
+import "fmt"
+
+// Warning reports a parsed descriptor whose declared descriptor_length
+// disagrees with the content its parser understood. The parser seeks to the
+// declared end regardless, so the mismatch is otherwise silent: bytes were
+// skipped (Parsed < Length) or the body spilled over it (Parsed > Length).
+type Warning struct {
+	Index  int `json:"index"` // position in the descriptor loop
+	Tag    Tag `json:"descriptor_tag"`
+	Length int `json:"descriptor_length"`
+	Parsed int `json:"parsed_length"`
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("descriptor #%d (%s): descriptor_length %d, content %d", w.Index, w.Tag, w.Length, w.Parsed)
+}
+
+// Validate is the strict pass over a parsed descriptor loop: it returns a
+// Warning for each descriptor whose Header.Length differs from its
+// CalcLength. Only parsed descriptors carry a meaningful Header.Length.
+func Validate(ds []Descriptor) (ws []Warning) {
+	for idx, d := range ds {
+		h, ok := d.(interface{ header() *Header })
+		if !ok {
+			continue
+		}
+		if l, n := int(h.header().Length), d.CalcLength(); l != n {
+			ws = append(ws, Warning{Index: idx, Tag: d.Tag(), Length: l, Parsed: n})
+		}
+	}
+	return
+}
@@ -1,7 +1,9 @@
 // Package astits is the documentation root of an opinionated, performance-
 // focused fork of asticode/go-astits for demuxing and remuxing MPEG-TS.
 //
-// This package holds no code — import the sub-packages instead:
+// Apart from Capabilities, which reports the tables, descriptors and packet
+// formats the current build supports, this package holds no code — import the
+// sub-packages instead:
 //
 //	ts          packets, headers, adaptation fields, clocks, CRC32, the packet reader
 //	pes         PES packets
@@ -9,6 +11,9 @@
 //	descriptor  DVB/MPEG descriptors
 //	demux       the event-based demuxer
 //	mux         the muxer
+//	tr101290    the ETSI TR 101 290 stream monitor
+//	teletext    the EBU Teletext page and subtitle decoder
+//	epg         the EIT schedule aggregator
 //
 // The API and semantics have diverged from upstream on purpose; this module is
 // not a drop-in replacement. It has no dependencies outside the standard
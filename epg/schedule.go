@@ -0,0 +1,197 @@
+// Package epg aggregates the DVB event information (EIT) of a stream into
+// per-service schedules: the present/following and schedule sections of every
+// table id and segment are merged into one set of events per service,
+// deduplicated by event id, with the short and extended event texts decoded.
+//
+// Feed a Schedule every event of a demuxer reading DVB tables:
+//
+//	dmx := demux.New(ctx, r, demux.WithDVBTables())
+//	var s epg.Schedule
+//	for {
+//		ev, err := dmx.Next()
+//		...
+//		s.Observe(dmx, ev)
+//	}
+//	for _, e := range s.Events(svc, from, to) { ... }
+package epg
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// ServiceKey identifies a DVB service.
+type ServiceKey struct {
+	OriginalNetworkID uint16
+	TransportStreamID uint16
+	ServiceID         uint16
+}
+
+// Event is a scheduled event of a service.
+type Event struct {
+	Start    time.Time // UTC
+	Duration time.Duration
+	Name     string
+	Text     string // short event text
+	Extended string // extended event text, its descriptors concatenated
+	Items    []Item // extended event items
+	Language string // ISO 639-2 code of the texts
+	// Descriptors are those of the event, e.g. content or parental rating.
+	Descriptors   []descriptor.Descriptor
+	ID            uint16
+	RunningStatus psi.RunningStatus
+	FreeCAMode    bool
+}
+
+// End returns the time the event ends at.
+func (e *Event) End() time.Time {
+	return e.Start.Add(e.Duration)
+}
+
+// Item is an item of an extended event, e.g. Director: ….
+type Item struct {
+	Description string
+	Content     string
+}
+
+// Schedule is the EPG of the services seen in EIT sections. The zero value is
+// an empty schedule, ready to use; it is not safe for concurrent use.
+type Schedule struct {
+	// Language is the ISO 639-2 code of the texts to prefer when an event
+	// carries several; the first ones are used when empty or absent.
+	Language string
+
+	services map[ServiceKey]map[uint16]*Event // events by id
+}
+
+// Observe records the EIT behind ev, the event the last Next returned; other
+// events are ignored.
+func (s *Schedule) Observe(dmx *demux.Demuxer, ev demux.Event) {
+	if ev != demux.EventEIT {
+		return
+	}
+	if _, data := dmx.Section(); data != nil {
+		s.Add(data.(*psi.EIT))
+	}
+}
+
+// Add merges the events of an EIT section, replacing those of the same id.
+func (s *Schedule) Add(eit *psi.EIT) {
+	k := ServiceKey{OriginalNetworkID: eit.OriginalNetworkID, TransportStreamID: eit.TransportStreamID, ServiceID: eit.ServiceID}
+	if s.services == nil {
+		s.services = make(map[ServiceKey]map[uint16]*Event)
+	}
+	events := s.services[k]
+	if events == nil {
+		events = make(map[uint16]*Event)
+		s.services[k] = events
+	}
+	for i := range eit.Events {
+		events[eit.Events[i].EventID] = s.event(&eit.Events[i])
+	}
+}
+
+// event decodes an EIT event.
+func (s *Schedule) event(e *psi.EITEvent) *Event {
+	out := &Event{
+		Start:         e.StartTime.UTC(),
+		Duration:      e.Duration,
+		ID:            e.EventID,
+		RunningStatus: e.RunningStatus,
+		FreeCAMode:    e.HasFreeCSAMode,
+		Descriptors:   slices.Clone(e.Descriptors),
+	}
+
+	var short *descriptor.ShortEvent
+	for _, d := range e.Descriptors {
+		if d, ok := d.(*descriptor.ShortEvent); ok && (short == nil || string(d.Language[:]) == s.Language) {
+			short = d
+		}
+	}
+	if short != nil {
+		out.Language = string(short.Language[:])
+		out.Name = descriptor.DecodeText(short.EventName)
+		out.Text = descriptor.DecodeText(short.Text)
+	}
+
+	var extended []*descriptor.ExtendedEvent
+	for _, d := range e.Descriptors {
+		if d, ok := d.(*descriptor.ExtendedEvent); ok {
+			extended = append(extended, d)
+		}
+	}
+	if len(extended) == 0 {
+		return out
+	}
+	// The extended texts of the language of the short one, or else of the
+	// first language
+	lang := out.Language
+	if !slices.ContainsFunc(extended, func(d *descriptor.ExtendedEvent) bool { return string(d.ISO639LanguageCode[:]) == lang }) {
+		lang = string(extended[0].ISO639LanguageCode[:])
+	}
+	extended = slices.DeleteFunc(extended, func(d *descriptor.ExtendedEvent) bool { return string(d.ISO639LanguageCode[:]) != lang })
+	slices.SortStableFunc(extended, func(a, b *descriptor.ExtendedEvent) int { return cmp.Compare(a.Number, b.Number) })
+
+	var sb strings.Builder
+	for _, d := range extended {
+		sb.WriteString(descriptor.DecodeText(d.Text))
+		for _, it := range d.Items {
+			out.Items = append(out.Items, Item{Description: descriptor.DecodeText(it.Description), Content: descriptor.DecodeText(it.Content)})
+		}
+	}
+	out.Extended = sb.String()
+	if out.Language == "" {
+		out.Language = lang
+	}
+	return out
+}
+
+// Services returns the services with events, in key order.
+func (s *Schedule) Services() []ServiceKey {
+	keys := make([]ServiceKey, 0, len(s.services))
+	for k := range s.services {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b ServiceKey) int {
+		return cmp.Or(
+			cmp.Compare(a.OriginalNetworkID, b.OriginalNetworkID),
+			cmp.Compare(a.TransportStreamID, b.TransportStreamID),
+			cmp.Compare(a.ServiceID, b.ServiceID),
+		)
+	})
+	return keys
+}
+
+// Events returns the events of svc overlapping [from, to), by start time; a
+// zero to has no upper bound.
+func (s *Schedule) Events(svc ServiceKey, from, to time.Time) (events []*Event) {
+	for _, e := range s.services[svc] {
+		if e.End().After(from) && (to.IsZero() || e.Start.Before(to)) {
+			events = append(events, e)
+		}
+	}
+	slices.SortFunc(events, func(a, b *Event) int {
+		return cmp.Or(a.Start.Compare(b.Start), cmp.Compare(a.ID, b.ID))
+	})
+	return
+}
+
+// Prune drops the events ended at or before t.
+func (s *Schedule) Prune(t time.Time) {
+	for k, events := range s.services {
+		for id, e := range events {
+			if !e.End().After(t) {
+				delete(events, id)
+			}
+		}
+		if len(events) == 0 {
+			delete(s.services, k)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package epg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// eitPacket wraps one EIT section of table id into a packet on the EIT PID.
+func eitPacket(t *testing.T, id psi.TableID, cc uint8, eit *psi.EIT) []byte {
+	d := &psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: id, SectionSyntaxIndicator: true},
+		Syntax: &psi.SectionSyntax{Header: psi.SectionSyntaxHeader{TableIDExtension: eit.ServiceID, CurrentNextIndicator: true}, Data: eit},
+	}}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+
+	bs := bytes.Repeat([]byte{0xff}, ts.PacketSize)
+	h := ts.PacketHeader{PID: ts.PIDEIT, HasPayload: true, PayloadUnitStartIndicator: true, ContinuityCounter: cc}
+	h.Put(bs)
+	copy(bs[ts.HeaderSize:], payload)
+	return bs
+}
+
+func shortEvent(lang, name, text string) descriptor.Descriptor {
+	return &descriptor.ShortEvent{Header: descriptor.Header{Tag: descriptor.TagShortEvent}, Language: [3]byte([]byte(lang)), EventName: []byte(name), Text: []byte(text)}
+}
+
+func extendedEvent(lang string, n, last uint8, text string, items ...descriptor.ExtendedEventItem) descriptor.Descriptor {
+	return &descriptor.ExtendedEvent{Header: descriptor.Header{Tag: descriptor.TagExtendedEvent}, ISO639LanguageCode: [3]byte([]byte(lang)), Number: n, LastDescriptorNumber: last, Text: []byte(text), Items: items}
+}
+
+func TestSchedule(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	news := psi.EITEvent{
+		EventID:   1,
+		StartTime: start,
+		Duration:  30 * time.Minute,
+		Descriptors: []descriptor.Descriptor{
+			shortEvent("deu", "Nachrichten", "Aktuelles"),
+			shortEvent("eng", "News", "Headlines"),
+		},
+		RunningStatus: 4,
+	}
+	film := psi.EITEvent{
+		EventID:   2,
+		StartTime: start.Add(30 * time.Minute),
+		Duration:  90 * time.Minute,
+		Descriptors: []descriptor.Descriptor{
+			shortEvent("eng", "Film", ""),
+			extendedEvent("eng", 1, 1, " world.", descriptor.ExtendedEventItem{Description: []byte("Year"), Content: []byte("1999")}),
+			extendedEvent("eng", 0, 1, "Hello\x8a", descriptor.ExtendedEventItem{Description: []byte("Director"), Content: []byte("J. Doe")}),
+		},
+	}
+	eit := func(events ...psi.EITEvent) *psi.EIT {
+		return &psi.EIT{OriginalNetworkID: 1, TransportStreamID: 2, ServiceID: 3, LastTableID: 0x50, Events: events}
+	}
+
+	var stream []byte
+	stream = append(stream, eitPacket(t, psi.TableIDEITStart, 0, eit(news, film))...)
+	// The schedule repeats the present/following events
+	stream = append(stream, eitPacket(t, 0x50, 1, eit(film))...)
+
+	dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize), demux.WithDVBTables())
+	defer dmx.Close()
+	s := Schedule{Language: "eng"}
+	for {
+		ev, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+		s.Observe(dmx, ev)
+	}
+
+	svc := ServiceKey{OriginalNetworkID: 1, TransportStreamID: 2, ServiceID: 3}
+	assert.Equal(t, []ServiceKey{svc}, s.Services())
+	events := s.Events(svc, start, time.Time{})
+	require.Len(t, events, 2)
+	assert.Equal(t, "News", events[0].Name)
+	assert.Equal(t, "Headlines", events[0].Text)
+	assert.Equal(t, "eng", events[0].Language)
+	assert.Equal(t, psi.RunningStatus(4), events[0].RunningStatus)
+	assert.Equal(t, start, events[0].Start)
+	assert.Equal(t, "Film", events[1].Name)
+	assert.Equal(t, "Hello\n world.", events[1].Extended)
+	assert.Equal(t, []Item{{Description: "Director", Content: "J. Doe"}, {Description: "Year", Content: "1999"}}, events[1].Items)
+	assert.Equal(t, start.Add(2*time.Hour), events[1].End())
+
+	assert.Len(t, s.Events(svc, start.Add(time.Hour), start.Add(2*time.Hour)), 1)
+	assert.Empty(t, s.Events(svc, start.Add(2*time.Hour), time.Time{}))
+
+	s.Prune(start.Add(30 * time.Minute))
+	events = s.Events(svc, time.Time{}, time.Time{})
+	require.Len(t, events, 1)
+	assert.Equal(t, uint16(2), events[0].ID)
+	s.Prune(start.Add(3 * time.Hour))
+	assert.Empty(t, s.Services())
+}
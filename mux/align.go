@@ -0,0 +1,97 @@
+package mux
+
+import (
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WithDataAlignment sets data_alignment_indicator in the optional header of
+// every PES packet written: each starts with an access unit, as strict
+// decoders and HLS packagers expect.
+func WithDataAlignment() func(*Muxer) {
+	return func(m *Muxer) {
+		m.dataAlignment = true
+	}
+}
+
+// WithAudioPacking packs the units written on audio PIDs, one access unit each,
+// into PES packets of up to maxPayload data bytes instead of one PES packet
+// per unit. A packet takes the PES header and adaptation field of its first
+// unit; it is written once full, once the next unit would overflow it, or by
+// FlushPES. A unit larger than maxPayload goes alone.
+func WithAudioPacking(maxPayload int) func(*Muxer) {
+	return func(m *Muxer) {
+		m.packLimit = maxPayload
+	}
+}
+
+// pesPack is the PES packet being packed on an audio PID.
+type pesPack struct {
+	data   []byte
+	header pes.Header
+	oh     pes.OptionalHeader
+	af     ts.PacketAdaptationField
+	hasAF  bool
+	units  int
+}
+
+// packUnit adds d to the PES packet packed on its PID, writing the packet
+// first when d does not fit it.
+func (m *Muxer) packUnit(ctx *esContext, d *Data) (bytesWritten int, err error) {
+	if ctx.pack == nil {
+		ctx.pack = &pesPack{}
+	}
+	p := ctx.pack
+	if p.units > 0 && len(p.data)+len(d.PES.Data) > m.packLimit {
+		if bytesWritten, err = m.writePack(ctx, d.PID); err != nil {
+			return
+		}
+	}
+	if p.units == 0 {
+		p.header = d.PES.Header
+		if oh := d.PES.Header.OptionalHeader; oh != nil {
+			p.oh = *oh
+			p.header.OptionalHeader = &p.oh
+		}
+		if p.hasAF = d.AdaptationField != nil; p.hasAF {
+			p.af.CopyFrom(d.AdaptationField)
+		}
+	}
+	p.data = append(p.data, d.PES.Data...)
+	p.units++
+	if len(p.data) >= m.packLimit {
+		var n int
+		n, err = m.writePack(ctx, d.PID)
+		bytesWritten += n
+	}
+	return
+}
+
+// writePack writes the PES packet packed on pid.
+func (m *Muxer) writePack(ctx *esContext, pid uint16) (n int, err error) {
+	p := ctx.pack
+	d := Data{PID: pid, PES: &pes.Data{Header: p.header, Data: p.data}}
+	if p.hasAF {
+		d.AdaptationField = &p.af
+	}
+	n, err = m.writeUnit(ctx, &d)
+	p.data, p.units = p.data[:0], 0
+	return
+}
+
+// FlushPES writes the PES packets being packed with WithAudioPacking, e.g.
+// before the end of the stream.
+func (m *Muxer) FlushPES() (bytesWritten int, err error) {
+	for i := range m.esContexts.Vals {
+		ctx := &m.esContexts.Vals[i]
+		if ctx.pack == nil || ctx.pack.units == 0 {
+			continue
+		}
+		var n int
+		if n, err = m.writePack(ctx, m.esContexts.Keys[i]); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	return
+}
@@ -0,0 +1,117 @@
+package mux
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerAudioPacking(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithAudioPacking(250), WithDataAlignment())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+
+	unit := func(pid uint16, pts uint64, size int) int {
+		n, err := m.WriteData(&Data{
+			PID: pid,
+			PES: &pes.Data{
+				Data:   bytes.Repeat([]byte{byte(pts)}, size),
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{MarkerBits: 2, PTS: ts.NewClockReference(pts, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+		return n
+	}
+	assert.NotZero(t, unit(0x100, 1000, 10)) // tables and video go out right away
+	assert.Zero(t, unit(0x101, 2000, 100))
+	assert.Zero(t, unit(0x101, 3000, 100))
+	assert.NotZero(t, unit(0x101, 4000, 100)) // overflows the packed 200 bytes
+	assert.NotZero(t, unit(0x101, 5000, 300)) // overflows, then too large to pack
+	assert.Zero(t, unit(0x101, 6000, 50))
+	n, err := m.FlushPES()
+	require.NoError(t, err)
+	assert.NotZero(t, n)
+	n, err = m.FlushPES()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	type unitInfo struct {
+		pid   uint16
+		pts   uint64
+		size  int
+		align bool
+	}
+	var got []unitInfo
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()))
+	defer dmx.Close()
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev != demux.EventPES {
+			continue
+		}
+		d := dmx.PES()
+		oh := d.Data.Header.OptionalHeader
+		got = append(got, unitInfo{d.PID, oh.PTS.Base(), len(d.Data.Data), oh.DataAlignmentIndicator})
+		d.Close()
+	}
+	slices.SortFunc(got, func(a, b unitInfo) int { return cmp.Compare(a.pts, b.pts) })
+	assert.Equal(t, []unitInfo{
+		{0x100, 1000, 10, true},
+		{0x101, 2000, 200, true},
+		{0x101, 4000, 100, true},
+		{0x101, 5000, 300, true},
+		{0x101, 6000, 50, true},
+	}, got)
+}
+
+func TestMuxerPESCRC(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithPESCRC())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	var units [][]byte
+	for i := range 3 {
+		data := bytes.Repeat([]byte{byte(i + 1)}, 100*(i+1))
+		units = append(units, data)
+		_, err := m.WriteData(&Data{
+			PID: 0x100,
+			PES: &pes.Data{
+				Data:   data,
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{MarkerBits: 2, PTS: ts.NewClockReference(uint64(i)*3000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()))
+	defer dmx.Close()
+	var i int
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev != demux.EventPES {
+			continue
+		}
+		d := dmx.PES()
+		oh := d.Data.Header.OptionalHeader
+		assert.Equal(t, units[i], d.Data.Data)
+		if assert.Equal(t, i > 0, oh.HasCRC, i) && i > 0 {
+			assert.Equal(t, pes.ComputeCRC16(units[i-1]), oh.CRC)
+		}
+		d.Close()
+		i++
+	}
+	assert.Equal(t, 3, i)
+}
@@ -0,0 +1,111 @@
+package mux
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrWouldBlock is returned by a non-blocking AsyncMuxer whose queue is full.
+var ErrWouldBlock = errors.New("astits: would block")
+
+// AsyncMuxer feeds a Muxer from a writer goroutine through a bounded queue,
+// so an encoder does not wait on a slow output. When the queue is full
+// WriteData blocks until there is room, or returns ErrWouldBlock with
+// WithAsyncNonBlocking. The first write error, or the cancellation of the
+// context, stops the goroutine and is returned from then on.
+type AsyncMuxer struct {
+	m        *Muxer
+	ctx      context.Context
+	queue    chan *Data
+	done     chan struct{}
+	err      error // set before done is closed
+	nonBlock bool  // WithAsyncNonBlocking
+}
+
+// WithAsyncNonBlocking makes WriteData return ErrWouldBlock instead of
+// waiting when the queue is full.
+func WithAsyncNonBlocking() func(*AsyncMuxer) {
+	return func(a *AsyncMuxer) {
+		a.nonBlock = true
+	}
+}
+
+// NewAsync starts writing m from a goroutine with a queue of size units,
+// running until ctx is done or Close is called. Once started, m is the
+// goroutine's: configure its streams and tables before.
+func NewAsync(ctx context.Context, m *Muxer, size int, opts ...func(*AsyncMuxer)) *AsyncMuxer {
+	a := &AsyncMuxer{
+		m:     m,
+		ctx:   ctx,
+		queue: make(chan *Data, size),
+		done:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncMuxer) run() {
+	defer close(a.done)
+	for {
+		// cancellation drops the queued units
+		if err := a.ctx.Err(); err != nil {
+			a.err = err
+			return
+		}
+		select {
+		case <-a.ctx.Done():
+			a.err = a.ctx.Err()
+			return
+		case d, ok := <-a.queue:
+			if !ok {
+				return
+			}
+			if _, err := a.m.WriteData(d); err != nil {
+				a.err = err
+				return
+			}
+		}
+	}
+}
+
+// WriteData queues d for Muxer.WriteData; d and its buffers must not be
+// touched until the unit has been written, at the latest when Close returns.
+// It returns the error that stopped the writer goroutine, if any.
+func (a *AsyncMuxer) WriteData(d *Data) error {
+	select {
+	case <-a.done:
+		return a.err
+	default:
+	}
+	if a.nonBlock {
+		select {
+		case a.queue <- d:
+			return nil
+		default:
+			return ErrWouldBlock
+		}
+	}
+	select {
+	case a.queue <- d:
+		return nil
+	case <-a.done:
+		return a.err
+	case <-a.ctx.Done():
+		return a.ctx.Err()
+	}
+}
+
+// Close writes the queued units, flushes the output (see Muxer.Flush) and
+// stops the writer goroutine, returning the error that stopped it earlier if
+// any. WriteData must not be called during or after Close.
+func (a *AsyncMuxer) Close() error {
+	close(a.queue)
+	<-a.done
+	if a.err != nil {
+		return a.err
+	}
+	return a.m.Flush()
+}
@@ -0,0 +1,108 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// gateWriter signals each write and waits for the gate to let it through.
+type gateWriter struct {
+	bytes.Buffer
+	entered chan struct{}
+	gate    chan struct{}
+	err     error
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.entered <- struct{}{}
+	<-w.gate
+	if w.err != nil {
+		return 0, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func asyncMuxer(t *testing.T, w *gateWriter, opts ...func(*AsyncMuxer)) *AsyncMuxer {
+	m := New(context.Background(), w)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	return NewAsync(context.Background(), m, 1, opts...)
+}
+
+func asyncUnit(i int) *Data {
+	return &Data{PID: 0x100, PES: &pes.Data{Data: []byte{byte(i)}}}
+}
+
+func TestAsyncMuxer(t *testing.T) {
+	want := &bytes.Buffer{}
+	m := New(context.Background(), want)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	for i := range 10 {
+		_, err := m.WriteData(asyncUnit(i))
+		require.NoError(t, err)
+	}
+
+	w := &gateWriter{entered: make(chan struct{}, 100), gate: make(chan struct{})}
+	close(w.gate)
+	a := asyncMuxer(t, w)
+	for i := range 10 {
+		require.NoError(t, a.WriteData(asyncUnit(i)))
+	}
+	require.NoError(t, a.Close())
+	assert.Equal(t, want.Bytes(), w.Bytes())
+}
+
+func TestAsyncMuxerNonBlocking(t *testing.T) {
+	w := &gateWriter{entered: make(chan struct{}), gate: make(chan struct{})}
+	a := asyncMuxer(t, w, WithAsyncNonBlocking())
+
+	// the goroutine holds the first unit in a write, the queue the second
+	require.NoError(t, a.WriteData(asyncUnit(0)))
+	<-w.entered
+	require.NoError(t, a.WriteData(asyncUnit(1)))
+	assert.Equal(t, ErrWouldBlock, a.WriteData(asyncUnit(2)))
+
+	// a failing write stops the goroutine
+	w.err = errors.New("write failed")
+	go func() {
+		for range w.entered {
+		}
+	}()
+	close(w.gate)
+	assert.Equal(t, w.err, a.Close())
+	assert.Equal(t, w.err, a.WriteData(asyncUnit(3)))
+}
+
+func TestAsyncMuxerCancel(t *testing.T) {
+	w := &gateWriter{entered: make(chan struct{}), gate: make(chan struct{})}
+	m := New(context.Background(), w)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	ctx, cancel := context.WithCancel(context.Background())
+	a := NewAsync(ctx, m, 1)
+
+	require.NoError(t, a.WriteData(asyncUnit(0)))
+	<-w.entered
+	require.NoError(t, a.WriteData(asyncUnit(1)))
+	// blocked on the full queue until the context is done
+	errs := make(chan error)
+	go func() { errs <- a.WriteData(asyncUnit(2)) }()
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+
+	go func() {
+		for range w.entered {
+		}
+	}()
+	close(w.gate)
+	assert.ErrorIs(t, a.Close(), context.Canceled)
+}
@@ -0,0 +1,157 @@
+package mux
+
+import (
+	"io"
+	"math/bits"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// clock27MHz is the rate of the system clock PCRs count.
+const clock27MHz = 27_000_000
+
+// pcrWrap is the period of a PCR: a 33-bit base of 300 ticks each.
+const pcrWrap = 300 << 33
+
+// pcrLastByte is the offset in a packet of the byte holding the last bit of
+// program_clock_reference_base, the byte a PCR stamps the arrival time of.
+const pcrLastByte = ts.HeaderSize + 2 + 4
+
+// WithCBR makes the output a constant rate stream of rate bits per second, for
+// modulators: null packets (ts.PIDNull) fill the room between units, and the
+// PCRs written with WriteData are restamped with the arrival time of their
+// packet on that byte clock, unless WithPCRPassthrough; PCR packets are
+// inserted to meet the PCR interval (WithPCRInterval). Each unit goes out lead ahead of its DTS (its
+// PTS without one), the first one setting the clock; a unit without a PTS goes
+// out right away. A unit due before the muxer gets to it goes out late: the
+// rate is too low for the content.
+func WithCBR(rate uint64, lead time.Duration) func(*Muxer) {
+	return func(m *Muxer) {
+		m.cbrRate = rate
+		m.cbrLead = uint64(lead.Nanoseconds()) * clock27MHz / uint64(time.Second)
+	}
+}
+
+// countingWriter counts the bytes written through it: the byte clock of CBR.
+type countingWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (c *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.n += uint64(n)
+	return
+}
+
+// cbrClock is the 27 MHz time at which byte pos of the output arrives.
+func (m *Muxer) cbrClock(pos uint64) uint64 {
+	return m.cbrStart + m.cbrElapsed(pos)
+}
+
+// cbrElapsed is the 27 MHz time pos bytes take at the mux rate.
+func (m *Muxer) cbrElapsed(pos uint64) uint64 {
+	return elapsed27MHz(pos, m.cbrRate)
+}
+
+// elapsed27MHz is the 27 MHz time pos bytes take at rate bits per second, in
+// 128 bits: 64 overflow past ~85 GB.
+func elapsed27MHz(pos, rate uint64) uint64 {
+	hi, lo := bits.Mul64(pos*8, clock27MHz)
+	q, _ := bits.Div64(hi, lo, rate)
+	return q
+}
+
+// cbrSchedule writes the null packets that hold d back until its time, and
+// restamps its PCR.
+func (m *Muxer) cbrSchedule(d *Data) (bytesWritten int, err error) {
+	if due, ok := m.cbrDue(d.PES); ok {
+		if bytesWritten, err = m.cbrPace(due); err != nil {
+			return
+		}
+	}
+	af := d.AdaptationField
+	if af == nil || !af.HasPCR || d.PID != m.pmt.PCRPID {
+		var n int
+		if n, err = m.insertPCR(); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	if af != nil && af.HasPCR && !m.pcrPassthrough {
+		c := m.cbrClock(m.cw.n+pcrLastByte) % pcrWrap
+		af.PCR = ts.NewClockReference(c/300, c%300)
+	}
+	return
+}
+
+// cbrPace writes null packets, and PCR packets as due, until the output
+// reaches the 27 MHz time due.
+func (m *Muxer) cbrPace(due uint64) (bytesWritten int, err error) {
+	if !m.cbrStarted {
+		// The packet goes out on time, after the tables written ahead of it
+		m.cbrStart, m.cbrStarted = due-min(due, m.cbrElapsed(m.cw.n)), true
+	}
+	for m.cbrClock(m.cw.n) < due {
+		var n int
+		if n, err = m.insertPCR(); err != nil {
+			return
+		}
+		bytesWritten += n
+		if n, err = m.writeNull(); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	return
+}
+
+// cbrDue is the 27 MHz time a unit is due on the output, its DTS unwrapped
+// past the 33-bit wrap less the lead time.
+func (m *Muxer) cbrDue(d *pes.Data) (due uint64, ok bool) {
+	h := d.Header.OptionalHeader
+	if h == nil || h.PTSDTSIndicator&pes.PTSDTSIndicatorOnlyPTS == 0 {
+		return
+	}
+	ts90k := h.PTS.Base()
+	if h.PTSDTSIndicator == pes.PTSDTSIndicatorBothPresent {
+		ts90k = h.DTS.Base()
+	}
+	due = m.cbrUnwrap(ts90k) * 300
+	if due < m.cbrLead {
+		return 0, true
+	}
+	return due - m.cbrLead, true
+}
+
+// cbrUnwrap unwraps a 90 kHz timestamp past the 33-bit wrap.
+func (m *Muxer) cbrUnwrap(ts90k uint64) uint64 {
+	if !m.cbrStarted {
+		m.cbrLastTS = ts90k
+	}
+	// The 33-bit clock moves by less than half its period between units
+	delta := int64((ts90k - m.cbrLastTS) & ptsMask)
+	if delta >= 1<<32 {
+		delta -= 1 << 33
+	}
+	m.cbrLastTS = uint64(int64(m.cbrLastTS) + delta)
+	return m.cbrLastTS
+}
+
+// initCBR sets up the byte clock and the null packet.
+func (m *Muxer) initCBR() {
+	m.cw = countingWriter{w: m.w}
+	m.w = &m.cw
+	h := ts.PacketHeader{PID: ts.PIDNull, HasPayload: true}
+	h.Put(m.nullPkt[:])
+	for i := ts.HeaderSize; i < len(m.nullPkt); i++ {
+		m.nullPkt[i] = 0xff
+	}
+}
+
+// writeNull writes a null packet.
+func (m *Muxer) writeNull() (int, error) {
+	return m.w.Write(m.nullPkt[:])
+}
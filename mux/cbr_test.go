@@ -0,0 +1,72 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerCBR(t *testing.T) {
+	// 1000 packets per second: a packet is 1 ms
+	const rate = ts.PacketSize * 8 * 1000
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	for i := range uint64(3) {
+		pts := ts.NewClockReference(90000+i*900, 0) // 10 ms apart
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true},
+			PES: &pes.Data{
+				Data:   []byte{byte(i)},
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: pts, PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// PAT, PMT, then each unit 10 packets after the previous one
+	var pids []uint16
+	for off := 0; off < buf.Len(); off += ts.PacketSize {
+		pids = append(pids, uint16(buf.Bytes()[off+1]&0x1f)<<8|uint16(buf.Bytes()[off+2]))
+	}
+	require.Len(t, pids, 2+1+9+1+9+1)
+	for i, pid := range pids {
+		switch {
+		case i == 0:
+			assert.Equal(t, ts.PIDPAT, pid)
+		case i == 1:
+			assert.Equal(t, pmtStartPID, pid)
+		case (i-2)%10 == 0:
+			assert.Equal(t, uint16(0x100), pid, i)
+		default:
+			assert.Equal(t, ts.PIDNull, pid, i)
+		}
+	}
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()), demux.WithPCREvents())
+	defer dmx.Close()
+	var pcrs []uint64
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev == demux.EventPCR {
+			pcr := dmx.PCR().PCR
+			pcrs = append(pcrs, pcr.Base()*300+pcr.Extension())
+		}
+	}
+	require.Len(t, pcrs, 3)
+	// The first unit's packet is due at its PTS; the PCR stamps its 11th byte
+	assert.Equal(t, uint64(90000*300+10*8*clock27MHz/rate), pcrs[0])
+	assert.Equal(t, uint64(270000), pcrs[1]-pcrs[0])
+	assert.Equal(t, uint64(270000), pcrs[2]-pcrs[1])
+}
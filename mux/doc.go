@@ -1,9 +1,14 @@
 // Package mux writes an MPEG-TS stream. [New] builds a [Muxer]; register
 // elementary streams with [Muxer.AddElementaryStream], then emit PES units with
-// [Muxer.WriteData] and PSI tables with [Muxer.WriteTables]. Already-formed
-// packets pass straight through [Muxer.WritePacket], writing [ts.Packet.Raw]
-// when available and reserializing otherwise.
+// [Muxer.WriteData] and PSI tables with [Muxer.WriteTables], which also
+// retransmits the SI tables set with [Muxer.SetEIT], [Muxer.SetNIT] and
+// [Muxer.SetCAT].
+// Already-formed packets pass straight through [Muxer.WritePacket], writing
+// [ts.Packet.Raw] when available and reserializing otherwise.
+// A [Remuxer] passes a program of another stream through a muxer, and a
+// [Segmenter] cuts the output of one into segments.
 //
-// A muxer is single-goroutine and holds no locks. Fixed-size serialization
+// A muxer is single-goroutine and holds no locks; an [AsyncMuxer] moves its
+// writes to a goroutine of their own behind a bounded queue. Fixed-size serialization
 // panics on a short buffer; see the module documentation.
 package mux
@@ -0,0 +1,74 @@
+package mux
+
+import (
+	"io"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// flusher is a writer that buffers, as bufio.Writer does.
+type flusher interface {
+	Flush() error
+}
+
+// httpFlusher is a writer that flushes without an error, as
+// http.ResponseWriter does through http.Flusher.
+type httpFlusher interface {
+	Flush()
+}
+
+// WithAutoFlush flushes the output writer every n packets written, so a live
+// output (UDP, chunked HTTP) wrapped in a buffer does not hold them back. See
+// Flush.
+func WithAutoFlush(n int) func(*Muxer) {
+	return func(m *Muxer) {
+		m.flushEvery = n
+	}
+}
+
+// Flush flushes the writer the muxer writes to, when it buffers: it has a
+// Flush() error method, as bufio.Writer, or a Flush() one, as an
+// http.ResponseWriter. Audio units held by WithAudioPacking are not written;
+// FlushPES does that.
+func (m *Muxer) Flush() error {
+	switch f := m.out.(type) {
+	case flusher:
+		return f.Flush()
+	case httpFlusher:
+		f.Flush()
+	}
+	return nil
+}
+
+// flushWriter flushes the muxer output on the packet boundary past every
+// n packets.
+type flushWriter struct {
+	m     *Muxer
+	w     io.Writer
+	size  uint64 // bytes per output packet
+	every uint64
+	pos   uint64
+	next  uint64 // position of the next flush
+}
+
+func (c *flushWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.pos += uint64(n)
+	if err == nil && c.pos >= c.next && c.pos%c.size == 0 {
+		c.next = c.pos + c.every*c.size
+		err = c.m.Flush()
+	}
+	return
+}
+
+// initFlush puts the flush writer right on the muxer output, under the M2TS
+// one so it counts whole output packets.
+func (m *Muxer) initFlush() {
+	size := uint64(ts.PacketSize)
+	if m.m2ts {
+		size = ts.M2TSPacketSize
+	}
+	m.fw = flushWriter{m: m, w: m.w, size: size, every: uint64(m.flushEvery)}
+	m.fw.next = m.fw.every * size
+	m.w = &m.fw
+}
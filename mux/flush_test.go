@@ -0,0 +1,64 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// flushBuffer records the output length at each flush.
+type flushBuffer struct {
+	bytes.Buffer
+	flushes []int
+}
+
+func (b *flushBuffer) Flush() error {
+	b.flushes = append(b.flushes, b.Len())
+	return nil
+}
+
+// httpFlushBuffer flushes as an http.ResponseWriter does.
+type httpFlushBuffer struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (b *httpFlushBuffer) Flush() {
+	b.flushes++
+}
+
+func TestMuxerFlush(t *testing.T) {
+	for _, m2ts := range []bool{false, true} {
+		buf := &flushBuffer{}
+		opts := []func(*Muxer){WithAutoFlush(2)}
+		size := ts.PacketSize
+		if m2ts {
+			opts = append(opts, WithM2TS(0))
+			size = ts.M2TSPacketSize
+		}
+		m := New(context.Background(), buf, opts...)
+		require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+		m.SetPCRPID(0x100)
+
+		// PAT, PMT, then a unit of three packets
+		_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: make([]byte, 500)}})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2 * size, 4 * size}, buf.flushes, "m2ts %v", m2ts)
+
+		require.NoError(t, m.Flush())
+		assert.Equal(t, []int{2 * size, 4 * size, 5 * size}, buf.flushes, "m2ts %v", m2ts)
+	}
+
+	hbuf := &httpFlushBuffer{}
+	m := New(context.Background(), hbuf)
+	require.NoError(t, m.Flush())
+	assert.Equal(t, 1, hbuf.flushes)
+	require.NoError(t, New(context.Background(), &bytes.Buffer{}).Flush())
+}
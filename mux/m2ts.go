@@ -0,0 +1,67 @@
+package mux
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WithM2TS makes the output BDAV M2TS: each 188-byte packet is prefixed with
+// the 4-byte TP_extra_header, its arrival_time_stamp counting the 27 MHz time
+// the packet arrives at rate bits per second from the start of the output
+// (the WithCBR rate when 0; without either the timestamps stay 0). The
+// copy_permission_indicator is 0. Byte counts returned by the muxer stay those
+// of the 188-byte packets.
+func WithM2TS(rate uint64) func(*Muxer) {
+	return func(m *Muxer) {
+		m.m2tsRate = rate
+		m.m2ts = true
+	}
+}
+
+// m2tsWriter prefixes each packet written through it with a TP_extra_header.
+// Writes need not be whole packets: the position within one is tracked.
+type m2tsWriter struct {
+	w    io.Writer
+	rate uint64
+	pos  uint64 // 188-byte packet bytes written
+	hdr  [ts.M2TSPacketSize - ts.PacketSize]byte
+}
+
+func (c *m2tsWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		off := int(c.pos % ts.PacketSize)
+		if off == 0 {
+			var ats uint64
+			if c.rate > 0 {
+				ats = elapsed27MHz(c.pos, c.rate) & 0x3fffffff
+			}
+			binary.BigEndian.PutUint32(c.hdr[:], uint32(ats))
+			if _, err = c.w.Write(c.hdr[:]); err != nil {
+				return
+			}
+		}
+		chunk := p[:min(ts.PacketSize-off, len(p))]
+		var w int
+		w, err = c.w.Write(chunk)
+		n += w
+		c.pos += uint64(w)
+		if err != nil {
+			return
+		}
+		p = p[len(chunk):]
+	}
+	return
+}
+
+// initM2TS puts the M2TS writer under the muxer output; it goes first, so the
+// CBR byte clock counts 188-byte packets only.
+func (m *Muxer) initM2TS() {
+	rate := m.m2tsRate
+	if rate == 0 {
+		rate = m.cbrRate
+	}
+	m.m2tsw = m2tsWriter{w: m.w, rate: rate}
+	m.w = &m.m2tsw
+}
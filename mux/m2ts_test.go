@@ -0,0 +1,85 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerM2TS(t *testing.T) {
+	// 1000 packets per second: a packet is 27000 ticks
+	const rate = ts.PacketSize * 8 * 1000
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithM2TS(rate))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	n, err := m.WriteData(&Data{
+		PID: 0x100,
+		PES: &pes.Data{
+			Data:   bytes.Repeat([]byte{0xab}, 1000),
+			Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+		},
+	})
+	require.NoError(t, err)
+	require.Zero(t, n%ts.PacketSize)
+	require.Equal(t, n/ts.PacketSize*ts.M2TSPacketSize, buf.Len())
+
+	for i := 0; i < buf.Len(); i += ts.M2TSPacketSize {
+		pkt := buf.Bytes()[i:]
+		assert.Equal(t, uint32(i/ts.M2TSPacketSize*27000), binary.BigEndian.Uint32(pkt))
+		assert.Equal(t, byte(0x47), pkt[4])
+	}
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()), demux.WithPacketSize(ts.M2TSPacketSize))
+	defer dmx.Close()
+	var units int
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev != demux.EventPES {
+			continue
+		}
+		d := dmx.PES()
+		assert.True(t, d.HasArrivalTimeStamp)
+		assert.Equal(t, uint32(2*27000), d.ArrivalTimeStamp)
+		assert.Len(t, d.Data.Data, 1000)
+		d.Close()
+		units++
+	}
+	assert.Equal(t, 1, units)
+}
+
+func TestMuxerM2TSCBR(t *testing.T) {
+	const rate = ts.PacketSize * 8 * 1000
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0), WithM2TS(0))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	for i := range uint64(2) {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true},
+			PES: &pes.Data{
+				Data:   []byte{byte(i)},
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000+i*900, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// The null packets are prefixed too, on the CBR clock
+	require.Equal(t, (2+1+9+1)*ts.M2TSPacketSize, buf.Len())
+	for i := 0; i < buf.Len(); i += ts.M2TSPacketSize {
+		assert.Equal(t, uint32(i/ts.M2TSPacketSize*27000), binary.BigEndian.Uint32(buf.Bytes()[i:]))
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
+	"github.com/k-danil/go-astits/v2/descriptor"
 	"github.com/k-danil/go-astits/v2/internal/pidmap"
 	"github.com/k-danil/go-astits/v2/pes"
 	"github.com/k-danil/go-astits/v2/psi"
@@ -26,26 +28,38 @@ var (
 	ErrPIDNotFound      = errors.New("astits: PID not found")
 	ErrPIDAlreadyExists = errors.New("astits: PID already exists")
 	ErrPCRPIDInvalid    = errors.New("astits: PCR PID invalid")
+	ErrProgramNotFound  = errors.New("astits: program not found")
+	ErrPIDInvalid       = errors.New("astits: PID invalid")
+	ErrProgramInvalid   = errors.New("astits: program number invalid")
 )
 
 // Muxer writes an MPEG-TS stream for a single program.
 type Muxer struct {
 	ctx context.Context
 	w   io.Writer
+	out io.Writer // the writer given to New
 
 	packetSize             int
 	tablesRetransmitPeriod int // period in PES packets
 
 	pm         pidmap.Map[uint16] // pid -> programNumber
 	pmt        psi.PMT
+	pmtPID     uint16
 	patVersion wrappingCounter
 	pmtVersion wrappingCounter
 	patCC      wrappingCounter
 	pmtCC      wrappingCounter
+	pcrCC      wrappingCounter // of a dedicated PCR PID
 	nextPID    uint16
 	pmUpdated  bool
 	pmtUpdated bool
 
+	pcrDedicated bool // SetDedicatedPCRPID
+
+	nextTables    bool // WithNextTables
+	nextAnnounced bool // pending versions written as next, switch due
+	tablesWritten bool
+
 	patBytes bytes.Buffer
 	pmtBytes bytes.Buffer
 
@@ -55,12 +69,77 @@ type Muxer struct {
 	pktArr    [ts.PacketSize]byte
 	pesHdrArr [maxPESHeader]byte
 
-	patData []byte
-	pmtData []byte
+	patData     []byte
+	pmtData     []byte
+	sectionData []byte // WriteSection scratch
 
 	esContexts              pidmap.Map[esContext]
+	siTables                []siTable                   // SetEIT/SetNIT tables, written after PAT and PMT
+	siCC                    pidmap.Map[wrappingCounter] // per SI PID
 	tablesRetransmitCounter int
 
+	timeInterval   time.Duration // WithTimeTables
+	clock          func() time.Time
+	timeSent       time.Time
+	totDescriptors []descriptor.Descriptor
+
+	pcrInterval    time.Duration
+	pcrPassthrough bool
+	pcrGaps        int
+	lastPCR        uint64 // 27 MHz, of the PCR PID
+	pcrSent        bool
+	pcrAF          ts.PacketAdaptationField
+	pcrFilter      *ts.PCROffsetFilter // WithPCRFilter
+
+	pcrSource        PCRSource // WithPCRSource
+	pcrDelay         int64     // 27 MHz
+	pcrClock         func() time.Time
+	pcrSourceStarted bool
+	pcrDTS           uint64 // 90 kHz, unwrapped
+	pcrLastDTS       uint64
+	pcrAnchor        int64 // 27 MHz PCR of the first unit
+	pcrStartTime     time.Time
+	pcrElapsed       time.Duration // clock time of the last PCR
+	pcrOffset        float64       // 27 MHz, clock PCR steering
+	srcAF            ts.PacketAdaptationField
+
+	cbrRate    uint64 // WithCBR, bits per second
+	cbrLead    uint64 // 27 MHz
+	cbrStart   uint64 // 27 MHz time of output byte 0
+	cbrLastTS  uint64 // last unit DTS, unwrapped
+	cbrStarted bool
+	cw         countingWriter
+	nullPkt    [ts.PacketSize]byte
+
+	dataAlignment bool // WithDataAlignment
+	scrambling    bool // a scrambler is set
+
+	cuePID     uint16 // WithSpliceCues
+	cuePreroll uint64 // 90 kHz
+	cues       []scheduledCue
+	pesCRC     bool // WithPESCRC
+	packLimit  int  // WithAudioPacking
+
+	stats           bool // WithStats
+	sw              statsWriter
+	statPCRs        uint64
+	statLastPCR     uint64 // 27 MHz
+	statPCRSpan     uint64 // 27 MHz, first to last PCR
+	statMaxPCR      uint64 // 27 MHz
+	statFirstPCRPos uint64
+	statLastPCRPos  uint64
+
+	m2ts     bool // WithM2TS
+	m2tsRate uint64
+	m2tsw    m2tsWriter
+
+	flushEvery int // WithAutoFlush, packets
+	fw         flushWriter
+
+	pace       bool // WithRealTime
+	paceJitter time.Duration
+	pw         paceWriter
+
 	// Inline storage, each paired with a field above to keep a fresh muxer's
 	// tables and small maps off the heap.
 	pmKeysArr [4]uint16    // pm keys
@@ -72,8 +151,22 @@ type Muxer struct {
 }
 
 type esContext struct {
-	es *psi.ElementaryStream
-	cc wrappingCounter
+	es   *psi.ElementaryStream
+	cc   wrappingCounter
+	pack *pesPack // WithAudioPacking
+
+	prevCRC    uint16 // WithPESCRC, of the previous PES packet's data
+	hasPrevCRC bool
+
+	scrambler Scrambler
+}
+
+// WithPESCRC writes the previous_PES_packet_CRC (pes.ComputeCRC16) in the
+// optional header of every PES packet after the first on a PID.
+func WithPESCRC() func(*Muxer) {
+	return func(m *Muxer) {
+		m.pesCRC = true
+	}
 }
 
 // WithTablesRetransmitPeriod sets how often PAT/PMT are re-emitted, counted in
@@ -92,10 +185,13 @@ func New(ctx context.Context, w io.Writer, opts ...func(*Muxer)) (m *Muxer) {
 	m = &Muxer{
 		ctx: ctx,
 		w:   w,
+		out: w,
 
-		packetSize:             ts.PacketSize, // no 192-byte packet support yet
+		packetSize:             ts.PacketSize, // WithM2TS prefixes on output
 		tablesRetransmitPeriod: 40,
+		pcrInterval:            DefaultPCRInterval,
 
+		pmtPID: pmtStartPID,
 		pmt: psi.PMT{
 			ElementaryStreams: []psi.ElementaryStream{},
 			ProgramNumber:     programNumberStart,
@@ -117,12 +213,27 @@ func New(ctx context.Context, w io.Writer, opts ...func(*Muxer)) (m *Muxer) {
 	m.pmtData = m.pmtArr[:0]
 
 	// TODO multiple programs support
-	m.pm.Set(pmtStartPID, programNumberStart)
+	m.pm.Set(m.pmtPID, programNumberStart)
 	m.pmUpdated = true
 
 	for _, opt := range opts {
 		opt(m)
 	}
+	if m.flushEvery > 0 {
+		m.initFlush()
+	}
+	if m.m2ts {
+		m.initM2TS()
+	}
+	if m.pace {
+		m.initPace()
+	}
+	if m.stats {
+		m.initStats()
+	}
+	if m.cbrRate > 0 {
+		m.initCBR()
+	}
 
 	// to output tables at the very start
 	m.tablesRetransmitCounter = m.tablesRetransmitPeriod
@@ -132,13 +243,17 @@ func New(ctx context.Context, w io.Writer, opts ...func(*Muxer)) (m *Muxer) {
 
 // if es.ElementaryPID is zero, it will be generated automatically
 func (m *Muxer) AddElementaryStream(es psi.ElementaryStream) error {
+	if err := descriptor.CheckLength(es.ElementaryStreamDescriptors); err != nil {
+		return err
+	}
 	if es.ElementaryPID != 0 {
-		for _, oes := range m.pmt.ElementaryStreams {
-			if oes.ElementaryPID == es.ElementaryPID {
-				return ErrPIDAlreadyExists
-			}
+		if m.pidInUse(es.ElementaryPID) {
+			return ErrPIDAlreadyExists
 		}
 	} else {
+		for m.nextPID < minProgramPID || m.pidInUse(m.nextPID) {
+			m.nextPID++
+		}
 		es.ElementaryPID = m.nextPID
 		m.nextPID++
 	}
@@ -149,8 +264,11 @@ func (m *Muxer) AddElementaryStream(es psi.ElementaryStream) error {
 		es: &es,
 		cc: newWrappingCounter(0b1111), // CC is 4 bits
 	}
-	// invalidate pmt cache
-	m.pmtBytes.Reset()
+	// the first stream after RemoveProgram brings the program back
+	if !m.pm.Has(m.pmtPID) {
+		m.pm.Set(m.pmtPID, m.pmt.ProgramNumber)
+		m.pmUpdated = true
+	}
 	m.pmtUpdated = true
 	return nil
 }
@@ -170,38 +288,136 @@ func (m *Muxer) RemoveElementaryStream(pid uint16) error {
 
 	m.pmt.ElementaryStreams = append(m.pmt.ElementaryStreams[:foundIdx], m.pmt.ElementaryStreams[foundIdx+1:]...)
 	m.esContexts.Remove(pid)
-	m.pmtBytes.Reset()
+	// the program goes on without a PCR until SetPCRPID names another stream
+	if pid == m.pmt.PCRPID {
+		m.pmt.PCRPID = ts.PIDNull
+	}
 	m.pmtUpdated = true
 	return nil
 }
 
-// SetPCRPID marks pid as one to look PCRs in
+// SetElementaryStreamDescriptors replaces the ES info descriptor loop of the
+// stream on pid (language, AC-3, teletext, registration...) in the PMT. The
+// PMT version moves only if the loop changes.
+func (m *Muxer) SetElementaryStreamDescriptors(pid uint16, ds []descriptor.Descriptor) error {
+	if err := descriptor.CheckLength(ds); err != nil {
+		return err
+	}
+	ctx := m.esContexts.Get(pid)
+	if ctx == nil {
+		return ErrPIDNotFound
+	}
+	for i := range m.pmt.ElementaryStreams {
+		if m.pmt.ElementaryStreams[i].ElementaryPID == pid {
+			m.pmt.ElementaryStreams[i].ElementaryStreamDescriptors = ds
+		}
+	}
+	ctx.es.ElementaryStreamDescriptors = ds
+	m.pmtUpdated = true
+	return nil
+}
+
+// SetProgramDescriptors replaces the program info descriptor loop of the PMT
+// (a "CUEI" registration, a metadata pointer...); it outlives RemoveProgram.
+// The PMT version moves only if the loop changes.
+func (m *Muxer) SetProgramDescriptors(ds []descriptor.Descriptor) error {
+	if err := descriptor.CheckLength(ds); err != nil {
+		return err
+	}
+	m.pmt.ProgramDescriptors = ds
+	m.pmtUpdated = true
+	return nil
+}
+
+// RemoveProgram drops the program and all its elementary streams: the next
+// tables carry a PAT without it and no PMT. AddElementaryStream registers it
+// again, with the PMT version carried on.
+func (m *Muxer) RemoveProgram() error {
+	if !m.pm.Has(m.pmtPID) {
+		return ErrProgramNotFound
+	}
+	m.pm.Remove(m.pmtPID)
+	m.pmUpdated = true
+
+	m.pmt.ElementaryStreams = m.pmt.ElementaryStreams[:0]
+	m.esContexts = pidmap.Map[esContext]{Keys: m.esContexts.Keys[:0], Vals: m.esContexts.Vals[:0]}
+	m.pmt.PCRPID, m.pcrDedicated = ts.PIDNull, false
+	m.pmtUpdated = true
+	return nil
+}
+
+// SetPCRPID marks pid as one to look PCRs in; it is one of the program's
+// streams, see SetDedicatedPCRPID otherwise.
 func (m *Muxer) SetPCRPID(pid uint16) {
-	m.pmt.PCRPID = pid
+	m.pmt.PCRPID, m.pcrDedicated = pid, false
 	m.pmtUpdated = true
 }
 
-// SetCC seeds the continuity counter for a PID so passthrough output continues
-// the source packet sequence without a discontinuity.
+// SetCC seeds the continuity counter of a PID — an elementary stream, the
+// PAT, the PMT or an SI table PID — with the counter of the last packet
+// written on it, so the output continues a previous file or the source
+// packet sequence without a discontinuity.
 func (m *Muxer) SetCC(pid uint16, cc uint8) error {
-	ctx := m.esContexts.Get(pid)
-	if ctx == nil {
+	c := m.counter(pid)
+	if c == nil {
 		return ErrPIDNotFound
 	}
-	return ctx.cc.set(int(cc))
+	return c.set(int(cc))
+}
+
+// CC returns the continuity counter of the last packet written on pid, 15
+// before the first so that it starts at 0.
+func (m *Muxer) CC(pid uint16) (uint8, error) {
+	c := m.counter(pid)
+	if c == nil {
+		return 0, ErrPIDNotFound
+	}
+	return uint8(min(c.value, c.wrapAt)), nil
+}
+
+// counter returns the continuity counter of pid.
+func (m *Muxer) counter(pid uint16) *wrappingCounter {
+	if ctx := m.esContexts.Get(pid); ctx != nil {
+		return &ctx.cc
+	}
+	switch {
+	case pid == ts.PIDPAT:
+		return &m.patCC
+	case pid == m.pmtPID:
+		return &m.pmtCC
+	case m.pcrDedicated && pid == m.pmt.PCRPID:
+		return &m.pcrCC
+	}
+	return m.siCC.Get(pid)
 }
 
 // WriteData writes Data to TS stream
 // Currently only PES packets are supported
 // Be aware that after successful call WriteData will set d.AdaptationField.StuffingLength value to zero
 // It issues several writes per unit (header and payload separately for full mid-unit
-// packets), so wrap an unbuffered destination such as a raw file or socket in bufio.
+// packets), so wrap an unbuffered destination such as a raw file or socket in bufio
+// (and Flush it, or use WithAutoFlush, on a live output).
 func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 	ctx := m.esContexts.Get(d.PID)
 	if ctx == nil {
 		return 0, ErrPIDNotFound
 	}
+	if len(m.cues) > 0 && ctx.es.StreamType.IsVideo() {
+		if bytesWritten, err = m.writeDueCues(d); err != nil {
+			return
+		}
+	}
+	var n int
+	if m.packLimit > 0 && ctx.es.StreamType.IsAudio() {
+		n, err = m.packUnit(ctx, d)
+	} else {
+		n, err = m.writeUnit(ctx, d)
+	}
+	return bytesWritten + n, err
+}
 
+// writeUnit packetizes d as one PES packet.
+func (m *Muxer) writeUnit(ctx *esContext, d *Data) (bytesWritten int, err error) {
 	forceTables := d.AdaptationField != nil &&
 		d.AdaptationField.RandomAccessIndicator &&
 		d.PID == m.pmt.PCRPID
@@ -213,9 +429,40 @@ func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 
 	bytesWritten += n
 
+	if m.timeInterval > 0 {
+		if n, err = m.writeTimeTables(); err != nil {
+			return bytesWritten + n, err
+		}
+		bytesWritten += n
+	}
+
+	if m.cbrRate > 0 {
+		if n, err = m.cbrSchedule(d); err != nil {
+			return bytesWritten + n, err
+		}
+		bytesWritten += n
+	}
+	if m.pcrSource != PCRSourceData && d.PID == m.pmt.PCRPID && !m.restampsPCR() {
+		if restore := m.stampSourcePCR(d); restore != nil {
+			defer restore()
+		}
+	}
+	if af := d.AdaptationField; af != nil && af.HasPCR && d.PID == m.pmt.PCRPID {
+		m.observePCR(af.PCR)
+	}
+
 	if d.PES.Header.StreamID == 0 {
 		d.PES.Header.StreamID = ctx.es.StreamType.ToPESStreamID()
 	}
+	if m.dataAlignment && d.PES.Header.OptionalHeader != nil {
+		d.PES.Header.OptionalHeader.DataAlignmentIndicator = true
+	}
+	if m.pesCRC {
+		if oh := d.PES.Header.OptionalHeader; oh != nil {
+			oh.HasCRC, oh.CRC = ctx.hasPrevCRC, ctx.prevCRC
+		}
+		ctx.prevCRC, ctx.hasPrevCRC = pes.ComputeCRC16(d.PES.Data), true
+	}
 
 	// Serialize the PES header once. Header and payload form one byte stream that
 	// is split across packets; a header wider than a packet spans several of them.
@@ -261,6 +508,12 @@ func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 	} else {
 		writeAf := d.AdaptationField != nil
 		for hdrWritten := 0; hdrWritten < hdrLen; {
+			if hdrWritten > 0 && m.cbrRate > 0 {
+				if n, err = m.insertPCR(); err != nil {
+					return
+				}
+				bytesWritten += n
+			}
 			header := ts.PacketHeader{ContinuityCounter: uint8(ctx.cc.inc()), PID: d.PID}
 			var af *ts.PacketAdaptationField
 			pktLen := ts.HeaderSize
@@ -308,7 +561,24 @@ func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 	fastHeader := ts.PacketHeader{PID: d.PID, HasPayload: true}
 	fastLocked := false
 	for len(d.PES.Data)-payloadWritten >= bulkChunk {
+		if m.cbrRate > 0 {
+			if n, err = m.insertPCR(); err != nil {
+				return
+			}
+			bytesWritten += n
+			// A PCR packet went through m.pkt
+			fastLocked = fastLocked && n == 0
+		}
 		cc := uint8(ctx.cc.inc())
+		if ctx.scrambler != nil {
+			fastHeader.ContinuityCounter = cc
+			if n, err = m.emitPacket(fastHeader, nil, ts.HeaderSize, nil, d.PES.Data[payloadWritten:payloadWritten+bulkChunk]); err != nil {
+				return
+			}
+			bytesWritten += n
+			payloadWritten += bulkChunk
+			continue
+		}
 		if fastLocked {
 			ts.SetContinuityCounter(m.pkt, cc)
 		} else {
@@ -328,6 +598,12 @@ func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 	}
 
 	if rem := len(d.PES.Data) - payloadWritten; rem > 0 {
+		if m.cbrRate > 0 {
+			if n, err = m.insertPCR(); err != nil {
+				return
+			}
+			bytesWritten += n
+		}
 		header := ts.PacketHeader{
 			ContinuityCounter:  uint8(ctx.cc.inc()),
 			PID:                d.PID,
@@ -351,6 +627,11 @@ func (m *Muxer) WriteData(d *Data) (bytesWritten int, err error) {
 // then hdr and payload straight from their own buffers — like the bulk path, so
 // neither is copied into m.pkt first.
 func (m *Muxer) emitPacket(header ts.PacketHeader, af *ts.PacketAdaptationField, front int, hdr, payload []byte) (n int, err error) {
+	if m.scrambling && header.HasPayload {
+		if ctx := m.esContexts.Get(header.PID); ctx != nil && ctx.scrambler != nil {
+			return m.emitScrambled(ctx.scrambler, header, af, front, hdr, payload)
+		}
+	}
 	header.Put(m.pkt)
 	if header.HasAdaptationField {
 		if _, err = af.Put(m.pkt[ts.HeaderSize:]); err != nil {
@@ -377,16 +658,41 @@ func (m *Muxer) emitPacket(header ts.PacketHeader, af *ts.PacketAdaptationField,
 	return
 }
 
-// Writes given packet to MPEG-TS stream
-// Stuffs with 0xffs if packet turns out to be shorter than target packet length
-func (m *Muxer) WritePacket(p *ts.Packet) (int, error) {
+// WritePacket writes an already-formed packet verbatim among the muxed content:
+// its raw 188 bytes when available (without an M2TS prefix or RS parity), the
+// packet serialized otherwise (stuffed with
+// 0xffs when shorter than a packet). It goes through the same output as the
+// muxed packets, so CBR pacing, M2TS timestamps and Stats count it. On a PID
+// the muxer writes, the muxer's continuity counter continues from the packet's,
+// and a PCR on the PCR PID is checked against the PCR interval.
+func (m *Muxer) WritePacket(p *ts.Packet) (n int, err error) {
+	hasPCR := p.Header.HasAdaptationField && p.AdaptationField != nil &&
+		p.AdaptationField.HasPCR && p.Header.PID == m.pmt.PCRPID
+	if m.cbrRate > 0 && !hasPCR {
+		if n, err = m.insertPCR(); err != nil {
+			return
+		}
+	}
+	if hasPCR {
+		m.observePCR(p.AdaptationField.PCR)
+	}
+
+	var w int
 	if raw := p.Raw(); len(raw) > 0 {
-		return m.w.Write(raw)
+		// a source M2TS prefix or RS parity does not carry over: the output
+		// writes its own framing
+		w, err = m.w.Write(raw[len(p.Prefix):][:ts.PacketSize])
+	} else if _, err = p.Put(m.pkt); err == nil {
+		w, err = m.w.Write(m.pkt)
 	}
-	if _, err := p.Put(m.pkt); err != nil {
-		return 0, err
+	if n += w; err != nil {
+		return
 	}
-	return m.w.Write(m.pkt)
+
+	if c := m.counter(p.Header.PID); c != nil {
+		err = c.set(int(p.Header.ContinuityCounter))
+	}
+	return
 }
 
 // stuffingAdaptationField reuses the muxer's scratch AF: no allocation per stuffed
@@ -404,6 +710,12 @@ func (m *Muxer) stuffingAdaptationField(bytesToStuff int) *ts.PacketAdaptationFi
 
 func (m *Muxer) retransmitTables(force bool) (n int, err error) {
 	m.tablesRetransmitCounter++
+	if err = m.settleTables(); err != nil {
+		return
+	}
+	// a changed PAT or PMT goes out with the next unit, unless it has just
+	// been announced as next: then the switch waits for the period
+	force = force || (m.pmUpdated || m.pmtUpdated) && !m.nextAnnounced
 	if !force && m.tablesRetransmitCounter < m.tablesRetransmitPeriod {
 		return
 	}
@@ -416,69 +728,133 @@ func (m *Muxer) retransmitTables(force bool) (n int, err error) {
 	return
 }
 
-// WriteTables writes the PAT and the PMT for the registered program.
+// WithNextTables makes a PAT or PMT change take two WriteTables calls: the
+// first repeats the current tables and writes the new version with
+// current_next_indicator cleared, the second switches to it.
+func WithNextTables() func(*Muxer) {
+	return func(m *Muxer) {
+		m.nextTables = true
+	}
+}
+
+// WriteTables writes the PAT and the PMT for the registered program, then the
+// SI tables set on the muxer. A changed table is written with a bumped version;
+// a change undone before it went out, or one leaving the table as it was, keeps
+// the version.
 func (m *Muxer) WriteTables() (bytesWritten int, err error) {
-	if err = m.generatePAT(); err != nil {
+	if err = m.settleTables(); err != nil {
 		return
 	}
+	if m.nextTables && m.tablesWritten && !m.nextAnnounced && (m.pmUpdated || m.pmtUpdated) {
+		return m.announceTables()
+	}
 
-	if err = m.generatePMT(); err != nil {
+	if err = m.generatePAT(); err != nil {
 		return
 	}
 
+	hasProgram := m.pm.Has(m.pmtPID)
+	if hasProgram {
+		if err = m.generatePMT(); err != nil {
+			return
+		}
+	} else {
+		m.pmtBytes.Reset()
+	}
+
 	var n int
 	if n, err = m.w.Write(m.patBytes.Bytes()); err != nil {
 		return
 	}
 	bytesWritten += n
 
-	if n, err = m.w.Write(m.pmtBytes.Bytes()); err != nil {
+	if hasProgram {
+		if n, err = m.w.Write(m.pmtBytes.Bytes()); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+
+	if n, err = m.writeSITables(); err != nil {
 		return
 	}
 	bytesWritten += n
 
+	m.tablesWritten = true
+	m.nextAnnounced = false
 	return
 }
 
-// maxPATProgramsPerSection is how many 4-byte program entries fit a section
-// next to the syntax header and CRC32.
-const maxPATProgramsPerSection = (1021 - 5 - 4) / 4
+// announceTables writes the current PAT and PMT, each changed one followed by
+// its pending version as next, then the SI tables.
+func (m *Muxer) announceTables() (bytesWritten int, err error) {
+	var n int
+	patchCC(m.patBytes.Bytes(), &m.patCC)
+	if n, err = m.w.Write(m.patBytes.Bytes()); err != nil {
+		return
+	}
+	bytesWritten += n
 
-func (m *Muxer) generatePAT() (err error) {
 	if m.pmUpdated {
-		d := toPATData(&m.pm)
+		if n, err = m.writeSections(ts.PIDPAT, &m.patCC, m.patSections(uint8(m.patVersion.next()), false)); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
 
-		numSections := (len(d.Programs) + maxPATProgramsPerSection - 1) / maxPATProgramsPerSection
-		if numSections == 0 {
-			numSections = 1
+	if m.pmtBytes.Len() > 0 {
+		patchCC(m.pmtBytes.Bytes(), &m.pmtCC)
+		if n, err = m.w.Write(m.pmtBytes.Bytes()); err != nil {
+			return
 		}
-		version := uint8(m.patVersion.inc())
+		bytesWritten += n
+	}
 
-		psiData := psi.Data{Sections: make([]psi.Section, 0, numSections)}
-		for si := 0; si < numSections; si++ {
-			part := &psi.PAT{TransportStreamID: d.TransportStreamID}
-			end := min((si+1)*maxPATProgramsPerSection, len(d.Programs))
-			part.Programs = d.Programs[si*maxPATProgramsPerSection : end]
+	// a removed program has no next PMT
+	if m.pmtUpdated && m.pm.Has(m.pmtPID) {
+		if err = m.checkPCRPID(); err != nil {
+			return
+		}
+		if n, err = m.writeSections(m.pmtPID, &m.pmtCC, m.pmtSections(uint8(m.pmtVersion.next()), false)); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
 
-			psiData.Sections = append(psiData.Sections, psi.Section{
-				Header: psi.SectionHeader{
-					SectionLength:          uint16(part.CalcSectionLength()),
-					SectionSyntaxIndicator: true,
-					TableID:                psi.TableID(d.TransportStreamID),
-				},
-				Syntax: &psi.SectionSyntax{
-					Data: part,
-					Header: psi.SectionSyntaxHeader{
-						CurrentNextIndicator: true,
-						SectionNumber:        uint8(si),
-						LastSectionNumber:    uint8(numSections - 1),
-						TableIDExtension:     d.TransportStreamID,
-						VersionNumber:        version,
-					},
-				},
-			})
+	if n, err = m.writeSITables(); err != nil {
+		return
+	}
+	bytesWritten += n
+
+	m.nextAnnounced = true
+	return
+}
+
+// settleTables clears the update flag of a PAT or PMT whose sections are
+// those last written, so only a change of content bumps the version.
+func (m *Muxer) settleTables() (err error) {
+	if m.pmUpdated && len(m.patData) > 0 {
+		if m.sectionData, err = m.patSections(uint8(m.patVersion.value), true).Append(m.sectionData[:0]); err != nil {
+			return
+		}
+		m.pmUpdated = !bytes.Equal(m.sectionData, m.patData)
+	}
+	if m.pmtUpdated && len(m.pmtData) > 0 && m.pm.Has(m.pmtPID) {
+		if m.sectionData, err = m.pmtSections(uint8(m.pmtVersion.value), true).Append(m.sectionData[:0]); err != nil {
+			return
 		}
+		m.pmtUpdated = !bytes.Equal(m.sectionData, m.pmtData)
+	}
+	return
+}
+
+// maxPATProgramsPerSection is how many 4-byte program entries fit a section
+// next to the syntax header and CRC32.
+const maxPATProgramsPerSection = (1021 - 5 - 4) / 4
 
+func (m *Muxer) generatePAT() (err error) {
+	if m.pmUpdated {
+		psiData := m.patSections(uint8(m.patVersion.inc()), true)
 		if m.patData, err = psiData.Append(m.patData[:0]); err != nil {
 			return
 		}
@@ -505,50 +881,61 @@ func (m *Muxer) generatePAT() (err error) {
 		}
 	}
 
-	// Only the continuity counter changes between emissions: patch it in place
-	// instead of repacketizing (mirrors the PES fast path).
-	b := m.patBytes.Bytes()
-	for off := 0; off < len(b); off += ts.PacketSize {
-		ts.SetContinuityCounter(b[off:], uint8(m.patCC.inc()))
-	}
-
+	patchCC(m.patBytes.Bytes(), &m.patCC)
 	return
 }
 
-func (m *Muxer) generatePMT() (err error) {
-	if m.pmtUpdated {
-		hasPCRPID := false
-		for _, es := range m.pmt.ElementaryStreams {
-			if es.ElementaryPID == m.pmt.PCRPID {
-				hasPCRPID = true
-				break
-			}
-		}
-		if !hasPCRPID {
-			return ErrPCRPIDInvalid
-		}
+// patSections splits the PAT into sections of the given version.
+func (m *Muxer) patSections(version uint8, current bool) *psi.Data {
+	d := toPATData(&m.pm)
 
-		psiData := psi.Data{
-			Sections: []psi.Section{
-				{
-					Header: psi.SectionHeader{
-						SectionLength:          uint16(m.pmt.CalcSectionLength()),
-						SectionSyntaxIndicator: true,
-						TableID:                psi.TableIDPMT,
-					},
-					Syntax: &psi.SectionSyntax{
-						Data: &m.pmt,
-						Header: psi.SectionSyntaxHeader{
-							CurrentNextIndicator: true,
-							//LastSectionNumber:    0,
-							//SectionNumber:        0,
-							TableIDExtension: m.pmt.ProgramNumber,
-							VersionNumber:    uint8(m.pmtVersion.inc()),
-						},
-					},
+	numSections := (len(d.Programs) + maxPATProgramsPerSection - 1) / maxPATProgramsPerSection
+	if numSections == 0 {
+		numSections = 1
+	}
+
+	psiData := &psi.Data{Sections: make([]psi.Section, 0, numSections)}
+	for si := 0; si < numSections; si++ {
+		part := &psi.PAT{TransportStreamID: d.TransportStreamID}
+		end := min((si+1)*maxPATProgramsPerSection, len(d.Programs))
+		part.Programs = d.Programs[si*maxPATProgramsPerSection : end]
+
+		psiData.Sections = append(psiData.Sections, psi.Section{
+			Header: psi.SectionHeader{
+				SectionLength:          uint16(part.CalcSectionLength()),
+				SectionSyntaxIndicator: true,
+				TableID:                psi.TableID(d.TransportStreamID),
+			},
+			Syntax: &psi.SectionSyntax{
+				Data: part,
+				Header: psi.SectionSyntaxHeader{
+					CurrentNextIndicator: current,
+					SectionNumber:        uint8(si),
+					LastSectionNumber:    uint8(numSections - 1),
+					TableIDExtension:     d.TransportStreamID,
+					VersionNumber:        version,
 				},
 			},
+		})
+	}
+	return psiData
+}
+
+// patchCC advances cc over the cached table packets in b. Only the continuity
+// counter changes between emissions: it is patched in place instead of
+// repacketizing (mirrors the PES fast path).
+func patchCC(b []byte, cc *wrappingCounter) {
+	for off := 0; off < len(b); off += ts.PacketSize {
+		ts.SetContinuityCounter(b[off:], uint8(cc.inc()))
+	}
+}
+
+func (m *Muxer) generatePMT() (err error) {
+	if m.pmtUpdated {
+		if err = m.checkPCRPID(); err != nil {
+			return
 		}
+		psiData := m.pmtSections(uint8(m.pmtVersion.inc()), true)
 
 		if m.pmtData, err = psiData.Append(m.pmtData[:0]); err != nil {
 			return
@@ -565,7 +952,7 @@ func (m *Muxer) generatePMT() (err error) {
 				Header: ts.PacketHeader{
 					HasPayload:                true,
 					PayloadUnitStartIndicator: i == 0,
-					PID:                       pmtStartPID, // FIXME multiple programs support
+					PID:                       m.pmtPID,
 				},
 				Payload: m.pmtData[start:stop],
 			}
@@ -576,14 +963,47 @@ func (m *Muxer) generatePMT() (err error) {
 		}
 	}
 
-	// Only the continuity counter changes between emissions: patch it in place
-	// instead of repacketizing (mirrors the PES fast path).
-	b := m.pmtBytes.Bytes()
-	for off := 0; off < len(b); off += ts.PacketSize {
-		ts.SetContinuityCounter(b[off:], uint8(m.pmtCC.inc()))
+	patchCC(m.pmtBytes.Bytes(), &m.pmtCC)
+	return
+}
+
+// checkPCRPID requires the PCR PID to be one of the program's streams, a
+// dedicated one or ts.PIDNull for a program without a PCR.
+func (m *Muxer) checkPCRPID() error {
+	if m.pmt.PCRPID == ts.PIDNull || m.pcrDedicated {
+		return nil
+	}
+	for _, es := range m.pmt.ElementaryStreams {
+		if es.ElementaryPID == m.pmt.PCRPID {
+			return nil
+		}
 	}
+	return ErrPCRPIDInvalid
+}
 
-	return
+// pmtSections wraps the PMT into its single section of the given version.
+func (m *Muxer) pmtSections(version uint8, current bool) *psi.Data {
+	return &psi.Data{
+		Sections: []psi.Section{
+			{
+				Header: psi.SectionHeader{
+					SectionLength:          uint16(m.pmt.CalcSectionLength()),
+					SectionSyntaxIndicator: true,
+					TableID:                psi.TableIDPMT,
+				},
+				Syntax: &psi.SectionSyntax{
+					Data: &m.pmt,
+					Header: psi.SectionSyntaxHeader{
+						CurrentNextIndicator: current,
+						//LastSectionNumber:    0,
+						//SectionNumber:        0,
+						TableIDExtension: m.pmt.ProgramNumber,
+						VersionNumber:    version,
+					},
+				},
+			},
+		},
+	}
 }
 
 func toPATData(pm *pidmap.Map[uint16]) *psi.PAT {
@@ -244,6 +244,15 @@ func TestMuxer_AddElementaryStream(t *testing.T) {
 		StreamType:    psi.StreamTypeH264Video,
 	})
 	assert.Equal(t, ErrPIDAlreadyExists, err)
+
+	err = muxer.AddElementaryStream(psi.ElementaryStream{
+		ElementaryPID: 0x1235,
+		StreamType:    psi.StreamTypeH264Video,
+		ElementaryStreamDescriptors: []descriptor.Descriptor{
+			&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}, Data: make([]byte, descriptor.MaxLength+1)},
+		},
+	})
+	assert.ErrorIs(t, err, descriptor.ErrLengthOverflow)
 }
 
 func TestMuxer_RemoveElementaryStream(t *testing.T) {
@@ -521,3 +530,428 @@ func TestWriteTablesSectionOverflow(t *testing.T) {
 	_, err := m.WriteTables()
 	assert.ErrorIs(t, err, psi.ErrSectionOverflow)
 }
+
+func TestMuxer_WriteSection(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	const pid = 0x1f0
+	_, err := m.WriteSection(pid, psi.NewSpliceInsert(1, 900000, 0, true).Data())
+	assert.Equal(t, ErrPIDNotFound, err)
+
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: pid, StreamType: psi.StreamTypeSCTE35}))
+	cue := psi.NewTimeSignal(900000, psi.NewSegmentationDescriptor(&psi.SegmentationDescriptor{
+		EventID:             1,
+		ProgramSegmentation: true,
+		HasDuration:         true,
+		Duration:            30 * 90000,
+		TypeID:              0x34,
+	}))
+	for cc := range 2 {
+		buf.Reset()
+		n, err := m.WriteSection(pid, cue.Data())
+		require.NoError(t, err)
+		require.Equal(t, ts.PacketSize, n)
+
+		var h ts.PacketHeader
+		_, err = h.Parse(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, ts.PacketHeader{
+			ContinuityCounter:         uint8(cc),
+			HasPayload:                true,
+			PayloadUnitStartIndicator: true,
+			PID:                       pid,
+		}, h)
+
+		d, err := psi.Parse(buf.Bytes()[ts.HeaderSize:])
+		require.NoError(t, err)
+		require.Len(t, d.Sections, 1)
+		assert.Equal(t, cue, d.Sections[0].Syntax.Data)
+	}
+}
+
+// tableSection is a PAT or PMT section read back from muxer output.
+type tableSection struct {
+	pid     uint16
+	version uint8
+	current bool
+	data    psi.SectionSyntaxData
+}
+
+// tableSections parses the single-packet PAT and PMT sections of bs.
+func tableSections(t *testing.T, bs []byte) (ss []tableSection) {
+	for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+		pkt := bs[off : off+ts.PacketSize]
+		pid := uint16(pkt[1]&0x1f)<<8 | uint16(pkt[2])
+		if pid != ts.PIDPAT && pid != pmtStartPID {
+			continue
+		}
+		d, err := psi.Parse(pkt[ts.HeaderSize:])
+		require.NoError(t, err)
+		for _, s := range d.Sections {
+			ss = append(ss, tableSection{
+				pid:     pid,
+				version: s.Syntax.Header.VersionNumber,
+				current: s.Syntax.Header.CurrentNextIndicator,
+				data:    s.Syntax.Data,
+			})
+		}
+	}
+	return
+}
+
+func TestMuxer_RemoveMidStream(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+
+	unit := func(pid uint16) {
+		_, err := m.WriteData(&Data{PID: pid, PES: &pes.Data{Data: []byte{1, 2, 3}}})
+		require.NoError(t, err)
+	}
+	unit(0x100)
+	unit(0x101)
+
+	// Removing the PCR stream leaves the program without a PCR, and the next
+	// unit carries the new PMT version.
+	require.NoError(t, m.RemoveElementaryStream(0x100))
+	buf.Reset()
+	unit(0x101)
+	ss := tableSections(t, buf.Bytes())
+	require.Len(t, ss, 2)
+	assert.Equal(t, uint8(0), ss[0].version)
+	assert.Equal(t, uint8(1), ss[1].version)
+	pmt := ss[1].data.(*psi.PMT)
+	assert.Equal(t, ts.PIDNull, pmt.PCRPID)
+	require.Len(t, pmt.ElementaryStreams, 1)
+	assert.Equal(t, uint16(0x101), pmt.ElementaryStreams[0].ElementaryPID)
+
+	require.NoError(t, m.RemoveProgram())
+	assert.Equal(t, ErrProgramNotFound, m.RemoveProgram())
+	_, err := m.WriteData(&Data{PID: 0x101})
+	assert.Equal(t, ErrPIDNotFound, err)
+	buf.Reset()
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	ss = tableSections(t, buf.Bytes())
+	require.Len(t, ss, 1)
+	assert.Equal(t, uint8(1), ss[0].version)
+	assert.Empty(t, ss[0].data.(*psi.PAT).Programs)
+
+	// A new stream brings the program back with the next PMT version.
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x200, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x200)
+	buf.Reset()
+	unit(0x200)
+	ss = tableSections(t, buf.Bytes())
+	require.Len(t, ss, 2)
+	assert.Equal(t, uint8(2), ss[0].version)
+	assert.Len(t, ss[0].data.(*psi.PAT).Programs, 1)
+	assert.Equal(t, uint8(2), ss[1].version)
+	assert.Equal(t, uint16(0x200), ss[1].data.(*psi.PMT).PCRPID)
+}
+
+func TestMuxer_WithNextTables(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithNextTables())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+
+	require.NoError(t, m.RemoveElementaryStream(0x101))
+	buf.Reset()
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	ss := tableSections(t, buf.Bytes())
+	require.Len(t, ss, 3)
+	assert.Equal(t, tableSection{pid: ts.PIDPAT, version: 0, current: true, data: ss[0].data}, ss[0])
+	assert.Equal(t, uint8(0), ss[1].version)
+	assert.True(t, ss[1].current)
+	assert.Len(t, ss[1].data.(*psi.PMT).ElementaryStreams, 2)
+	assert.Equal(t, uint8(1), ss[2].version)
+	assert.False(t, ss[2].current)
+	assert.Len(t, ss[2].data.(*psi.PMT).ElementaryStreams, 1)
+
+	// The announced version becomes current on the next emission.
+	buf.Reset()
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	ss = tableSections(t, buf.Bytes())
+	require.Len(t, ss, 2)
+	assert.Equal(t, uint8(1), ss[1].version)
+	assert.True(t, ss[1].current)
+	assert.Len(t, ss[1].data.(*psi.PMT).ElementaryStreams, 1)
+}
+
+func TestMuxer_TableVersionOnChange(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	video := psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}
+	require.NoError(t, m.AddElementaryStream(video))
+	m.SetPCRPID(0x100)
+
+	versions := func() (pat, pmt uint8) {
+		buf.Reset()
+		_, err := m.WriteTables()
+		require.NoError(t, err)
+		ss := tableSections(t, buf.Bytes())
+		require.Len(t, ss, 2)
+		return ss[0].version, ss[1].version
+	}
+	pat, pmt := versions()
+	assert.Equal(t, [2]uint8{0, 0}, [2]uint8{pat, pmt})
+
+	// no change of content: the versions stay
+	m.SetPCRPID(0x100)
+	require.NoError(t, m.RemoveElementaryStream(0x100))
+	require.NoError(t, m.AddElementaryStream(video))
+	m.SetPCRPID(0x100)
+	pat, pmt = versions()
+	assert.Equal(t, [2]uint8{0, 0}, [2]uint8{pat, pmt})
+
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	pat, pmt = versions()
+	assert.Equal(t, [2]uint8{0, 1}, [2]uint8{pat, pmt})
+
+	// the PMT version wraps at 31
+	for i := range 31 {
+		m.SetPCRPID(0x101 - uint16(i%2))
+		pat, pmt = versions()
+	}
+	assert.Equal(t, [2]uint8{0, 0}, [2]uint8{pat, pmt})
+}
+
+func TestMuxer_SetElementaryStreamDescriptors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+
+	pmt := func() (uint8, *psi.PMT) {
+		buf.Reset()
+		_, err := m.WriteTables()
+		require.NoError(t, err)
+		ss := tableSections(t, buf.Bytes())
+		require.Len(t, ss, 2)
+		return ss[1].version, ss[1].data.(*psi.PMT)
+	}
+	lang := func(code string) []descriptor.Descriptor {
+		it := descriptor.ISO639Item{Type: descriptor.AudioTypeCleanEffects}
+		copy(it.Language[:], code)
+		return []descriptor.Descriptor{&descriptor.ISO639LanguageAndAudioType{
+			Header: descriptor.Header{Tag: descriptor.TagISO639LanguageAndAudioType},
+			Items:  []descriptor.ISO639Item{it},
+		}}
+	}
+
+	require.NoError(t, m.SetElementaryStreamDescriptors(0x101, lang("eng")))
+	version, p := pmt()
+	assert.Equal(t, uint8(0), version)
+	require.Len(t, p.ElementaryStreams, 2)
+	assert.Empty(t, p.ElementaryStreams[0].ElementaryStreamDescriptors)
+	require.Len(t, p.ElementaryStreams[1].ElementaryStreamDescriptors, 1)
+	d := p.ElementaryStreams[1].ElementaryStreamDescriptors[0].(*descriptor.ISO639LanguageAndAudioType)
+	assert.Equal(t, [3]byte{'e', 'n', 'g'}, d.Items[0].Language)
+
+	// the same loop again keeps the version, another one moves it
+	require.NoError(t, m.SetElementaryStreamDescriptors(0x101, lang("eng")))
+	version, _ = pmt()
+	assert.Equal(t, uint8(0), version)
+	require.NoError(t, m.SetElementaryStreamDescriptors(0x101, lang("fra")))
+	version, p = pmt()
+	assert.Equal(t, uint8(1), version)
+	d = p.ElementaryStreams[1].ElementaryStreamDescriptors[0].(*descriptor.ISO639LanguageAndAudioType)
+	assert.Equal(t, [3]byte{'f', 'r', 'a'}, d.Items[0].Language)
+
+	assert.Equal(t, ErrPIDNotFound, m.SetElementaryStreamDescriptors(0x102, lang("eng")))
+	err := m.SetElementaryStreamDescriptors(0x101, []descriptor.Descriptor{
+		&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}, Data: make([]byte, descriptor.MaxLength+1)},
+	})
+	assert.ErrorIs(t, err, descriptor.ErrLengthOverflow)
+}
+
+func TestMuxer_SetProgramDescriptors(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	pmt := func() (uint8, *psi.PMT) {
+		buf.Reset()
+		_, err := m.WriteTables()
+		require.NoError(t, err)
+		ss := tableSections(t, buf.Bytes())
+		require.Len(t, ss, 2)
+		return ss[1].version, ss[1].data.(*psi.PMT)
+	}
+	registration := func(id uint32) []descriptor.Descriptor {
+		return []descriptor.Descriptor{&descriptor.Registration{
+			Header:           descriptor.Header{Tag: descriptor.TagRegistration},
+			FormatIdentifier: id,
+		}}
+	}
+
+	require.NoError(t, m.SetProgramDescriptors(registration(0x43554549))) // "CUEI"
+	version, p := pmt()
+	assert.Equal(t, uint8(0), version)
+	require.Len(t, p.ProgramDescriptors, 1)
+	assert.Equal(t, uint32(0x43554549), p.ProgramDescriptors[0].(*descriptor.Registration).FormatIdentifier)
+	require.Len(t, p.ElementaryStreams, 1)
+	assert.Equal(t, uint16(0x100), p.ElementaryStreams[0].ElementaryPID)
+
+	// the same loop again keeps the version, another one moves it
+	require.NoError(t, m.SetProgramDescriptors(registration(0x43554549)))
+	version, _ = pmt()
+	assert.Equal(t, uint8(0), version)
+	require.NoError(t, m.SetProgramDescriptors(nil))
+	version, p = pmt()
+	assert.Equal(t, uint8(1), version)
+	assert.Empty(t, p.ProgramDescriptors)
+
+	err := m.SetProgramDescriptors([]descriptor.Descriptor{
+		&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}, Data: make([]byte, descriptor.MaxLength+1)},
+	})
+	assert.ErrorIs(t, err, descriptor.ErrLengthOverflow)
+
+	// the loop counts in the section length
+	big := make([]descriptor.Descriptor, 5)
+	for i := range big {
+		big[i] = &descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}, Data: bytes.Repeat([]byte{0xab}, 220)}
+	}
+	require.NoError(t, m.SetProgramDescriptors(big))
+	_, err = m.WriteTables()
+	assert.ErrorIs(t, err, psi.ErrSectionOverflow)
+}
+
+func TestMuxer_CC(t *testing.T) {
+	newMuxer := func(buf *bytes.Buffer) *Muxer {
+		m := New(context.Background(), buf)
+		require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+		m.SetPCRPID(0x100)
+		return m
+	}
+	first := newMuxer(&bytes.Buffer{})
+	for _, pid := range []uint16{ts.PIDPAT, pmtStartPID, 0x100} {
+		cc, err := first.CC(pid)
+		require.NoError(t, err)
+		assert.Equal(t, uint8(15), cc)
+	}
+	_, err := first.CC(0x101)
+	assert.Equal(t, ErrPIDNotFound, err)
+	assert.Equal(t, ErrPIDNotFound, first.SetCC(0x101, 0))
+
+	for range 3 {
+		_, err = first.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: []byte{1}}})
+		require.NoError(t, err)
+	}
+
+	// a second file continues the counters of the first
+	buf := &bytes.Buffer{}
+	second := newMuxer(buf)
+	for _, pid := range []uint16{ts.PIDPAT, pmtStartPID, 0x100} {
+		cc, err := first.CC(pid)
+		require.NoError(t, err)
+		require.NoError(t, second.SetCC(pid, cc))
+	}
+	_, err = second.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: []byte{1}}})
+	require.NoError(t, err)
+
+	bs := buf.Bytes()
+	require.Len(t, bs, 3*ts.PacketSize)
+	for i, cc := range []uint8{1, 1, 3} {
+		var h ts.PacketHeader
+		_, err = h.Parse(bs[i*ts.PacketSize:])
+		require.NoError(t, err)
+		assert.Equal(t, cc, h.ContinuityCounter, "packet %d", i)
+	}
+}
+
+func TestMuxer_AdaptationFieldPrivateData(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	buf.Reset()
+
+	ebp := (&ts.EBP{Segment: true, HasSAP: true, SAPType: 1}).Item()
+	af := &ts.PacketAdaptationField{RandomAccessIndicator: true}
+	af.SetTransportPrivateData(ts.AppendPrivateDataItems(nil, ebp))
+	_, err = m.WriteData(&Data{PID: 0x100, AdaptationField: af, PES: &pes.Data{Data: testPayload()}})
+	require.NoError(t, err)
+
+	// the random access point brings PAT and PMT first
+	bs := buf.Bytes()[2*ts.PacketSize:]
+	var h ts.PacketHeader
+	_, err = h.Parse(bs)
+	require.NoError(t, err)
+	require.Equal(t, uint16(0x100), h.PID)
+	require.True(t, h.HasAdaptationField)
+	var got ts.PacketAdaptationField
+	_, err = got.Parse(bs[ts.HeaderSize:])
+	require.NoError(t, err)
+	assert.True(t, got.RandomAccessIndicator)
+	items, err := ts.ParsePrivateDataItems(got.TransportPrivateData)
+	require.NoError(t, err)
+	require.Equal(t, []ts.PrivateDataItem{ebp}, items)
+	e, err := ts.ParseEBP(items[0].Data)
+	require.NoError(t, err)
+	assert.True(t, e.Segment)
+	assert.Equal(t, uint8(1), e.SAPType)
+}
+
+func TestMuxer_WritePacket(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithStats())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	buf.Reset()
+
+	// a pre-built packet on a muxed PID, the muxed ones continue its counter
+	p := &ts.Packet{
+		Header: ts.PacketHeader{PID: 0x100, HasPayload: true, HasAdaptationField: true, ContinuityCounter: 7},
+		AdaptationField: &ts.PacketAdaptationField{
+			HasPCR: true, PCR: ts.NewClockReference(90000, 0),
+			HasTransportPrivateData: true, TransportPrivateData: []byte{1, 2},
+		},
+		Payload: []byte{0xaa, 0xbb},
+	}
+	n, err := m.WritePacket(p)
+	require.NoError(t, err)
+	assert.Equal(t, ts.PacketSize, n)
+	// and one on a PID of its own
+	_, err = m.WritePacket(&ts.Packet{Header: ts.PacketHeader{PID: 0x300, HasPayload: true}, Payload: []byte{1}})
+	require.NoError(t, err)
+	_, err = m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: []byte{1}}})
+	require.NoError(t, err)
+
+	// the unit follows the tables retransmitted with it
+	bs := buf.Bytes()
+	require.Len(t, bs, 5*ts.PacketSize)
+	var got ts.Packet
+	got.AdaptationField = &ts.PacketAdaptationField{}
+	_, err = got.Header.Parse(bs)
+	require.NoError(t, err)
+	assert.Equal(t, p.Header, got.Header)
+	an, err := got.AdaptationField.Parse(bs[ts.HeaderSize:])
+	require.NoError(t, err)
+	assert.Equal(t, p.AdaptationField.PCR, got.AdaptationField.PCR)
+	assert.Equal(t, p.AdaptationField.TransportPrivateData, got.AdaptationField.TransportPrivateData)
+	assert.Equal(t, []byte{0xaa, 0xbb, 0xff}, bs[ts.HeaderSize+an:ts.HeaderSize+an+3])
+
+	var h ts.PacketHeader
+	_, err = h.Parse(bs[4*ts.PacketSize:])
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x100), h.PID)
+	assert.Equal(t, uint8(8), h.ContinuityCounter)
+
+	assert.Equal(t, PIDStats{Bytes: ts.PacketSize, Packets: 1}, m.Stats().PIDs[0x300])
+	assert.Equal(t, uint64(1), m.Stats().PCRs)
+}
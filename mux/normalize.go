@@ -0,0 +1,198 @@
+package mux
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// Normalize rewrites the TS read from r into a canonical form on w, so two
+// functionally identical muxes produced by different tools compare byte for
+// byte:
+//
+//   - every packet is re-serialized as a plain 188-byte packet (an M2TS prefix
+//     or Reed-Solomon parity is dropped), with reserved bits set and stuffing
+//     bytes set to 0xff;
+//   - continuity counters are rebased so each PID starts at 0;
+//   - PSI sections on the PAT, CAT, TSDT, DVB-SI and PMT PIDs are re-serialized
+//     with their descriptor loops sorted ([descriptor.Sort]) and the CRC32
+//     recomputed.
+//
+// Packet order and timing fields are kept. A section that cannot be rewritten
+// exactly — one starting mid-packet, failing its CRC, of an unknown table, or
+// left incomplete past a section's worth of bytes or normalizeQueueMax packets
+// — keeps its original payload bytes.
+func Normalize(r io.Reader, w io.Writer) (err error) {
+	var pb *ts.PacketBuffer
+	if pb, err = ts.NewPacketBuffer(r, ts.PacketBufferConfig{}); err != nil {
+		return fmt.Errorf("astits: creating packet buffer failed: %w", err)
+	}
+	n := normalizer{
+		w:       w,
+		psiPIDs: ts.NewPIDSet(ts.PIDPAT, ts.PIDCAT, ts.PIDTSDT, 0x10, 0x11, 0x12, 0x13, 0x14),
+		ccBase:  pidmap.New[uint8](8),
+		units:   pidmap.New[normalizeUnit](4),
+	}
+	p := ts.NewPacket()
+	defer p.Close()
+	for {
+		if err = pb.Next(p); err != nil {
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				break
+			}
+			return fmt.Errorf("astits: fetching next packet failed: %w", err)
+		}
+		if err = n.add(p); err != nil {
+			return err
+		}
+	}
+	// Sections still incomplete at EOF go out as they are.
+	return n.flush()
+}
+
+const (
+	// normalizeUnitMax is the most payload an open PSI unit collects before it
+	// is abandoned: a full 4096-byte section and the pointer field.
+	normalizeUnitMax = 1 + 3 + psi.MaxPrivateSectionLength
+	// normalizeQueueMax is the most packets queued behind open PSI units, e.g.
+	// of a PSI PID gone silent mid-section, before they are abandoned.
+	normalizeQueueMax = 4096
+)
+
+type normalizer struct {
+	w       io.Writer
+	psiPIDs ts.PIDSet
+	ccBase  pidmap.Map[uint8]
+	units   pidmap.Map[normalizeUnit]
+	// queue holds canonical packets in stream order. It is flushed whenever no
+	// PSI unit is open, since an open unit's packets are patched in place.
+	queue   [][ts.PacketSize]byte
+	scratch []byte
+}
+
+// normalizeUnit is a PSI unit being collected: the queue positions of its
+// packets, where the payload starts in each, and the concatenated payload.
+type normalizeUnit struct {
+	idx     []int
+	offs    []int
+	payload []byte
+}
+
+func (n *normalizer) add(p *ts.Packet) (err error) {
+	base := n.ccBase.Get(p.Header.PID)
+	if base == nil {
+		n.ccBase.Set(p.Header.PID, p.Header.ContinuityCounter)
+		base = n.ccBase.Get(p.Header.PID)
+	}
+	p.Header.ContinuityCounter = (p.Header.ContinuityCounter - *base) & 0xf
+	if p.AdaptationField != nil && p.AdaptationField.Length == 0 {
+		p.AdaptationField.IsOneByteStuffing = true
+	}
+
+	n.queue = append(n.queue, [ts.PacketSize]byte{})
+	bs := n.queue[len(n.queue)-1][:]
+	if _, err = p.Put(bs); err != nil {
+		return fmt.Errorf("astits: writing packet at offset %d failed: %w", p.Offset, err)
+	}
+
+	if n.psiPIDs.Has(p.Header.PID) && p.Header.HasPayload {
+		n.addPSI(p.Header, len(n.queue)-1, ts.PacketSize-len(p.Payload), p.Payload)
+	}
+	if len(n.queue) >= normalizeQueueMax {
+		n.units = pidmap.New[normalizeUnit](4)
+	}
+	if len(n.units.Keys) == 0 {
+		return n.flush()
+	}
+	return nil
+}
+
+func (n *normalizer) addPSI(h ts.PacketHeader, idx, off int, payload []byte) {
+	u := n.units.Get(h.PID)
+	if h.PayloadUnitStartIndicator {
+		// A new unit closes the previous one: incomplete, it keeps its bytes.
+		n.units.Remove(h.PID)
+		// Only units starting right after the pointer field are rewritten.
+		if len(payload) == 0 || payload[0] != 0 {
+			return
+		}
+		u = n.units.GetOrAdd(h.PID)
+	} else if u == nil {
+		return
+	}
+	u.idx = append(u.idx, idx)
+	u.offs = append(u.offs, off)
+	u.payload = append(u.payload, payload...)
+
+	if end, ok := sectionsEnd(u.payload); ok {
+		n.rewrite(u, end)
+		n.units.Remove(h.PID)
+	} else if len(u.payload) > normalizeUnitMax {
+		n.units.Remove(h.PID)
+	}
+}
+
+// sectionsEnd walks the sections of a unit (pointer field first) and reports
+// where they end, once the unit holds all of them.
+func sectionsEnd(payload []byte) (end int, ok bool) {
+	o := 1 + int(payload[0])
+	for o < len(payload) && payload[o] != 0xff {
+		if o+3 > len(payload) {
+			return 0, false
+		}
+		o += 3 + int(binary.BigEndian.Uint16(payload[o+1:])&0xfff)
+	}
+	return o, o <= len(payload)
+}
+
+func (n *normalizer) rewrite(u *normalizeUnit, end int) {
+	d, err := psi.Parse(u.payload)
+	if err != nil {
+		return
+	}
+	for _, s := range d.Sections {
+		if s.Syntax == nil {
+			continue
+		}
+		sortTableDescriptors(s.Syntax.Data)
+		if pat, ok := s.Syntax.Data.(*psi.PAT); ok {
+			for _, p := range pat.Programs {
+				n.psiPIDs.Add(p.ProgramMapID)
+			}
+		}
+	}
+	if n.scratch, err = d.Append(n.scratch[:0]); err != nil || len(n.scratch) != end {
+		return
+	}
+
+	copy(u.payload, n.scratch)
+	for i := end; i < len(u.payload); i++ {
+		u.payload[i] = 0xff
+	}
+	o := 0
+	for k, idx := range u.idx {
+		o += copy(n.queue[idx][u.offs[k]:], u.payload[o:])
+	}
+}
+
+func (n *normalizer) flush() error {
+	for i := range n.queue {
+		if _, err := n.w.Write(n.queue[i][:]); err != nil {
+			return fmt.Errorf("astits: writing packet failed: %w", err)
+		}
+	}
+	n.queue = n.queue[:0]
+	return nil
+}
+
+func sortTableDescriptors(data psi.SectionSyntaxData) {
+	for _, ds := range psi.DescriptorLoops(data) {
+		descriptor.Sort(ds)
+	}
+}
@@ -0,0 +1,130 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// normalizeFixture muxes the same content with the given descriptor order and
+// starting CC, as two different tools would.
+func normalizeFixture(t *testing.T, ds []descriptor.Descriptor, cc uint8) []byte {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{
+		ElementaryPID:               0x100,
+		StreamType:                  psi.StreamTypeH264Video,
+		ElementaryStreamDescriptors: ds,
+	}))
+	m.SetPCRPID(0x100)
+	require.NoError(t, m.SetCC(0x100, cc))
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	_, err = m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Header: pes.Header{StreamID: 0xe0}, Data: testPayload()}})
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestNormalize(t *testing.T) {
+	streamID := &descriptor.StreamIdentifier{Header: descriptor.Header{Tag: descriptor.TagStreamIdentifier, Length: 1}, ComponentTag: 7}
+	alignment := &descriptor.DataStreamAlignment{Header: descriptor.Header{Tag: descriptor.TagDataStreamAlignment, Length: 1}, Type: 1}
+
+	a := normalizeFixture(t, []descriptor.Descriptor{streamID, alignment}, 3)
+	b := normalizeFixture(t, []descriptor.Descriptor{alignment, streamID}, 9)
+	require.NotEqual(t, a, b)
+
+	var na, nb bytes.Buffer
+	require.NoError(t, Normalize(bytes.NewReader(a), &na))
+	require.NoError(t, Normalize(bytes.NewReader(b), &nb))
+	assert.Equal(t, na.Bytes(), nb.Bytes())
+	assert.Len(t, na.Bytes(), len(a))
+
+	dmx := demux.New(context.Background(), bytes.NewReader(na.Bytes()), demux.WithPacketSize(ts.PacketSize))
+	defer dmx.Close()
+	var sawPMT, sawPES bool
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventPMT:
+			sawPMT = true
+			assert.Equal(t, []descriptor.Descriptor{alignment, streamID}, dmx.PMT().ElementaryStreams[0].ElementaryStreamDescriptors)
+		case demux.EventPES:
+			sawPES = true
+			assert.Equal(t, uint8(0), dmx.PES().ContinuityCounter)
+		}
+	}
+	assert.True(t, sawPMT)
+	assert.True(t, sawPES)
+}
+
+func TestNormalizeOpenUnitBound(t *testing.T) {
+	n := normalizer{
+		w:       &bytes.Buffer{},
+		psiPIDs: ts.NewPIDSet(ts.PIDPAT),
+		ccBase:  pidmap.New[uint8](8),
+		units:   pidmap.New[normalizeUnit](4),
+	}
+	add := func(pid uint16, start bool, cc uint8) {
+		p := ts.NewPacket()
+		defer p.Close()
+		p.Header = ts.PacketHeader{PID: pid, HasPayload: true, PayloadUnitStartIndicator: start, ContinuityCounter: cc & 0xf}
+		p.Payload = bytes.Repeat([]byte{0x01}, ts.PacketSize-4)
+		if start {
+			p.Payload[0] = 0 // pointer_field
+		}
+		require.NoError(t, n.add(p))
+	}
+
+	// 0x01 bytes chain 260-byte sections, never ending on a packet boundary:
+	// the unit is abandoned past a section's worth of bytes
+	add(ts.PIDPAT, true, 0)
+	for cc := range uint8(30) {
+		add(ts.PIDPAT, false, cc+1)
+		if cc == 10 {
+			assert.NotEmpty(t, n.units.Keys)
+		}
+	}
+	assert.Empty(t, n.units.Keys)
+	assert.Empty(t, n.queue)
+
+	// A PSI PID going silent mid-section holds the queue only so long
+	add(ts.PIDPAT, true, 0)
+	for cc := range normalizeQueueMax {
+		add(0x100, false, uint8(cc))
+		assert.Less(t, len(n.queue), normalizeQueueMax)
+	}
+	assert.Empty(t, n.units.Keys)
+}
+
+func TestNormalizeTOT(t *testing.T) {
+	lto := &descriptor.LocalTimeOffset{Header: descriptor.Header{Tag: descriptor.TagLocalTimeOffset}}
+	private := &descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}}
+	packet := func(ds ...descriptor.Descriptor) []byte {
+		d := &psi.Data{Sections: []psi.Section{{
+			Header: psi.SectionHeader{TableID: psi.TableIDTOT, PrivateBit: true},
+			Syntax: &psi.SectionSyntax{Data: &psi.TOT{Descriptors: ds}},
+		}}}
+		bs, err := d.Append(nil)
+		require.NoError(t, err)
+		b := bytes.Repeat([]byte{0xff}, ts.PacketSize)
+		copy(b, []byte{0x47, 0x40, 0x14, 0x10})
+		copy(b[4:], bs)
+		return bytes.Repeat(b, 2) // packet size detection needs a second one
+	}
+
+	var na, nb bytes.Buffer
+	require.NoError(t, Normalize(bytes.NewReader(packet(private, lto)), &na))
+	require.NoError(t, Normalize(bytes.NewReader(packet(lto, private)), &nb))
+	assert.Equal(t, packet(lto, private), na.Bytes())
+	assert.Equal(t, na.Bytes(), nb.Bytes())
+}
@@ -0,0 +1,124 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// paceResync is how late the output may fall, or how far a PCR may jump, before
+// the pacing restarts from the current time instead of bursting or stalling.
+const paceResync = time.Second
+
+// WithRealTime paces the output in real time, sleeping between writes so a UDP
+// sender gets the packets at their time instead of in bursts. Under WithCBR
+// the bytes go out at the mux rate; otherwise each packet carrying a PCR of
+// the PCR PID goes out at the time of its PCR, the bytes up to the next one at
+// the rate between the last two. A write less than jitter ahead of its time
+// goes out without waiting, so the sleeps come at most about once per jitter.
+// A cancelled context stops a sleep with its error.
+func WithRealTime(jitter time.Duration) func(*Muxer) {
+	return func(m *Muxer) {
+		m.pace = true
+		m.paceJitter = jitter
+	}
+}
+
+// paceWriter holds each write back until the time of its first byte: the
+// anchor time plus the bytes written since the anchor at rate.
+type paceWriter struct {
+	ctx    context.Context
+	w      io.Writer
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	jitter time.Duration
+
+	rate    uint64 // bits per second, 0 until known
+	cbr     bool   // rate fixed, PCRs ignored
+	pos     uint64 // bytes written
+	started bool
+	at      time.Time // time of byte anchor
+	anchor  uint64
+	pcr     uint64    // 27 MHz, the last PCR
+	pcrAt   time.Time // its time
+	pcrPos  uint64    // and position
+	hasPCR  bool
+}
+
+func (c *paceWriter) Write(p []byte) (n int, err error) {
+	now := c.now()
+	if !c.started {
+		c.started, c.at, c.anchor = true, now, c.pos
+	}
+	due := c.due(c.pos)
+	if d := due.Sub(now); d > paceResync || d < -paceResync {
+		// a stall or a jump: the pacing restarts here
+		c.at, c.pcrAt = c.at.Add(-d), c.pcrAt.Add(-d)
+	} else if d > c.jitter {
+		if err = c.sleep(c.ctx, d); err != nil {
+			return
+		}
+	}
+	n, err = c.w.Write(p)
+	c.pos += uint64(n)
+	return
+}
+
+// due is the time of byte pos.
+func (c *paceWriter) due(pos uint64) time.Time {
+	if c.rate == 0 {
+		return c.at
+	}
+	return c.at.Add(ticks(elapsed27MHz(pos-c.anchor, c.rate)))
+}
+
+// mark anchors the pacing on a PCR, in 27 MHz, about to be written: its
+// packet is due at the PCR time, and the bytes since the previous PCR give
+// the rate up to the next one.
+func (c *paceWriter) mark(pcr uint64) {
+	if c.cbr {
+		return
+	}
+	at := c.now()
+	if c.hasPCR {
+		// a PCR discontinuity is due now
+		if d := (pcr + pcrWrap - c.pcr) % pcrWrap; d > 0 && ticks(d) <= paceResync {
+			at = c.pcrAt.Add(ticks(d))
+			c.rate = (c.pos - c.pcrPos) * 8 * clock27MHz / d
+		}
+	}
+	c.started, c.at, c.anchor = true, at, c.pos
+	c.pcr, c.pcrAt, c.pcrPos, c.hasPCR = pcr, at, c.pos, true
+}
+
+// ticks converts 27 MHz ticks to a duration.
+func ticks(t uint64) time.Duration {
+	return time.Duration(t * 1000 / 27)
+}
+
+// sleepContext sleeps for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// initPace puts the pacing writer over the M2TS one, so a CBR rate counts
+// 188-byte packets as the byte clock does.
+func (m *Muxer) initPace() {
+	m.pw = paceWriter{
+		ctx:    m.ctx,
+		w:      m.w,
+		now:    time.Now,
+		sleep:  sleepContext,
+		jitter: m.paceJitter,
+		rate:   m.cbrRate,
+		cbr:    m.cbrRate > 0,
+	}
+	m.w = &m.pw
+}
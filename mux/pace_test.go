@@ -0,0 +1,113 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// fakeClock is a clock that moves only when slept on.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) install(m *Muxer) {
+	m.pw.now = func() time.Time { return c.now }
+	m.pw.sleep = func(_ context.Context, d time.Duration) error {
+		c.sleeps = append(c.sleeps, d)
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func (c *fakeClock) elapsed(start time.Time) time.Duration {
+	return c.now.Sub(start)
+}
+
+func TestMuxerRealTimeCBR(t *testing.T) {
+	const rate = ts.PacketSize * 8 * 1000 // a packet is 1 ms
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0), WithRealTime(5*time.Millisecond))
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	start := clock.now
+	clock.install(m)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	for i := range uint64(3) {
+		_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{
+			Data:   make([]byte, 1000),
+			Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000+i*9000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+		}})
+		require.NoError(t, err)
+	}
+
+	// the last packet went out at its time, within the jitter
+	last := time.Duration(buf.Len()/ts.PacketSize-1) * time.Millisecond
+	assert.InDelta(t, last, clock.elapsed(start), float64(5*time.Millisecond))
+	require.NotEmpty(t, clock.sleeps)
+	for _, d := range clock.sleeps {
+		assert.Greater(t, d, 5*time.Millisecond)
+	}
+}
+
+func TestMuxerRealTimePCR(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithRealTime(time.Millisecond))
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	start := clock.now
+	clock.install(m)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	// units 40 ms apart, each with a PCR
+	var at []time.Duration
+	for i := range uint64(5) {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(i*3600, 0)},
+			PES: &pes.Data{
+				Data:   make([]byte, 2000),
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(9000+i*3600, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+		at = append(at, clock.elapsed(start))
+	}
+	// from the second PCR on, each unit spreads over the 40 ms after its PCR
+	for i, d := range at[1:] {
+		assert.InDelta(t, time.Duration(i+2)*40*time.Millisecond, d, float64(5*time.Millisecond), "unit %d", i+1)
+	}
+	assert.GreaterOrEqual(t, clock.elapsed(start), 160*time.Millisecond)
+
+	// a PCR discontinuity restarts the pacing instead of stalling
+	before := clock.elapsed(start)
+	_, err := m.WriteData(&Data{
+		PID:             0x100,
+		AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(900000, 0)},
+		PES:             &pes.Data{Data: make([]byte, 100)},
+	})
+	require.NoError(t, err)
+	assert.Less(t, clock.elapsed(start)-before, 50*time.Millisecond)
+}
+
+func TestMuxerRealTimeCancel(t *testing.T) {
+	const rate = ts.PacketSize * 8 * 10 // a packet is 100 ms
+	ctx, cancel := context.WithCancel(context.Background())
+	m := New(ctx, &bytes.Buffer{}, WithCBR(rate, 0), WithRealTime(0))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	cancel()
+
+	_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: make([]byte, 1000)}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
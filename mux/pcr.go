@@ -0,0 +1,114 @@
+package mux
+
+import (
+	"time"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// DefaultPCRInterval is the PCR repetition interval of DVB (TR 101 290
+// PCR_repetition_error): at most 40 ms between PCRs of a program.
+const DefaultPCRInterval = 40 * time.Millisecond
+
+// WithPCRInterval sets the longest time between two PCRs on the PCR PID (see
+// SetPCRPID), DefaultPCRInterval by default. Where the muxer restamps PCRs
+// (WithCBR) it meets the interval by writing adaptation-field-only PCR packets
+// on the PCR PID, between the packets of a unit and between null packets;
+// otherwise it checks the PCRs passed through and counts the late ones
+// (PCRGaps).
+func WithPCRInterval(interval time.Duration) func(*Muxer) {
+	return func(m *Muxer) {
+		m.pcrInterval = interval
+	}
+}
+
+// WithPCRPassthrough keeps the PCRs of WriteData as given under WithCBR
+// instead of restamping them from the output byte position; the muxer then
+// inserts no PCR packets either.
+func WithPCRPassthrough() func(*Muxer) {
+	return func(m *Muxer) {
+		m.pcrPassthrough = true
+	}
+}
+
+// WithPCRFilter passes the PCRs written on the PCR PID through a filter from
+// fn, e.g. ts.NewPCRMedianFilter, before the stats (WithStats) and the pacing
+// (WithRealTime) take them. The filter's arrival clock is the output byte
+// position (ts.PCROffsetFilter). The PCRs written, and PCRGaps, are as given.
+func WithPCRFilter(fn func() ts.PCRFilter) func(*Muxer) {
+	return func(m *Muxer) {
+		m.pcrFilter = ts.NewPCROffsetFilter(fn())
+	}
+}
+
+// PCRGaps returns the number of PCRs written later than the PCR interval after
+// the previous one of the PCR PID.
+func (m *Muxer) PCRGaps() int {
+	return m.pcrGaps
+}
+
+// restampsPCR tells whether the muxer owns the PCRs: byte clock and no
+// passthrough.
+func (m *Muxer) restampsPCR() bool {
+	return m.cbrRate > 0 && !m.pcrPassthrough
+}
+
+// interval27MHz is the PCR interval in 27 MHz ticks.
+func (m *Muxer) interval27MHz() uint64 {
+	return uint64(m.pcrInterval.Nanoseconds()) * clock27MHz / uint64(time.Second)
+}
+
+// pcrDelta is the 27 MHz time from PCR prev to PCR c, across the wrap.
+func pcrDelta(c, prev uint64) uint64 {
+	return (c + pcrWrap - prev) % pcrWrap
+}
+
+// observePCR checks the gap of a PCR written on the PCR PID.
+func (m *Muxer) observePCR(pcr ts.ClockReference) {
+	c := pcr.Base()*300 + pcr.Extension()
+	if m.pcrSent && pcrDelta(c, m.lastPCR) > m.interval27MHz() {
+		m.pcrGaps++
+	}
+	m.lastPCR, m.pcrSent = c, true
+	if m.pcrFilter != nil && (m.stats || m.pace) {
+		pos := m.pw.pos
+		if m.stats {
+			pos = m.sw.pos
+		}
+		pcr = m.pcrFilter.Filter(pcr, int64(pos), false)
+		c = pcr.Ticks()
+	}
+	if m.stats {
+		m.statPCR(c)
+	}
+	if m.pace {
+		m.pw.mark(c)
+	}
+}
+
+// insertPCR writes an adaptation-field-only PCR packet on the PCR PID when the
+// next packet would arrive past the interval, under restamping.
+func (m *Muxer) insertPCR() (n int, err error) {
+	if !m.cbrStarted || !m.restampsPCR() {
+		return
+	}
+	cc := m.counter(m.pmt.PCRPID)
+	if cc == nil {
+		return
+	}
+	c := m.cbrClock(m.cw.n+pcrLastByte) % pcrWrap
+	if m.pcrSent && pcrDelta(c, m.lastPCR) < m.interval27MHz() {
+		return
+	}
+	m.pcrAF.Reset()
+	m.pcrAF.HasPCR = true
+	m.pcrAF.PCR = ts.NewClockReference(c/300, c%300)
+	m.pcrAF.StuffingLength = uint8(packetMaxPayload - 2 - ts.PCRSize)
+	m.lastPCR, m.pcrSent = c, true
+	if m.stats {
+		m.statPCR(c)
+	}
+	// No payload: the continuity counter does not advance
+	header := ts.PacketHeader{PID: m.pmt.PCRPID, HasAdaptationField: true, ContinuityCounter: uint8(cc.value) & 0xf}
+	return m.emitPacket(header, &m.pcrAF, m.packetSize, nil, nil)
+}
@@ -0,0 +1,108 @@
+package mux
+
+import (
+	"time"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// PCRSource is where the PCRs of the PCR PID come from.
+type PCRSource uint8
+
+const (
+	// PCRSourceData keeps the PCRs given with WriteData.
+	PCRSourceData PCRSource = iota
+	// PCRSourceDTS derives each PCR from the DTS of its unit, for files and
+	// transcodes: the media timeline is the clock.
+	PCRSourceDTS
+	// PCRSourceClock derives the PCRs from a real-time clock, for live
+	// passthrough: they run at the rate of the clock, steered towards the DTS
+	// so the decoder buffer stays bounded.
+	PCRSourceClock
+)
+
+// pcrSlew is how far a clock-derived PCR is steered per second, the 500 ns/s
+// rate-of-change limit of the system clock.
+const pcrSlew = 500 * time.Nanosecond
+
+// WithPCRSource makes the muxer stamp a PCR on every unit of the PCR PID from
+// source, delay ahead of the unit's DTS (its PTS without one). For
+// PCRSourceClock the PCR starts at the first unit and then follows clock,
+// time.Now when nil; its offset from the DTS timeline moves by at most
+// 500 ns per second of clock, so a drift between the media and the clock is
+// absorbed without a PCR jump. Under WithCBR without WithPCRPassthrough the
+// output byte clock stamps the PCRs instead.
+func WithPCRSource(source PCRSource, delay time.Duration, clock func() time.Time) func(*Muxer) {
+	return func(m *Muxer) {
+		if clock == nil {
+			clock = time.Now
+		}
+		m.pcrSource = source
+		m.pcrDelay = ticks27MHz(delay)
+		m.pcrClock = clock
+	}
+}
+
+// stampSourcePCR sets the PCR of a unit of the PCR PID from the PCR source,
+// in the adaptation field of d or, without one, in the muxer's; the returned
+// func puts d back as it was.
+func (m *Muxer) stampSourcePCR(d *Data) (restore func()) {
+	c, ok := m.sourcePCR(d.PES)
+	if !ok {
+		return
+	}
+	if d.AdaptationField == nil {
+		m.srcAF.Reset()
+		d.AdaptationField = &m.srcAF
+		restore = func() { d.AdaptationField = nil }
+	}
+	d.AdaptationField.HasPCR = true
+	d.AdaptationField.PCR = ts.NewClockReference(c/300, c%300)
+	return
+}
+
+// sourcePCR is the 27 MHz PCR of a unit with a PTS.
+func (m *Muxer) sourcePCR(d *pes.Data) (pcr uint64, ok bool) {
+	h := d.Header.OptionalHeader
+	if h == nil || h.PTSDTSIndicator&pes.PTSDTSIndicatorOnlyPTS == 0 {
+		return
+	}
+	dts := h.PTS.Base()
+	if h.PTSDTSIndicator == pes.PTSDTSIndicatorBothPresent {
+		dts = h.DTS.Base()
+	}
+
+	// the DTS unwrapped past the 33-bit wrap, from the first unit on
+	if !m.pcrSourceStarted {
+		m.pcrSourceStarted = true
+		m.pcrDTS, m.pcrLastDTS = dts, dts
+		m.pcrStartTime, m.pcrElapsed = m.pcrClock(), 0
+		m.pcrAnchor = int64(dts*300) - m.pcrDelay
+	}
+	delta := int64((dts - m.pcrLastDTS) & ptsMask)
+	if delta >= 1<<32 {
+		delta -= 1 << 33
+	}
+	m.pcrDTS += uint64(delta)
+	m.pcrLastDTS = dts
+	target := int64(m.pcrDTS*300) - m.pcrDelay
+
+	c := target
+	if m.pcrSource == PCRSourceClock {
+		elapsed := m.pcrClock().Sub(m.pcrStartTime)
+		free := m.pcrAnchor + ticks27MHz(elapsed)
+		// steer the offset towards the DTS timeline, within the slew limit
+		step := float64(elapsed-m.pcrElapsed) * float64(pcrSlew) / float64(time.Second) * clock27MHz / float64(time.Second)
+		m.pcrElapsed = elapsed
+		m.pcrOffset += min(max(float64(target-free)-m.pcrOffset, -step), step)
+		c = free + int64(m.pcrOffset)
+	}
+	return uint64(c%pcrWrap+pcrWrap) % pcrWrap, true
+}
+
+// ticks27MHz converts d to 27 MHz ticks, seconds and the rest apart: the
+// nanoseconds times 27 MHz overflow past 341 s.
+func ticks27MHz(d time.Duration) int64 {
+	return int64(d/time.Second)*clock27MHz + int64(d%time.Second)*clock27MHz/int64(time.Second)
+}
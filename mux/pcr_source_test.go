@@ -0,0 +1,78 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// pcrSourceUnits writes units on the PCR PID with the DTS of i at step.
+func pcrSourceUnits(t *testing.T, m *Muxer, units int, step func(i int) uint64, each func()) {
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	for i := range units {
+		dts := ts.NewClockReference(step(i), 0)
+		d := &Data{PID: 0x100, PES: &pes.Data{
+			Data: []byte{1},
+			Header: pes.Header{OptionalHeader: &pes.OptionalHeader{
+				PTS: ts.NewClockReference(step(i)+7200, 0), DTS: dts, PTSDTSIndicator: pes.PTSDTSIndicatorBothPresent,
+			}},
+		}}
+		_, err := m.WriteData(d)
+		require.NoError(t, err)
+		assert.Nil(t, d.AdaptationField)
+		if each != nil {
+			each()
+		}
+	}
+}
+
+func TestMuxerPCRSourceDTS(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithPCRSource(PCRSourceDTS, 100*time.Millisecond, nil))
+	pcrSourceUnits(t, m, 3, func(i int) uint64 { return 90000 + uint64(i)*3600 }, nil)
+	assert.Equal(t, []uint64{81000 * 300, 84600 * 300, 88200 * 300}, pcrs(t, buf.Bytes()))
+	assert.Zero(t, m.PCRGaps())
+}
+
+func TestMuxerPCRSourceClock(t *testing.T) {
+	buf := &bytes.Buffer{}
+	now := time.Unix(1000, 0)
+	m := New(context.Background(), buf, WithPCRSource(PCRSourceClock, 100*time.Millisecond, func() time.Time { return now }))
+	// the media runs 1/3600 fast of the clock, far past the slew limit
+	pcrSourceUnits(t, m, 100, func(i int) uint64 { return 90000 + uint64(i)*3601 },
+		func() { now = now.Add(40 * time.Millisecond) })
+
+	got := pcrs(t, buf.Bytes())
+	require.Len(t, got, 100)
+	assert.Equal(t, uint64(81000*300), got[0])
+	for i := 1; i < len(got); i++ {
+		// the clock rate, steered by at most 500 ns/s: 0.54 ticks per 40 ms
+		assert.InDelta(t, 1080000, got[i]-got[i-1], 1, "PCR %d", i)
+	}
+	assert.Equal(t, uint64(81000*300+99*1080000+53), got[99])
+}
+
+func TestMuxerPCRSourceClockLongRun(t *testing.T) {
+	buf := &bytes.Buffer{}
+	now := time.Unix(1000, 0)
+	m := New(context.Background(), buf, WithPCRSource(PCRSourceClock, 100*time.Millisecond, func() time.Time { return now }))
+	// the clock and the media run 500 s, past the 341 s a nanosecond product
+	// of 27 MHz holds
+	pcrSourceUnits(t, m, 3, func(i int) uint64 { return 90000 + uint64(i)*250*90000 },
+		func() { now = now.Add(250 * time.Second) })
+
+	got := pcrs(t, buf.Bytes())
+	require.Len(t, got, 3)
+	for i := 1; i < len(got); i++ {
+		assert.Equal(t, uint64(250*27_000_000), got[i]-got[i-1], "PCR %d", i)
+	}
+}
@@ -0,0 +1,90 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// pcrs demuxes the PCRs of bs in 27 MHz ticks.
+func pcrs(t *testing.T, bs []byte) (ret []uint64) {
+	dmx := demux.New(context.Background(), bytes.NewReader(bs), demux.WithPCREvents())
+	defer dmx.Close()
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev == demux.EventPCR {
+			pcr := dmx.PCR().PCR
+			ret = append(ret, pcr.Base()*300+pcr.Extension())
+		}
+	}
+	return
+}
+
+func TestMuxerPCRInterval(t *testing.T) {
+	const rate = ts.PacketSize * 8 * 1000 // a packet is 1 ms
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0), WithPCRInterval(30*time.Millisecond))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	// Units 100 ms apart, with no PCR of their own
+	for i := range uint64(3) {
+		_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{
+			Data:   make([]byte, 1000),
+			Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000+i*9000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+		}})
+		require.NoError(t, err)
+	}
+
+	got := pcrs(t, buf.Bytes())
+	// Every 30 ms from the first unit's first packet to the last unit
+	require.Len(t, got, 7)
+	for i := 1; i < len(got); i++ {
+		assert.Equal(t, uint64(30*27000), got[i]-got[i-1], i)
+	}
+	assert.Zero(t, m.PCRGaps())
+}
+
+func TestMuxerPCRGaps(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	for _, ms := range []uint64{0, 40, 90, 120} {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(ms*90, 0)},
+			PES:             &pes.Data{Data: []byte{0}},
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, m.PCRGaps())
+	assert.Equal(t, []uint64{0, 40 * 27000, 90 * 27000, 120 * 27000}, pcrs(t, buf.Bytes()))
+}
+
+func TestMuxerPCRGapsWrap(t *testing.T) {
+	m := New(context.Background(), &bytes.Buffer{})
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	// 10 ms apart across the 33-bit wrap
+	for _, base := range []uint64{1<<33 - 1800, 1<<33 - 900, 0, 900} {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(base, 0)},
+			PES:             &pes.Data{Data: []byte{0}},
+		})
+		require.NoError(t, err)
+	}
+	assert.Zero(t, m.PCRGaps())
+}
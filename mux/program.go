@@ -0,0 +1,69 @@
+package mux
+
+import (
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// minProgramPID is the lowest PID a program may use: DVB reserves the ones
+// below for its SI tables.
+const minProgramPID uint16 = 0x20
+
+// SetProgram sets the program_number and the PMT PID of the program, 1 and
+// 0x1000 by default. The number may not be 0, reserved to the NIT, and the
+// PID may not be one the muxer already writes (ErrPIDAlreadyExists). A change
+// mid-stream goes out with the next tables; a new PMT PID starts a fresh
+// continuity counter and bumps the PMT version.
+func (m *Muxer) SetProgram(number, pmtPID uint16) error {
+	if number == 0 {
+		return ErrProgramInvalid
+	}
+	if pmtPID < minProgramPID || pmtPID >= ts.PIDNull {
+		return ErrPIDInvalid
+	}
+	if pmtPID != m.pmtPID && m.pidInUse(pmtPID) {
+		return ErrPIDAlreadyExists
+	}
+
+	if m.pm.Has(m.pmtPID) {
+		m.pm.Remove(m.pmtPID)
+		m.pm.Set(pmtPID, number)
+		m.pmUpdated = true
+	}
+	if pmtPID != m.pmtPID {
+		m.pmtPID = pmtPID
+		m.pmtCC = newWrappingCounter(0b1111)
+		// the cached PMT packets carry the old PID
+		m.pmtData = m.pmtData[:0]
+	}
+	m.pmt.ProgramNumber = number
+	m.pmtUpdated = true
+	return nil
+}
+
+// SetDedicatedPCRPID makes pid, carrying none of the program's streams, its
+// PCR PID. Its packets carry only an adaptation field with the PCR: the muxer
+// writes them at the PCR interval where it restamps PCRs (WithCBR), and
+// WritePacket passes them otherwise. SetPCRPID puts the PCR back on a stream.
+func (m *Muxer) SetDedicatedPCRPID(pid uint16) error {
+	if pid < minProgramPID || pid >= ts.PIDNull {
+		return ErrPIDInvalid
+	}
+	if m.pcrDedicated && pid == m.pmt.PCRPID {
+		return nil
+	}
+	if m.pidInUse(pid) {
+		return ErrPIDAlreadyExists
+	}
+	m.pmt.PCRPID, m.pcrDedicated = pid, true
+	m.pcrCC = newWrappingCounter(0b1111)
+	m.pcrSent = false
+	m.pmtUpdated = true
+	return nil
+}
+
+// pidInUse tells whether the muxer writes pid: the PAT, the PMT, a stream, an
+// SI table or the dedicated PCR PID.
+func (m *Muxer) pidInUse(pid uint16) bool {
+	return pid == ts.PIDPAT || pid == m.pmtPID || m.esContexts.Has(pid) || m.pm.Has(pid) ||
+		m.siCC.Has(pid) || m.siTableOn(pid) != nil || m.pcrDedicated && pid == m.pmt.PCRPID
+}
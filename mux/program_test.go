@@ -0,0 +1,129 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// sectionsOn returns the table sections of the packets of bs on pid.
+func sectionsOn(t *testing.T, bs []byte, pid uint16) (ss []psi.Section) {
+	for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+		pkt := bs[off : off+ts.PacketSize]
+		if uint16(pkt[1]&0x1f)<<8|uint16(pkt[2]) != pid {
+			continue
+		}
+		d, err := psi.Parse(pkt[ts.HeaderSize:])
+		require.NoError(t, err)
+		ss = append(ss, d.Sections...)
+	}
+	return
+}
+
+func TestMuxer_SetProgram(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	assert.Equal(t, ErrProgramInvalid, m.SetProgram(0, 0x200))
+	assert.Equal(t, ErrPIDInvalid, m.SetProgram(7, ts.PIDSDT))
+	assert.Equal(t, ErrPIDInvalid, m.SetProgram(7, ts.PIDNull))
+	assert.Equal(t, ErrPIDAlreadyExists, m.SetProgram(7, 0x100))
+	require.NoError(t, m.SetProgram(7, 0x200))
+	assert.Equal(t, ErrPIDAlreadyExists, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x200, StreamType: psi.StreamTypeAACAudio}))
+
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	pat := sectionsOn(t, buf.Bytes(), ts.PIDPAT)
+	require.Len(t, pat, 1)
+	assert.Equal(t, []psi.PATProgram{{ProgramMapID: 0x200, ProgramNumber: 7}}, pat[0].Syntax.Data.(*psi.PAT).Programs)
+	assert.Empty(t, sectionsOn(t, buf.Bytes(), pmtStartPID))
+	pmt := sectionsOn(t, buf.Bytes(), 0x200)
+	require.Len(t, pmt, 1)
+	assert.Equal(t, uint16(7), pmt[0].Syntax.Header.TableIDExtension)
+	assert.Equal(t, uint16(7), pmt[0].Syntax.Data.(*psi.PMT).ProgramNumber)
+
+	// mid-stream, the PMT moves with a fresh counter
+	buf.Reset()
+	require.NoError(t, m.SetProgram(7, 0x300))
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	pat = sectionsOn(t, buf.Bytes(), ts.PIDPAT)
+	require.Len(t, pat, 1)
+	assert.Equal(t, uint8(1), pat[0].Syntax.Header.VersionNumber)
+	assert.Equal(t, []psi.PATProgram{{ProgramMapID: 0x300, ProgramNumber: 7}}, pat[0].Syntax.Data.(*psi.PAT).Programs)
+	require.Len(t, sectionsOn(t, buf.Bytes(), 0x300), 1)
+	cc, err := m.CC(0x300)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), cc)
+
+	// the program keeps its PID and number through RemoveProgram
+	require.NoError(t, m.RemoveProgram())
+	require.NoError(t, m.SetProgram(8, 0x300))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	buf.Reset()
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	pat = sectionsOn(t, buf.Bytes(), ts.PIDPAT)
+	require.Len(t, pat, 1)
+	assert.Equal(t, []psi.PATProgram{{ProgramMapID: 0x300, ProgramNumber: 8}}, pat[0].Syntax.Data.(*psi.PAT).Programs)
+}
+
+func TestMuxer_SetDedicatedPCRPID(t *testing.T) {
+	const rate = ts.PacketSize * 8 * 1000 // a packet is 1 ms
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+
+	assert.Equal(t, ErrPIDAlreadyExists, m.SetDedicatedPCRPID(0x100))
+	assert.Equal(t, ErrPIDAlreadyExists, m.SetDedicatedPCRPID(pmtStartPID))
+	assert.Equal(t, ErrPIDInvalid, m.SetDedicatedPCRPID(ts.PIDNull))
+	require.NoError(t, m.SetDedicatedPCRPID(0x1ff))
+	require.NoError(t, m.SetDedicatedPCRPID(0x1ff))
+	assert.Equal(t, ErrPIDAlreadyExists, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x1ff, StreamType: psi.StreamTypeAACAudio}))
+
+	for i := range uint64(3) {
+		_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{
+			Data:   make([]byte, 1000),
+			Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000+i*9000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+		}})
+		require.NoError(t, err)
+	}
+
+	pmt := sectionsOn(t, buf.Bytes(), pmtStartPID)
+	require.NotEmpty(t, pmt)
+	assert.Equal(t, uint16(0x1ff), pmt[0].Syntax.Data.(*psi.PMT).PCRPID)
+
+	// the PCRs go out on their own PID, in adaptation-field-only packets
+	var n int
+	for off := 0; off < buf.Len(); off += ts.PacketSize {
+		pkt := buf.Bytes()[off : off+ts.PacketSize]
+		var h ts.PacketHeader
+		_, err := h.Parse(pkt)
+		require.NoError(t, err)
+		if h.HasAdaptationField && pkt[4] > 0 && pkt[5]&0x10 > 0 { // PCR_flag
+			assert.Equal(t, uint16(0x1ff), h.PID)
+			assert.False(t, h.HasPayload)
+			n++
+		}
+	}
+	assert.Greater(t, n, 5)
+	got := pcrs(t, buf.Bytes())
+	assert.Len(t, got, n)
+	for i := 1; i < len(got); i++ {
+		assert.LessOrEqual(t, got[i]-got[i-1], m.interval27MHz())
+	}
+
+	// back on the stream
+	m.SetPCRPID(0x100)
+	_, err := m.CC(0x1ff)
+	assert.Equal(t, ErrPIDNotFound, err)
+}
@@ -0,0 +1,385 @@
+package mux
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// Remuxer passes one program of a transport stream through a Muxer packet by
+// packet: the muxer regenerates its PAT and PMT from the source PMT, and the
+// elementary stream packets go out with PIDs remapped, continuity counters
+// restamped on the output PIDs (unless WithRemuxPreserveCC) and, under
+// WithCBR, PCRs restamped from the output byte clock, paced by null packets to
+// arrive at their source time.
+// Packets of other programs, of dropped PIDs and of the source PSI are not
+// passed, null packets as WithRemuxNullPolicy says. A PCR PID carrying no
+// passed stream goes out as a dedicated one (Muxer.SetDedicatedPCRPID), its
+// PCRs alone in adaptation-field-only packets. The descriptors of the passed
+// streams go out as read (demux.WithRawDescriptors).
+type Remuxer struct {
+	dmx *demux.Demuxer
+	m   *Muxer
+	err error
+
+	program uint16 // WithRemuxProgram, 0 for the first of the PAT
+	pmtPID  uint16
+	pidMap  map[uint16]uint16 // WithRemuxPIDMap, source -> output
+	drop    ts.PIDSet         // WithRemuxDrop
+	pass    ts.PIDSet         // source PIDs of the current PMT
+	pcrSrc  uint16            // source PID of a dedicated PCR PID, 0 without
+	keepCC  ts.PIDSet         // WithRemuxPreserveCC
+	keepAll bool
+	opcr    bool // WithRemuxOPCR
+	opcrAF  ts.PacketAdaptationField
+	af      ts.PacketAdaptationField
+
+	nullPolicy NullPolicy        // WithRemuxNullPolicy
+	nullFill   func() *ts.Packet // WithRemuxNullFill
+}
+
+// NullPolicy is what the remuxer does with the null packets of the source.
+type NullPolicy uint8
+
+const (
+	// NullDrop drops them: the output carries only the stuffing the muxer
+	// writes itself, at the WithCBR rate.
+	NullDrop NullPolicy = iota
+	// NullPass passes them, keeping the bitrate of the source.
+	NullPass
+	// NullReplace writes a packet of WithRemuxNullFill in place of each, the
+	// null packet where it has none.
+	NullReplace
+)
+
+// WithRemuxNullPolicy sets what is done with the source null packets,
+// NullDrop by default.
+func WithRemuxNullPolicy(policy NullPolicy) func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.nullPolicy = policy
+	}
+}
+
+// WithRemuxNullFill replaces the source null packets (NullReplace) with the
+// packets fill returns, opportunistic data such as a carousel, written as
+// Muxer.WritePacket writes them; a nil packet keeps the null one.
+func WithRemuxNullFill(fill func() *ts.Packet) func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.nullPolicy, r.nullFill = NullReplace, fill
+	}
+}
+
+// WithRemuxProgram selects the program passed by its program_number, the
+// first of the PAT by default.
+func WithRemuxProgram(programNumber uint16) func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.program = programNumber
+	}
+}
+
+// WithRemuxPIDMap writes the source PIDs of pids on the PIDs they map to.
+func WithRemuxPIDMap(pids map[uint16]uint16) func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.pidMap = pids
+	}
+}
+
+// WithRemuxDrop drops the elementary streams of the source PIDs pids, from the
+// output and from the PMT.
+func WithRemuxDrop(pids ...uint16) func(*Remuxer) {
+	return func(r *Remuxer) {
+		for _, pid := range pids {
+			r.drop.Add(pid)
+		}
+	}
+}
+
+// WithRemuxPreserveCC keeps the continuity counters of the source packets on
+// the source PIDs pids, on every passed PID when none are given, instead of
+// restamping them: a discontinuity of the source goes through as is.
+func WithRemuxPreserveCC(pids ...uint16) func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.keepAll = len(pids) == 0
+		for _, pid := range pids {
+			r.keepCC.Add(pid)
+		}
+	}
+}
+
+// WithRemuxOPCR keeps the source PCR of each PCR restamped under WithCBR as
+// the OPCR of the packet.
+func WithRemuxOPCR() func(*Remuxer) {
+	return func(r *Remuxer) {
+		r.opcr = true
+	}
+}
+
+// NewRemuxer creates a remuxer reading the transport stream of rd and writing
+// through m, a muxer with no elementary streams of its own.
+func NewRemuxer(ctx context.Context, rd io.Reader, m *Muxer, opts ...func(*Remuxer)) *Remuxer {
+	r := &Remuxer{m: m}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.dmx = demux.New(ctx, rd, demux.WithPacketHook(r.packet), demux.WithRawDescriptors())
+	return r
+}
+
+// Run remuxes the stream to its end.
+func (r *Remuxer) Run() error {
+	defer r.dmx.Close()
+	for {
+		ev, err := r.dmx.Next()
+		if r.err != nil {
+			return r.err
+		}
+		if err != nil {
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				return nil
+			}
+			return err
+		}
+		switch ev {
+		case demux.EventPAT:
+			r.selectProgram(r.dmx.PAT())
+		case demux.EventPMT:
+			if pid, data := r.dmx.Section(); pid == r.pmtPID {
+				if r.err = r.configure(data.(*psi.PMT)); r.err != nil {
+					return r.err
+				}
+			}
+		}
+	}
+}
+
+// outPID is the output PID of a source one.
+func (r *Remuxer) outPID(pid uint16) uint16 {
+	if out, ok := r.pidMap[pid]; ok {
+		return out
+	}
+	return pid
+}
+
+// selectProgram finds the PMT PID of the program in the PAT.
+func (r *Remuxer) selectProgram(pat *psi.PAT) {
+	for _, p := range pat.Programs {
+		// Program number 0 is reserved to NIT
+		if p.ProgramNumber == 0 || r.program != 0 && p.ProgramNumber != r.program {
+			continue
+		}
+		r.program, r.pmtPID = p.ProgramNumber, p.ProgramMapID
+		return
+	}
+}
+
+// configure brings the muxer streams in line with the source PMT: a stream
+// gone from it is removed, a new one added, so the muxer PMT version moves
+// only on a change.
+func (r *Remuxer) configure(pmt *psi.PMT) (err error) {
+	m := r.m
+	var pass ts.PIDSet
+	for _, es := range pmt.ElementaryStreams {
+		if !r.drop.Has(es.ElementaryPID) {
+			pass.Add(es.ElementaryPID)
+		}
+	}
+	for i := len(m.esContexts.Keys) - 1; i >= 0; i-- {
+		out := m.esContexts.Keys[i]
+		if out != m.cuePID && !r.passesTo(pmt, &pass, out) {
+			if err = m.RemoveElementaryStream(out); err != nil {
+				return
+			}
+		}
+	}
+	// a dedicated PCR PID moving or becoming a stream's is let go first
+	pcrOut := r.outPID(pmt.PCRPID)
+	if m.pcrDedicated && (pass.Has(pmt.PCRPID) || pcrOut != m.pmt.PCRPID) {
+		m.SetPCRPID(ts.PIDNull)
+	}
+	for _, es := range pmt.ElementaryStreams {
+		if !pass.Has(es.ElementaryPID) {
+			continue
+		}
+		es.ElementaryPID = r.outPID(es.ElementaryPID)
+		if m.esContexts.Has(es.ElementaryPID) {
+			continue
+		}
+		if err = m.AddElementaryStream(es); err != nil {
+			return
+		}
+	}
+	// A PCR PID that carries no passed stream, of its own or dropped, goes
+	// out as a dedicated one with its PCRs only
+	r.pcrSrc = 0
+	switch {
+	case pass.Has(pmt.PCRPID):
+		if pcrOut != m.pmt.PCRPID {
+			m.SetPCRPID(pcrOut)
+		}
+	case pmt.PCRPID != ts.PIDNull:
+		if err = m.SetDedicatedPCRPID(pcrOut); err != nil {
+			return
+		}
+		r.pcrSrc = pmt.PCRPID
+	case m.pmt.PCRPID != ts.PIDNull:
+		m.SetPCRPID(ts.PIDNull)
+	}
+	r.pass = pass
+	return
+}
+
+// passesTo tells whether a passed stream of pmt goes out on PID out.
+func (r *Remuxer) passesTo(pmt *psi.PMT, pass *ts.PIDSet, out uint16) bool {
+	for _, es := range pmt.ElementaryStreams {
+		if pass.Has(es.ElementaryPID) && r.outPID(es.ElementaryPID) == out {
+			return true
+		}
+	}
+	return false
+}
+
+// packet passes a source packet; the demuxer keeps reading it, so it is left
+// untouched.
+func (r *Remuxer) packet(p *ts.Packet) {
+	if r.err == nil && p.Header.PID == ts.PIDNull {
+		r.null(p)
+		return
+	}
+	if r.err == nil && r.pcrSrc != 0 && p.Header.PID == r.pcrSrc {
+		r.pcrPacket(p)
+		return
+	}
+	if r.err != nil || !r.pass.Has(p.Header.PID) {
+		return
+	}
+	m := r.m
+	out := r.outPID(p.Header.PID)
+	ctx := m.esContexts.Get(out)
+	if ctx == nil {
+		return
+	}
+
+	var af *ts.PacketAdaptationField
+	if p.Header.HasAdaptationField && p.AdaptationField != nil {
+		r.af.CopyFrom(p.AdaptationField)
+		af = &r.af
+	}
+	if p.Header.PayloadUnitStartIndicator {
+		force := af != nil && af.RandomAccessIndicator && out == m.pmt.PCRPID
+		if _, r.err = m.retransmitTables(force); r.err != nil {
+			return
+		}
+	}
+	if af != nil && af.HasPCR && out == m.pmt.PCRPID {
+		if r.err = r.passPCR(af, &ctx.cc, out); r.err != nil {
+			return
+		}
+	}
+
+	header := p.Header
+	header.PID = out
+	if r.keepAll || r.keepCC.Has(p.Header.PID) {
+		// the muxer counter follows, for the tables and units it writes itself
+		if r.err = ctx.cc.set(int(header.ContinuityCounter)); r.err != nil {
+			return
+		}
+	} else if header.HasPayload {
+		header.ContinuityCounter = uint8(ctx.cc.inc())
+	} else {
+		// No payload: the continuity counter does not advance
+		header.ContinuityCounter = uint8(ctx.cc.value) & 0xf
+	}
+	_, r.err = m.emitPacket(header, af, ts.PacketSize-len(p.Payload), nil, p.Payload)
+}
+
+// passPCR restamps the PCR of af on the output byte clock under WithCBR,
+// pacing the output to its source time, and observes it.
+func (r *Remuxer) passPCR(af *ts.PacketAdaptationField, cc *wrappingCounter, out uint16) (err error) {
+	m := r.m
+	if m.restampsPCR() {
+		due := m.cbrUnwrap(af.PCR.Base())*300 + af.PCR.Extension()
+		if _, err = m.cbrPace(due); err != nil {
+			return
+		}
+		c := m.cbrClock(m.cw.n+pcrLastByte) % pcrWrap
+		src := af.PCR
+		af.PCR = ts.NewClockReference(c/300, c%300)
+		if r.opcr {
+			if err = r.copyOPCR(af, cc, out, src); err != nil {
+				return
+			}
+		}
+	}
+	if af.HasPCR {
+		m.observePCR(af.PCR)
+	}
+	return
+}
+
+// pcrPacket passes the PCR of a source packet of a dedicated PCR PID in an
+// adaptation-field-only packet, without the payload of a dropped stream.
+func (r *Remuxer) pcrPacket(p *ts.Packet) {
+	if !p.Header.HasAdaptationField || p.AdaptationField == nil || !p.AdaptationField.HasPCR {
+		return
+	}
+	m := r.m
+	out := m.pmt.PCRPID
+	cc := m.counter(out)
+	r.af.Reset()
+	r.af.HasPCR, r.af.PCR = true, p.AdaptationField.PCR
+	r.af.DiscontinuityIndicator = p.AdaptationField.DiscontinuityIndicator
+	r.af.StuffingLength = uint8(packetMaxPayload - 2 - ts.PCRSize)
+	if r.err = r.passPCR(&r.af, cc, out); r.err != nil {
+		return
+	}
+	// No payload: the continuity counter does not advance
+	header := ts.PacketHeader{PID: out, HasAdaptationField: true, ContinuityCounter: uint8(cc.value) & 0xf}
+	_, r.err = m.emitPacket(header, &r.af, m.packetSize, nil, nil)
+}
+
+// null writes a source null packet as the null policy says.
+func (r *Remuxer) null(p *ts.Packet) {
+	switch r.nullPolicy {
+	case NullDrop:
+		return
+	case NullReplace:
+		if r.nullFill == nil {
+			break
+		}
+		if fp := r.nullFill(); fp != nil {
+			p = fp
+		}
+	}
+	_, r.err = r.m.WritePacket(p)
+}
+
+// copyOPCR carries the source PCR of a restamped packet as its OPCR, in
+// place of 6 bytes of its stuffing. A packet without the room gives its PCR
+// up to an adaptation-field-only packet written before it, with the OPCR.
+func (r *Remuxer) copyOPCR(af *ts.PacketAdaptationField, cc *wrappingCounter, pid uint16, pcr ts.ClockReference) (err error) {
+	switch {
+	case af.HasOPCR:
+	case af.StuffingLength >= ts.PCRSize:
+		af.StuffingLength -= ts.PCRSize
+	default:
+		r.opcrAF.Reset()
+		r.opcrAF.HasPCR, r.opcrAF.PCR = true, af.PCR
+		r.opcrAF.HasOPCR, r.opcrAF.OPCR = true, pcr
+		r.opcrAF.StuffingLength = uint8(packetMaxPayload - 2 - 2*ts.PCRSize)
+		r.m.observePCR(af.PCR)
+		// No payload: the continuity counter does not advance
+		header := ts.PacketHeader{PID: pid, HasAdaptationField: true, ContinuityCounter: uint8(cc.value) & 0xf}
+		if _, err = r.m.emitPacket(header, &r.opcrAF, r.m.packetSize, nil, nil); err != nil {
+			return
+		}
+		af.HasPCR = false
+		af.StuffingLength += ts.PCRSize
+		return
+	}
+	af.HasOPCR, af.OPCR = true, pcr
+	return
+}
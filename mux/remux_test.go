@@ -0,0 +1,406 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// remuxSource muxes units of 500 bytes 40 ms apart on a video PID carrying
+// the PCR and an audio PID.
+func remuxSource(t *testing.T, units int) []byte {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+	for i := range units {
+		pts := uint64(90000 + i*3600)
+		for _, pid := range []uint16{0x100, 0x101} {
+			d := &Data{
+				PID: pid,
+				PES: &pes.Data{
+					Data:   bytes.Repeat([]byte{byte(i)}, 500),
+					Header: pes.Header{OptionalHeader: &pes.OptionalHeader{MarkerBits: 2, PTS: ts.NewClockReference(pts, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+				},
+			}
+			if pid == 0x100 {
+				d.AdaptationField = &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(pts-9000, 0)}
+			}
+			_, err := m.WriteData(d)
+			require.NoError(t, err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestRemuxer(t *testing.T) {
+	src := remuxSource(t, 5)
+
+	out := &bytes.Buffer{}
+	m := New(context.Background(), out)
+	r := NewRemuxer(context.Background(), bytes.NewReader(src), m,
+		WithRemuxDrop(0x101), WithRemuxPIDMap(map[uint16]uint16{0x100: 0x200}))
+	require.NoError(t, r.Run())
+
+	var ccErrors int
+	dmx := demux.New(context.Background(), bytes.NewReader(out.Bytes()),
+		demux.WithCCErrorHook(func(demux.CCError) { ccErrors++ }))
+	defer dmx.Close()
+	var units []uint64
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventPMT:
+			_, data := dmx.Section()
+			pmt := data.(*psi.PMT)
+			assert.Equal(t, uint16(0x200), pmt.PCRPID)
+			require.Len(t, pmt.ElementaryStreams, 1)
+			assert.Equal(t, uint16(0x200), pmt.ElementaryStreams[0].ElementaryPID)
+			assert.Equal(t, psi.StreamTypeH264Video, pmt.ElementaryStreams[0].StreamType)
+		case demux.EventPES:
+			d := dmx.PES()
+			assert.Equal(t, uint16(0x200), d.PID)
+			assert.Len(t, d.Data.Data, 500)
+			units = append(units, d.Data.Header.OptionalHeader.PTS.Base())
+			d.Close()
+		}
+	}
+	assert.Equal(t, []uint64{90000, 93600, 97200, 100800, 104400}, units)
+	assert.Zero(t, ccErrors)
+}
+
+func TestRemuxerCBR(t *testing.T) {
+	src := remuxSource(t, 5)
+
+	// 1000 packets per second: a packet is 1 ms
+	const rate = ts.PacketSize * 8 * 1000
+	out := &bytes.Buffer{}
+	m := New(context.Background(), out, WithCBR(rate, 0), WithPCRInterval(time.Second))
+	require.NoError(t, NewRemuxer(context.Background(), bytes.NewReader(src), m).Run())
+
+	// PCRs 40 ms apart in the source stay 40 ms apart, restamped on the
+	// output byte clock and paced by null packets
+	got := pcrs(t, out.Bytes())
+	require.Len(t, got, 5)
+	for i := 1; i < len(got); i++ {
+		assert.Equal(t, uint64(40*27000), got[i]-got[i-1])
+	}
+	var nulls int
+	for off := 0; off < out.Len(); off += ts.PacketSize {
+		if uint16(out.Bytes()[off+1]&0x1f)<<8|uint16(out.Bytes()[off+2]) == ts.PIDNull {
+			nulls++
+		}
+	}
+	assert.NotZero(t, nulls)
+}
+
+func TestRemuxerConfigure(t *testing.T) {
+	m := New(context.Background(), &bytes.Buffer{})
+	r := &Remuxer{m: m, pidMap: map[uint16]uint16{0x101: 0x201}}
+	video := psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}
+	audio := psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}
+
+	require.NoError(t, r.configure(&psi.PMT{PCRPID: 0x101, ElementaryStreams: []psi.ElementaryStream{video, audio}}))
+	assert.Equal(t, uint16(0x201), m.pmt.PCRPID)
+	assert.Equal(t, []uint16{0x100, 0x201}, m.esContexts.Keys)
+	require.NoError(t, m.generatePMT())
+	version := m.pmtVersion.value
+
+	// The same streams leave the PMT as it is
+	require.NoError(t, r.configure(&psi.PMT{PCRPID: 0x101, ElementaryStreams: []psi.ElementaryStream{video, audio}}))
+	assert.False(t, m.pmtUpdated)
+
+	require.NoError(t, r.configure(&psi.PMT{PCRPID: 0x100, ElementaryStreams: []psi.ElementaryStream{video}}))
+	assert.Equal(t, uint16(0x100), m.pmt.PCRPID)
+	assert.Equal(t, []uint16{0x100}, m.esContexts.Keys)
+	require.NoError(t, m.generatePMT())
+	assert.Equal(t, version+1, m.pmtVersion.value)
+}
+
+func TestRemuxerPreserveCC(t *testing.T) {
+	ccs := func(bs []byte, pid uint16) (ccs []uint8) {
+		for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+			var h ts.PacketHeader
+			_, err := h.Parse(bs[off:])
+			require.NoError(t, err)
+			if h.PID == pid {
+				ccs = append(ccs, h.ContinuityCounter)
+			}
+		}
+		return
+	}
+	src := remuxSource(t, 5)
+	// drop the second video packet: the gap goes through
+	var gapped []byte
+	for off := 0; off < len(src); off += ts.PacketSize {
+		if off != 3*ts.PacketSize {
+			gapped = append(gapped, src[off:off+ts.PacketSize]...)
+		}
+	}
+	require.Equal(t, []uint8{0, 2, 3}, ccs(gapped, 0x100)[:3])
+
+	out := &bytes.Buffer{}
+	m := New(context.Background(), out)
+	r := NewRemuxer(context.Background(), bytes.NewReader(gapped), m,
+		WithRemuxPreserveCC(0x100), WithRemuxPIDMap(map[uint16]uint16{0x100: 0x200}))
+	require.NoError(t, r.Run())
+
+	assert.Equal(t, ccs(gapped, 0x100), ccs(out.Bytes(), 0x200))
+	// the audio PID is restamped
+	assert.Equal(t, []uint8{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, ccs(out.Bytes(), 0x101))
+	cc, err := m.CC(0x200)
+	require.NoError(t, err)
+	assert.Equal(t, ccs(gapped, 0x100)[len(ccs(gapped, 0x100))-1], cc)
+}
+
+func TestRemuxerOPCR(t *testing.T) {
+	opcrs := func(bs []byte) (pcrs, opcrs []uint64) {
+		for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+			var h ts.PacketHeader
+			_, err := h.Parse(bs[off:])
+			require.NoError(t, err)
+			if h.PID != 0x100 || !h.HasAdaptationField {
+				continue
+			}
+			var af ts.PacketAdaptationField
+			_, err = af.Parse(bs[off+ts.HeaderSize:])
+			require.NoError(t, err)
+			if af.HasOPCR {
+				require.True(t, af.HasPCR)
+				pcrs = append(pcrs, af.PCR.Base()*300+af.PCR.Extension())
+				opcrs = append(opcrs, af.OPCR.Base()*300+af.OPCR.Extension())
+			}
+		}
+		return
+	}
+	const rate = ts.PacketSize * 8 * 1000
+	want := []uint64{81000 * 300, 84600 * 300, 88200 * 300, 91800 * 300, 95400 * 300}
+
+	// full packets: PCR and OPCR go in packets of their own
+	out := &bytes.Buffer{}
+	m := New(context.Background(), out, WithCBR(rate, 0), WithPCRInterval(time.Second))
+	require.NoError(t, NewRemuxer(context.Background(), bytes.NewReader(remuxSource(t, 5)), m, WithRemuxOPCR()).Run())
+	gotPCRs, gotOPCRs := opcrs(out.Bytes())
+	assert.Equal(t, want, gotOPCRs)
+	assert.Equal(t, pcrs(t, out.Bytes()), gotPCRs)
+	assert.Zero(t, m.PCRGaps())
+
+	// room in the stuffing: the OPCR goes next to the PCR
+	src := &bytes.Buffer{}
+	sm := New(context.Background(), src)
+	require.NoError(t, sm.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	sm.SetPCRPID(0x100)
+	for i := range uint64(5) {
+		pts := 90000 + i*3600
+		_, err := sm.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(pts-9000, 0)},
+			PES:             &pes.Data{Data: []byte{1}, Header: pes.Header{OptionalHeader: &pes.OptionalHeader{MarkerBits: 2, PTS: ts.NewClockReference(pts, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}}},
+		})
+		require.NoError(t, err)
+	}
+	out.Reset()
+	m = New(context.Background(), out, WithCBR(rate, 0), WithPCRInterval(time.Second))
+	require.NoError(t, NewRemuxer(context.Background(), bytes.NewReader(src.Bytes()), m, WithRemuxOPCR()).Run())
+	gotPCRs, gotOPCRs = opcrs(out.Bytes())
+	assert.Equal(t, want, gotOPCRs)
+	assert.Equal(t, pcrs(t, out.Bytes()), gotPCRs)
+	dmx := demux.New(context.Background(), bytes.NewReader(out.Bytes()))
+	defer dmx.Close()
+	var units int
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		if ev == demux.EventPES {
+			d := dmx.PES()
+			assert.Equal(t, []byte{1}, d.Data.Data)
+			d.Close()
+			units++
+		}
+	}
+	assert.Equal(t, 5, units)
+}
+
+func TestRemuxerNullPolicy(t *testing.T) {
+	pids := func(bs []byte) map[uint16]int {
+		ret := map[uint16]int{}
+		for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+			var h ts.PacketHeader
+			_, err := h.Parse(bs[off:])
+			require.NoError(t, err)
+			ret[h.PID]++
+		}
+		return ret
+	}
+	null := append([]byte{0x47, 0x1f, 0xff, 0x10}, bytes.Repeat([]byte{0xff}, ts.PacketSize-ts.HeaderSize)...)
+	// a null packet after every packet of the source
+	src := remuxSource(t, 5)
+	var padded []byte
+	for off := 0; off < len(src); off += ts.PacketSize {
+		padded = append(padded, src[off:off+ts.PacketSize]...)
+		padded = append(padded, null...)
+	}
+	nulls := len(src) / ts.PacketSize
+
+	remux := func(opts ...func(*Remuxer)) map[uint16]int {
+		out := &bytes.Buffer{}
+		r := NewRemuxer(context.Background(), bytes.NewReader(padded), New(context.Background(), out), opts...)
+		require.NoError(t, r.Run())
+		return pids(out.Bytes())
+	}
+
+	assert.Zero(t, remux()[ts.PIDNull])
+	got := remux(WithRemuxNullPolicy(NullPass))
+	assert.Equal(t, nulls, got[ts.PIDNull])
+	assert.Equal(t, pids(src)[0x100], got[0x100])
+
+	// the fill has data for the first two
+	var fills int
+	got = remux(WithRemuxNullFill(func() *ts.Packet {
+		if fills++; fills > 2 {
+			return nil
+		}
+		return &ts.Packet{
+			Header:  ts.PacketHeader{PID: 0x300, HasPayload: true, PayloadUnitStartIndicator: true, ContinuityCounter: uint8(fills)},
+			Payload: bytes.Repeat([]byte{0xab}, ts.PacketSize-ts.HeaderSize),
+		}
+	}))
+	assert.Equal(t, 2, got[0x300])
+	assert.Equal(t, nulls-2, got[ts.PIDNull])
+}
+
+func TestRemuxerNullPolicyFraming(t *testing.T) {
+	null := append([]byte{0x47, 0x1f, 0xff, 0x10}, bytes.Repeat([]byte{0xff}, ts.PacketSize-ts.HeaderSize)...)
+	src := remuxSource(t, 5)
+	nulls := len(src) / ts.PacketSize
+
+	for _, size := range []int{ts.M2TSPacketSize, ts.RSPacketSize} {
+		// a null packet after every packet of the source, in size-byte framing
+		var framed []byte
+		frame := func(pkt []byte) {
+			if size == ts.M2TSPacketSize {
+				framed = append(framed, 0x00, 0x00, 0x00, 0x00)
+			}
+			framed = append(framed, pkt...)
+			if size == ts.RSPacketSize {
+				framed = append(framed, bytes.Repeat([]byte{0xaa}, ts.RSPacketSize-ts.PacketSize)...)
+			}
+		}
+		for off := 0; off < len(src); off += ts.PacketSize {
+			frame(src[off : off+ts.PacketSize])
+			frame(null)
+		}
+
+		out := &bytes.Buffer{}
+		r := NewRemuxer(context.Background(), bytes.NewReader(framed), New(context.Background(), out), WithRemuxNullPolicy(NullPass))
+		require.NoError(t, r.Run())
+		bs := out.Bytes()
+		require.Zero(t, len(bs)%ts.PacketSize, "source %d", size)
+		var got int
+		for off := 0; off < len(bs); off += ts.PacketSize {
+			require.Equal(t, byte(0x47), bs[off], "source %d, offset %d", size, off)
+			if bytes.Equal(bs[off:off+ts.PacketSize], null) {
+				got++
+			}
+		}
+		assert.Equal(t, nulls, got, "source %d", size)
+	}
+}
+
+func TestRemuxerDedicatedPCR(t *testing.T) {
+	// PCR-only packets of the source on the PCR PID, and their PCRs
+	pcrPackets := func(bs []byte, pid uint16) (ret []uint64) {
+		for off := 0; off+ts.PacketSize <= len(bs); off += ts.PacketSize {
+			pkt := bs[off : off+ts.PacketSize]
+			var h ts.PacketHeader
+			_, err := h.Parse(pkt)
+			require.NoError(t, err)
+			if h.PID != pid {
+				continue
+			}
+			require.False(t, h.HasPayload)
+			var af ts.PacketAdaptationField
+			_, err = af.Parse(pkt[ts.HeaderSize:])
+			require.NoError(t, err)
+			require.True(t, af.HasPCR)
+			ret = append(ret, af.PCR.Base()*300+af.PCR.Extension())
+		}
+		return
+	}
+	pcrPID := func(bs []byte) uint16 {
+		ss := sectionsOn(t, bs, pmtStartPID)
+		require.NotEmpty(t, ss)
+		return ss[0].Syntax.Data.(*psi.PMT).PCRPID
+	}
+
+	// a source with its PCR on a PID of its own
+	src := &bytes.Buffer{}
+	m := New(context.Background(), src)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.SetDedicatedPCRPID(0x1ff))
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	for i := range uint64(3) {
+		_, err := m.WritePacket(&ts.Packet{
+			Header:          ts.PacketHeader{PID: 0x1ff, HasAdaptationField: true},
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(i*3600, 0), StuffingLength: packetMaxPayload - 2 - ts.PCRSize},
+		})
+		require.NoError(t, err)
+		_, err = m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: []byte{byte(i)}}})
+		require.NoError(t, err)
+	}
+	want := []uint64{0, 3600 * 300, 7200 * 300}
+	require.Equal(t, want, pcrPackets(src.Bytes(), 0x1ff))
+
+	out := &bytes.Buffer{}
+	r := NewRemuxer(context.Background(), bytes.NewReader(src.Bytes()), New(context.Background(), out),
+		WithRemuxPIDMap(map[uint16]uint16{0x1ff: 0x2ff}))
+	require.NoError(t, r.Run())
+	assert.Equal(t, uint16(0x2ff), pcrPID(out.Bytes()))
+	assert.Equal(t, want, pcrPackets(out.Bytes(), 0x2ff))
+
+	// the PCRs of a dropped stream go on without its payload
+	out.Reset()
+	r = NewRemuxer(context.Background(), bytes.NewReader(remuxSource(t, 3)), New(context.Background(), out), WithRemuxDrop(0x100))
+	require.NoError(t, r.Run())
+	assert.Equal(t, uint16(0x100), pcrPID(out.Bytes()))
+	assert.Equal(t, []uint64{81000 * 300, 84600 * 300, 88200 * 300}, pcrPackets(out.Bytes(), 0x100))
+}
+
+func TestRemuxerRawDescriptors(t *testing.T) {
+	// reserved bits cleared, and a byte past the component tag: neither
+	// survives a parse and Append
+	wire := []byte{byte(descriptor.TagAudioStream), 1, 0xd0, byte(descriptor.TagStreamIdentifier), 2, 0x07, 0x00}
+	ds, _, err := descriptor.ParseNKeepRaw(wire, len(wire))
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	src := New(context.Background(), buf)
+	require.NoError(t, src.AddElementaryStream(psi.ElementaryStream{
+		ElementaryPID:               0x100,
+		StreamType:                  psi.StreamTypeMPEG1Audio,
+		ElementaryStreamDescriptors: ds,
+	}))
+	src.SetPCRPID(0x100)
+	_, err = src.WriteTables()
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(buf.Bytes(), wire))
+
+	out := &bytes.Buffer{}
+	m := New(context.Background(), out)
+	require.NoError(t, NewRemuxer(context.Background(), bytes.NewReader(buf.Bytes()), m).Run())
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+	pmt := sectionsOn(t, out.Bytes(), pmtStartPID)
+	require.NotEmpty(t, pmt)
+	assert.True(t, bytes.Contains(out.Bytes(), wire))
+}
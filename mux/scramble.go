@@ -0,0 +1,43 @@
+package mux
+
+import "github.com/k-danil/go-astits/v2/ts"
+
+// Scrambler encrypts the payload of a packet in place, e.g. with DVB-CSA or
+// AES for a simulcrypt head-end, and returns the transport_scrambling_control
+// of the key it used: ts.ScramblingControlScrambledWithEvenKey or
+// ts.ScramblingControlScrambledWithOddKey, or
+// ts.ScramblingControlNotScrambled to leave the packet clear. The payload is
+// the whole packet after the adaptation field, PES header included.
+type Scrambler func(payload []byte) ts.ScramblingControl
+
+// SetScrambler scrambles the packets WriteData writes on pid with fn; a nil fn
+// stops scrambling them. Packets without payload, such as inserted PCR ones,
+// stay clear.
+func (m *Muxer) SetScrambler(pid uint16, fn Scrambler) error {
+	ctx := m.esContexts.Get(pid)
+	if ctx == nil {
+		return ErrPIDNotFound
+	}
+	ctx.scrambler = fn
+	if fn != nil {
+		m.scrambling = true
+	}
+	return nil
+}
+
+// emitScrambled assembles the packet in one buffer, has fn scramble its
+// payload and writes it with the control fn returned.
+func (m *Muxer) emitScrambled(fn Scrambler, header ts.PacketHeader, af *ts.PacketAdaptationField, front int, hdr, payload []byte) (n int, err error) {
+	header.TransportScramblingControl = ts.ScramblingControlNotScrambled
+	header.Put(m.pkt)
+	if header.HasAdaptationField {
+		if _, err = af.Put(m.pkt[ts.HeaderSize:]); err != nil {
+			return
+		}
+	}
+	end := front + copy(m.pkt[front:], hdr)
+	end += copy(m.pkt[end:], payload)
+	sc := fn(m.pkt[front:end])
+	m.pkt[3] = m.pkt[3]&0x3f | uint8(sc)<<6
+	return m.w.Write(m.pkt[:end])
+}
@@ -0,0 +1,79 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerScrambler(t *testing.T) {
+	mux := func(scramble bool) []byte {
+		buf := &bytes.Buffer{}
+		m := New(context.Background(), buf, WithCBR(ts.PacketSize*8*1000, 0))
+		require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+		m.SetPCRPID(0x100)
+		if scramble {
+			assert.Equal(t, ErrPIDNotFound, m.SetScrambler(0x200, nil))
+			odd := false
+			require.NoError(t, m.SetScrambler(0x100, func(payload []byte) ts.ScramblingControl {
+				for i := range payload {
+					payload[i] ^= 0xff
+				}
+				if odd = !odd; odd {
+					return ts.ScramblingControlScrambledWithOddKey
+				}
+				return ts.ScramblingControlScrambledWithEvenKey
+			}))
+		}
+		data := bytes.Repeat([]byte{0xab}, 1000)
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true},
+			PES: &pes.Data{
+				Data:   data,
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+		// The caller's data stays clear
+		assert.Equal(t, bytes.Repeat([]byte{0xab}, 1000), data)
+		return buf.Bytes()
+	}
+
+	plain, scrambled := mux(false), mux(true)
+	require.Equal(t, len(plain), len(scrambled))
+	var controls []ts.ScramblingControl
+	for off := 0; off < len(plain); off += ts.PacketSize {
+		c, s := plain[off:off+ts.PacketSize], scrambled[off:off+ts.PacketSize]
+		if pid := uint16(c[1]&0x1f)<<8 | uint16(c[2]); pid != 0x100 {
+			assert.Equal(t, c, s)
+			continue
+		}
+		controls = append(controls, ts.ScramblingControl(s[3]>>6))
+		assert.Equal(t, c[:3], s[:3])
+		assert.Equal(t, c[3]&0x3f, s[3]&0x3f)
+		front := ts.HeaderSize
+		if c[3]&0x20 != 0 {
+			front += 1 + int(c[4])
+		}
+		assert.Equal(t, c[4:front], s[4:front])
+		for i := front; i < ts.PacketSize; i++ {
+			assert.Equal(t, c[i]^0xff, s[i])
+		}
+	}
+	assert.Equal(t, []ts.ScramblingControl{
+		ts.ScramblingControlScrambledWithOddKey,
+		ts.ScramblingControlScrambledWithEvenKey,
+		ts.ScramblingControlScrambledWithOddKey,
+		ts.ScramblingControlScrambledWithEvenKey,
+		ts.ScramblingControlScrambledWithOddKey,
+		ts.ScramblingControlScrambledWithEvenKey,
+	}, controls)
+}
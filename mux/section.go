@@ -0,0 +1,47 @@
+package mux
+
+import (
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WriteSection writes the sections of d on the elementary stream pid, e.g. a
+// SCTE-35 splice_info_section on a StreamTypeSCTE35 PID. The payload starts with
+// a zero pointer field and the last packet is stuffed with 0xff; the PID's
+// continuity counter is shared with WriteData.
+func (m *Muxer) WriteSection(pid uint16, d *psi.Data) (bytesWritten int, err error) {
+	ctx := m.esContexts.Get(pid)
+	if ctx == nil {
+		return 0, ErrPIDNotFound
+	}
+
+	return m.writeSections(pid, &ctx.cc, d)
+}
+
+// writeSections packetizes the sections of d on pid, advancing cc per packet.
+func (m *Muxer) writeSections(pid uint16, cc *wrappingCounter, d *psi.Data) (bytesWritten int, err error) {
+	if m.sectionData, err = d.Append(m.sectionData[:0]); err != nil {
+		return
+	}
+
+	var n int
+	for start, l := 0, len(m.sectionData); start < l; start += packetMaxPayload {
+		pkt := ts.Packet{
+			Header: ts.PacketHeader{
+				ContinuityCounter:         uint8(cc.inc()),
+				HasPayload:                true,
+				PayloadUnitStartIndicator: start == 0,
+				PID:                       pid,
+			},
+			Payload: m.sectionData[start:min(start+packetMaxPayload, l)],
+		}
+		if _, err = pkt.Put(m.pkt); err != nil {
+			return
+		}
+		if n, err = m.w.Write(m.pkt); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	return
+}
@@ -0,0 +1,173 @@
+package mux
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/pes"
+)
+
+// Segment describes a finished segment of a Segmenter.
+type Segment struct {
+	Index    int
+	PTS      uint64 // 90 kHz, of the unit starting it
+	Duration time.Duration
+	Size     int64        // bytes written
+	Cues     []SegmentCue // SCTE-35 splice points it starts at (Segmenter.AddCue)
+}
+
+// Segmenter cuts the output of a Muxer into segments, as HLS plays them. The
+// reference stream is the first video stream of the program, or its first
+// stream when it has no video: a segment ends at the first random access unit
+// of the reference stream (its adaptation field RandomAccessIndicator set;
+// any unit of a stream other than video) at or past the target duration, or
+// at or past an SCTE-35 splice point of AddCue, and the next one starts with
+// PAT and PMT. Durations are measured on the PTS of the reference stream.
+type Segmenter struct {
+	*Muxer
+
+	target   uint64 // 90 kHz
+	sw       segmentWriter
+	segments []Segment
+	cues     []SegmentCue // pending splice points
+
+	started bool
+	start   uint64 // 90 kHz PTS of the current segment
+	last    uint64 // 90 kHz PTS, the latest of the reference stream
+	dts     uint64 // 90 kHz, of the last reference unit
+	step    uint64 // 90 kHz, between the DTS of the last two reference units
+}
+
+// segmentWriter opens a segment on its first write.
+type segmentWriter struct {
+	create func(index int) (io.WriteCloser, error)
+	w      io.WriteCloser
+	index  int
+	size   int64
+}
+
+func (c *segmentWriter) Write(p []byte) (n int, err error) {
+	if c.w == nil {
+		if c.w, err = c.create(c.index); err != nil {
+			return
+		}
+	}
+	n, err = c.w.Write(p)
+	c.size += int64(n)
+	return
+}
+
+// NewSegmenter creates a segmenter cutting segments of about target
+// duration, each written to the writer create returns for its index, counted
+// from 0; opts configure the underlying muxer.
+func NewSegmenter(ctx context.Context, target time.Duration, create func(index int) (io.WriteCloser, error), opts ...func(*Muxer)) *Segmenter {
+	s := &Segmenter{target: uint64(target * 90000 / time.Second)}
+	s.sw.create = create
+	s.Muxer = New(ctx, &s.sw, opts...)
+	return s
+}
+
+// WriteData writes d as Muxer.WriteData does, starting a segment before it
+// when it is due.
+func (s *Segmenter) WriteData(d *Data) (bytesWritten int, err error) {
+	if pid, video := s.referenceStream(); d.PID == pid {
+		if h := d.PES.Header.OptionalHeader; h != nil && h.PTSDTSIndicator&pes.PTSDTSIndicatorOnlyPTS != 0 {
+			if bytesWritten, err = s.reference(d, h, video); err != nil {
+				return
+			}
+		}
+	}
+	var n int
+	n, err = s.Muxer.WriteData(d)
+	return bytesWritten + n, err
+}
+
+// reference tracks the PTS of a reference unit and cuts the segment when it
+// starts the next one.
+func (s *Segmenter) reference(d *Data, h *pes.OptionalHeader, video bool) (bytesWritten int, err error) {
+	pts, dts := h.PTS.Base(), h.PTS.Base()
+	if h.PTSDTSIndicator == pes.PTSDTSIndicatorBothPresent {
+		dts = h.DTS.Base()
+	}
+	if !s.started {
+		s.start, s.last, s.dts, s.started = pts, pts, dts, true
+		return
+	}
+	// decode order steps evenly where presentation order may not; both
+	// compare within half the 33-bit wrap
+	if step := (dts - s.dts) & ptsMask; step < 1<<32 {
+		s.step = step
+	}
+	s.dts = dts
+	if (pts-s.last)&ptsMask < 1<<32 {
+		s.last = pts
+	}
+
+	random := !video || d.AdaptationField != nil && d.AdaptationField.RandomAccessIndicator
+	if !random || (pts-s.start)&ptsMask >= 1<<32 || (pts-s.start)&ptsMask < s.target && !s.cueDue(pts) {
+		return
+	}
+	if s.packLimit > 0 {
+		if bytesWritten, err = s.FlushPES(); err != nil {
+			return
+		}
+	}
+	if err = s.finish(pts); err != nil {
+		return
+	}
+	s.start = pts
+	// tables lead the unit into the new segment
+	s.tablesRetransmitCounter = s.tablesRetransmitPeriod
+	return
+}
+
+// finish closes the current segment, ending at PTS end.
+func (s *Segmenter) finish(end uint64) error {
+	if s.sw.w == nil {
+		return nil
+	}
+	s.segments = append(s.segments, Segment{
+		Index:    s.sw.index,
+		PTS:      s.start,
+		Duration: time.Duration((end-s.start)&ptsMask) * time.Second / 90000,
+		Size:     s.sw.size,
+		Cues:     s.segmentCues(),
+	})
+	err := s.sw.w.Close()
+	s.sw.w, s.sw.size = nil, 0
+	s.sw.index++
+	return err
+}
+
+// referenceStream is the stream durations are measured and segments cut on, and
+// whether it is video.
+func (s *Segmenter) referenceStream() (pid uint16, video bool) {
+	for _, es := range s.pmt.ElementaryStreams {
+		if es.StreamType.IsVideo() {
+			return es.ElementaryPID, true
+		}
+	}
+	if len(s.pmt.ElementaryStreams) > 0 {
+		return s.pmt.ElementaryStreams[0].ElementaryPID, false
+	}
+	return
+}
+
+// Segments returns the segments finished so far.
+func (s *Segmenter) Segments() []Segment {
+	return s.segments
+}
+
+// Close writes the packed units left and finishes the last segment, ending
+// one reference unit interval past its latest PTS.
+func (s *Segmenter) Close() (err error) {
+	if s.packLimit > 0 {
+		if _, err = s.FlushPES(); err != nil {
+			return
+		}
+	}
+	err = s.finish(s.last + s.step)
+	s.cues = nil
+	return
+}
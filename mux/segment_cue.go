@@ -0,0 +1,184 @@
+package mux
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// ErrCueNotSplice is returned by Segmenter.AddCue for a cue marking neither
+// the start nor the end of a break.
+var ErrCueNotSplice = errors.New("astits: cue marks no break")
+
+// CueKind is the HLS marker of a SegmentCue.
+type CueKind uint8
+
+// Cue kinds
+const (
+	CueOut CueKind = iota // EXT-X-CUE-OUT, a break starts
+	CueIn                 // EXT-X-CUE-IN, the break ends
+)
+
+// SegmentCue is an SCTE-35 splice point of the segment starting at the first
+// random access unit at or past it.
+type SegmentCue struct {
+	PTS      uint64        // 90 kHz, pts_adjustment applied
+	Offset   time.Duration // from PTS to the start of the segment, 0 on a keyframe
+	Duration time.Duration // of the break, on a CueOut; 0 when unknown
+	EventID  uint32        // splice_event_id or segmentation_event_id
+	Kind     CueKind
+}
+
+// Tag is the HLS tag of c: #EXT-X-CUE-OUT, with the break duration in seconds
+// when known, or #EXT-X-CUE-IN. A playlist writes it before the segment.
+func (c SegmentCue) Tag() string {
+	if c.Kind == CueIn {
+		return "#EXT-X-CUE-IN"
+	}
+	if c.Duration == 0 {
+		return "#EXT-X-CUE-OUT"
+	}
+	return "#EXT-X-CUE-OUT:DURATION=" + strconv.FormatFloat(c.Duration.Seconds(), 'f', -1, 64)
+}
+
+// AddCue bridges cue, a parsed SCTE-35 splice_info_section, to the segments:
+// a segment is cut at the first random access unit of the reference stream at
+// or past its splice point, which becomes a SegmentCue of the segment starting
+// there, the HLS tag going right before it. A splice_insert going out of
+// network is a CueOut of its break_duration, followed by a CueIn where the
+// break ends with auto_return, and one returning to the network a CueIn; a
+// time_signal is bridged through its segmentation descriptors of breaks,
+// advertisements and placement opportunities, start types being a CueOut of
+// the segmentation_duration and end types a CueIn. A splice_immediate point is
+// the next reference unit. A cancel drops the splice points of its event not
+// yet in a segment, and the splice points no segment starts at or past are
+// dropped on Close.
+func (s *Segmenter) AddCue(cue *psi.SpliceInfo) error {
+	switch {
+	case cue.SpliceInsert != nil:
+		si := cue.SpliceInsert
+		if si.Cancel {
+			s.cancelCues(si.SpliceEventID)
+			return nil
+		}
+		pts := s.last + s.step
+		if !si.SpliceImmediate {
+			if si.SpliceTime == nil || !si.SpliceTime.TimeSpecified {
+				return ErrCueNotSplice
+			}
+			pts = si.SpliceTime.PTSTime + cue.PTSAdjustment
+		}
+		c := SegmentCue{PTS: pts & ptsMask, EventID: si.SpliceEventID, Kind: CueIn}
+		if si.OutOfNetwork {
+			c.Kind = CueOut
+			if si.BreakDuration != nil {
+				c.Duration = time.Duration(si.BreakDuration.Duration) * time.Second / 90000
+			}
+		}
+		s.cues = append(s.cues, c)
+		if c.Kind == CueOut && si.BreakDuration != nil && si.BreakDuration.AutoReturn {
+			s.cues = append(s.cues, SegmentCue{PTS: (pts + si.BreakDuration.Duration) & ptsMask, EventID: si.SpliceEventID, Kind: CueIn})
+		}
+		return nil
+	case cue.TimeSignal != nil && cue.TimeSignal.TimeSpecified:
+		pts := (cue.TimeSignal.PTSTime + cue.PTSAdjustment) & ptsMask
+		added := false
+		for _, d := range cue.Descriptors {
+			sd := d.Segmentation
+			if sd == nil {
+				continue
+			}
+			if sd.Cancel {
+				s.cancelCues(sd.EventID)
+				added = true
+				continue
+			}
+			kind, ok := segmentationCueKind(sd.TypeID)
+			if !ok {
+				continue
+			}
+			c := SegmentCue{PTS: pts, EventID: sd.EventID, Kind: kind}
+			if kind == CueOut && sd.HasDuration {
+				c.Duration = time.Duration(sd.Duration) * time.Second / 90000
+			}
+			s.cues = append(s.cues, c)
+			added = true
+		}
+		if added {
+			return nil
+		}
+	}
+	return ErrCueNotSplice
+}
+
+// ScheduleSplice schedules cue as Muxer.ScheduleSplice does and bridges it to
+// the segments as AddCue does.
+func (s *Segmenter) ScheduleSplice(cue *psi.SpliceInfo, at uint64) error {
+	if err := s.Muxer.ScheduleSplice(cue, at); err != nil {
+		return err
+	}
+	if err := s.AddCue(cue); err != nil && !errors.Is(err, ErrCueNotSplice) {
+		return err
+	}
+	return nil
+}
+
+// segmentationCueKind maps a segmentation_type_id of SCTE 35 table 23 to its
+// marker: the break (0x22), advertisement, placement opportunity and ad block
+// (0x30 to 0x3b, 0x44 to 0x47) types, starts being even and ends odd.
+func segmentationCueKind(typeID uint8) (CueKind, bool) {
+	switch {
+	case typeID == 0x22 || typeID == 0x23,
+		typeID >= 0x30 && typeID <= 0x3b,
+		typeID >= 0x44 && typeID <= 0x47:
+	default:
+		return 0, false
+	}
+	if typeID&1 == 0 {
+		return CueOut, true
+	}
+	return CueIn, true
+}
+
+// cancelCues drops the pending splice points of an event.
+func (s *Segmenter) cancelCues(eventID uint32) {
+	kept := s.cues[:0]
+	for _, c := range s.cues {
+		if c.EventID != eventID {
+			kept = append(kept, c)
+		}
+	}
+	clear(s.cues[len(kept):])
+	s.cues = kept
+}
+
+// cueDue reports whether a pending splice point falls after the start of the
+// current segment and at or before pts.
+func (s *Segmenter) cueDue(pts uint64) bool {
+	for _, c := range s.cues {
+		if off := (c.PTS - s.start) & ptsMask; off > 0 && off < 1<<32 && (pts-c.PTS)&ptsMask < 1<<32 {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentCues takes the pending splice points at or before the start of the
+// current segment out to it.
+func (s *Segmenter) segmentCues() (cues []SegmentCue) {
+	kept := s.cues[:0]
+	for _, c := range s.cues {
+		late := (s.start - c.PTS) & ptsMask
+		if late >= 1<<32 {
+			kept = append(kept, c)
+			continue
+		}
+		c.Offset = time.Duration(late) * time.Second / 90000
+		cues = append(cues, c)
+	}
+	clear(s.cues[len(kept):])
+	s.cues = kept
+	return
+}
@@ -0,0 +1,153 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+type segmentBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *segmentBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSegmenter(t *testing.T) {
+	var bufs []*segmentBuffer
+	s := NewSegmenter(context.Background(), 2*time.Second, func(index int) (io.WriteCloser, error) {
+		require.Equal(t, len(bufs), index)
+		bufs = append(bufs, &segmentBuffer{})
+		return bufs[index], nil
+	})
+	require.NoError(t, s.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	require.NoError(t, s.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	s.SetPCRPID(0x100)
+
+	// 25 fps with a keyframe each second, 4.2 s of it
+	const frame = 3600
+	for i := range uint64(105) {
+		pts := ts.NewClockReference(90000+i*frame, 0)
+		_, err := s.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{RandomAccessIndicator: i%25 == 0},
+			PES:             &pes.Data{Data: []byte{byte(i)}, Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: pts, PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}}},
+		})
+		require.NoError(t, err)
+		_, err = s.WriteData(&Data{
+			PID: 0x101,
+			PES: &pes.Data{Data: []byte{byte(i)}, Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: pts, PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}}},
+		})
+		require.NoError(t, err)
+	}
+	require.Len(t, s.Segments(), 2)
+	require.NoError(t, s.Close())
+
+	// cut at the keyframes of 2 s and 4 s
+	require.Len(t, bufs, 3)
+	assert.Equal(t, []Segment{
+		{Index: 0, PTS: 90000, Duration: 2 * time.Second, Size: int64(bufs[0].Len())},
+		{Index: 1, PTS: 90000 + 50*frame, Duration: 2 * time.Second, Size: int64(bufs[1].Len())},
+		{Index: 2, PTS: 90000 + 100*frame, Duration: 5 * frame * time.Second / 90000, Size: int64(bufs[2].Len())},
+	}, s.Segments())
+
+	for i, b := range bufs {
+		assert.True(t, b.closed)
+		bs := b.Bytes()
+		require.Zero(t, len(bs)%ts.PacketSize)
+		// PAT, PMT, then the keyframe
+		for j, pid := range []uint16{ts.PIDPAT, pmtStartPID, 0x100} {
+			var h ts.PacketHeader
+			_, err := h.Parse(bs[j*ts.PacketSize:])
+			require.NoError(t, err)
+			assert.Equal(t, pid, h.PID, "segment %d", i)
+		}
+		// random_access_indicator
+		assert.NotZero(t, bs[2*ts.PacketSize+5]&0x40, "segment %d", i)
+	}
+}
+
+func TestSegmenterCues(t *testing.T) {
+	s := NewSegmenter(context.Background(), 2*time.Second, func(int) (io.WriteCloser, error) {
+		return &segmentBuffer{}, nil
+	})
+	require.NoError(t, s.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	s.SetPCRPID(0x100)
+
+	const frame = 3600
+	at := func(i uint64) uint64 { return 90000 + i*frame }
+
+	// a 2 s break out at the keyframe of 3 s, returning by itself
+	require.NoError(t, s.AddCue(&psi.SpliceInfo{
+		PTSAdjustment: 1000,
+		SpliceInsert: &psi.SpliceInsert{
+			SpliceEventID: 1,
+			OutOfNetwork:  true,
+			SpliceTime:    &psi.SpliceTime{TimeSpecified: true, PTSTime: at(75) - 1000},
+			BreakDuration: &psi.BreakDuration{AutoReturn: true, Duration: 2 * 90000},
+		},
+	}))
+	// cancelled before its splice point at 3.5 s
+	require.NoError(t, s.AddCue(&psi.SpliceInfo{SpliceInsert: &psi.SpliceInsert{
+		SpliceEventID: 3,
+		OutOfNetwork:  true,
+		SpliceTime:    &psi.SpliceTime{TimeSpecified: true, PTSTime: at(88)},
+	}}))
+	require.NoError(t, s.AddCue(&psi.SpliceInfo{SpliceInsert: &psi.SpliceInsert{SpliceEventID: 3, Cancel: true}}))
+	// a placement opportunity at 5.6 s, between keyframes
+	require.NoError(t, s.AddCue(&psi.SpliceInfo{
+		TimeSignal: &psi.SpliceTime{TimeSpecified: true, PTSTime: at(140)},
+		Descriptors: []psi.SpliceDescriptor{{Segmentation: &psi.SegmentationDescriptor{
+			EventID:     2,
+			TypeID:      0x34,
+			HasDuration: true,
+			Duration:    30 * 90000,
+		}}},
+	}))
+	require.ErrorIs(t, s.AddCue(&psi.SpliceInfo{CommandType: psi.SpliceCommandTypeSpliceNull}), ErrCueNotSplice)
+
+	for i := range uint64(160) {
+		_, err := s.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{RandomAccessIndicator: i%25 == 0},
+			PES:             &pes.Data{Data: []byte{byte(i)}, Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(at(i), 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}}},
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close())
+
+	// cut at the target of 2 s, then at the keyframes at or past each splice
+	// point
+	type cut struct {
+		PTS      uint64
+		Duration time.Duration
+		Cues     []SegmentCue
+	}
+	var cuts []cut
+	for _, sg := range s.Segments() {
+		cuts = append(cuts, cut{sg.PTS, sg.Duration, sg.Cues})
+	}
+	assert.Equal(t, []cut{
+		{PTS: at(0), Duration: 2 * time.Second},
+		{PTS: at(50), Duration: time.Second},
+		{PTS: at(75), Duration: 2 * time.Second, Cues: []SegmentCue{{PTS: at(75), Duration: 2 * time.Second, EventID: 1, Kind: CueOut}}},
+		{PTS: at(125), Duration: time.Second, Cues: []SegmentCue{{PTS: at(125), EventID: 1, Kind: CueIn}}},
+		{PTS: at(150), Duration: 10 * frame * time.Second / 90000, Cues: []SegmentCue{{PTS: at(140), Offset: 10 * frame * time.Second / 90000, Duration: 30 * time.Second, EventID: 2, Kind: CueOut}}},
+	}, cuts)
+
+	assert.Equal(t, "#EXT-X-CUE-OUT:DURATION=2", s.Segments()[2].Cues[0].Tag())
+	assert.Equal(t, "#EXT-X-CUE-IN", s.Segments()[3].Cues[0].Tag())
+	assert.Equal(t, "#EXT-X-CUE-OUT", SegmentCue{Kind: CueOut}.Tag())
+}
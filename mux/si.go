@@ -0,0 +1,355 @@
+package mux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+var (
+	ErrTableIDInvalid = errors.New("astits: table id invalid")
+	ErrTableNotFound  = errors.New("astits: table not found")
+	ErrTooManyEvents  = errors.New("astits: too many events")
+)
+
+// maxEITEventBytes is how many bytes of event loop fit a section next to the
+// syntax header, the EIT fixed fields and CRC32.
+const maxEITEventBytes = psi.MaxSectionLength - 5 - 6 - 4
+
+// siTable is an SI table retransmitted by WriteTables after PAT and PMT, its
+// sections serialized and packetized once per update.
+type siTable struct {
+	pid     uint16
+	tableID psi.TableID
+	ext     uint16
+	version wrappingCounter
+	data    []byte // serialized sections
+	packets []byte
+}
+
+// setSITable (re)places the table identified by pid, tableID and ext with
+// sections, numbering them. A table already set keeps its version when its
+// sections are unchanged, and has it bumped otherwise.
+func (m *Muxer) setSITable(pid uint16, tableID psi.TableID, ext uint16, sections []psi.SectionSyntaxData) (err error) {
+	nt := siTable{pid: pid, tableID: tableID, ext: ext, version: newWrappingCounter(0b11111)}
+	t := m.siTable(pid, tableID, ext)
+	if t != nil {
+		if m.sectionData, err = siSections(tableID, ext, uint8(t.version.value), sections).Append(m.sectionData[:0]); err != nil {
+			return
+		}
+		if bytes.Equal(m.sectionData, t.data) {
+			return
+		}
+		nt.version, nt.data, nt.packets = t.version, t.data[:0], t.packets[:0]
+	}
+	if m.sectionData, err = siSections(tableID, ext, uint8(nt.version.inc()), sections).Append(m.sectionData[:0]); err != nil {
+		return
+	}
+	nt.data = append(nt.data, m.sectionData...)
+
+	for start, l := 0, len(m.sectionData); start < l; start += packetMaxPayload {
+		pkt := ts.Packet{
+			Header: ts.PacketHeader{
+				HasPayload:                true,
+				PayloadUnitStartIndicator: start == 0,
+				PID:                       pid,
+			},
+			Payload: m.sectionData[start:min(start+packetMaxPayload, l)],
+		}
+		if _, err = pkt.Put(m.pkt); err != nil {
+			return
+		}
+		nt.packets = append(nt.packets, m.pkt...)
+	}
+
+	if t != nil {
+		*t = nt
+		return
+	}
+	m.siTables = append(m.siTables, nt)
+	if !m.siCC.Has(pid) {
+		*m.siCC.GetOrAdd(pid) = newWrappingCounter(0b1111)
+	}
+	return
+}
+
+// siSections numbers sections into the table tableID of the given version.
+func siSections(tableID psi.TableID, ext uint16, version uint8, sections []psi.SectionSyntaxData) *psi.Data {
+	d := &psi.Data{Sections: make([]psi.Section, 0, len(sections))}
+	for si, s := range sections {
+		d.Sections = append(d.Sections, psi.Section{
+			Header: psi.SectionHeader{
+				SectionSyntaxIndicator: true,
+				TableID:                tableID,
+			},
+			Syntax: &psi.SectionSyntax{
+				Data: s,
+				Header: psi.SectionSyntaxHeader{
+					CurrentNextIndicator: true,
+					SectionNumber:        uint8(si),
+					LastSectionNumber:    uint8(len(sections) - 1),
+					TableIDExtension:     ext,
+					VersionNumber:        version,
+				},
+			},
+		})
+	}
+	return d
+}
+
+func (m *Muxer) siTable(pid uint16, tableID psi.TableID, ext uint16) *siTable {
+	for i := range m.siTables {
+		if t := &m.siTables[i]; t.pid == pid && t.tableID == tableID && t.ext == ext {
+			return t
+		}
+	}
+	return nil
+}
+
+func (m *Muxer) removeSITable(pid uint16, tableID psi.TableID, ext uint16) error {
+	for i := range m.siTables {
+		if t := &m.siTables[i]; t.pid == pid && t.tableID == tableID && t.ext == ext {
+			m.siTables = append(m.siTables[:i], m.siTables[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTableNotFound
+}
+
+// writeSITables writes the SI tables, patching the continuity counter of
+// their PID in place.
+func (m *Muxer) writeSITables() (bytesWritten int, err error) {
+	var n int
+	for i := range m.siTables {
+		t := &m.siTables[i]
+		cc := m.siCC.Get(t.pid)
+		for off := 0; off < len(t.packets); off += ts.PacketSize {
+			ts.SetContinuityCounter(t.packets[off:], uint8(cc.inc()))
+		}
+		if n, err = m.w.Write(t.packets); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	return
+}
+
+// SetEIT sets the event information table tableID of service d.ServiceID,
+// emitted on ts.PIDEIT with every WriteTables. A present/following table
+// (0x4e, 0x4f) takes the present event then the following one, each in its own
+// section; a schedule table (0x50-0x6f) packs its events in order into as many
+// sections as needed, grouped in segments of eight. Mapping events to the
+// three-hour segments of EN 300 468 §5.1.4 is left to the caller. A call
+// changing the table bumps its version.
+func (m *Muxer) SetEIT(tableID psi.TableID, d *psi.EIT) error {
+	if tableID < psi.TableIDEITStart || tableID > psi.TableIDEITEnd {
+		return ErrTableIDInvalid
+	}
+	for j := range d.Events {
+		if err := descriptor.CheckLength(d.Events[j].Descriptors); err != nil {
+			return err
+		}
+	}
+
+	var sections []psi.SectionSyntaxData
+	if tableID <= psi.TableIDEITStart+1 {
+		if len(d.Events) > 2 {
+			return fmt.Errorf("astits: %d present/following events: %w", len(d.Events), ErrTooManyEvents)
+		}
+		for j := range 2 {
+			s := eitSection(d, tableID)
+			if j < len(d.Events) {
+				s.Events = d.Events[j : j+1]
+			}
+			s.SegmentLastSectionNumber = 1
+			s.LastTableID = tableID
+			sections = append(sections, s)
+		}
+	} else {
+		s := eitSection(d, tableID)
+		n := 0
+		for j := range d.Events {
+			l := 12 + descriptor.CalcLength(d.Events[j].Descriptors) // event_id(2) + start_time(5) + duration(3) + 2 flag/length bytes
+			if l > maxEITEventBytes {
+				return fmt.Errorf("astits: event %d: %w", d.Events[j].EventID, psi.ErrSectionOverflow)
+			}
+			if n+l > maxEITEventBytes {
+				sections = append(sections, s)
+				s, n = eitSection(d, tableID), 0
+			}
+			s.Events = append(s.Events, d.Events[j])
+			n += l
+		}
+		sections = append(sections, s)
+		if len(sections) > 256 {
+			return fmt.Errorf("astits: %d events: %w", len(d.Events), ErrTooManyEvents)
+		}
+		for si, s := range sections {
+			s.(*psi.EIT).SegmentLastSectionNumber = uint8(min(si|7, len(sections)-1))
+		}
+	}
+	return m.setSITable(ts.PIDEIT, tableID, d.ServiceID, sections)
+}
+
+// RemoveEIT stops emitting the event information table tableID of serviceID.
+func (m *Muxer) RemoveEIT(tableID psi.TableID, serviceID uint16) error {
+	return m.removeSITable(ts.PIDEIT, tableID, serviceID)
+}
+
+// eitSection returns a section of d without events. last_table_id defaults to
+// tableID.
+func eitSection(d *psi.EIT, tableID psi.TableID) *psi.EIT {
+	s := &psi.EIT{
+		OriginalNetworkID: d.OriginalNetworkID,
+		ServiceID:         d.ServiceID,
+		TransportStreamID: d.TransportStreamID,
+		LastTableID:       d.LastTableID,
+	}
+	if s.LastTableID < tableID {
+		s.LastTableID = tableID
+	}
+	return s
+}
+
+// maxNITLoopBytes is how many bytes of descriptor and transport stream loops
+// fit a NIT section next to the syntax header, both loop lengths and CRC32.
+const maxNITLoopBytes = psi.MaxSectionLength - 5 - 2 - 2 - 4
+
+// SetNIT sets the network information table of the actual network
+// d.NetworkID, emitted on ts.PIDNIT with every WriteTables and announced in the
+// PAT as program 0. The network descriptors go in the first section, the
+// transport streams — with their delivery system descriptors — fill as many
+// sections as needed. A call changing the table bumps its version.
+func (m *Muxer) SetNIT(d *psi.NIT) error {
+	if err := descriptor.CheckLength(d.NetworkDescriptors); err != nil {
+		return err
+	}
+	s := &psi.NIT{NetworkID: d.NetworkID, NetworkDescriptors: d.NetworkDescriptors}
+	n := descriptor.CalcLength(d.NetworkDescriptors)
+	var sections []psi.SectionSyntaxData
+	for j := range d.TransportStreams {
+		if err := descriptor.CheckLength(d.TransportStreams[j].TransportDescriptors); err != nil {
+			return err
+		}
+		l := 6 + descriptor.CalcLength(d.TransportStreams[j].TransportDescriptors) // TSID + ONID + transport_descriptors_length
+		if n+l > maxNITLoopBytes && len(s.TransportStreams) > 0 {
+			sections = append(sections, s)
+			s, n = &psi.NIT{NetworkID: d.NetworkID}, 0
+		}
+		s.TransportStreams = append(s.TransportStreams, d.TransportStreams[j])
+		n += l
+	}
+	sections = append(sections, s)
+	if len(sections) > 256 {
+		return fmt.Errorf("astits: %d transport streams: %w", len(d.TransportStreams), psi.ErrSectionOverflow)
+	}
+
+	if t := m.siTableOn(ts.PIDNIT); t != nil && t.ext != d.NetworkID {
+		if err := m.removeSITable(ts.PIDNIT, t.tableID, t.ext); err != nil {
+			return err
+		}
+	}
+	if err := m.setSITable(ts.PIDNIT, psi.TableIDNITVariant1, d.NetworkID, sections); err != nil {
+		return err
+	}
+	if pn := m.pm.Get(ts.PIDNIT); pn == nil || *pn != 0 {
+		m.pm.Set(ts.PIDNIT, 0)
+		m.pmUpdated = true
+	}
+	return nil
+}
+
+// RemoveNIT stops emitting the network information table.
+func (m *Muxer) RemoveNIT() error {
+	t := m.siTableOn(ts.PIDNIT)
+	if t == nil {
+		return ErrTableNotFound
+	}
+	if err := m.removeSITable(ts.PIDNIT, t.tableID, t.ext); err != nil {
+		return err
+	}
+	m.pm.Remove(ts.PIDNIT)
+	m.pmUpdated = true
+	return nil
+}
+
+// SetCAT sets the conditional access table, emitted on ts.PIDCAT with every
+// WriteTables: ds are its CA descriptors, one per CA system with the PID of
+// its EMM stream. A call changing the table bumps its version.
+func (m *Muxer) SetCAT(ds []descriptor.Descriptor) error {
+	if err := descriptor.CheckLength(ds); err != nil {
+		return err
+	}
+	return m.setSITable(ts.PIDCAT, psi.TableIDCAT, catExtension, []psi.SectionSyntaxData{&psi.CAT{Descriptors: ds}})
+}
+
+// RemoveCAT stops emitting the conditional access table.
+func (m *Muxer) RemoveCAT() error {
+	return m.removeSITable(ts.PIDCAT, psi.TableIDCAT, catExtension)
+}
+
+// maxSDTServiceBytes is how many bytes of service loop fit an SDT section next
+// to the syntax header, the original_network_id and CRC32.
+const maxSDTServiceBytes = psi.MaxSectionLength - 5 - 3 - 4
+
+// SetSDT sets the service description table of the actual transport stream
+// d.TransportStreamID, emitted on ts.PIDSDT with every WriteTables; its
+// services fill as many sections as needed. A call changing the table bumps
+// its version.
+func (m *Muxer) SetSDT(d *psi.SDT) error {
+	s := &psi.SDT{OriginalNetworkID: d.OriginalNetworkID, TransportStreamID: d.TransportStreamID}
+	n := 0
+	var sections []psi.SectionSyntaxData
+	for j := range d.Services {
+		if err := descriptor.CheckLength(d.Services[j].Descriptors); err != nil {
+			return err
+		}
+		l := 5 + descriptor.CalcLength(d.Services[j].Descriptors) // service_id + 2 flag/length bytes + descriptors_loop_length_lo
+		if l > maxSDTServiceBytes {
+			return fmt.Errorf("astits: service %d: %w", d.Services[j].ServiceID, psi.ErrSectionOverflow)
+		}
+		if n+l > maxSDTServiceBytes {
+			sections = append(sections, s)
+			s, n = &psi.SDT{OriginalNetworkID: d.OriginalNetworkID, TransportStreamID: d.TransportStreamID}, 0
+		}
+		s.Services = append(s.Services, d.Services[j])
+		n += l
+	}
+	sections = append(sections, s)
+	if len(sections) > 256 {
+		return fmt.Errorf("astits: %d services: %w", len(d.Services), psi.ErrSectionOverflow)
+	}
+
+	if t := m.siTableOn(ts.PIDSDT); t != nil && t.ext != d.TransportStreamID {
+		if err := m.removeSITable(ts.PIDSDT, t.tableID, t.ext); err != nil {
+			return err
+		}
+	}
+	return m.setSITable(ts.PIDSDT, psi.TableIDSDTVariant1, d.TransportStreamID, sections)
+}
+
+// RemoveSDT stops emitting the service description table.
+func (m *Muxer) RemoveSDT() error {
+	t := m.siTableOn(ts.PIDSDT)
+	if t == nil {
+		return ErrTableNotFound
+	}
+	return m.removeSITable(ts.PIDSDT, t.tableID, t.ext)
+}
+
+// catExtension fills the reserved table_id_extension of the CAT.
+const catExtension = 0xffff
+
+// siTableOn returns the first SI table emitted on pid.
+func (m *Muxer) siTableOn(pid uint16) *siTable {
+	for i := range m.siTables {
+		if m.siTables[i].pid == pid {
+			return &m.siTables[i]
+		}
+	}
+	return nil
+}
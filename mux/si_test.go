@@ -0,0 +1,237 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxer_SetEIT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	start := time.Date(2026, time.October, 16, 20, 0, 0, 0, time.UTC)
+	event := func(id uint16) psi.EITEvent {
+		return psi.EITEvent{
+			EventID:       id,
+			StartTime:     start.Add(time.Duration(id) * 30 * time.Minute),
+			Duration:      30 * time.Minute,
+			RunningStatus: psi.RunningStatusNotRunning,
+			Descriptors: []descriptor.Descriptor{&descriptor.UserDefined{
+				Header: descriptor.Header{Tag: descriptor.Tag(0x80), Length: 30},
+				Data:   bytes.Repeat([]byte{byte(id)}, 30),
+			}},
+		}
+	}
+
+	pf := &psi.EIT{ServiceID: 1, TransportStreamID: 7, OriginalNetworkID: 2, Events: []psi.EITEvent{event(0), event(1)}}
+	schedule := &psi.EIT{ServiceID: 2, TransportStreamID: 7, OriginalNetworkID: 2}
+	for id := range uint16(100) {
+		schedule.Events = append(schedule.Events, event(id))
+	}
+
+	assert.Equal(t, ErrTableIDInvalid, m.SetEIT(psi.TableIDTDT, pf))
+	assert.ErrorIs(t, m.SetEIT(psi.TableIDEITStart, schedule), ErrTooManyEvents)
+	require.NoError(t, m.SetEIT(psi.TableIDEITStart, pf))
+	require.NoError(t, m.SetEIT(0x50, schedule))
+	require.Len(t, m.siTables, 2)
+	assert.Greater(t, len(m.siTables[1].packets), 4*ts.PacketSize)
+
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()),
+		demux.WithPacketSize(ts.PacketSize), demux.WithDVBTables(), demux.WithTableAssembly())
+	defer dmx.Close()
+	got := map[uint16]*psi.EIT{}
+	for {
+		ev, derr := dmx.Next()
+		if errors.Is(derr, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, derr)
+		if ev != demux.EventEIT {
+			continue
+		}
+		pid, data := dmx.Section()
+		assert.Equal(t, ts.PIDEIT, pid)
+		eit := data.(*psi.EIT)
+		got[eit.ServiceID] = eit
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, pf.Events, got[1].Events)
+	assert.Equal(t, psi.TableIDEITStart, got[1].LastTableID)
+	assert.Equal(t, schedule.Events, got[2].Events)
+
+	require.NoError(t, m.RemoveEIT(psi.TableIDEITStart, 1))
+	assert.Equal(t, ErrTableNotFound, m.RemoveEIT(psi.TableIDEITStart, 1))
+	require.Len(t, m.siTables, 1)
+}
+
+func TestMuxer_SetNIT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	nit := &psi.NIT{
+		NetworkID: 0x3001,
+		NetworkDescriptors: []descriptor.Descriptor{&descriptor.NetworkName{
+			Header: descriptor.Header{Tag: descriptor.TagNetworkName, Length: 7},
+			Name:   []byte("network"),
+		}},
+	}
+	// 17 bytes per transport stream: several sections
+	for tsid := range uint16(150) {
+		nit.TransportStreams = append(nit.TransportStreams, psi.NITTransportStream{
+			TransportStreamID: tsid,
+			OriginalNetworkID: 0x3001,
+			TransportDescriptors: []descriptor.Descriptor{&descriptor.CableDeliverySystem{
+				Header:     descriptor.Header{Tag: descriptor.TagCableDeliverySystem, Length: 11},
+				Frequency:  0x03460000 + uint32(tsid),
+				SymbolRate: 0x0069000,
+				FECOuter:   2,
+				Modulation: 3,
+				FECInner:   0xf,
+			}},
+		})
+	}
+	require.NoError(t, m.SetNIT(nit))
+	assert.Greater(t, len(m.siTables[0].packets), 10*ts.PacketSize)
+
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()),
+		demux.WithPacketSize(ts.PacketSize), demux.WithDVBTables(), demux.WithTableAssembly())
+	defer dmx.Close()
+	var got *psi.NIT
+	for {
+		ev, derr := dmx.Next()
+		if errors.Is(derr, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, derr)
+		switch ev {
+		case demux.EventPAT:
+			assert.Contains(t, dmx.PAT().Programs, psi.PATProgram{ProgramMapID: ts.PIDNIT})
+		case demux.EventNIT:
+			pid, data := dmx.Section()
+			assert.Equal(t, ts.PIDNIT, pid)
+			got = data.(*psi.NIT)
+		}
+	}
+	assert.Equal(t, nit, got)
+
+	require.NoError(t, m.RemoveNIT())
+	assert.Equal(t, ErrTableNotFound, m.RemoveNIT())
+	assert.False(t, m.pm.Has(ts.PIDNIT))
+}
+
+func TestMuxer_SetCAT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	ca := func(systemID, pid uint16) descriptor.Descriptor {
+		return &descriptor.CA{Header: descriptor.Header{Tag: descriptor.TagCA, Length: 4}, SystemID: systemID, PID: pid}
+	}
+	first := []descriptor.Descriptor{ca(0x0100, 0x200)}
+	second := []descriptor.Descriptor{ca(0x0100, 0x200), ca(0x0b00, 0x201)}
+	require.NoError(t, m.SetCAT(first))
+	_, err := m.WriteTables()
+	require.NoError(t, err)
+	require.NoError(t, m.SetCAT(second))
+	_, err = m.WriteTables()
+	require.NoError(t, err)
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()), demux.WithDVBTables(), demux.WithVersionTracking())
+	defer dmx.Close()
+	var cats [][]descriptor.Descriptor
+	var versions []uint8
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventVersionChange:
+			if c := dmx.VersionChange(); c.TableID == psi.TableIDCAT {
+				versions = append(versions, c.Current)
+			}
+		case demux.EventCAT:
+			pid, data := dmx.Section()
+			assert.Equal(t, ts.PIDCAT, pid)
+			cats = append(cats, data.(*psi.CAT).Descriptors)
+		}
+	}
+	assert.Equal(t, [][]descriptor.Descriptor{first, second}, cats)
+	assert.Equal(t, []uint8{0, 1}, versions)
+
+	require.NoError(t, m.RemoveCAT())
+	assert.Equal(t, ErrTableNotFound, m.RemoveCAT())
+}
+
+func TestMuxer_SetSDT(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	sdt := func(name string) *psi.SDT {
+		return &psi.SDT{
+			OriginalNetworkID: 0x3001,
+			TransportStreamID: 1,
+			Services: []psi.SDTService{{
+				ServiceID:     1,
+				RunningStatus: psi.RunningStatusRunning,
+				Descriptors: []descriptor.Descriptor{&descriptor.Service{
+					Header:   descriptor.Header{Tag: descriptor.TagService, Length: uint8(3 + len(name))},
+					Type:     descriptor.ServiceTypeDigitalTelevisionService,
+					Name:     []byte(name),
+					Provider: []byte{},
+				}},
+			}},
+		}
+	}
+	// an unchanged table keeps its version
+	for _, name := range []string{"one", "one", "two"} {
+		require.NoError(t, m.SetSDT(sdt(name)))
+		_, err := m.WriteTables()
+		require.NoError(t, err)
+	}
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()), demux.WithDVBTables(), demux.WithVersionTracking())
+	defer dmx.Close()
+	var sdts []*psi.SDT
+	var versions []uint8
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventVersionChange:
+			if c := dmx.VersionChange(); c.TableID == psi.TableIDSDTVariant1 {
+				versions = append(versions, c.Current)
+			}
+		case demux.EventSDT:
+			pid, data := dmx.Section()
+			assert.Equal(t, ts.PIDSDT, pid)
+			sdts = append(sdts, data.(*psi.SDT))
+		}
+	}
+	// the repeat of version 0 is the same table to the demuxer
+	assert.Equal(t, []*psi.SDT{sdt("one"), sdt("two")}, sdts)
+	assert.Equal(t, []uint8{0, 1}, versions)
+
+	require.NoError(t, m.RemoveSDT())
+	assert.Equal(t, ErrTableNotFound, m.RemoveSDT())
+}
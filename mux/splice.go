@@ -0,0 +1,81 @@
+package mux
+
+import (
+	"time"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+)
+
+// DefaultSplicePreroll is the time a scheduled cue goes out ahead of its
+// splice point: the 4 s minimum of SCTE 67.
+const DefaultSplicePreroll = 4 * time.Second
+
+// ptsMask keeps the 33 bits of a PTS.
+const ptsMask = 1<<33 - 1
+
+// WithSpliceCues makes pid the cue PID of ScheduleSplice, an elementary
+// stream of psi.StreamTypeSCTE35 to add, with cues written preroll ahead of
+// their splice point (DefaultSplicePreroll when 0).
+func WithSpliceCues(pid uint16, preroll time.Duration) func(*Muxer) {
+	return func(m *Muxer) {
+		if preroll == 0 {
+			preroll = DefaultSplicePreroll
+		}
+		m.cuePID = pid
+		m.cuePreroll = uint64(preroll * 90000 / time.Second)
+	}
+}
+
+// scheduledCue is a cue waiting for its preroll point.
+type scheduledCue struct {
+	cue *psi.SpliceInfo
+	at  uint64 // 90 kHz
+}
+
+// ScheduleSplice sets the splice time of cue, a splice_insert or time_signal,
+// to at (90 kHz PTS, less the cue's PTSAdjustment) and writes it on the cue
+// PID (WithSpliceCues) right before the first unit of a video stream whose
+// PTS reaches at less the preroll: the cue goes out on a PES boundary of the
+// video. A cue scheduled too late goes out before the next video unit.
+func (m *Muxer) ScheduleSplice(cue *psi.SpliceInfo, at uint64) error {
+	if !m.esContexts.Has(m.cuePID) {
+		return ErrPIDNotFound
+	}
+	pts := (at - cue.PTSAdjustment) & ptsMask
+	switch {
+	case cue.SpliceInsert != nil && !cue.SpliceInsert.SpliceImmediate:
+		cue.SpliceInsert.SpliceTime = &psi.SpliceTime{TimeSpecified: true, PTSTime: pts}
+	case cue.TimeSignal != nil:
+		cue.TimeSignal.TimeSpecified, cue.TimeSignal.PTSTime = true, pts
+	}
+	m.cues = append(m.cues, scheduledCue{cue: cue, at: at & ptsMask})
+	return nil
+}
+
+// writeDueCues writes the scheduled cues whose preroll point the PTS of a
+// video unit has reached.
+func (m *Muxer) writeDueCues(d *Data) (bytesWritten int, err error) {
+	h := d.PES.Header.OptionalHeader
+	if h == nil || h.PTSDTSIndicator&pes.PTSDTSIndicatorOnlyPTS == 0 {
+		return
+	}
+	pts := h.PTS.Base()
+	kept := m.cues[:0]
+	for _, c := range m.cues {
+		// due when the PTS is at or past the preroll point, within half the
+		// 33-bit wrap
+		if (pts-(c.at-m.cuePreroll))&ptsMask >= 1<<32 {
+			kept = append(kept, c)
+			continue
+		}
+		var n int
+		if n, err = m.WriteSection(m.cuePID, c.cue.Data()); err != nil {
+			return
+		}
+		bytesWritten += n
+	}
+	clear(m.cues[len(kept):])
+	m.cues = kept
+	return
+}
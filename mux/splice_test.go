@@ -0,0 +1,75 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerScheduleSplice(t *testing.T) {
+	const cuePID = 0x1f0
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithSpliceCues(cuePID, 2*time.Second))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio}))
+	m.SetPCRPID(0x100)
+
+	cue := psi.NewSpliceInsert(7, 0, 30*90000, true)
+	assert.Equal(t, ErrPIDNotFound, m.ScheduleSplice(cue, 10*90000))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: cuePID, StreamType: psi.StreamTypeSCTE35}))
+	require.NoError(t, m.ScheduleSplice(cue, 10*90000))
+	assert.Equal(t, uint64(10*90000), cue.SpliceInsert.SpliceTime.PTSTime)
+
+	// Units every second: the cue goes out before the video unit at 8 s
+	var pids []uint16
+	for sec := range uint64(10) {
+		for _, pid := range []uint16{0x101, 0x100} {
+			buf.Reset()
+			_, err := m.WriteData(&Data{
+				PID: pid,
+				PES: &pes.Data{
+					Data:   []byte{byte(sec)},
+					Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(sec*90000, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+				},
+			})
+			require.NoError(t, err)
+			for off := 0; off < buf.Len(); off += ts.PacketSize {
+				pkt := buf.Bytes()[off:]
+				pid := uint16(pkt[1]&0x1f)<<8 | uint16(pkt[2])
+				if pid == cuePID {
+					d, err := psi.Parse(pkt[ts.HeaderSize:ts.PacketSize])
+					require.NoError(t, err)
+					assert.Equal(t, cue, d.Sections[0].Syntax.Data)
+				}
+				if pid >= 0x100 && pid != pmtStartPID {
+					pids = append(pids, pid)
+				}
+			}
+		}
+	}
+	want := make([]uint16, 0, 21)
+	for sec := range 10 {
+		want = append(want, 0x101)
+		if sec == 8 {
+			want = append(want, cuePID)
+		}
+		want = append(want, 0x100)
+	}
+	assert.Equal(t, want, pids)
+	assert.Empty(t, m.cues)
+}
+
+func TestWithSpliceCuesPreroll(t *testing.T) {
+	m := New(context.Background(), &bytes.Buffer{}, WithSpliceCues(0x1f0, 0))
+	assert.Equal(t, uint64(360000), m.cuePreroll)
+	m = New(context.Background(), &bytes.Buffer{}, WithSpliceCues(0x1f0, 1500*time.Millisecond))
+	assert.Equal(t, uint64(135000), m.cuePreroll)
+}
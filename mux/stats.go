@@ -0,0 +1,133 @@
+package mux
+
+import (
+	"io"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// Stats is what a muxer has written, for end-to-end monitoring against the
+// demuxer's GetStats.
+type Stats struct {
+	// PIDs holds the bytes and packets written per PID.
+	PIDs map[uint16]PIDStats
+
+	Bytes   uint64
+	Packets uint64
+
+	// PSIBytes are those of the table PIDs (PAT, PMT, SI, CAT), PESBytes
+	// those of the elementary streams and StuffingBytes the null packets; the
+	// ratios are their shares of Bytes.
+	PSIBytes      uint64
+	PESBytes      uint64
+	StuffingBytes uint64
+	PSIRatio      float64
+	PESRatio      float64
+	StuffingRatio float64
+
+	// Rate is the mux rate in bits per second over the PCR timeline: the bytes
+	// between the first and the last PCR of the PCR PID by the time between
+	// them. 0 before two PCRs.
+	Rate uint64
+
+	// PCRs is the number of PCRs written on the PCR PID, MaxPCRInterval and
+	// MeanPCRInterval the achieved time between two of them.
+	PCRs            uint64
+	MaxPCRInterval  time.Duration
+	MeanPCRInterval time.Duration
+}
+
+// PIDStats is what a muxer has written on a PID.
+type PIDStats struct {
+	Bytes   uint64
+	Packets uint64
+}
+
+// WithStats makes the muxer count what it writes, for Stats.
+func WithStats() func(*Muxer) {
+	return func(m *Muxer) {
+		m.stats = true
+	}
+}
+
+// statsWriter counts the packets written through it per PID. Writes need not
+// be whole packets, but a packet's first one holds at least its header.
+type statsWriter struct {
+	w    io.Writer
+	pos  uint64
+	pids pidmap.Map[PIDStats]
+}
+
+func (c *statsWriter) Write(p []byte) (n int, err error) {
+	for off := (ts.PacketSize - int(c.pos%ts.PacketSize)) % ts.PacketSize; off+2 < len(p); off += ts.PacketSize {
+		s := c.pids.GetOrAdd(uint16(p[off+1]&0x1f)<<8 | uint16(p[off+2]))
+		s.Bytes += ts.PacketSize
+		s.Packets++
+	}
+	n, err = c.w.Write(p)
+	c.pos += uint64(n)
+	return
+}
+
+// initStats puts the stats writer under the muxer output, over the M2TS one.
+func (m *Muxer) initStats() {
+	m.sw = statsWriter{w: m.w}
+	m.w = &m.sw
+}
+
+// statPCR records a PCR written on the PCR PID, c in 27 MHz.
+func (m *Muxer) statPCR(c uint64) {
+	if m.statPCRs > 0 {
+		d := pcrDelta(c, m.statLastPCR)
+		m.statPCRSpan += d
+		m.statMaxPCR = max(m.statMaxPCR, d)
+	} else {
+		m.statFirstPCRPos = m.sw.pos
+	}
+	m.statLastPCR, m.statLastPCRPos = c, m.sw.pos
+	m.statPCRs++
+}
+
+// Stats returns what the muxer has written so far; zero without WithStats.
+func (m *Muxer) Stats() (s Stats) {
+	if !m.stats {
+		return
+	}
+	s.PIDs = make(map[uint16]PIDStats, len(m.sw.pids.Keys))
+	for i, pid := range m.sw.pids.Keys {
+		ps := m.sw.pids.Vals[i]
+		s.PIDs[pid] = ps
+		s.Bytes += ps.Bytes
+		s.Packets += ps.Packets
+		switch {
+		case pid == ts.PIDNull:
+			s.StuffingBytes += ps.Bytes
+		case m.esContexts.Has(pid):
+			s.PESBytes += ps.Bytes
+		case pid <= ts.PIDTDT || m.pm.Has(pid) || pid == m.pmtPID || m.siTableOn(pid) != nil:
+			s.PSIBytes += ps.Bytes
+		}
+	}
+	if s.Bytes > 0 {
+		s.PSIRatio = float64(s.PSIBytes) / float64(s.Bytes)
+		s.PESRatio = float64(s.PESBytes) / float64(s.Bytes)
+		s.StuffingRatio = float64(s.StuffingBytes) / float64(s.Bytes)
+	}
+
+	s.PCRs = m.statPCRs
+	if m.statPCRs > 1 {
+		if m.statPCRSpan > 0 {
+			s.Rate = uint64(float64(m.statLastPCRPos-m.statFirstPCRPos) * 8 * clock27MHz / float64(m.statPCRSpan))
+		}
+		s.MaxPCRInterval = ticksToDuration(m.statMaxPCR)
+		s.MeanPCRInterval = ticksToDuration(m.statPCRSpan / (m.statPCRs - 1))
+	}
+	return
+}
+
+// ticksToDuration converts 27 MHz ticks to a duration.
+func ticksToDuration(ticks uint64) time.Duration {
+	return time.Duration(ticks * 1000 / 27)
+}
@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerStats(t *testing.T) {
+	// 1000 packets per second: a packet is 1 ms
+	const rate = ts.PacketSize * 8 * 1000
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithCBR(rate, 0), WithStats())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	assert.Zero(t, New(context.Background(), buf).Stats())
+
+	for i := range uint64(3) {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true},
+			PES: &pes.Data{
+				Data:   []byte{byte(i)},
+				Header: pes.Header{OptionalHeader: &pes.OptionalHeader{PTS: ts.NewClockReference(90000+i*900, 0), PTSDTSIndicator: pes.PTSDTSIndicatorOnlyPTS}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	// PAT, PMT, then each unit 10 packets after the previous one
+	s := m.Stats()
+	assert.Equal(t, uint64(buf.Len()), s.Bytes)
+	assert.Equal(t, uint64(23), s.Packets)
+	assert.Equal(t, map[uint16]PIDStats{
+		ts.PIDPAT:   {Bytes: ts.PacketSize, Packets: 1},
+		pmtStartPID: {Bytes: ts.PacketSize, Packets: 1},
+		0x100:       {Bytes: 3 * ts.PacketSize, Packets: 3},
+		ts.PIDNull:  {Bytes: 18 * ts.PacketSize, Packets: 18},
+	}, s.PIDs)
+	assert.Equal(t, uint64(2*ts.PacketSize), s.PSIBytes)
+	assert.Equal(t, uint64(3*ts.PacketSize), s.PESBytes)
+	assert.Equal(t, uint64(18*ts.PacketSize), s.StuffingBytes)
+	assert.InDelta(t, 18.0/23, s.StuffingRatio, 1e-9)
+	assert.InDelta(t, 3.0/23, s.PESRatio, 1e-9)
+	assert.InDelta(t, 2.0/23, s.PSIRatio, 1e-9)
+	assert.Equal(t, uint64(3), s.PCRs)
+	assert.Equal(t, uint64(rate), s.Rate)
+	assert.Equal(t, 10*time.Millisecond, s.MaxPCRInterval)
+	assert.Equal(t, 10*time.Millisecond, s.MeanPCRInterval)
+}
+
+func TestMuxerStatsPCRWrap(t *testing.T) {
+	m := New(context.Background(), &bytes.Buffer{}, WithStats())
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	// 10 ms apart across the 33-bit wrap
+	for _, base := range []uint64{1<<33 - 900, 0} {
+		_, err := m.WriteData(&Data{
+			PID:             0x100,
+			AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(base, 0)},
+			PES:             &pes.Data{Data: []byte{0}},
+		})
+		require.NoError(t, err)
+	}
+	s := m.Stats()
+	assert.Equal(t, uint64(2), s.PCRs)
+	assert.Equal(t, 10*time.Millisecond, s.MaxPCRInterval)
+}
+
+func TestMuxerStatsPCRFilter(t *testing.T) {
+	run := func(opts ...func(*Muxer)) Stats {
+		m := New(context.Background(), &bytes.Buffer{}, append(opts, WithStats())...)
+		require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+		m.SetPCRPID(0x100)
+		// 10 ms apart, one packet each, the fifth 3 ms late
+		for n := range uint64(8) {
+			base := 90000 + n*900
+			if n == 4 {
+				base += 270
+			}
+			_, err := m.WriteData(&Data{
+				PID:             0x100,
+				AdaptationField: &ts.PacketAdaptationField{HasPCR: true, PCR: ts.NewClockReference(base, 0)},
+				PES:             &pes.Data{Data: []byte{0}},
+			})
+			require.NoError(t, err)
+		}
+		return m.Stats()
+	}
+
+	assert.Equal(t, 13*time.Millisecond, run().MaxPCRInterval)
+	s := run(WithPCRFilter(func() ts.PCRFilter { return ts.NewPCRMedianFilter(5) }))
+	assert.Equal(t, uint64(8), s.PCRs)
+	assert.InDelta(t, 10*time.Millisecond, s.MaxPCRInterval, float64(time.Millisecond))
+}
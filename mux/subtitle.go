@@ -0,0 +1,119 @@
+package mux
+
+import (
+	"math/bits"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// EN 300 472 and EN 300 743 PES constants
+const (
+	// TeletextPacketSize is the size of a teletext packet (EN 300 706) from its
+	// magazine and packet address on, as a data unit carries it.
+	TeletextPacketSize = 42
+
+	teletextHeaderLength    = 0x24 // the fixed PES_header_data_length
+	teletextDataIdentifier  = 0x10 // EBU data
+	teletextUnitSubtitle    = 0x03
+	teletextUnitNonSubtitle = 0x02
+	teletextUnitStuffing    = 0xff
+	teletextUnitLength      = 0x2c
+	teletextFramingCode     = 0xe4
+	// reserved bits set, field_parity 1, line_offset 0 (undefined line)
+	teletextFieldLine = 0xe0
+
+	subtitleDataIdentifier = 0x20
+	subtitleStreamID       = 0x00
+	subtitleEndMarker      = 0xff
+)
+
+// AddTeletextStream registers pid as an EBU teletext stream: private data
+// announced in the PMT by a teletext_descriptor of items. Write its units with
+// TeletextPES.
+func (m *Muxer) AddTeletextStream(pid uint16, items []descriptor.TeletextItem) error {
+	return m.AddElementaryStream(psi.ElementaryStream{
+		ElementaryPID: pid,
+		StreamType:    psi.StreamTypePrivateData,
+		ElementaryStreamDescriptors: []descriptor.Descriptor{
+			&descriptor.Teletext{Header: descriptor.Header{Tag: descriptor.TagTeletext}, Items: items},
+		},
+	})
+}
+
+// AddSubtitleStream registers pid as a DVB subtitle stream: private data
+// announced in the PMT by a subtitling_descriptor of items. Write its units
+// with SubtitlePES.
+func (m *Muxer) AddSubtitleStream(pid uint16, items []descriptor.SubtitlingItem) error {
+	return m.AddElementaryStream(psi.ElementaryStream{
+		ElementaryPID: pid,
+		StreamType:    psi.StreamTypePrivateData,
+		ElementaryStreamDescriptors: []descriptor.Descriptor{
+			&descriptor.Subtitling{Header: descriptor.Header{Tag: descriptor.TagSubtitling}, Items: items},
+		},
+	})
+}
+
+// TeletextPES wraps teletext packets into a PES packet as EN 300 472 has it:
+// private_stream_1 with a PTS, a PES_header_data_length of 0x24, one data unit
+// per packet (of subtitle data when subtitle is set) sent in transmission bit
+// order, and stuffing data units up to a packet whose size is a multiple of
+// 184, so it fills transport packets without adaptation field stuffing.
+func TeletextPES(pts uint64, subtitle bool, packets [][TeletextPacketSize]byte) *pes.Data {
+	unitID := uint8(teletextUnitNonSubtitle)
+	if subtitle {
+		unitID = teletextUnitSubtitle
+	}
+
+	// 45 header bytes and the data_identifier make a 46-byte unit, and 4 units
+	// make 184 bytes
+	units := len(packets)
+	units += 3 - units%4
+	data := make([]byte, 0, 1+units*(2+teletextUnitLength))
+	data = append(data, teletextDataIdentifier)
+	for i := range units {
+		if i >= len(packets) {
+			data = append(data, teletextUnitStuffing, teletextUnitLength)
+			for range teletextUnitLength {
+				data = append(data, 0xff)
+			}
+			continue
+		}
+		data = append(data, unitID, teletextUnitLength, teletextFieldLine, teletextFramingCode)
+		for _, b := range packets[i] {
+			data = append(data, bits.Reverse8(b))
+		}
+	}
+
+	h := privatePESHeader(pts)
+	h.OptionalHeader.StuffingLength = teletextHeaderLength - ts.PTSDTSSize
+	return &pes.Data{Header: h, Data: data}
+}
+
+// SubtitlePES wraps DVB subtitling segments (EN 300 743), as serialized one
+// after the other, into a PES packet: private_stream_1 with a PTS, the
+// subtitle data_identifier and stream id, then the segments and the
+// end_of_PES_data_field_marker.
+func SubtitlePES(pts uint64, segments []byte) *pes.Data {
+	data := make([]byte, 0, 3+len(segments))
+	data = append(data, subtitleDataIdentifier, subtitleStreamID)
+	data = append(data, segments...)
+	data = append(data, subtitleEndMarker)
+	return &pes.Data{Header: privatePESHeader(pts), Data: data}
+}
+
+// privatePESHeader is the aligned private_stream_1 header with a PTS the
+// subtitle PES packets share.
+func privatePESHeader(pts uint64) pes.Header {
+	return pes.Header{
+		StreamID: pes.StreamIDPrivateStream1,
+		OptionalHeader: &pes.OptionalHeader{
+			MarkerBits:             2,
+			DataAlignmentIndicator: true,
+			PTSDTSIndicator:        pes.PTSDTSIndicatorOnlyPTS,
+			PTS:                    ts.NewClockReference(pts, 0),
+		},
+	}
+}
@@ -0,0 +1,104 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerTeletextPES(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf)
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+	items := []descriptor.TeletextItem{{Language: [3]byte{'e', 'n', 'g'}, Type: descriptor.TeletextTypeTeletextSubtitlePage, Magazine: 1, Page: 0x88}}
+	require.NoError(t, m.AddTeletextStream(0x200, items))
+
+	var packets [][TeletextPacketSize]byte
+	for i := range 5 {
+		var p [TeletextPacketSize]byte
+		for j := range p {
+			p[j] = byte(i*TeletextPacketSize + j)
+		}
+		packets = append(packets, p)
+	}
+	_, err := m.WriteData(&Data{PID: 0x200, PES: TeletextPES(90000, true, packets)})
+	require.NoError(t, err)
+	// 5 packets and 2 stuffing units: 46 * 8 bytes, two transport packets
+	// without adaptation field
+	var pkts int
+	for off := 0; off < buf.Len(); off += ts.PacketSize {
+		pkt := buf.Bytes()[off:]
+		if uint16(pkt[1]&0x1f)<<8|uint16(pkt[2]) == 0x200 {
+			assert.Equal(t, byte(0x10), pkt[3]&0x30, "payload only")
+			pkts++
+		}
+	}
+	assert.Equal(t, 2, pkts)
+
+	m.tablesRetransmitCounter = m.tablesRetransmitPeriod
+	buf.Reset()
+	_, err = m.WriteData(&Data{PID: 0x200, PES: TeletextPES(180000, false, packets[:1])})
+	require.NoError(t, err)
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()))
+	defer dmx.Close()
+	var units int
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventPMT:
+			_, data := dmx.Section()
+			es := data.(*psi.PMT).ElementaryStreams
+			require.Len(t, es, 2)
+			assert.Equal(t, psi.StreamTypePrivateData, es[1].StreamType)
+			require.Len(t, es[1].ElementaryStreamDescriptors, 1)
+			assert.Equal(t, items, es[1].ElementaryStreamDescriptors[0].(*descriptor.Teletext).Items)
+		case demux.EventPES:
+			d := dmx.PES()
+			assert.Equal(t, pes.StreamIDPrivateStream1, d.Data.Header.StreamID)
+			assert.Equal(t, uint16(ts.PacketSize-4-6), d.Data.Header.PacketLength)
+			oh := d.Data.Header.OptionalHeader
+			assert.Equal(t, uint8(0x24), oh.HeaderLength)
+			assert.True(t, oh.DataAlignmentIndicator)
+			assert.Equal(t, uint64(180000), oh.PTS.Base())
+
+			data := d.Data.Data
+			assert.Equal(t, byte(0x10), data[0])
+			assert.Equal(t, []byte{0x02, 0x2c, 0xe0, 0xe4}, data[1:5])
+			assert.Equal(t, bits.Reverse8(packets[0][1]), data[6])
+			for u := 1; u < 3; u++ {
+				assert.Equal(t, []byte{0xff, 0x2c}, data[1+u*46:3+u*46])
+			}
+			d.Close()
+			units++
+		}
+	}
+	assert.Equal(t, 1, units)
+}
+
+func TestSubtitlePES(t *testing.T) {
+	segment := []byte{0x0f, 0x10, 0x00, 0x01, 0x00, 0x02, 0xab, 0xcd}
+	d := SubtitlePES(90000, segment)
+	assert.Equal(t, append([]byte{0x20, 0x00}, append(segment, 0xff)...), d.Data)
+	assert.Equal(t, pes.StreamIDPrivateStream1, d.Header.StreamID)
+
+	buf := make([]byte, maxPESHeader)
+	n, err := d.Header.PutHeader(buf, len(d.Data))
+	require.NoError(t, err)
+	var got pes.Data
+	require.NoError(t, got.Parse(append(buf[:n], d.Data...)))
+	assert.Equal(t, d.Data, got.Data)
+	assert.True(t, got.Header.OptionalHeader.DataAlignmentIndicator)
+	assert.Equal(t, uint64(90000), got.Header.OptionalHeader.PTS.Base())
+}
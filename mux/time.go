@@ -0,0 +1,57 @@
+package mux
+
+import (
+	"time"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// WithTimeTables emits a TDT on ts.PIDTDT every interval of clock time
+// (time.Now when clock is nil), followed by a TOT once SetTimeOffsets has set
+// its descriptors, so receivers keep their clock in sync. The tables go out
+// ahead of the unit of the WriteData call that finds them due, the first
+// with the first unit.
+func WithTimeTables(interval time.Duration, clock func() time.Time) func(*Muxer) {
+	return func(m *Muxer) {
+		if clock == nil {
+			clock = time.Now
+		}
+		m.timeInterval = interval
+		m.clock = clock
+		*m.siCC.GetOrAdd(ts.PIDTDT) = newWrappingCounter(0b1111)
+	}
+}
+
+// SetTimeOffsets sets the descriptors of the TOT emitted with WithTimeTables,
+// typically local_time_offset descriptors; nil stops the TOT.
+func (m *Muxer) SetTimeOffsets(ds []descriptor.Descriptor) error {
+	if err := descriptor.CheckLength(ds); err != nil {
+		return err
+	}
+	m.totDescriptors = ds
+	return nil
+}
+
+// writeTimeTables writes the TDT and the TOT when they are due.
+func (m *Muxer) writeTimeTables() (bytesWritten int, err error) {
+	now := m.clock()
+	if !m.timeSent.IsZero() && now.Sub(m.timeSent) < m.timeInterval {
+		return
+	}
+	m.timeSent = now
+
+	utc := now.UTC().Truncate(time.Second)
+	d := psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: psi.TableIDTDT},
+		Syntax: &psi.SectionSyntax{Data: &psi.TDT{UTCTime: utc}},
+	}}}
+	if m.totDescriptors != nil {
+		d.Sections = append(d.Sections, psi.Section{
+			Header: psi.SectionHeader{TableID: psi.TableIDTOT},
+			Syntax: &psi.SectionSyntax{Data: &psi.TOT{UTCTime: utc, Descriptors: m.totDescriptors}},
+		})
+	}
+	return m.writeSections(ts.PIDTDT, m.siCC.Get(ts.PIDTDT), &d)
+}
@@ -0,0 +1,63 @@
+package mux
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/pes"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+func TestMuxerTimeTables(t *testing.T) {
+	start := time.Date(2026, time.October, 16, 20, 0, 0, 0, time.UTC)
+	now := start
+	buf := &bytes.Buffer{}
+	m := New(context.Background(), buf, WithTimeTables(time.Second, func() time.Time { return now }))
+	require.NoError(t, m.AddElementaryStream(psi.ElementaryStream{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video}))
+	m.SetPCRPID(0x100)
+
+	offsets := []descriptor.Descriptor{&descriptor.LocalTimeOffset{
+		Header: descriptor.Header{Tag: descriptor.TagLocalTimeOffset, Length: 13},
+		Items: []descriptor.LocalTimeOffsetItem{{
+			CountryCode:     [3]byte{'F', 'R', 'A'},
+			LocalTimeOffset: 2 * time.Hour,
+			NextTimeOffset:  time.Hour,
+			TimeOfChange:    time.Date(2026, time.October, 25, 1, 0, 0, 0, time.UTC),
+		}},
+	}}
+	for i, step := range []time.Duration{0, 500 * time.Millisecond, time.Second, 2500 * time.Millisecond} {
+		now = start.Add(step)
+		if i == 2 {
+			require.NoError(t, m.SetTimeOffsets(offsets))
+		}
+		_, err := m.WriteData(&Data{PID: 0x100, PES: &pes.Data{Data: []byte{byte(i)}}})
+		require.NoError(t, err)
+	}
+
+	dmx := demux.New(context.Background(), bytes.NewReader(buf.Bytes()), demux.WithDVBTables())
+	defer dmx.Close()
+	var got []string
+	for ev, err := range dmx.Events() {
+		require.NoError(t, err)
+		switch ev {
+		case demux.EventTDT:
+			pid, data := dmx.Section()
+			assert.Equal(t, ts.PIDTDT, pid)
+			got = append(got, "TDT "+data.(*psi.TDT).UTCTime.Format(time.TimeOnly))
+		case demux.EventTOT:
+			_, data := dmx.Section()
+			tot := data.(*psi.TOT)
+			assert.Equal(t, offsets, tot.Descriptors)
+			got = append(got, "TOT "+tot.UTCTime.Format(time.TimeOnly))
+		}
+	}
+	assert.Equal(t, []string{"TDT 20:00:00", "TDT 20:00:01", "TOT 20:00:01", "TDT 20:00:02", "TOT 20:00:02"}, got)
+}
@@ -31,3 +31,11 @@ func (c *wrappingCounter) inc() int {
 	}
 	return c.value
 }
+
+// next returns the value inc would, without advancing.
+func (c *wrappingCounter) next() int {
+	if c.value >= c.wrapAt {
+		return 0
+	}
+	return c.value + 1
+}
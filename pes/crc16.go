@@ -0,0 +1,34 @@
+package pes
+
+// CRC16Seed is the initial value of the previous_PES_packet_CRC register.
+const CRC16Seed = uint16(0xffff)
+
+// ComputeCRC16 computes the previous_PES_packet_CRC of a PES packet's data
+// bytes (its header excluded): the Annex A decoder model with the
+// x^16 + x^12 + x^5 + 1 polynomial.
+func ComputeCRC16(bs []byte) uint16 {
+	return UpdateCRC16(CRC16Seed, bs)
+}
+
+// UpdateCRC16 continues crc16 over bs, for data held in several chunks.
+func UpdateCRC16(crc16 uint16, bs []byte) uint16 {
+	for _, b := range bs {
+		crc16 = crc16<<8 ^ tableCRC16[uint8(crc16>>8)^b]
+	}
+	return crc16
+}
+
+var tableCRC16 = func() (t [256]uint16) {
+	for i := range t {
+		c := uint16(i) << 8
+		for range 8 {
+			if c&0x8000 != 0 {
+				c = c<<1 ^ 0x1021
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return
+}()
@@ -0,0 +1,13 @@
+package pes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeCRC16(t *testing.T) {
+	assert.Equal(t, uint16(0x29b1), ComputeCRC16([]byte("123456789")))
+	assert.Equal(t, CRC16Seed, ComputeCRC16(nil))
+	assert.Equal(t, ComputeCRC16([]byte("123456789")), UpdateCRC16(ComputeCRC16([]byte("1234")), []byte("56789")))
+}
@@ -302,6 +302,10 @@ func (t *FrequencyTruncation) UnmarshalJSON(b []byte) (err error) {
 const (
 	HeaderSize         = 6
 	dsmTrickModeLength = 1
+	// MaxPacketLength is the largest PES_packet_length; a longer packet is
+	// written with a zero (unbounded) length, which the spec reserves for
+	// video but receivers commonly accept for any stream carried in TS.
+	MaxPacketLength = 0xffff
 )
 
 // Data represents a PES data
@@ -340,12 +344,24 @@ type OptionalHeader struct {
 	HasExtension           bool                     `json:"PES_extension_flag"`
 	HasOptionalFields      bool                     `json:"_has_optional_fields"`
 	HeaderLength           uint8                    `json:"PES_header_data_length"`
-	IsCopyrighted          bool                     `json:"copyright"`
-	IsOriginal             bool                     `json:"original_or_copy"`
-	MarkerBits             uint8                    `json:"_marker_bits"`
-	Priority               bool                     `json:"PES_priority"`
-	PTSDTSIndicator        PTSDTSIndicator          `json:"PTS_DTS_flags"`
-	ScramblingControl      ScramblingControl        `json:"PES_scrambling_control"`
+	// StuffingLength is the number of 0xff bytes written after the optional
+	// fields, padding PES_header_data_length to a fixed size such as the 0x24
+	// of EN 300 472 teletext. Parsing leaves the stuffing in HeaderLength.
+	StuffingLength uint8 `json:"-"`
+	// MPEG1 marks the packet header of an ISO/IEC 11172-1 (MPEG-1 system)
+	// stream, as remuxed from VCD-era encoders: only PTSDTSIndicator, PTS,
+	// DTS, the STD buffer and the stuffing apply, HeaderLength is unused.
+	MPEG1             bool              `json:"_MPEG1"`
+	MPEG1Stuffing     uint8             `json:"_MPEG1_stuffing_length"` // 0xff stuffing bytes, at most 16
+	HasSTDBuffer      bool              `json:"_MPEG1_STD_buffer_flag"`
+	STDBufferScale    PSTDBufferScale   `json:"STD_buffer_scale"`
+	STDBufferSize     uint16            `json:"STD_buffer_size"`
+	IsCopyrighted     bool              `json:"copyright"`
+	IsOriginal        bool              `json:"original_or_copy"`
+	MarkerBits        uint8             `json:"_marker_bits"`
+	Priority          bool              `json:"PES_priority"`
+	PTSDTSIndicator   PTSDTSIndicator   `json:"PTS_DTS_flags"`
+	ScramblingControl ScramblingControl `json:"PES_scrambling_control"`
 }
 
 type OptionalHeaderExtension struct {
@@ -390,6 +406,18 @@ func (h *Header) IsVideoStream() bool {
 
 // Parse parses a PES data
 func (d *Data) Parse(bs []byte) (err error) {
+	_, err = d.parse(bs, false)
+	return
+}
+
+// ParseTruncated parses a PES data that may end early, as the last unit of a
+// capture cut mid-PES: when PES_packet_length runs past bs, the data is cut at
+// the end of bs and truncated is set. The header must be whole.
+func (d *Data) ParseTruncated(bs []byte) (truncated bool, err error) {
+	return d.parse(bs, true)
+}
+
+func (d *Data) parse(bs []byte, allowShort bool) (truncated bool, err error) {
 	const pesPayloadPrefixSize = 3
 
 	var dataStart, dataEnd int
@@ -402,8 +430,14 @@ func (d *Data) Parse(bs []byte) (err error) {
 		err = fmt.Errorf("astits: data end %d is before data start %d: %w", dataEnd, dataStart, ts.ErrInvalidData)
 		return
 	}
-	if dataStart > len(bs) || dataEnd > len(bs) {
-		return ts.ErrShortPacket
+	if dataStart > len(bs) {
+		return false, ts.ErrShortPacket
+	}
+	if dataEnd > len(bs) {
+		if !allowShort {
+			return false, ts.ErrShortPacket
+		}
+		dataEnd, truncated = len(bs), true
 	}
 
 	d.Data = bs[dataStart:dataEnd]
@@ -452,6 +486,15 @@ func (h *Header) parseBytes(bs []byte, o int) (dataStart, dataEnd int, err error
 
 // parseBytes parses a PES optional header starting at bs[o]
 func (h *OptionalHeader) parseBytes(bs []byte, o int) (dataStart int, err error) {
+	// The MPEG-2 header starts with '10', the MPEG-1 one with stuffing, the
+	// STD buffer ('01') or the timestamps ('0010', '0011', 0x0f). Bytes that
+	// are neither are parsed as MPEG-2, as before MPEG-1 detection.
+	if o < len(bs) && bs[o]>>6 != 0b10 {
+		if dataStart, err = h.parseMPEG1(bs, o); err == nil {
+			return
+		}
+		*h = OptionalHeader{}
+	}
 	if o+3 > len(bs) {
 		return 0, ts.ErrShortPacket
 	}
@@ -548,6 +591,63 @@ func (h *OptionalHeader) parseBytes(bs []byte, o int) (dataStart int, err error)
 	return
 }
 
+// mpeg1MaxStuffing is the most stuffing bytes an MPEG-1 packet header holds.
+const mpeg1MaxStuffing = 16
+
+// parseMPEG1 parses an ISO/IEC 11172-1 packet header starting at bs[o].
+func (h *OptionalHeader) parseMPEG1(bs []byte, o int) (dataStart int, err error) {
+	h.MPEG1 = true
+	for o < len(bs) && bs[o] == 0xff {
+		if h.MPEG1Stuffing == mpeg1MaxStuffing {
+			return 0, fmt.Errorf("astits: MPEG-1 packet header has more than %d stuffing bytes: %w", mpeg1MaxStuffing, ts.ErrInvalidData)
+		}
+		h.MPEG1Stuffing++
+		o++
+	}
+
+	if o < len(bs) && bs[o]>>6 == 0b01 {
+		if o+2 > len(bs) {
+			return 0, ts.ErrShortPacket
+		}
+		h.HasSTDBuffer = true
+		h.STDBufferScale = PSTDBufferScale(bs[o] >> 5 & 0x1)
+		h.STDBufferSize = binary.BigEndian.Uint16(bs[o:]) & 0x1fff
+		o += 2
+	}
+
+	if o >= len(bs) {
+		return 0, ts.ErrShortPacket
+	}
+	var n int
+	switch bs[o] >> 4 {
+	case 0b0010:
+		h.PTSDTSIndicator = PTSDTSIndicatorOnlyPTS
+		if n, err = h.PTS.ParsePTSDTS(bs[o:]); err != nil {
+			err = fmt.Errorf("astits: parsing PTS failed: %w", err)
+			return
+		}
+		o += n
+	case 0b0011:
+		h.PTSDTSIndicator = PTSDTSIndicatorBothPresent
+		if n, err = h.PTS.ParsePTSDTS(bs[o:]); err != nil {
+			err = fmt.Errorf("astits: parsing PTS failed: %w", err)
+			return
+		}
+		o += n
+		if n, err = h.DTS.ParsePTSDTS(bs[o:]); err != nil {
+			err = fmt.Errorf("astits: parsing DTS failed: %w", err)
+			return
+		}
+		o += n
+	default:
+		if bs[o] != 0x0f {
+			return 0, fmt.Errorf("astits: MPEG-1 packet header byte 0x%02x is invalid: %w", bs[o], ts.ErrInvalidData)
+		}
+		o++
+	}
+	return o, nil
+}
+
 func (h *OptionalHeaderExtension) parseBytes(bs []byte, o int) (err error) {
 	if o >= len(bs) {
 		return ts.ErrShortPacket
@@ -701,22 +801,29 @@ func (h *Header) PutHeader(bs []byte, payloadLen int) (n int, err error) {
 	return h.putBytes(bs, payloadLen)
 }
 
+// CalcPacketLength returns the PES_packet_length written for payloadSize
+// payload bytes: the optional header plus payload, or 0 (unbounded) for video
+// streams and for packets longer than MaxPacketLength.
+func (h *Header) CalcPacketLength(payloadSize int) uint16 {
+	if h.IsVideoStream() {
+		return 0
+	}
+	length := payloadSize
+	if hasPESOptionalHeader(h.StreamID) {
+		length += h.OptionalHeader.CalcLength()
+	}
+	if length > MaxPacketLength {
+		return 0
+	}
+	return uint16(length)
+}
+
 func (h *Header) putBytes(bs []byte, payloadSize int) (n int, err error) {
 	if len(bs) < HeaderSize {
 		return 0, ts.ErrShortPacket
 	}
 	binary.BigEndian.PutUint32(bs, uint32(h.StreamID)|0x1<<8)
-	pesPacketLength := 0
-
-	if !h.IsVideoStream() {
-		pesPacketLength = payloadSize
-		if hasPESOptionalHeader(h.StreamID) {
-			pesPacketLength += h.OptionalHeader.CalcLength()
-		}
-		pesPacketLength *= int((uint64(pesPacketLength) - 0x10000) >> 63)
-	}
-
-	binary.BigEndian.PutUint16(bs[4:], uint16(pesPacketLength))
+	binary.BigEndian.PutUint16(bs[4:], h.CalcPacketLength(payloadSize))
 	n = HeaderSize
 
 	if hasPESOptionalHeader(h.StreamID) {
@@ -730,9 +837,26 @@ func (h *OptionalHeader) CalcLength() int {
 	if h == nil {
 		return 0
 	}
+	if h.MPEG1 {
+		return h.calcMPEG1Length()
+	}
 	return 3 + int(h.calcDataLength())
 }
 
+// calcMPEG1Length returns the size of an MPEG-1 packet header.
+func (h *OptionalHeader) calcMPEG1Length() (length int) {
+	length = int(h.MPEG1Stuffing) + 2*int(util.B2U(h.HasSTDBuffer))
+	switch h.PTSDTSIndicator {
+	case PTSDTSIndicatorOnlyPTS:
+		length += ts.PTSDTSSize
+	case PTSDTSIndicatorBothPresent:
+		length += 2 * ts.PTSDTSSize
+	default:
+		length++
+	}
+	return
+}
+
 func (h *OptionalHeader) calcDataLength() (length uint8) {
 	switch h.PTSDTSIndicator {
 	case PTSDTSIndicatorOnlyPTS:
@@ -750,6 +874,7 @@ func (h *OptionalHeader) calcDataLength() (length uint8) {
 	if h.HasExtension {
 		length += h.Extension.calcDataLength()
 	}
+	length += h.StuffingLength
 	return
 }
 
@@ -774,6 +899,9 @@ func (h *OptionalHeader) putBytes(bs []byte) (n int) {
 	if h == nil {
 		return 0
 	}
+	if h.MPEG1 {
+		return h.putMPEG1(bs)
+	}
 
 	b := uint8(0b10) << 6
 	b |= uint8(h.ScramblingControl) << 4
@@ -831,6 +959,31 @@ func (h *OptionalHeader) putBytes(bs []byte) (n int) {
 		n += h.Extension.putBytes(bs[n:])
 	}
 
+	for end := n + int(h.StuffingLength); n < end; n++ {
+		bs[n] = 0xff
+	}
+	return
+}
+
+// putMPEG1 writes an MPEG-1 packet header.
+func (h *OptionalHeader) putMPEG1(bs []byte) (n int) {
+	for ; n < int(h.MPEG1Stuffing); n++ {
+		bs[n] = 0xff
+	}
+	if h.HasSTDBuffer {
+		binary.BigEndian.PutUint16(bs[n:], 0b01<<14|uint16(h.STDBufferScale&0x1)<<13|h.STDBufferSize&0x1fff)
+		n += 2
+	}
+	switch h.PTSDTSIndicator {
+	case PTSDTSIndicatorOnlyPTS:
+		n += h.PTS.PutPTSDTS(bs[n:], 0b0010)
+	case PTSDTSIndicatorBothPresent:
+		n += h.PTS.PutPTSDTS(bs[n:], 0b0011)
+		n += h.DTS.PutPTSDTS(bs[n:], 0b0001)
+	default:
+		bs[n] = 0x0f
+		n++
+	}
 	return
 }
 
@@ -377,6 +377,36 @@ func TestParsePESData(t *testing.T) {
 	}
 }
 
+func TestParsePESDataTruncated(t *testing.T) {
+	tc := pesTestCases[0]
+	buf := bytes.Buffer{}
+	w := bitstest.NewWriter(&buf)
+	tc.headerBytesFunc(w, true, true)
+	tc.optionalHeaderBytesFunc(w, true, true)
+	tc.bytesFunc(w, true, true)
+	bs := buf.Bytes()
+
+	// Whole
+	d := &Data{}
+	truncated, err := d.ParseTruncated(bs)
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	whole := d.Data
+
+	// Cut inside the data
+	bs = bs[:len(bs)-2]
+	assert.ErrorIs(t, (&Data{}).Parse(bs), ts.ErrShortPacket)
+	d = &Data{}
+	truncated, err = d.ParseTruncated(bs)
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Equal(t, whole[:len(whole)-2], d.Data)
+
+	// Cut inside the header
+	_, err = (&Data{}).ParseTruncated(bs[:4])
+	assert.Error(t, err)
+}
+
 func TestWritePESData(t *testing.T) {
 	for _, tc := range pesTestCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -551,3 +581,83 @@ func TestWriteOptionalHeaderCRCAndPackHeader(t *testing.T) {
 	assert.True(t, got.Extension.HasPackHeaderField)
 	assert.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, got.Extension.PackHeader)
 }
+
+func TestCalcPacketLength(t *testing.T) {
+	h := Header{StreamID: StreamIDPrivateStream1, OptionalHeader: &OptionalHeader{PTSDTSIndicator: PTSDTSIndicatorOnlyPTS}}
+	oh := h.OptionalHeader.CalcLength()
+	assert.Equal(t, uint16(MaxPacketLength), h.CalcPacketLength(MaxPacketLength-oh))
+	assert.Equal(t, uint16(0), h.CalcPacketLength(MaxPacketLength-oh+1))
+
+	bs := make([]byte, HeaderSize+oh)
+	_, err := h.PutHeader(bs, MaxPacketLength-oh)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0xff}, bs[4:6])
+
+	// Video is always unbounded.
+	v := Header{StreamID: streamIDVideoBase}
+	assert.Equal(t, uint16(0), v.CalcPacketLength(10))
+}
+
+func TestParsePESDataMPEG1(t *testing.T) {
+	pts := ts.NewClockReference(0x1_2345_6789, 0)
+	dts := ts.NewClockReference(0x0_2345_6789, 0)
+	payload := []byte("payload")
+	for _, tc := range []struct {
+		name   string
+		header OptionalHeader
+		bytes  func() []byte
+	}{
+		{
+			name:   "no timestamps",
+			header: OptionalHeader{MPEG1: true},
+			bytes:  func() []byte { return []byte{0x0f} },
+		},
+		{
+			name: "stuffing, STD buffer and PTS",
+			header: OptionalHeader{
+				MPEG1: true, MPEG1Stuffing: 2,
+				HasSTDBuffer: true, STDBufferScale: PSTDBufferScale1024Bytes, STDBufferSize: 0x123,
+				PTSDTSIndicator: PTSDTSIndicatorOnlyPTS, PTS: pts,
+			},
+			bytes: func() []byte {
+				bs := []byte{0xff, 0xff, 0x61, 0x23, 0, 0, 0, 0, 0}
+				pts.PutPTSDTS(bs[4:], 0b0010)
+				return bs
+			},
+		},
+		{
+			name:   "PTS and DTS",
+			header: OptionalHeader{MPEG1: true, PTSDTSIndicator: PTSDTSIndicatorBothPresent, PTS: pts, DTS: dts},
+			bytes: func() []byte {
+				bs := make([]byte, 10)
+				pts.PutPTSDTS(bs, 0b0011)
+				dts.PutPTSDTS(bs[5:], 0b0001)
+				return bs
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tc.bytes()
+			bs := append([]byte{0, 0, 1, 0xc0, 0, byte(len(h) + len(payload))}, h...)
+			bs = append(bs, payload...)
+
+			d := &Data{}
+			require.NoError(t, d.Parse(bs))
+			require.NotNil(t, d.Header.OptionalHeader)
+			assert.Equal(t, tc.header, *d.Header.OptionalHeader)
+			assert.Equal(t, payload, d.Data)
+
+			// Written back byte for byte
+			w := make([]byte, len(bs))
+			n, err := d.Header.PutHeader(w, len(payload))
+			require.NoError(t, err)
+			copy(w[n:], payload)
+			assert.Equal(t, bs, w)
+		})
+	}
+
+	// Too much stuffing is not MPEG-1
+	bs := append([]byte{0, 0, 1, 0xc0, 0, 20}, bytes.Repeat([]byte{0xff}, 17)...)
+	_, err := (&OptionalHeader{}).parseMPEG1(append(bs, 0x0f), 6)
+	assert.ErrorIs(t, err, ts.ErrInvalidData)
+}
@@ -0,0 +1,201 @@
+package psi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// ErrUnsupportedText reports a multiple string segment whose compression type
+// or mode the decoder does not handle; its bytes stay available in the segment.
+var ErrUnsupportedText = errors.New("astits: unsupported ATSC text compression or mode")
+
+// ATSC multiple string structure compression types (A/65 Table 6.40)
+const (
+	TextCompressionNone           uint8 = 0x00
+	TextCompressionHuffmanTitle   uint8 = 0x01
+	TextCompressionHuffmanProgram uint8 = 0x02
+)
+
+// ATSC multiple string structure modes (A/65 Table 6.41) with a special
+// meaning; modes 0x00-0x33 select the upper byte of a Unicode code point.
+const (
+	TextModeSCSU  uint8 = 0x3e
+	TextModeUTF16 uint8 = 0x3f
+)
+
+// gpsEpoch is the origin of ATSC system time.
+var gpsEpoch = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+
+// GPSTime converts an ATSC system time (GPS seconds since 1980-01-06) to UTC,
+// gpsUTCOffset being the leap second count carried by the STT.
+func GPSTime(seconds uint32, gpsUTCOffset uint8) time.Time {
+	return gpsEpoch.Add(time.Duration(int64(seconds)-int64(gpsUTCOffset)) * time.Second)
+}
+
+// MultipleString is an ATSC multiple_string_structure() (A/65 §6.10): the
+// same text in one or more languages.
+type MultipleString []MultipleStringEntry
+
+// MultipleStringEntry is the text of a multiple string structure in one
+// language, split into segments of possibly different encodings.
+type MultipleStringEntry struct {
+	Segments []MultipleStringSegment `json:"_segments"`
+	Language [3]byte                 `json:"ISO_639_language_code"`
+}
+
+// MultipleStringSegment is one segment of a multiple string structure. Bytes
+// are kept as transmitted; Text decodes them.
+type MultipleStringSegment struct {
+	Bytes           []byte `json:"compressed_string_byte"`
+	CompressionType uint8  `json:"compression_type"`
+	Mode            uint8  `json:"mode"`
+}
+
+// Text decodes a segment: a mode below 0x34 selects the upper byte of each
+// code point, TextModeUTF16 carries UTF-16BE. Huffman coded segments (A/65
+// Annex C) are decoded with the standard's decode tables once embedded; SCSU,
+// and Huffman coding without its table, are reported as ErrUnsupportedText.
+func (s *MultipleStringSegment) Text() (string, error) {
+	switch s.CompressionType {
+	case TextCompressionNone:
+	case TextCompressionHuffmanTitle, TextCompressionHuffmanProgram:
+		tree := huffmanTitleTree
+		if s.CompressionType == TextCompressionHuffmanProgram {
+			tree = huffmanProgramTree
+		}
+		if tree == nil || s.Mode != 0x00 {
+			break
+		}
+		return decodeHuffman(tree, s.Bytes)
+	}
+	if s.CompressionType != TextCompressionNone {
+		return "", fmt.Errorf("astits: compression type 0x%02x: %w", s.CompressionType, ErrUnsupportedText)
+	}
+	switch {
+	case s.Mode == TextModeUTF16:
+		u := make([]uint16, len(s.Bytes)/2)
+		for j := range u {
+			u[j] = uint16(s.Bytes[2*j])<<8 | uint16(s.Bytes[2*j+1])
+		}
+		return string(utf16.Decode(u)), nil
+	case s.Mode <= 0x06, s.Mode >= 0x09 && s.Mode <= 0x10, s.Mode >= 0x20 && s.Mode <= 0x27, s.Mode >= 0x30 && s.Mode <= 0x33:
+		var b strings.Builder
+		for _, c := range s.Bytes {
+			b.WriteRune(rune(s.Mode)<<8 | rune(c))
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("astits: mode 0x%02x: %w", s.Mode, ErrUnsupportedText)
+}
+
+// Text concatenates the decoded segments of the entry.
+func (e *MultipleStringEntry) Text() (string, error) {
+	var b strings.Builder
+	for j := range e.Segments {
+		t, err := e.Segments[j].Text()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(t)
+	}
+	return b.String(), nil
+}
+
+// Text returns the decoded text in language, or in the first language when
+// none matches.
+func (s MultipleString) Text(language [3]byte) (string, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	for j := range s {
+		if s[j].Language == language {
+			return s[j].Text()
+		}
+	}
+	return s[0].Text()
+}
+
+// parseMultipleString parses a multiple_string_structure() ending at offsetEnd
+func parseMultipleString(i *bytesiter.Iterator, offsetEnd int) (s MultipleString, err error) {
+	if i.Offset() >= offsetEnd {
+		return
+	}
+
+	var n byte
+	if n, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+
+	// An empty structure still takes its number_strings byte: keep it non-nil.
+	s = make(MultipleString, 0, n)
+	var bs []byte
+	for range n {
+		e := MultipleStringEntry{}
+		if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		copy(e.Language[:], bs)
+
+		for range bs[3] {
+			seg := MultipleStringSegment{}
+			if bs, err = i.NextBytesNoCopy(3); err != nil || len(bs) < 3 {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+			seg.CompressionType = bs[0]
+			seg.Mode = bs[1]
+			if l := int(bs[2]); l > 0 {
+				if seg.Bytes, err = i.NextBytes(l); err != nil {
+					err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+					return
+				}
+			}
+			e.Segments = append(e.Segments, seg)
+		}
+		s = append(s, e)
+	}
+
+	if i.Offset() > offsetEnd {
+		err = fmt.Errorf("astits: multiple string structure overruns its length: %w", ts.ErrInvalidData)
+	}
+	return
+}
+
+func (s MultipleString) calcLength() (ret int) {
+	if s == nil {
+		return 0
+	}
+	ret = 1
+	for j := range s {
+		ret += 4
+		for k := range s[j].Segments {
+			ret += 3 + len(s[j].Segments[k].Bytes)
+		}
+	}
+	return
+}
+
+func (s MultipleString) append(dst []byte) []byte {
+	if s == nil {
+		return dst
+	}
+	dst = append(dst, uint8(len(s)))
+	for j := range s {
+		e := &s[j]
+		dst = append(dst, e.Language[0], e.Language[1], e.Language[2], uint8(len(e.Segments)))
+		for k := range e.Segments {
+			seg := &e.Segments[k]
+			dst = append(dst, seg.CompressionType, seg.Mode, uint8(len(seg.Bytes)))
+			dst = append(dst, seg.Bytes...)
+		}
+	}
+	return dst
+}
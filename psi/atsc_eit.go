@@ -0,0 +1,144 @@
+package psi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+)
+
+// ATSCEIT represents an ATSC event information table section: the events of
+// one virtual channel (SourceID, carried as table_id_extension) within a
+// three-hour time slot. Start times are GPS seconds, see GPSTime.
+// Chapter: 6.5 | Link: https://www.atsc.org/atsc-documents/a65-program-and-system-information-protocol-for-terrestrial-broadcast-and-cable/
+type ATSCEIT struct {
+	Events          []ATSCEITEvent `json:"_events"`
+	SourceID        uint16         `json:"source_id"`
+	ProtocolVersion uint8          `json:"protocol_version"`
+}
+
+// ATSCEITEvent represents an ATSC EIT event. ETMLocation tells whether an
+// extended text message describing the event is carried in an ETT.
+type ATSCEITEvent struct {
+	Title           MultipleString          `json:"title_text"`
+	Descriptors     []descriptor.Descriptor `json:"_descriptors"`
+	StartTime       uint32                  `json:"start_time"`
+	LengthInSeconds uint32                  `json:"length_in_seconds"`
+	EventID         uint16                  `json:"event_id"`
+	ETMLocation     uint8                   `json:"ETM_location"`
+}
+
+// ATSCETT represents an ATSC extended text table section: the long
+// description of a channel or an event, identified by ETMID.
+// Chapter: 6.6 | Link: https://www.atsc.org/atsc-documents/a65-program-and-system-information-protocol-for-terrestrial-broadcast-and-cable/
+type ATSCETT struct {
+	ExtendedText    MultipleString `json:"extended_text_message"`
+	ETMID           uint32         `json:"ETM_id"`
+	ProtocolVersion uint8          `json:"protocol_version"`
+}
+
+// ETMID returns the ETM_id of the extended text of event eventID on source
+// sourceID, or of the channel itself when eventID is zero.
+func ETMID(sourceID, eventID uint16) uint32 {
+	if eventID == 0 {
+		return uint32(sourceID) << 16
+	}
+	return uint32(sourceID)<<16 | uint32(eventID&0x3fff)<<2 | 0x2
+}
+
+// parseATSCEITSection parses an ATSC EIT section
+func parseATSCEITSection(i *bytesiter.Iterator, tableIDExtension uint16, keepRaw bool) (d *ATSCEIT, err error) {
+	d = &ATSCEIT{SourceID: tableIDExtension}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.ProtocolVersion = bs[0]
+	numEvents := int(bs[1])
+
+	for range numEvents {
+		e := ATSCEITEvent{}
+		if bs, err = i.NextBytesNoCopy(10); err != nil || len(bs) < 10 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		e.EventID = binary.BigEndian.Uint16(bs) & 0x3fff
+		e.StartTime = binary.BigEndian.Uint32(bs[2:])
+		e.ETMLocation = bs[6] >> 4 & 0x3
+		e.LengthInSeconds = uint32(bs[6]&0xf)<<16 | uint32(bs[7])<<8 | uint32(bs[8])
+
+		titleEnd := i.Offset() + int(bs[9])
+		if e.Title, err = parseMultipleString(i, titleEnd); err != nil {
+			err = fmt.Errorf("astits: parsing title text failed: %w", err)
+			return
+		}
+		i.Seek(titleEnd)
+
+		var dn int
+		if e.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+			return
+		}
+		i.Skip(dn)
+
+		d.Events = append(d.Events, e)
+	}
+	return
+}
+
+func (d *ATSCEIT) CalcSectionLength() int {
+	n := 2 // protocol_version + num_events_in_section
+	for j := range d.Events {
+		n += 12 + d.Events[j].Title.calcLength() + descriptor.CalcLength(d.Events[j].Descriptors) // event_id(2) + start_time(4) + ETM/length(3) + title_length(1) + descriptors_length(2)
+	}
+	return n
+}
+
+func (d *ATSCEIT) appendSection(dst []byte) []byte {
+	dst = append(dst, d.ProtocolVersion, uint8(len(d.Events)))
+	for j := range d.Events {
+		e := &d.Events[j]
+		dst = append(dst, 0xc0|byte(e.EventID>>8)&0x3f, byte(e.EventID))
+		dst = binary.BigEndian.AppendUint32(dst, e.StartTime)
+		dst = append(dst,
+			0xc0|e.ETMLocation&0x3<<4|byte(e.LengthInSeconds>>16)&0xf, byte(e.LengthInSeconds>>8), byte(e.LengthInSeconds),
+			uint8(e.Title.calcLength()))
+		dst = e.Title.append(dst)
+		loopLen := descriptor.CalcLength(e.Descriptors)
+		dst = append(dst, 0xf0|byte(loopLen>>8)&0xf, byte(loopLen))
+		dst = descriptor.Append(dst, e.Descriptors)
+	}
+	return dst
+}
+
+// parseATSCETTSection parses an ATSC ETT section
+func parseATSCETTSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *ATSCETT, err error) {
+	d = &ATSCETT{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.ProtocolVersion = bs[0]
+	d.ETMID = binary.BigEndian.Uint32(bs[1:])
+
+	if d.ExtendedText, err = parseMultipleString(i, offsetSectionsEnd); err != nil {
+		err = fmt.Errorf("astits: parsing extended text message failed: %w", err)
+		return
+	}
+	return
+}
+
+func (d *ATSCETT) CalcSectionLength() int {
+	return 5 + d.ExtendedText.calcLength() // protocol_version + ETM_id
+}
+
+func (d *ATSCETT) appendSection(dst []byte) []byte {
+	dst = append(dst, d.ProtocolVersion)
+	dst = binary.BigEndian.AppendUint32(dst, d.ETMID)
+	return d.ExtendedText.append(dst)
+}
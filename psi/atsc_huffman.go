@@ -0,0 +1,98 @@
+package psi
+
+import (
+	"errors"
+	"strings"
+)
+
+// A/65 Annex C decode trees, in the byte layout of the standard's decode
+// tables: 128 big-endian offsets, one per prior symbol, each locating that
+// context's tree of two-byte nodes. A node byte with bit 7 set is a leaf
+// holding a 7-bit symbol, otherwise the index of the next node; a 0 bit
+// takes the first byte of a node, a 1 bit the second.
+//
+// huffmanTitleTree decodes TextCompressionHuffmanTitle segments (Table C.5)
+// and huffmanProgramTree TextCompressionHuffmanProgram ones (Table C.7). The
+// tables are not embedded yet: until they are, such segments are reported as
+// ErrUnsupportedText.
+var (
+	huffmanTitleTree   []byte
+	huffmanProgramTree []byte
+)
+
+const (
+	huffmanTerminate = 0x00
+	huffmanEscape    = 0x1b
+	huffmanContexts  = 128
+)
+
+var errHuffmanTree = errors.New("astits: ATSC Huffman code leaves the decode tree")
+
+// decodeHuffman decodes an A/65 Annex C string: symbols are coded in the
+// context of the previous one, starting from the terminate context. An escape
+// is followed by 8-bit uncompressed characters up to and including the first
+// one below 0x80. The string ends at a terminate symbol or with its bytes.
+func decodeHuffman(tree, bs []byte) (string, error) {
+	if len(tree) < 2*huffmanContexts {
+		return "", errHuffmanTree
+	}
+
+	var sb strings.Builder
+	var pos int // bit position in bs
+	bit := func() (int, bool) {
+		if pos >= 8*len(bs) {
+			return 0, false
+		}
+		b := int(bs[pos>>3]>>(7-pos&7)) & 0x1
+		pos++
+		return b, true
+	}
+
+	prior, escaped := 0, false
+	for {
+		if escaped {
+			if pos+8 > 8*len(bs) {
+				return sb.String(), nil
+			}
+			var c int
+			for range 8 {
+				b, _ := bit()
+				c = c<<1 | b
+			}
+			sb.WriteRune(rune(c))
+			if c < 0x80 {
+				prior, escaped = c, false
+			}
+			continue
+		}
+
+		root := int(tree[2*prior])<<8 | int(tree[2*prior+1])
+		node := 0
+		for {
+			b, ok := bit()
+			if !ok {
+				return sb.String(), nil
+			}
+			j := root + 2*node + b
+			if j >= len(tree) {
+				return "", errHuffmanTree
+			}
+			if v := tree[j]; v&0x80 != 0 {
+				node = int(v & 0x7f)
+				break
+			} else {
+				node = int(v)
+			}
+		}
+
+		switch node {
+		case huffmanTerminate:
+			return sb.String(), nil
+		case huffmanEscape:
+			escaped = true
+		default:
+			sb.WriteByte(byte(node))
+			prior = node
+		}
+	}
+}
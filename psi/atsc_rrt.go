@@ -0,0 +1,203 @@
+package psi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/internal/util"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// TagContentAdvisory is the ATSC content_advisory_descriptor tag. It falls in
+// the user defined range, so it parses as a descriptor.UserDefined whose Data
+// ParseContentAdvisory decodes.
+const TagContentAdvisory descriptor.Tag = 0x87
+
+// RRT represents an ATSC rating region table section: the rating dimensions of
+// one region (RatingRegion, the low byte of table_id_extension) and the
+// labels of their values.
+// Chapter: 6.4 | Link: https://www.atsc.org/atsc-documents/a65-program-and-system-information-protocol-for-terrestrial-broadcast-and-cable/
+type RRT struct {
+	RegionName      MultipleString          `json:"rating_region_name_text"`
+	Dimensions      []RRTDimension          `json:"_dimensions"`
+	Descriptors     []descriptor.Descriptor `json:"_descriptors"`
+	RatingRegion    uint8                   `json:"rating_region"`
+	ProtocolVersion uint8                   `json:"protocol_version"`
+}
+
+// RRTDimension is one rating dimension of an RRT, e.g. "MPAA" or "Violence".
+type RRTDimension struct {
+	Name           MultipleString   `json:"dimension_name_text"`
+	Values         []RRTRatingValue `json:"_values"`
+	GraduatedScale bool             `json:"graduated_scale"`
+}
+
+// RRTRatingValue is one value of a rating dimension, e.g. "PG-13".
+type RRTRatingValue struct {
+	Abbrev MultipleString `json:"abbrev_rating_value_text"`
+	Value  MultipleString `json:"rating_value_text"`
+}
+
+// Label returns the abbreviated label of value in dimension, in language or
+// the first one transmitted.
+func (d *RRT) Label(dimension, value uint8, language [3]byte) (string, error) {
+	if int(dimension) >= len(d.Dimensions) || int(value) >= len(d.Dimensions[dimension].Values) {
+		return "", fmt.Errorf("astits: rating dimension %d value %d not in region %d: %w", dimension, value, d.RatingRegion, ts.ErrInvalidData)
+	}
+	return d.Dimensions[dimension].Values[value].Abbrev.Text(language)
+}
+
+// ContentAdvisoryRegion is the rating of an event in one rating region, as
+// carried by a content_advisory_descriptor.
+type ContentAdvisoryRegion struct {
+	Dimensions   []ContentAdvisoryRating `json:"_dimensions"`
+	Description  MultipleString          `json:"rating_description_text"`
+	RatingRegion uint8                   `json:"rating_region"`
+}
+
+// ContentAdvisoryRating is a rated dimension: an index into the dimensions of
+// the region's RRT and into the values of that dimension.
+type ContentAdvisoryRating struct {
+	Dimension uint8 `json:"rating_dimension_j"`
+	Value     uint8 `json:"rating_value"`
+}
+
+// ParseContentAdvisory decodes the body of a content_advisory_descriptor (the
+// Data of a TagContentAdvisory UserDefined descriptor).
+func ParseContentAdvisory(bs []byte) (rs []ContentAdvisoryRegion, err error) {
+	i := bytesiter.New(bs)
+
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	for range b & 0x3f {
+		r := ContentAdvisoryRegion{}
+		var hdr []byte
+		if hdr, err = i.NextBytesNoCopy(2); err != nil || len(hdr) < 2 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		r.RatingRegion = hdr[0]
+		for range hdr[1] {
+			var dv []byte
+			if dv, err = i.NextBytesNoCopy(2); err != nil || len(dv) < 2 {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+			r.Dimensions = append(r.Dimensions, ContentAdvisoryRating{Dimension: dv[0], Value: dv[1] & 0xf})
+		}
+		if r.Description, err = parseLengthPrefixedMultipleString(i); err != nil {
+			return
+		}
+		rs = append(rs, r)
+	}
+	return
+}
+
+// parseLengthPrefixedMultipleString parses an 8-bit length followed by a
+// multiple_string_structure() of that length.
+func parseLengthPrefixedMultipleString(i *bytesiter.Iterator) (s MultipleString, err error) {
+	var l byte
+	if l, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	end := i.Offset() + int(l)
+	if s, err = parseMultipleString(i, end); err != nil {
+		err = fmt.Errorf("astits: parsing multiple string structure failed: %w", err)
+		return
+	}
+	i.Seek(end)
+	return
+}
+
+// parseRRTSection parses an RRT section
+func parseRRTSection(i *bytesiter.Iterator, tableIDExtension uint16, keepRaw bool) (d *RRT, err error) {
+	d = &RRT{RatingRegion: uint8(tableIDExtension)}
+
+	if d.ProtocolVersion, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	if d.RegionName, err = parseLengthPrefixedMultipleString(i); err != nil {
+		return
+	}
+
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	for range b {
+		dim := RRTDimension{}
+		if dim.Name, err = parseLengthPrefixedMultipleString(i); err != nil {
+			return
+		}
+		var f byte
+		if f, err = i.NextByte(); err != nil {
+			err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+			return
+		}
+		dim.GraduatedScale = f&0x10 > 0
+		for range f & 0xf {
+			v := RRTRatingValue{}
+			if v.Abbrev, err = parseLengthPrefixedMultipleString(i); err != nil {
+				return
+			}
+			if v.Value, err = parseLengthPrefixedMultipleString(i); err != nil {
+				return
+			}
+			dim.Values = append(dim.Values, v)
+		}
+		d.Dimensions = append(d.Dimensions, dim)
+	}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	var dn int
+	if d.Descriptors, dn, err = parseDescriptorsN(i.Bytes(), int(binary.BigEndian.Uint16(bs)&0x3ff), keepRaw); err != nil {
+		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+		return
+	}
+	i.Skip(dn)
+	return
+}
+
+func (d *RRT) CalcSectionLength() int {
+	n := 2 + d.RegionName.calcLength() + 1 // protocol_version + rating_region_name_length + dimensions_defined
+	for j := range d.Dimensions {
+		n += 2 + d.Dimensions[j].Name.calcLength() // dimension_name_length + graduated_scale/values_defined
+		for _, v := range d.Dimensions[j].Values {
+			n += 2 + v.Abbrev.calcLength() + v.Value.calcLength()
+		}
+	}
+	return n + 2 + descriptor.CalcLength(d.Descriptors)
+}
+
+func (d *RRT) appendSection(dst []byte) []byte {
+	dst = append(dst, d.ProtocolVersion, uint8(d.RegionName.calcLength()))
+	dst = d.RegionName.append(dst)
+	dst = append(dst, uint8(len(d.Dimensions)))
+	for j := range d.Dimensions {
+		dim := &d.Dimensions[j]
+		dst = append(dst, uint8(dim.Name.calcLength()))
+		dst = dim.Name.append(dst)
+		dst = append(dst, 0xe0|util.B2U(dim.GraduatedScale)<<4|uint8(len(dim.Values))&0xf)
+		for _, v := range dim.Values {
+			dst = append(dst, uint8(v.Abbrev.calcLength()))
+			dst = v.Abbrev.append(dst)
+			dst = append(dst, uint8(v.Value.calcLength()))
+			dst = v.Value.append(dst)
+		}
+	}
+	l := descriptor.CalcLength(d.Descriptors)
+	dst = append(dst, 0xfc|byte(l>>8)&0x3, byte(l))
+	return descriptor.Append(dst, d.Descriptors)
+}
@@ -0,0 +1,129 @@
+package psi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseATSCEITSection(t *testing.T) {
+	bs := []byte{
+		0x00, 0x01, // protocol_version, num_events_in_section
+		0xc0, 0x2a, // event_id 42
+		0x4b, 0x20, 0xdb, 0xca, // start_time
+		0xd0, 0x0e, 0x10, // ETM_location 1, length_in_seconds 3600
+		0x0c,                                                            // title_length
+		0x01, 'e', 'n', 'g', 0x01, 0x00, 0x00, 0x04, 'N', 'e', 'w', 's', // title_text
+		0xf0, 0x04, 0xa0, 0x02, 0x01, 0x02, // descriptors_length, one user defined descriptor
+	}
+	d, err := parseATSCEITSection(bytesiter.New(bs), 0x0003, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(3), d.SourceID)
+	require.Len(t, d.Events, 1)
+
+	e := d.Events[0]
+	assert.Equal(t, uint16(42), e.EventID)
+	assert.Equal(t, uint8(1), e.ETMLocation)
+	assert.Equal(t, uint32(3600), e.LengthInSeconds)
+	assert.Equal(t, time.Date(2019, time.December, 15, 11, 30, 0, 0, time.UTC), GPSTime(e.StartTime, 18))
+	title, err := e.Title.Text([3]byte{'e', 'n', 'g'})
+	require.NoError(t, err)
+	assert.Equal(t, "News", title)
+	require.Len(t, e.Descriptors, 1)
+	assert.Equal(t, descriptor.Tag(0xa0), e.Descriptors[0].Tag())
+
+	assert.Equal(t, len(bs), d.CalcSectionLength())
+	assert.Equal(t, bs, d.appendSection(nil))
+}
+
+func TestParseATSCETTSection(t *testing.T) {
+	bs := []byte{
+		0x00,                   // protocol_version
+		0x00, 0x03, 0x00, 0xaa, // ETM_id: source 3, event 42
+		0x02,                                                         // number_strings
+		'f', 'r', 'a', 0x01, 0x00, 0x3f, 0x04, 0x00, 0xe9, 0x00, 't', // UTF-16 "ét"
+		'e', 'n', 'g', 0x01, 0x01, 0x00, 0x02, 0x9c, 0x40, // Huffman coded
+	}
+	d, err := parseATSCETTSection(bytesiter.New(bs), len(bs))
+	require.NoError(t, err)
+	assert.Equal(t, ETMID(3, 42), d.ETMID)
+
+	text, err := d.ExtendedText.Text([3]byte{'f', 'r', 'a'})
+	require.NoError(t, err)
+	assert.Equal(t, "ét", text)
+	_, err = d.ExtendedText.Text([3]byte{'e', 'n', 'g'})
+	assert.ErrorIs(t, err, ErrUnsupportedText)
+
+	assert.Equal(t, bs, d.appendSection(nil))
+}
+
+func TestMultipleStringHuffman(t *testing.T) {
+	defer func(tree []byte) { huffmanTitleTree = tree }(huffmanTitleTree)
+
+	// context 'A': 0 'B', 1 terminate; any other: 0 'A', 10 escape, 11 terminate
+	tree := make([]byte, 2*huffmanContexts, 2*huffmanContexts+6)
+	for j := range huffmanContexts {
+		tree[2*j] = 0x01 // root at 0x0100
+	}
+	tree[2*'A'+1] = 0x04 // root at 0x0104
+	tree = append(tree, 0x80|'A', 0x01, 0x80|huffmanEscape, 0x80|huffmanTerminate, 0x80|'B', 0x80|huffmanTerminate)
+
+	seg := MultipleStringSegment{
+		// A B escape 0xe9 'x' A terminate
+		Bytes:           []byte{0x2e, 0x97, 0x84},
+		CompressionType: TextCompressionHuffmanTitle,
+	}
+	_, err := seg.Text()
+	assert.ErrorIs(t, err, ErrUnsupportedText)
+
+	huffmanTitleTree = tree
+	text, err := seg.Text()
+	require.NoError(t, err)
+	assert.Equal(t, "ABéxA", text)
+
+	seg.CompressionType = TextCompressionHuffmanProgram
+	_, err = seg.Text()
+	assert.ErrorIs(t, err, ErrUnsupportedText)
+}
+
+func TestParseRRTSection(t *testing.T) {
+	bs := []byte{
+		0x00,                                                        // protocol_version
+		0x0a, 0x01, 'e', 'n', 'g', 0x01, 0x00, 0x00, 0x02, 'U', 'S', // rating_region_name_text
+		0x01,                                                                  // dimensions_defined
+		0x0c, 0x01, 'e', 'n', 'g', 0x01, 0x00, 0x00, 0x04, 'M', 'P', 'A', 'A', // dimension_name_text
+		0xf2,       // graduated_scale, values_defined 2
+		0x00, 0x00, // value 0: empty abbrev and value texts
+		0x09, 0x01, 'e', 'n', 'g', 0x01, 0x00, 0x00, 0x01, 'G', // value 1 abbrev
+		0x00,       // value 1 text
+		0xfc, 0x00, // descriptors_length
+	}
+	d, err := parseRRTSection(bytesiter.New(bs), 0xff01, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(1), d.RatingRegion)
+	require.Len(t, d.Dimensions, 1)
+	assert.True(t, d.Dimensions[0].GraduatedScale)
+	require.Len(t, d.Dimensions[0].Values, 2)
+
+	ca, err := ParseContentAdvisory([]byte{
+		0xc1,       // rating_region_count
+		0x01, 0x01, // rating_region 1, rated_dimensions 1
+		0x00, 0xf1, // dimension 0, value 1
+		0x00, // rating_description_length
+	})
+	require.NoError(t, err)
+	require.Len(t, ca, 1)
+	require.Len(t, ca[0].Dimensions, 1)
+	label, err := d.Label(ca[0].Dimensions[0].Dimension, ca[0].Dimensions[0].Value, [3]byte{'e', 'n', 'g'})
+	require.NoError(t, err)
+	assert.Equal(t, "G", label)
+	_, err = d.Label(0, 5, [3]byte{})
+	assert.Error(t, err)
+
+	assert.Equal(t, len(bs), d.CalcSectionLength())
+	assert.Equal(t, bs, d.appendSection(nil))
+}
@@ -27,11 +27,11 @@ type BATTransportStream struct {
 }
 
 // parseBATSection parses a BAT section
-func parseBATSection(i *bytesiter.Iterator, tableIDExtension uint16) (d *BAT, err error) {
+func parseBATSection(i *bytesiter.Iterator, tableIDExtension uint16, keepRaw bool) (d *BAT, err error) {
 	d = &BAT{BouquetID: tableIDExtension}
 
 	var dn int
-	if d.BouquetDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+	if d.BouquetDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 		return
 	}
@@ -58,7 +58,7 @@ func parseBATSection(i *bytesiter.Iterator, tableIDExtension uint16) (d *BAT, er
 		s.TransportStreamID = uint16(val >> 16)
 		s.OriginalNetworkID = uint16(val)
 
-		if s.TransportDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if s.TransportDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
@@ -15,7 +15,7 @@ func TestParseBATSection(t *testing.T) {
 		0xf0, 0x06, // transport_stream_loop_length = 6
 		0x00, 0x01, 0x00, 0x02, 0xf0, 0x00, // TS: tsid 1, onid 2, no descriptors
 	}
-	d, err := parseBATSection(bytesiter.New(bs), 0x1234)
+	d, err := parseBATSection(bytesiter.New(bs), 0x1234, false)
 	require.NoError(t, err)
 
 	assert.Equal(t, uint16(0x1234), d.BouquetID)
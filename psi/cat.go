@@ -16,14 +16,14 @@ type CAT struct {
 
 // parseCATSection parses a CAT section. Its descriptor loop is bounded by the
 // section length rather than a leading loop-length, so it uses descriptor.ParseN.
-func parseCATSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *CAT, err error) {
+func parseCATSection(i *bytesiter.Iterator, offsetSectionsEnd int, keepRaw bool) (d *CAT, err error) {
 	d = &CAT{}
 	length := offsetSectionsEnd - i.Offset()
 	if length <= 0 {
 		return
 	}
 	var n int
-	if d.Descriptors, n, err = descriptor.ParseN(i.Bytes(), length); err != nil {
+	if d.Descriptors, n, err = parseDescriptorsN(i.Bytes(), length, keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing CAT descriptors failed: %w", err)
 		return
 	}
@@ -0,0 +1,324 @@
+package psi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// DSM-CC U-N download message ids (ISO/IEC 13818-6 §7.3)
+const (
+	DSMCCMessageIDDownloadInfoIndication   uint16 = 0x1002
+	DSMCCMessageIDDownloadDataBlock        uint16 = 0x1003
+	DSMCCMessageIDDownloadServerInitiate   uint16 = 0x1006
+	DSMCCProtocolDiscriminator             uint8  = 0x11
+	DSMCCTypeUNDownload                    uint8  = 0x03
+	dsmccMessageHeaderLength                      = 12
+	dsmccDownloadServerInitiateFixedLength        = 24 // server_id, compatibility descriptor and private data lengths
+)
+
+// DSMCC represents a DSM-CC section (ISO/IEC 13818-6 §9.2): a download
+// control message (table id 0x3b) or a download data message (0x3c) of an
+// object carousel or a system software update. The message header is kept in
+// the fields below; DSI, DII and DDB messages are decoded into their own
+// struct, any other message keeps its body in Payload. The section checksum
+// form (section_syntax_indicator 0) is not supported, a CRC32 is assumed.
+// Link: https://www.etsi.org/deliver/etsi_tr/101200_101299/101202/01.02.01_60/tr_101202v010201p.pdf
+type DSMCC struct {
+	DSI                   *DSMCCDownloadServerInitiate `json:"download_server_initiate,omitempty"`
+	DII                   *DSMCCDownloadInfoIndication `json:"download_info_indication,omitempty"`
+	DDB                   *DSMCCDownloadDataBlock      `json:"download_data_block,omitempty"`
+	Adaptation            []byte                       `json:"dsmcc_adaptation_header,omitempty"`
+	Payload               []byte                       `json:"payload,omitempty"`
+	TransactionID         uint32                       `json:"transaction_id"` // download_id for a DDB
+	MessageID             uint16                       `json:"message_id"`
+	ProtocolDiscriminator uint8                        `json:"protocol_discriminator"`
+	DSMCCType             uint8                        `json:"dsmcc_type"`
+}
+
+// DSMCCDownloadServerInitiate is a DownloadServerInitiate message: the entry
+// point of a carousel. For an object carousel PrivateData holds the
+// ServiceGatewayInfo, for a data carousel the GroupInfoIndication.
+type DSMCCDownloadServerInitiate struct {
+	ServerID                [20]byte `json:"server_id"`
+	CompatibilityDescriptor []byte   `json:"compatibility_descriptor,omitempty"`
+	PrivateData             []byte   `json:"private_data,omitempty"`
+}
+
+// DSMCCDownloadInfoIndication is a DownloadInfoIndication message: it lists
+// the modules of a carousel and the block size they are split into.
+type DSMCCDownloadInfoIndication struct {
+	CompatibilityDescriptor []byte        `json:"compatibility_descriptor,omitempty"`
+	Modules                 []DSMCCModule `json:"_modules"`
+	PrivateData             []byte        `json:"private_data,omitempty"`
+	DownloadID              uint32        `json:"download_id"`
+	TCDownloadWindow        uint32        `json:"t_c_download_window"`
+	TCDownloadScenario      uint32        `json:"t_c_download_scenario"`
+	BlockSize               uint16        `json:"block_size"`
+	WindowSize              uint8         `json:"window_size"`
+	AckPeriod               uint8         `json:"ack_period"`
+}
+
+// DSMCCModule is one module entry of a DownloadInfoIndication.
+type DSMCCModule struct {
+	ModuleInfo    []byte `json:"module_info,omitempty"`
+	ModuleSize    uint32 `json:"module_size"`
+	ModuleID      uint16 `json:"module_id"`
+	ModuleVersion uint8  `json:"module_version"`
+}
+
+// DSMCCDownloadDataBlock is a DownloadDataBlock message: block BlockNumber of
+// module ModuleID, BlockSize bytes long except for the last one.
+type DSMCCDownloadDataBlock struct {
+	BlockData     []byte `json:"block_data"`
+	ModuleID      uint16 `json:"module_id"`
+	BlockNumber   uint16 `json:"block_number"`
+	ModuleVersion uint8  `json:"module_version"`
+}
+
+// parseDSMCCSection parses a DSM-CC section
+func parseDSMCCSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *DSMCC, err error) {
+	d = &DSMCC{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(dsmccMessageHeaderLength); err != nil || len(bs) < dsmccMessageHeaderLength {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.ProtocolDiscriminator = bs[0]
+	d.DSMCCType = bs[1]
+	d.MessageID = binary.BigEndian.Uint16(bs[2:])
+	d.TransactionID = binary.BigEndian.Uint32(bs[4:])
+	adaptationLength := int(bs[9])
+	messageLength := int(binary.BigEndian.Uint16(bs[10:]))
+
+	if i.Offset()+messageLength > offsetSectionsEnd || adaptationLength > messageLength {
+		err = fmt.Errorf("astits: DSM-CC message length %d exceeds section: %w", messageLength, ts.ErrInvalidData)
+		return
+	}
+	if adaptationLength > 0 {
+		if d.Adaptation, err = i.NextBytes(adaptationLength); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+	}
+
+	var body []byte
+	if body, err = i.NextBytesNoCopy(messageLength - adaptationLength); err != nil {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	bi := bytesiter.New(body)
+
+	switch d.MessageID {
+	case DSMCCMessageIDDownloadServerInitiate:
+		if d.DSI, err = parseDSMCCDownloadServerInitiate(bi); err != nil {
+			err = fmt.Errorf("astits: parsing DSI failed: %w", err)
+			return
+		}
+	case DSMCCMessageIDDownloadInfoIndication:
+		if d.DII, err = parseDSMCCDownloadInfoIndication(bi); err != nil {
+			err = fmt.Errorf("astits: parsing DII failed: %w", err)
+			return
+		}
+	case DSMCCMessageIDDownloadDataBlock:
+		if d.DDB, err = parseDSMCCDownloadDataBlock(bi); err != nil {
+			err = fmt.Errorf("astits: parsing DDB failed: %w", err)
+			return
+		}
+	default:
+		if len(body) > 0 {
+			d.Payload = append([]byte(nil), body...)
+		}
+	}
+	return
+}
+
+// nextDSMCCBytes reads a 16-bit length followed by that many bytes, as used by
+// compatibilityDescriptor() and the private data fields.
+func nextDSMCCBytes(i *bytesiter.Iterator) (b []byte, err error) {
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	if l := int(binary.BigEndian.Uint16(bs)); l > 0 {
+		if b, err = i.NextBytes(l); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+	}
+	return
+}
+
+func parseDSMCCDownloadServerInitiate(i *bytesiter.Iterator) (d *DSMCCDownloadServerInitiate, err error) {
+	d = &DSMCCDownloadServerInitiate{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(len(d.ServerID)); err != nil || len(bs) < len(d.ServerID) {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	copy(d.ServerID[:], bs)
+
+	if d.CompatibilityDescriptor, err = nextDSMCCBytes(i); err != nil {
+		return
+	}
+	d.PrivateData, err = nextDSMCCBytes(i)
+	return
+}
+
+func parseDSMCCDownloadInfoIndication(i *bytesiter.Iterator) (d *DSMCCDownloadInfoIndication, err error) {
+	d = &DSMCCDownloadInfoIndication{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(16); err != nil || len(bs) < 16 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.DownloadID = binary.BigEndian.Uint32(bs)
+	d.BlockSize = binary.BigEndian.Uint16(bs[4:])
+	d.WindowSize = bs[6]
+	d.AckPeriod = bs[7]
+	d.TCDownloadWindow = binary.BigEndian.Uint32(bs[8:])
+	d.TCDownloadScenario = binary.BigEndian.Uint32(bs[12:])
+
+	if d.CompatibilityDescriptor, err = nextDSMCCBytes(i); err != nil {
+		return
+	}
+
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	numberOfModules := int(binary.BigEndian.Uint16(bs))
+	for range numberOfModules {
+		m := DSMCCModule{}
+		if bs, err = i.NextBytesNoCopy(8); err != nil || len(bs) < 8 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		m.ModuleID = binary.BigEndian.Uint16(bs)
+		m.ModuleSize = binary.BigEndian.Uint32(bs[2:])
+		m.ModuleVersion = bs[6]
+		if l := int(bs[7]); l > 0 {
+			if m.ModuleInfo, err = i.NextBytes(l); err != nil {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+		}
+		d.Modules = append(d.Modules, m)
+	}
+
+	d.PrivateData, err = nextDSMCCBytes(i)
+	return
+}
+
+func parseDSMCCDownloadDataBlock(i *bytesiter.Iterator) (d *DSMCCDownloadDataBlock, err error) {
+	d = &DSMCCDownloadDataBlock{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(6); err != nil || len(bs) < 6 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.ModuleID = binary.BigEndian.Uint16(bs)
+	d.ModuleVersion = bs[2]
+	d.BlockNumber = binary.BigEndian.Uint16(bs[4:])
+
+	if l := i.Len() - i.Offset(); l > 0 {
+		if d.BlockData, err = i.NextBytes(l); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+	}
+	return
+}
+
+func (d *DSMCCDownloadServerInitiate) calcLength() int {
+	return dsmccDownloadServerInitiateFixedLength + len(d.CompatibilityDescriptor) + len(d.PrivateData)
+}
+
+func (d *DSMCCDownloadServerInitiate) append(dst []byte) []byte {
+	dst = append(dst, d.ServerID[:]...)
+	dst = appendDSMCCBytes(dst, d.CompatibilityDescriptor)
+	return appendDSMCCBytes(dst, d.PrivateData)
+}
+
+func (d *DSMCCDownloadInfoIndication) calcLength() (ret int) {
+	ret = 16 + 2 + len(d.CompatibilityDescriptor) + 2
+	for j := range d.Modules {
+		ret += 8 + len(d.Modules[j].ModuleInfo)
+	}
+	return ret + 2 + len(d.PrivateData)
+}
+
+func (d *DSMCCDownloadInfoIndication) append(dst []byte) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, d.DownloadID)
+	dst = binary.BigEndian.AppendUint16(dst, d.BlockSize)
+	dst = append(dst, d.WindowSize, d.AckPeriod)
+	dst = binary.BigEndian.AppendUint32(dst, d.TCDownloadWindow)
+	dst = binary.BigEndian.AppendUint32(dst, d.TCDownloadScenario)
+	dst = appendDSMCCBytes(dst, d.CompatibilityDescriptor)
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(d.Modules)))
+	for j := range d.Modules {
+		m := &d.Modules[j]
+		dst = binary.BigEndian.AppendUint16(dst, m.ModuleID)
+		dst = binary.BigEndian.AppendUint32(dst, m.ModuleSize)
+		dst = append(dst, m.ModuleVersion, uint8(len(m.ModuleInfo)))
+		dst = append(dst, m.ModuleInfo...)
+	}
+	return appendDSMCCBytes(dst, d.PrivateData)
+}
+
+func (d *DSMCCDownloadDataBlock) calcLength() int {
+	return 6 + len(d.BlockData)
+}
+
+func (d *DSMCCDownloadDataBlock) append(dst []byte) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, d.ModuleID)
+	dst = append(dst, d.ModuleVersion, 0xff)
+	dst = binary.BigEndian.AppendUint16(dst, d.BlockNumber)
+	return append(dst, d.BlockData...)
+}
+
+func appendDSMCCBytes(dst, b []byte) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(b)))
+	return append(dst, b...)
+}
+
+func (d *DSMCC) messageBodyLength() int {
+	switch {
+	case d.DSI != nil:
+		return d.DSI.calcLength()
+	case d.DII != nil:
+		return d.DII.calcLength()
+	case d.DDB != nil:
+		return d.DDB.calcLength()
+	}
+	return len(d.Payload)
+}
+
+func (d *DSMCC) CalcSectionLength() int {
+	return dsmccMessageHeaderLength + len(d.Adaptation) + d.messageBodyLength()
+}
+
+func (d *DSMCC) appendSection(dst []byte) []byte {
+	dst = append(dst, d.ProtocolDiscriminator, d.DSMCCType)
+	dst = binary.BigEndian.AppendUint16(dst, d.MessageID)
+	dst = binary.BigEndian.AppendUint32(dst, d.TransactionID)
+	dst = append(dst, 0xff, uint8(len(d.Adaptation)))
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(d.Adaptation)+d.messageBodyLength()))
+	dst = append(dst, d.Adaptation...)
+	switch {
+	case d.DSI != nil:
+		return d.DSI.append(dst)
+	case d.DII != nil:
+		return d.DII.append(dst)
+	case d.DDB != nil:
+		return d.DDB.append(dst)
+	}
+	return append(dst, d.Payload...)
+}
@@ -0,0 +1,65 @@
+package psi
+
+import (
+	"testing"
+
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDSMCCSection(t *testing.T) {
+	t.Run("DII", func(t *testing.T) {
+		bs := []byte{
+			0x11, 0x03, 0x10, 0x02, // protocol_discriminator, dsmcc_type, message_id
+			0x80, 0x00, 0x00, 0x02, // transaction_id
+			0xff, 0x00, 0x00, 0x1f, // reserved, adaptation_length, message_length 31
+			0x00, 0x00, 0x00, 0x01, // download_id
+			0x0f, 0xf4, 0x00, 0x00, // block_size 4084, window_size, ack_period
+			0x00, 0x00, 0x00, 0x00, // tc_download_window
+			0xff, 0xff, 0xff, 0xff, // tc_download_scenario
+			0x00, 0x00, // compatibility_descriptor_length
+			0x00, 0x01, // number_of_modules
+			0x00, 0x07, 0x00, 0x00, 0x10, 0x00, 0x03, 0x01, 0xaa, // module 7, 4096 bytes, version 3, 1 info byte
+			0x00, 0x00, // private_data_length
+		}
+		d, err := parseDSMCCSection(bytesiter.New(bs), len(bs))
+		require.NoError(t, err)
+		assert.Equal(t, &DSMCC{
+			ProtocolDiscriminator: DSMCCProtocolDiscriminator,
+			DSMCCType:             DSMCCTypeUNDownload,
+			MessageID:             DSMCCMessageIDDownloadInfoIndication,
+			TransactionID:         0x80000002,
+			DII: &DSMCCDownloadInfoIndication{
+				DownloadID:         1,
+				BlockSize:          4084,
+				TCDownloadScenario: 0xffffffff,
+				Modules:            []DSMCCModule{{ModuleID: 7, ModuleSize: 4096, ModuleVersion: 3, ModuleInfo: []byte{0xaa}}},
+			},
+		}, d)
+		assert.Equal(t, len(bs), d.CalcSectionLength())
+		assert.Equal(t, bs, d.appendSection(nil))
+	})
+
+	t.Run("DDB", func(t *testing.T) {
+		bs := []byte{
+			0x11, 0x03, 0x10, 0x03, // protocol_discriminator, dsmcc_type, message_id
+			0x00, 0x00, 0x00, 0x01, // download_id
+			0xff, 0x00, 0x00, 0x09, // reserved, adaptation_length, message_length 9
+			0x00, 0x07, 0x03, 0xff, // module_id, module_version, reserved
+			0x00, 0x02, // block_number
+			0x01, 0x02, 0x03, // block data
+		}
+		d, err := parseDSMCCSection(bytesiter.New(bs), len(bs))
+		require.NoError(t, err)
+		require.NotNil(t, d.DDB)
+		assert.Equal(t, &DSMCCDownloadDataBlock{ModuleID: 7, ModuleVersion: 3, BlockNumber: 2, BlockData: []byte{1, 2, 3}}, d.DDB)
+		assert.Equal(t, bs, d.appendSection(nil))
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		bs := []byte{0x11, 0x03, 0x10, 0x03, 0x00, 0x00, 0x00, 0x01, 0xff, 0x00, 0x00, 0x40}
+		_, err := parseDSMCCSection(bytesiter.New(bs), len(bs))
+		assert.Error(t, err)
+	})
+}
@@ -34,7 +34,7 @@ type EITEvent struct {
 }
 
 // parseEITSection parses an EIT section
-func parseEITSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16) (d *EIT, err error) {
+func parseEITSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *EIT, err error) {
 	d = &EIT{ServiceID: tableIDExtension}
 
 	var bs []byte
@@ -94,7 +94,7 @@ func parseEITSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtens
 		i.Skip(-1)
 
 		var dn int
-		if e.Descriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if e.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
@@ -44,7 +44,7 @@ func eitBytes() []byte {
 
 func TestParseEITSection(t *testing.T) {
 	var b = eitBytes()
-	d, err := parseEITSection(bytesiter.New(b), len(b), uint16(1))
+	d, err := parseEITSection(bytesiter.New(b), len(b), uint16(1), false)
 	assert.Equal(t, d, eit)
 	assert.NoError(t, err)
 }
@@ -22,4 +22,5 @@ func TestEnumJSONRoundtrip(t *testing.T) {
 	t.Run("TableID", testEnumJSONRoundtrip[TableID])
 	t.Run("StreamType", testEnumJSONRoundtrip[StreamType])
 	t.Run("RunningStatus", testEnumJSONRoundtrip[RunningStatus])
+	t.Run("SpliceCommandType", testEnumJSONRoundtrip[SpliceCommandType])
 }
@@ -0,0 +1,237 @@
+package psi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/internal/util"
+)
+
+// BIT represents an ISDB broadcaster information table section: the
+// broadcasters of a network (OriginalNetworkID, carried as table_id_extension)
+// and their SI transmission parameters.
+// Chapter: 5.2.13 | Link: https://www.arib.or.jp/english/html/overview/doc/6-STD-B10v5_1-E1.pdf
+type BIT struct {
+	Descriptors            []descriptor.Descriptor `json:"_first_descriptors"`
+	Broadcasters           []BITBroadcaster        `json:"_broadcasters"`
+	OriginalNetworkID      uint16                  `json:"original_network_id"`
+	BroadcastViewPropriety bool                    `json:"broadcast_view_propriety"`
+}
+
+// BITBroadcaster represents one broadcaster entry of a BIT
+type BITBroadcaster struct {
+	Descriptors   []descriptor.Descriptor `json:"_descriptors"`
+	BroadcasterID uint8                   `json:"broadcaster_id"`
+}
+
+// NBIT represents an ISDB network board information table section: bulletin
+// board information of a network (OriginalNetworkID, carried as
+// table_id_extension). TableIDNBITBody sections carry the information itself,
+// TableIDNBITReference sections the references to obtain it.
+// Chapter: 5.2.15 | Link: https://www.arib.or.jp/english/html/overview/doc/6-STD-B10v5_1-E1.pdf
+type NBIT struct {
+	Informations      []NBITInformation `json:"_informations"`
+	OriginalNetworkID uint16            `json:"original_network_id"`
+}
+
+// NBITInformation represents one board information entry of an NBIT
+type NBITInformation struct {
+	Descriptors             []descriptor.Descriptor `json:"_descriptors"`
+	KeyIDs                  []uint16                `json:"key_id"`
+	InformationID           uint16                  `json:"information_id"`
+	InformationType         uint8                   `json:"information_type"`
+	DescriptionBodyLocation uint8                   `json:"description_body_location"`
+	UserDefined             uint8                   `json:"user_defined"`
+}
+
+// LDT represents an ISDB linked description table section: descriptions
+// shared by several events or services, referenced by DescriptionID from
+// other tables of the service (OriginalServiceID, carried as
+// table_id_extension).
+// Chapter: 5.2.15 | Link: https://www.arib.or.jp/english/html/overview/doc/6-STD-B10v5_1-E1.pdf
+type LDT struct {
+	Descriptions      []LDTDescription `json:"_descriptions"`
+	OriginalServiceID uint16           `json:"original_service_id"`
+	TransportStreamID uint16           `json:"transport_stream_id"`
+	OriginalNetworkID uint16           `json:"original_network_id"`
+}
+
+// LDTDescription represents one description entry of an LDT
+type LDTDescription struct {
+	Descriptors   []descriptor.Descriptor `json:"_descriptors"`
+	DescriptionID uint16                  `json:"description_id"`
+}
+
+// parseBITSection parses a BIT section
+func parseBITSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *BIT, err error) {
+	d = &BIT{OriginalNetworkID: tableIDExtension}
+
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	d.BroadcastViewPropriety = b&0x10 > 0
+
+	// The flag shares its byte with first_descriptors_length; rewind so
+	// descriptor.Parse consumes it as its prefix.
+	i.Skip(-1)
+	var dn int
+	if d.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
+		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+		return
+	}
+	i.Skip(dn)
+
+	for i.Offset() < offsetSectionsEnd {
+		br := BITBroadcaster{}
+		if br.BroadcasterID, err = i.NextByte(); err != nil {
+			err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+			return
+		}
+		if br.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+			return
+		}
+		i.Skip(dn)
+		d.Broadcasters = append(d.Broadcasters, br)
+	}
+	return
+}
+
+func (d *BIT) CalcSectionLength() int {
+	n := 2 + descriptor.CalcLength(d.Descriptors) // flag + first_descriptors_length
+	for j := range d.Broadcasters {
+		n += 3 + descriptor.CalcLength(d.Broadcasters[j].Descriptors) // broadcaster_id + broadcaster_descriptors_length
+	}
+	return n
+}
+
+func (d *BIT) appendSection(dst []byte) []byte {
+	l := descriptor.CalcLength(d.Descriptors)
+	dst = append(dst, 0xe0|util.B2U(d.BroadcastViewPropriety)<<4|byte(l>>8)&0xf, byte(l))
+	dst = descriptor.Append(dst, d.Descriptors)
+	for j := range d.Broadcasters {
+		br := &d.Broadcasters[j]
+		l = descriptor.CalcLength(br.Descriptors)
+		dst = append(dst, br.BroadcasterID, 0xf0|byte(l>>8)&0xf, byte(l))
+		dst = descriptor.Append(dst, br.Descriptors)
+	}
+	return dst
+}
+
+// parseNBITSection parses an NBIT section
+func parseNBITSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *NBIT, err error) {
+	d = &NBIT{OriginalNetworkID: tableIDExtension}
+
+	var bs []byte
+	for i.Offset() < offsetSectionsEnd {
+		info := NBITInformation{}
+		if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		info.InformationID = binary.BigEndian.Uint16(bs)
+		info.InformationType = bs[2] >> 4
+		info.DescriptionBodyLocation = bs[2] >> 2 & 0x3
+		info.UserDefined = bs[3]
+
+		if numberOfKeys := int(bs[4]); numberOfKeys > 0 {
+			if bs, err = i.NextBytesNoCopy(2 * numberOfKeys); err != nil || len(bs) < 2*numberOfKeys {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+			info.KeyIDs = make([]uint16, numberOfKeys)
+			for j := range info.KeyIDs {
+				info.KeyIDs[j] = binary.BigEndian.Uint16(bs[2*j:])
+			}
+		}
+
+		var dn int
+		if info.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+			return
+		}
+		i.Skip(dn)
+		d.Informations = append(d.Informations, info)
+	}
+	return
+}
+
+func (d *NBIT) CalcSectionLength() (n int) {
+	for j := range d.Informations {
+		n += 7 + 2*len(d.Informations[j].KeyIDs) + descriptor.CalcLength(d.Informations[j].Descriptors) // information_id(2) + type/location(1) + user_defined(1) + number_of_keys(1) + descriptors_loop_length(2)
+	}
+	return
+}
+
+func (d *NBIT) appendSection(dst []byte) []byte {
+	for j := range d.Informations {
+		info := &d.Informations[j]
+		dst = binary.BigEndian.AppendUint16(dst, info.InformationID)
+		dst = append(dst, info.InformationType<<4|info.DescriptionBodyLocation&0x3<<2|0x3, info.UserDefined, uint8(len(info.KeyIDs)))
+		for _, k := range info.KeyIDs {
+			dst = binary.BigEndian.AppendUint16(dst, k)
+		}
+		l := descriptor.CalcLength(info.Descriptors)
+		dst = append(dst, 0xf0|byte(l>>8)&0xf, byte(l))
+		dst = descriptor.Append(dst, info.Descriptors)
+	}
+	return dst
+}
+
+// parseLDTSection parses an LDT section
+func parseLDTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *LDT, err error) {
+	d = &LDT{OriginalServiceID: tableIDExtension}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.TransportStreamID = binary.BigEndian.Uint16(bs)
+	d.OriginalNetworkID = binary.BigEndian.Uint16(bs[2:])
+
+	for i.Offset() < offsetSectionsEnd {
+		desc := LDTDescription{}
+		if bs, err = i.NextBytesNoCopy(3); err != nil || len(bs) < 3 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		desc.DescriptionID = binary.BigEndian.Uint16(bs)
+
+		// reserved_future_use spans 12 bits: the last one shares its byte with
+		// descriptors_loop_length, which descriptor.Parse masks out.
+		var dn int
+		if desc.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
+			return
+		}
+		i.Skip(dn)
+		d.Descriptions = append(d.Descriptions, desc)
+	}
+	return
+}
+
+func (d *LDT) CalcSectionLength() int {
+	n := 4 // transport_stream_id + original_network_id
+	for j := range d.Descriptions {
+		n += 5 + descriptor.CalcLength(d.Descriptions[j].Descriptors) // description_id(2) + reserved_future_use/descriptors_loop_length(3)
+	}
+	return n
+}
+
+func (d *LDT) appendSection(dst []byte) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, d.TransportStreamID)
+	dst = binary.BigEndian.AppendUint16(dst, d.OriginalNetworkID)
+	for j := range d.Descriptions {
+		desc := &d.Descriptions[j]
+		l := descriptor.CalcLength(desc.Descriptors)
+		dst = binary.BigEndian.AppendUint16(dst, desc.DescriptionID)
+		dst = append(dst, 0xff, 0xf0|byte(l>>8)&0xf, byte(l))
+		dst = descriptor.Append(dst, desc.Descriptors)
+	}
+	return dst
+}
@@ -0,0 +1,58 @@
+package psi
+
+import (
+	"testing"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBITSection(t *testing.T) {
+	bs := []byte{
+		0xf0, 0x00, // broadcast_view_propriety, first_descriptors_length 0
+		0x01,                         // broadcaster_id
+		0xf0, 0x03, 0x52, 0x01, 0x07, // broadcaster_descriptors_length, stream identifier
+	}
+	d, err := parseBITSection(bytesiter.New(bs), len(bs), 0x7fe0, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x7fe0), d.OriginalNetworkID)
+	assert.True(t, d.BroadcastViewPropriety)
+	require.Len(t, d.Broadcasters, 1)
+	assert.Equal(t, uint8(1), d.Broadcasters[0].BroadcasterID)
+	require.Len(t, d.Broadcasters[0].Descriptors, 1)
+	si, ok := d.Broadcasters[0].Descriptors[0].(*descriptor.StreamIdentifier)
+	require.True(t, ok)
+	assert.Equal(t, uint8(7), si.ComponentTag)
+	assert.Equal(t, bs, d.appendSection(nil))
+}
+
+func TestParseNBITSection(t *testing.T) {
+	bs := []byte{
+		0x00, 0x10, // information_id
+		0x27, 0x00, 0x02, // information_type 2, description_body_location 1, user_defined, number_of_keys
+		0x00, 0x01, 0x00, 0x02, // key_id
+		0xf0, 0x00, // descriptors_loop_length
+	}
+	d, err := parseNBITSection(bytesiter.New(bs), len(bs), 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, []NBITInformation{{InformationID: 0x10, InformationType: 2, DescriptionBodyLocation: 1, KeyIDs: []uint16{1, 2}}}, d.Informations)
+	assert.Equal(t, bs, d.appendSection(nil))
+}
+
+func TestParseLDTSection(t *testing.T) {
+	bs := []byte{
+		0x7f, 0xe1, 0x7f, 0xe0, // transport_stream_id, original_network_id
+		0x00, 0x05, 0xff, 0xf0, 0x03, 0x52, 0x01, 0x09, // description 5, one stream identifier
+	}
+	d, err := parseLDTSection(bytesiter.New(bs), len(bs), 0x0400, false)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x0400), d.OriginalServiceID)
+	assert.Equal(t, uint16(0x7fe1), d.TransportStreamID)
+	require.Len(t, d.Descriptions, 1)
+	assert.Equal(t, uint16(5), d.Descriptions[0].DescriptionID)
+	require.Len(t, d.Descriptions[0].Descriptors, 1)
+	assert.Equal(t, len(bs), d.CalcSectionLength())
+	assert.Equal(t, bs, d.appendSection(nil))
+}
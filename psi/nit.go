@@ -25,11 +25,11 @@ type NITTransportStream struct {
 }
 
 // parseNITSection parses a NIT section
-func parseNITSection(i *bytesiter.Iterator, tableIDExtension uint16) (d *NIT, err error) {
+func parseNITSection(i *bytesiter.Iterator, tableIDExtension uint16, keepRaw bool) (d *NIT, err error) {
 	d = &NIT{NetworkID: tableIDExtension}
 
 	var dn int
-	if d.NetworkDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+	if d.NetworkDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 		return
 	}
@@ -56,7 +56,7 @@ func parseNITSection(i *bytesiter.Iterator, tableIDExtension uint16) (d *NIT, er
 		ts.TransportStreamID = uint16(val >> 16)
 		ts.OriginalNetworkID = uint16(val)
 
-		if ts.TransportDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if ts.TransportDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
@@ -36,7 +36,7 @@ func nitBytes() []byte {
 
 func TestParseNITSection(t *testing.T) {
 	var b = nitBytes()
-	d, err := parseNITSection(bytesiter.New(b), uint16(1))
+	d, err := parseNITSection(bytesiter.New(b), uint16(1), false)
 	assert.Equal(t, d, nit)
 	assert.NoError(t, err)
 }
@@ -59,7 +59,7 @@ type ElementaryStream struct {
 }
 
 // parsePMTSection parses a PMT section
-func parsePMTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16) (d *PMT, err error) {
+func parsePMTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *PMT, err error) {
 	d = &PMT{ProgramNumber: tableIDExtension}
 
 	var bs []byte
@@ -71,7 +71,7 @@ func parsePMTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtens
 	d.PCRPID = binary.BigEndian.Uint16(bs) & 0x1fff
 
 	var dn int
-	if d.ProgramDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+	if d.ProgramDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 		return
 	}
@@ -95,7 +95,7 @@ func parsePMTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtens
 
 		e.ElementaryPID = binary.BigEndian.Uint16(bs) & 0x1fff
 
-		if e.ElementaryStreamDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if e.ElementaryStreamDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
@@ -38,7 +38,7 @@ func pmtBytes() []byte {
 
 func TestParsePMTSection(t *testing.T) {
 	var b = pmtBytes()
-	d, err := parsePMTSection(bytesiter.New(b), len(b), uint16(1))
+	d, err := parsePMTSection(bytesiter.New(b), len(b), uint16(1), false)
 	assert.Equal(t, d, pmt)
 	assert.NoError(t, err)
 }
@@ -53,7 +53,7 @@ func BenchmarkParsePMTSection(b *testing.B) {
 	bs := pmtBytes()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = parsePMTSection(bytesiter.New(bs), len(bs), uint16(1))
+		_, _ = parsePMTSection(bytesiter.New(bs), len(bs), uint16(1), false)
 	}
 }
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/k-danil/go-astits/v2/descriptor"
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
 	"github.com/k-danil/go-astits/v2/internal/errclass"
 	"github.com/k-danil/go-astits/v2/internal/util"
@@ -14,17 +15,25 @@ import (
 
 // PSI table IDs
 const (
+	TableTypeATSCEIT  = "ATSCEIT"
+	TableTypeATSCETT  = "ATSCETT"
 	TableTypeBAT      = "BAT"
+	TableTypeBIT      = "BIT"
 	TableTypeCAT      = "CAT"
 	TableTypeDIT      = "DIT"
+	TableTypeDSMCC    = "DSMCC"
 	TableTypeEIT      = "EIT"
 	TableTypeISO14496 = "ISO14496"
+	TableTypeLDT      = "LDT"
 	TableTypeMetadata = "Metadata"
+	TableTypeNBIT     = "NBIT"
 	TableTypeNIT      = "NIT"
 	TableTypeNull     = "Null"
 	TableTypePAT      = "PAT"
 	TableTypePMT      = "PMT"
+	TableTypeRRT      = "RRT"
 	TableTypeRST      = "RST"
+	TableTypeSCTE35   = "SCTE35"
 	TableTypeSDT      = "SDT"
 	TableTypeSIT      = "SIT"
 	TableTypeST       = "ST"
@@ -40,12 +49,19 @@ var ErrCRC32Mismatch = errclass.New("astits: CRC32 mismatch", ts.ErrInvalidData)
 // ErrTableNotImplemented reports a table type whose serialization is not implemented.
 var ErrTableNotImplemented = errors.New("astits: table serialization is not implemented")
 
-// ErrSectionOverflow reports table data that does not fit the 1021-byte section
-// limit; only PAT may span multiple sections, a PMT must fit one by spec.
+// ErrSectionOverflow reports table data that does not fit the section length
+// limit of its table (TableID.MaxSectionLength); only PAT may span multiple
+// sections, a PMT must fit one by spec.
 var ErrSectionOverflow = errors.New("astits: section data does not fit a single section")
 
-// maxSectionLength bounds the section_length field (12 bits, capped by spec).
-const maxSectionLength = 1021
+// MaxSectionLength bounds the section_length field of the PSI and most DVB SI
+// tables (12 bits, capped at 1021 by the spec so a section never exceeds 1024
+// bytes).
+const MaxSectionLength = 1021
+
+// MaxPrivateSectionLength bounds the section_length field of private sections,
+// EIT and the other tables allowed to reach 4096 bytes, 4093 by the spec.
+const MaxPrivateSectionLength = 4093
 
 // TableID identifies a PSI table (PAT, PMT, EIT, NIT, SDT, TOT, ...).
 type TableID uint8
@@ -61,6 +77,9 @@ const (
 	TableIDMetadata       TableID = 0x06
 	TableIDISO14496       TableID = 0x08
 
+	TableIDDSMCCUNMessage    TableID = 0x3b
+	TableIDDSMCCDownloadData TableID = 0x3c
+
 	TableIDNITVariant1 TableID = 0x40
 	TableIDNITVariant2 TableID = 0x41
 	TableIDSDTVariant1 TableID = 0x42
@@ -79,6 +98,17 @@ const (
 	TableIDDIT TableID = 0x7e
 	TableIDSIT TableID = 0x7f
 
+	TableIDBIT           TableID = 0xc4
+	TableIDNBITBody      TableID = 0xc5
+	TableIDNBITReference TableID = 0xc6
+	TableIDLDT           TableID = 0xc7
+
+	TableIDRRT     TableID = 0xca
+	TableIDATSCEIT TableID = 0xcb
+	TableIDATSCETT TableID = 0xcc
+
+	TableIDSCTE35 TableID = 0xfc
+
 	TableIDNull TableID = 0xff
 )
 
@@ -98,6 +128,8 @@ var tableIDNames = map[TableID]string{
 	TableIDISO14496Object:           "ISO_IEC_14496_object_descriptor_section",
 	TableIDMetadata:                 "Metadata_section",
 	TableIDISO14496:                 "ISO_IEC_14496_section",
+	TableIDDSMCCUNMessage:           "DSM-CC_section - U-N messages",
+	TableIDDSMCCDownloadData:        "DSM-CC_section - download data messages",
 	TableIDNITVariant1:              "network_information_section - actual_network",
 	TableIDNITVariant2:              "network_information_section - other_network",
 	TableIDSDTVariant1:              "service_description_section - actual_transport_stream",
@@ -111,6 +143,14 @@ var tableIDNames = map[TableID]string{
 	TableIDTOT:                      "time_offset_section",
 	TableIDDIT:                      "discontinuity_information_section",
 	TableIDSIT:                      "selection_information_section",
+	TableIDBIT:                      "broadcaster_information_section",
+	TableIDNBITBody:                 "network_board_information_section - information body",
+	TableIDNBITReference:            "network_board_information_section - reference",
+	TableIDLDT:                      "linked_description_section",
+	TableIDRRT:                      "rating_region_table_section",
+	TableIDATSCEIT:                  "event_information_table_section (ATSC)",
+	TableIDATSCETT:                  "extended_text_table_section (ATSC)",
+	TableIDSCTE35:                   "splice_info_section",
 	TableIDNull:                     "forbidden",
 }
 
@@ -151,11 +191,14 @@ type Section struct {
 	Syntax *SectionSyntax `json:"_syntax"`
 	CRC32  uint32         `json:"_crc32"` // A checksum of the entire table excluding the pointer field, pointer filler bytes and the trailing CRC32.
 	Header SectionHeader  `json:"_header"`
+	// CRC32Mismatch is set on a section kept by a CRCLenient parse although
+	// its CRC32 does not match.
+	CRC32Mismatch bool `json:"_crc32_mismatch,omitempty"`
 }
 
 // SectionHeader represents a PSI section header
 type SectionHeader struct {
-	SectionLength          uint16  `json:"section_length"`           // The number of bytes that follow for the syntax section (with CRC value) and/or table data. These bytes must not exceed a value of 1021.
+	SectionLength          uint16  `json:"section_length"`           // The number of bytes that follow for the syntax section (with CRC value) and/or table data. These bytes must not exceed a value of 1021 (4093 for private sections and EIT, see TableID.MaxSectionLength).
 	TableID                TableID `json:"table_id"`                 // Table Identifier, that defines the structure of the syntax section and other contained data. As an exception, if this is the byte that immediately follow previous table section and is set to 0xFF, then it indicates that the repeat of table section end here and the rest of TS data payload shall be stuffed with 0xFF. Consequently the value 0xFF shall not be used for the Table Identifier.
 	SectionSyntaxIndicator bool    `json:"section_syntax_indicator"` // A flag that indicates if the syntax section follows the section length. The PAT, PMT, and CAT all set this to 1.
 	PrivateBit             bool    `json:"private_indicator"`        // The PAT, PMT, and CAT all set this to 0. Other tables set this to 1.
@@ -181,8 +224,37 @@ type SectionSyntaxHeader struct {
 // SectionSyntaxData represents a PSI section syntax data
 type SectionSyntaxData any
 
+// CRCMode selects how parsing treats the CRC32 of the sections.
+type CRCMode uint8
+
+const (
+	// CRCVerify fails a section whose CRC32 does not match with
+	// ErrCRC32Mismatch.
+	CRCVerify CRCMode = iota
+	// CRCSkip does not compute the CRC32, for trusted input.
+	CRCSkip
+	// CRCLenient keeps a section whose CRC32 does not match, flagged by
+	// Section.CRC32Mismatch, for damaged but still useful captures.
+	CRCLenient
+)
+
 // Parse parses a PSI data
 func Parse(bs []byte) (d *Data, err error) {
+	return ParseWith(bs, ParseConfig{})
+}
+
+// ParseConfig selects how ParseWith and ParseSectionWith parse sections; the
+// zero value is the default of Parse and ParseSection.
+type ParseConfig struct {
+	// CRC selects the CRC32 check of the sections.
+	CRC CRCMode
+	// KeepRawDescriptors keeps the wire bytes of every descriptor (see
+	// descriptor.ParseKeepRaw), so Append writes them back byte for byte.
+	KeepRawDescriptors bool
+}
+
+// ParseWith is Parse configured by cfg.
+func ParseWith(bs []byte, cfg ParseConfig) (d *Data, err error) {
 	i := bytesiter.New(bs)
 
 	d = &Data{}
@@ -200,7 +272,7 @@ func Parse(bs []byte) (d *Data, err error) {
 	var s Section
 	var stop bool
 	for i.HasBytesLeft() {
-		if s, stop, err = parsePSISection(i); err != nil {
+		if s, stop, err = parsePSISection(i, cfg); err != nil {
 			err = fmt.Errorf("astits: parsing PSI table failed: %w", err)
 			return
 		}
@@ -212,8 +284,23 @@ func Parse(bs []byte) (d *Data, err error) {
 	return
 }
 
+// ParseSection parses a single section starting at its table_id, without the
+// pointer field Parse expects. A stuffing or unknown table id yields a Section
+// with a nil Syntax.
+func ParseSection(bs []byte) (s Section, err error) {
+	return ParseSectionWith(bs, ParseConfig{})
+}
+
+// ParseSectionWith is ParseSection configured by cfg.
+func ParseSectionWith(bs []byte, cfg ParseConfig) (s Section, err error) {
+	if s, _, err = parsePSISection(bytesiter.New(bs), cfg); err != nil {
+		err = fmt.Errorf("astits: parsing PSI table failed: %w", err)
+	}
+	return
+}
+
 // parsePSISection parses a PSI section
-func parsePSISection(i *bytesiter.Iterator) (s Section, stop bool, err error) {
+func parsePSISection(i *bytesiter.Iterator, cfg ParseConfig) (s Section, stop bool, err error) {
 	var offsets psiOffsets
 	if offsets, stop, err = s.Header.parsePSISectionHeader(i); err != nil {
 		err = fmt.Errorf("astits: parsing PSI section header failed: %w", err)
@@ -225,7 +312,7 @@ func parsePSISection(i *bytesiter.Iterator) (s Section, stop bool, err error) {
 	}
 
 	if s.Header.SectionLength > 0 {
-		if s.Syntax, err = parsePSISectionSyntax(i, &s.Header, offsets.sectionsEnd); err != nil {
+		if s.Syntax, err = parsePSISectionSyntax(i, &s.Header, offsets.sectionsEnd, cfg.KeepRawDescriptors); err != nil {
 			err = fmt.Errorf("astits: parsing PSI section syntax failed: %w", err)
 			return
 		}
@@ -238,18 +325,21 @@ func parsePSISection(i *bytesiter.Iterator) (s Section, stop bool, err error) {
 				return
 			}
 
-			i.Seek(offsets.start)
-			var crc32Data []byte
-			if crc32Data, err = i.NextBytesNoCopy(offsets.sectionsEnd - offsets.start); err != nil {
-				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
-				return
-			}
-
-			crc32 := ts.ComputeCRC32(crc32Data)
-
-			if crc32 != s.CRC32 {
-				err = fmt.Errorf("astits: table CRC32 %x != computed CRC32 %x: %w", s.CRC32, crc32, ErrCRC32Mismatch)
-				return
+			if cfg.CRC != CRCSkip {
+				i.Seek(offsets.start)
+				var crc32Data []byte
+				if crc32Data, err = i.NextBytesNoCopy(offsets.sectionsEnd - offsets.start); err != nil {
+					err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+					return
+				}
+
+				if crc32 := ts.ComputeCRC32(crc32Data); crc32 != s.CRC32 {
+					if cfg.CRC != CRCLenient {
+						err = fmt.Errorf("astits: table CRC32 %x != computed CRC32 %x: %w", s.CRC32, crc32, ErrCRC32Mismatch)
+						return
+					}
+					s.CRC32Mismatch = true
+				}
 			}
 		}
 	}
@@ -258,6 +348,22 @@ func parsePSISection(i *bytesiter.Iterator) (s Section, stop bool, err error) {
 	return
 }
 
+// parseDescriptors is descriptor.Parse, or ParseKeepRaw under keepRaw.
+func parseDescriptors(bs []byte, keepRaw bool) ([]descriptor.Descriptor, int, error) {
+	if keepRaw {
+		return descriptor.ParseKeepRaw(bs)
+	}
+	return descriptor.Parse(bs)
+}
+
+// parseDescriptorsN is descriptor.ParseN, or ParseNKeepRaw under keepRaw.
+func parseDescriptorsN(bs []byte, length int, keepRaw bool) ([]descriptor.Descriptor, int, error) {
+	if keepRaw {
+		return descriptor.ParseNKeepRaw(bs, length)
+	}
+	return descriptor.ParseN(bs, length)
+}
+
 // parseCRC32 parses a CRC32
 func parseCRC32(i *bytesiter.Iterator) (c uint32, err error) {
 	var bs []byte
@@ -333,14 +439,26 @@ func (h *SectionHeader) parsePSISectionHeader(i *bytesiter.Iterator) (offsets ps
 // (barbashov) the link above can be broken, alternative: https://dvb.org/wp-content/uploads/2019/12/a038_tm1217r37_en300468v1_17_1_-_rev-134_-_si_specification.pdf
 func (t TableID) Type() string {
 	switch {
+	case t == TableIDATSCEIT:
+		return TableTypeATSCEIT
+	case t == TableIDATSCETT:
+		return TableTypeATSCETT
 	case t == TableIDBAT:
 		return TableTypeBAT
+	case t == TableIDBIT:
+		return TableTypeBIT
 	case t == TableIDCAT:
 		return TableTypeCAT
 	case t >= TableIDEITStart && t <= TableIDEITEnd:
 		return TableTypeEIT
 	case t == TableIDDIT:
 		return TableTypeDIT
+	case t == TableIDDSMCCUNMessage, t == TableIDDSMCCDownloadData:
+		return TableTypeDSMCC
+	case t == TableIDLDT:
+		return TableTypeLDT
+	case t == TableIDNBITBody, t == TableIDNBITReference:
+		return TableTypeNBIT
 	case t == TableIDNITVariant1, t == TableIDNITVariant2:
 		return TableTypeNIT
 	case t == TableIDNull:
@@ -355,12 +473,16 @@ func (t TableID) Type() string {
 		return TableTypeISO14496
 	case t == TableIDMetadata:
 		return TableTypeMetadata
+	case t == TableIDRRT:
+		return TableTypeRRT
 	case t == TableIDRST:
 		return TableTypeRST
 	case t == TableIDSDTVariant1, t == TableIDSDTVariant2:
 		return TableTypeSDT
 	case t == TableIDSIT:
 		return TableTypeSIT
+	case t == TableIDSCTE35:
+		return TableTypeSCTE35
 	case t == TableIDST:
 		return TableTypeST
 	case t == TableIDTDT:
@@ -383,19 +505,41 @@ func (t TableID) hasPSISyntaxHeader() bool {
 		t == TableIDSDTVariant1 || t == TableIDSDTVariant2 ||
 		t == TableIDSIT ||
 		t == TableIDISO14496Scene || t == TableIDISO14496Object || t == TableIDISO14496 ||
+		t == TableIDDSMCCUNMessage || t == TableIDDSMCCDownloadData ||
+		t == TableIDBIT || t == TableIDNBITBody || t == TableIDNBITReference || t == TableIDLDT ||
+		t == TableIDRRT || t == TableIDATSCEIT || t == TableIDATSCETT ||
 		(t >= TableIDEITStart && t <= TableIDEITEnd)
 }
 
+// MaxSectionLength returns the section_length limit of the table:
+// MaxSectionLength for the MPEG-2 PSI tables and the DVB SI tables capped at
+// 1024 bytes (NIT, SDT, BAT, TDT, TOT, RST, DIT), MaxPrivateSectionLength for
+// EIT, SIT, ST and every private section.
+func (t TableID) MaxSectionLength() int {
+	switch {
+	case t <= TableIDTSDT,
+		t >= TableIDNITVariant1 && t < TableIDEITStart,
+		t == TableIDTDT, t == TableIDRST, t == TableIDTOT, t == TableIDDIT:
+		return MaxSectionLength
+	}
+	return MaxPrivateSectionLength
+}
+
 // hasCRC32 checks whether the table has a CRC32
 func (t TableID) hasCRC32() bool {
-	return t.hasPSISyntaxHeader() || t == TableIDTOT || t == TableIDMetadata
+	return t.hasPSISyntaxHeader() || t == TableIDTOT || t == TableIDMetadata || t == TableIDSCTE35
 }
 
 func (t TableID) IsUnknown() bool {
 	switch t {
-	case TableIDBAT,
+	case TableIDATSCEIT, TableIDATSCETT,
+		TableIDBAT,
+		TableIDBIT,
 		TableIDCAT,
 		TableIDDIT,
+		TableIDDSMCCUNMessage, TableIDDSMCCDownloadData,
+		TableIDLDT,
+		TableIDNBITBody, TableIDNBITReference,
 		TableIDNITVariant1, TableIDNITVariant2,
 		TableIDNull,
 		TableIDPAT,
@@ -403,9 +547,11 @@ func (t TableID) IsUnknown() bool {
 		TableIDTSDT,
 		TableIDISO14496Scene, TableIDISO14496Object, TableIDISO14496,
 		TableIDMetadata,
+		TableIDRRT,
 		TableIDRST,
 		TableIDSDTVariant1, TableIDSDTVariant2,
 		TableIDSIT,
+		TableIDSCTE35,
 		TableIDST,
 		TableIDTDT,
 		TableIDTOT:
@@ -417,8 +563,19 @@ func (t TableID) IsUnknown() bool {
 	return true
 }
 
+// SupportedTableIDs returns the table ids parsed and serialized by this
+// package, in ascending order. Sections of any other table stop parsing.
+func SupportedTableIDs() (ids []TableID) {
+	for t := range 0x100 {
+		if id := TableID(t); !id.IsUnknown() && id != TableIDNull {
+			ids = append(ids, id)
+		}
+	}
+	return
+}
+
 // parsePSISectionSyntax parses a PSI section syntax
-func parsePSISectionSyntax(i *bytesiter.Iterator, h *SectionHeader, offsetSectionsEnd int) (s *SectionSyntax, err error) {
+func parsePSISectionSyntax(i *bytesiter.Iterator, h *SectionHeader, offsetSectionsEnd int, keepRaw bool) (s *SectionSyntax, err error) {
 	s = &SectionSyntax{}
 
 	if h.TableID.hasPSISyntaxHeader() {
@@ -428,7 +585,7 @@ func parsePSISectionSyntax(i *bytesiter.Iterator, h *SectionHeader, offsetSectio
 		}
 	}
 
-	if s.Data, err = parsePSISectionSyntaxData(i, h, &s.Header, offsetSectionsEnd); err != nil {
+	if s.Data, err = parsePSISectionSyntaxData(i, h, &s.Header, offsetSectionsEnd, keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing PSI section syntax data failed: %w", err)
 		return
 	}
@@ -473,20 +630,50 @@ func (h *SectionSyntaxHeader) parsePSISectionSyntaxHeader(i *bytesiter.Iterator)
 }
 
 // parsePSISectionSyntaxData parses a PSI section data
-func parsePSISectionSyntaxData(i *bytesiter.Iterator, h *SectionHeader, sh *SectionSyntaxHeader, offsetSectionsEnd int) (d SectionSyntaxData, err error) {
+func parsePSISectionSyntaxData(i *bytesiter.Iterator, h *SectionHeader, sh *SectionSyntaxHeader, offsetSectionsEnd int, keepRaw bool) (d SectionSyntaxData, err error) {
 	switch h.TableID {
+	case TableIDATSCEIT:
+		if d, err = parseATSCEITSection(i, sh.TableIDExtension, keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing ATSC EIT section failed: %w", err)
+			return
+		}
+	case TableIDATSCETT:
+		if d, err = parseATSCETTSection(i, offsetSectionsEnd); err != nil {
+			err = fmt.Errorf("astits: parsing ATSC ETT section failed: %w", err)
+			return
+		}
 	case TableIDBAT:
-		if d, err = parseBATSection(i, sh.TableIDExtension); err != nil {
+		if d, err = parseBATSection(i, sh.TableIDExtension, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing BAT section failed: %w", err)
 			return
 		}
+	case TableIDBIT:
+		if d, err = parseBITSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing BIT section failed: %w", err)
+			return
+		}
 	case TableIDDIT:
 		if d, err = parseDITSection(i); err != nil {
 			err = fmt.Errorf("astits: parsing DIT section failed: %w", err)
 			return
 		}
+	case TableIDDSMCCUNMessage, TableIDDSMCCDownloadData:
+		if d, err = parseDSMCCSection(i, offsetSectionsEnd); err != nil {
+			err = fmt.Errorf("astits: parsing DSM-CC section failed: %w", err)
+			return
+		}
+	case TableIDLDT:
+		if d, err = parseLDTSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing LDT section failed: %w", err)
+			return
+		}
+	case TableIDNBITBody, TableIDNBITReference:
+		if d, err = parseNBITSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing NBIT section failed: %w", err)
+			return
+		}
 	case TableIDNITVariant1, TableIDNITVariant2:
-		if d, err = parseNITSection(i, sh.TableIDExtension); err != nil {
+		if d, err = parseNITSection(i, sh.TableIDExtension, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing NIT section failed: %w", err)
 			return
 		}
@@ -496,17 +683,17 @@ func parsePSISectionSyntaxData(i *bytesiter.Iterator, h *SectionHeader, sh *Sect
 			return
 		}
 	case TableIDCAT:
-		if d, err = parseCATSection(i, offsetSectionsEnd); err != nil {
+		if d, err = parseCATSection(i, offsetSectionsEnd, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing CAT section failed: %w", err)
 			return
 		}
 	case TableIDPMT:
-		if d, err = parsePMTSection(i, offsetSectionsEnd, sh.TableIDExtension); err != nil {
+		if d, err = parsePMTSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing PMT section failed: %w", err)
 			return
 		}
 	case TableIDTSDT:
-		if d, err = parseTSDTSection(i, offsetSectionsEnd); err != nil {
+		if d, err = parseTSDTSection(i, offsetSectionsEnd, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing TSDT section failed: %w", err)
 			return
 		}
@@ -520,25 +707,35 @@ func parsePSISectionSyntaxData(i *bytesiter.Iterator, h *SectionHeader, sh *Sect
 			err = fmt.Errorf("astits: parsing metadata section failed: %w", err)
 			return
 		}
+	case TableIDRRT:
+		if d, err = parseRRTSection(i, sh.TableIDExtension, keepRaw); err != nil {
+			err = fmt.Errorf("astits: parsing RRT section failed: %w", err)
+			return
+		}
 	case TableIDRST:
 		if d, err = parseRSTSection(i, offsetSectionsEnd); err != nil {
 			err = fmt.Errorf("astits: parsing RST section failed: %w", err)
 			return
 		}
 	case TableIDSDTVariant1, TableIDSDTVariant2:
-		if d, err = parseSDTSection(i, offsetSectionsEnd, sh.TableIDExtension); err != nil {
+		if d, err = parseSDTSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing PMT section failed: %w", err)
 			return
 		}
 	case TableIDSIT:
-		if d, err = parseSITSection(i, offsetSectionsEnd); err != nil {
+		if d, err = parseSITSection(i, offsetSectionsEnd, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing SIT section failed: %w", err)
 			return
 		}
 	case TableIDST:
-		d = parseSTSection()
+		d = parseSTSection(i, offsetSectionsEnd)
+	case TableIDSCTE35:
+		if d, err = parseSpliceInfoSection(i, offsetSectionsEnd); err != nil {
+			err = fmt.Errorf("astits: parsing SCTE 35 section failed: %w", err)
+			return
+		}
 	case TableIDTOT:
-		if d, err = parseTOTSection(i); err != nil {
+		if d, err = parseTOTSection(i, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing TOT section failed: %w", err)
 			return
 		}
@@ -550,7 +747,7 @@ func parsePSISectionSyntaxData(i *bytesiter.Iterator, h *SectionHeader, sh *Sect
 	}
 
 	if h.TableID >= TableIDEITStart && h.TableID <= TableIDEITEnd {
-		if d, err = parseEITSection(i, offsetSectionsEnd, sh.TableIDExtension); err != nil {
+		if d, err = parseEITSection(i, offsetSectionsEnd, sh.TableIDExtension, keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing EIT section failed: %w", err)
 			return
 		}
@@ -584,11 +781,11 @@ type sectionBody interface {
 	appendSection(dst []byte) []byte
 }
 
-func (s *Section) calcPSISectionLength(body sectionBody) (ret uint16) {
+func (s *Section) calcPSISectionLength(body sectionBody) (ret int) {
 	if s.Header.TableID.hasPSISyntaxHeader() {
 		ret += 5 // PSI syntax header length
 	}
-	ret += uint16(body.CalcSectionLength())
+	ret += body.CalcSectionLength()
 	if s.Header.TableID.hasCRC32() {
 		ret += 4
 	}
@@ -606,12 +803,12 @@ func (s *Section) appendSection(dst []byte) ([]byte, error) {
 		}
 	}
 
-	var sectionLength uint16
+	var sectionLength int
 	if body != nil {
 		sectionLength = s.calcPSISectionLength(body)
 	}
-	if sectionLength > maxSectionLength {
-		return dst, fmt.Errorf("astits: section length %d exceeds %d: %w", sectionLength, maxSectionLength, ErrSectionOverflow)
+	if limit := s.Header.TableID.MaxSectionLength(); sectionLength > limit {
+		return dst, fmt.Errorf("astits: section length %d exceeds %d: %w", sectionLength, limit, ErrSectionOverflow)
 	}
 	crcStart := len(dst)
 
@@ -3,10 +3,12 @@ package psi
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/k-danil/go-astits/v2/descriptor"
 	"github.com/k-danil/go-astits/v2/internal/bitstest"
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
 	"github.com/k-danil/go-astits/v2/ts"
@@ -171,8 +173,19 @@ func TestParsePSIData(t *testing.T) {
 	assert.ErrorIs(t, err, ErrCRC32Mismatch)
 	assert.ErrorIs(t, err, ts.ErrInvalidData)
 
+	// Invalid CRC32, skipped or kept
+	d, err := ParseWith(buf.Bytes(), ParseConfig{CRC: CRCSkip})
+	assert.NoError(t, err)
+	assert.Len(t, d.Sections, 1)
+	assert.False(t, d.Sections[0].CRC32Mismatch)
+	d, err = ParseWith(buf.Bytes(), ParseConfig{CRC: CRCLenient})
+	assert.NoError(t, err)
+	assert.Len(t, d.Sections, 1)
+	assert.True(t, d.Sections[0].CRC32Mismatch)
+	assert.Equal(t, uint32(32), d.Sections[0].CRC32)
+
 	// Valid
-	d, err := Parse(psiBytes())
+	d, err = Parse(psiBytes())
 	assert.NoError(t, err)
 	assert.Equal(t, d, psi)
 }
@@ -389,3 +402,98 @@ func TestParseOwnsInput(t *testing.T) {
 
 	assert.Equal(t, reference, parsed)
 }
+
+func TestParseWithKeepRawDescriptors(t *testing.T) {
+	// A PMT whose stream_identifier_descriptor declares a byte past its
+	// component tag
+	section := []byte{
+		0x02, 0xb0, 0x16, 0x00, 0x01, 0xc1, 0x00, 0x00, 0xe1, 0x00, 0xf0, 0x00,
+		0x1b, 0xe1, 0x00, 0xf0, 0x04, 0x52, 0x02, 0x07, 0x00,
+	}
+	crc := ts.ComputeCRC32(section)
+	src := append(append([]byte{0x00}, section...), byte(crc>>24), byte(crc>>16), byte(crc>>8), byte(crc))
+
+	d, err := Parse(src)
+	require.NoError(t, err)
+	b, err := d.Append(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, src, b)
+
+	d, err = ParseWith(src, ParseConfig{KeepRawDescriptors: true})
+	require.NoError(t, err)
+	b, err = d.Append(nil)
+	require.NoError(t, err)
+	assert.Equal(t, src, b)
+}
+
+// A section of exactly MaxSectionLength bytes is written and parses back; one
+// byte more is rejected instead of being written with a corrupt length.
+func TestWriteMaxSectionLength(t *testing.T) {
+	// 5 syntax header + 4 PMT fixed fields + 4 CRC around the descriptor loop.
+	loop := MaxSectionLength - 13
+	pmt := &PMT{PCRPID: 0x100}
+	for loop > 0 {
+		n := min(loop, descriptor.MaxLength+2)
+		pmt.ProgramDescriptors = append(pmt.ProgramDescriptors,
+			&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80, Length: uint8(n - 2)}, Data: make([]byte, n-2)})
+		loop -= n
+	}
+	d := &Data{Sections: []Section{{
+		Header: SectionHeader{TableID: TableIDPMT, SectionSyntaxIndicator: true},
+		Syntax: &SectionSyntax{Header: SectionSyntaxHeader{TableIDExtension: 1, CurrentNextIndicator: true}, Data: pmt},
+	}}}
+
+	bs, err := d.Append(nil)
+	require.NoError(t, err)
+	assert.Len(t, bs, 1+3+MaxSectionLength)
+	parsed, err := Parse(bs)
+	require.NoError(t, err)
+	assert.Equal(t, pmt.ProgramDescriptors, parsed.Sections[0].Syntax.Data.(*PMT).ProgramDescriptors)
+
+	ud := pmt.ProgramDescriptors[0].(*descriptor.UserDefined)
+	ud.Data = ud.Data[:len(ud.Data)-1]
+	pmt.ProgramDescriptors = append(pmt.ProgramDescriptors, &descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80}, Data: []byte{0}})
+	_, err = d.Append(nil)
+	assert.ErrorIs(t, err, ErrSectionOverflow)
+}
+
+// An EIT may reach MaxPrivateSectionLength, past the PSI limit; one byte more
+// is rejected.
+func TestWriteMaxPrivateSectionLength(t *testing.T) {
+	assert.Equal(t, MaxSectionLength, TableIDPMT.MaxSectionLength())
+	assert.Equal(t, MaxSectionLength, TableIDSDTVariant1.MaxSectionLength())
+	assert.Equal(t, MaxPrivateSectionLength, TableIDEITStart.MaxSectionLength())
+	assert.Equal(t, MaxPrivateSectionLength, TableIDSCTE35.MaxSectionLength())
+
+	// 5 syntax header + 6 EIT fixed fields + 4 CRC around the event loop.
+	loop := MaxPrivateSectionLength - 15
+	eit := &EIT{ServiceID: 1, LastTableID: 0x50}
+	for loop > 0 {
+		n := min(loop, 12+descriptor.MaxLength+2)
+		if rest := loop - n; rest > 0 && rest < 14 {
+			n -= 14 - rest
+		}
+		eit.Events = append(eit.Events, EITEvent{
+			EventID:     uint16(len(eit.Events)),
+			StartTime:   time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+			Duration:    time.Hour,
+			Descriptors: []descriptor.Descriptor{&descriptor.UserDefined{Header: descriptor.Header{Tag: 0x80, Length: uint8(n - 14)}, Data: make([]byte, n-14)}},
+		})
+		loop -= n
+	}
+	d := &Data{Sections: []Section{{
+		Header: SectionHeader{TableID: 0x50, SectionSyntaxIndicator: true},
+		Syntax: &SectionSyntax{Header: SectionSyntaxHeader{TableIDExtension: 1, CurrentNextIndicator: true}, Data: eit},
+	}}}
+
+	bs, err := d.Append(nil)
+	require.NoError(t, err)
+	assert.Len(t, bs, 1+3+MaxPrivateSectionLength)
+	parsed, err := Parse(bs)
+	require.NoError(t, err)
+	assert.Equal(t, eit.Events, parsed.Sections[0].Syntax.Data.(*EIT).Events)
+
+	eit.Events = append(eit.Events, EITEvent{})
+	_, err = d.Append(nil)
+	assert.ErrorIs(t, err, ErrSectionOverflow)
+}
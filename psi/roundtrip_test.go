@@ -110,6 +110,61 @@ func randRST(r *rand.Rand) *RST {
 	return rst
 }
 
+func randBytes(r *rand.Rand, n uint) (bs []byte) {
+	for j := uint(0); j < r.UintN(n); j++ {
+		bs = append(bs, uint8(r.UintN(256)))
+	}
+	return
+}
+
+func randDSMCCDSI(r *rand.Rand) *DSMCC {
+	dsi := &DSMCCDownloadServerInitiate{CompatibilityDescriptor: randBytes(r, 8), PrivateData: randBytes(r, 64)}
+	for j := range dsi.ServerID {
+		dsi.ServerID[j] = 0xff
+	}
+	return &DSMCC{
+		ProtocolDiscriminator: DSMCCProtocolDiscriminator,
+		DSMCCType:             DSMCCTypeUNDownload,
+		MessageID:             DSMCCMessageIDDownloadServerInitiate,
+		TransactionID:         uint32(r.UintN(1 << 32)),
+		Adaptation:            randBytes(r, 4),
+		DSI:                   dsi,
+	}
+}
+
+func randDSMCCDDB(r *rand.Rand) *DSMCC {
+	return &DSMCC{
+		ProtocolDiscriminator: DSMCCProtocolDiscriminator,
+		DSMCCType:             DSMCCTypeUNDownload,
+		MessageID:             DSMCCMessageIDDownloadDataBlock,
+		TransactionID:         uint32(r.UintN(1 << 32)),
+		DDB: &DSMCCDownloadDataBlock{
+			ModuleID:      uint16(r.UintN(1 << 16)),
+			ModuleVersion: uint8(r.UintN(256)),
+			BlockNumber:   uint16(r.UintN(1 << 16)),
+			BlockData:     randBytes(r, 512),
+		},
+	}
+}
+
+// randMultipleString returns nil (an absent structure) about a quarter of the time.
+func randMultipleString(r *rand.Rand) (s MultipleString) {
+	if r.UintN(4) == 0 {
+		return
+	}
+	s = MultipleString{}
+	for j := uint(0); j < r.UintN(3); j++ {
+		e := MultipleStringEntry{Language: [3]byte{'e', 'n', 'g'}}
+		for k := uint(0); k < 1+r.UintN(2); k++ {
+			e.Segments = append(e.Segments, MultipleStringSegment{
+				CompressionType: uint8(r.UintN(3)), Mode: uint8(r.UintN(256)), Bytes: randBytes(r, 32),
+			})
+		}
+		s = append(s, e)
+	}
+	return
+}
+
 func TestRoundtripPSITrivial(t *testing.T) {
 	r := rand.New(rand.NewPCG(11, 12))
 	for i := 0; i < 300; i++ {
@@ -118,7 +173,11 @@ func TestRoundtripPSITrivial(t *testing.T) {
 			data    SectionSyntaxData
 		}{
 			{TableIDST, &ST{}},
+			{TableIDST, &ST{Length: 1 + int(r.UintN(64))}},
 			{TableIDDIT, &DIT{TransitionFlag: r.UintN(2) == 1}},
+			{TableIDDSMCCUNMessage, randDSMCCDSI(r)},
+			{TableIDDSMCCDownloadData, randDSMCCDDB(r)},
+			{TableIDATSCETT, &ATSCETT{ProtocolVersion: uint8(r.UintN(256)), ETMID: uint32(r.UintN(1 << 32)), ExtendedText: randMultipleString(r)}},
 			{TableIDRST, randRST(r)},
 			{TableIDTSDT, &TSDT{Descriptors: randDescriptors(r)}},
 			{TableIDTDT, &TDT{UTCTime: randDVBTime(r)}},
@@ -188,6 +247,39 @@ func TestRoundtripPSITables(t *testing.T) {
 			sit.Services = append(sit.Services, SITService{ServiceID: uint16(r.UintN(1 << 16)), RunningStatus: RunningStatus(r.UintN(8)), Descriptors: randDescriptors(r)})
 		}
 
+		atscEIT := &ATSCEIT{SourceID: ext, ProtocolVersion: uint8(r.UintN(256))}
+		for j := uint(0); j < r.UintN(4); j++ {
+			atscEIT.Events = append(atscEIT.Events, ATSCEITEvent{
+				EventID: uint16(r.UintN(1 << 14)), StartTime: uint32(r.UintN(1 << 32)), ETMLocation: uint8(r.UintN(4)),
+				LengthInSeconds: uint32(r.UintN(1 << 20)), Title: randMultipleString(r), Descriptors: randDescriptors(r),
+			})
+		}
+
+		rrt := &RRT{RatingRegion: uint8(ext), RegionName: randMultipleString(r), Descriptors: randDescriptors(r)}
+		for j := uint(0); j < r.UintN(3); j++ {
+			dim := RRTDimension{Name: randMultipleString(r), GraduatedScale: r.UintN(2) == 1}
+			for k := uint(0); k < r.UintN(4); k++ {
+				dim.Values = append(dim.Values, RRTRatingValue{Abbrev: randMultipleString(r), Value: randMultipleString(r)})
+			}
+			rrt.Dimensions = append(rrt.Dimensions, dim)
+		}
+
+		bit := &BIT{OriginalNetworkID: ext, BroadcastViewPropriety: r.UintN(2) == 1, Descriptors: randDescriptors(r)}
+		nbit := &NBIT{OriginalNetworkID: ext}
+		ldt := &LDT{OriginalServiceID: ext, TransportStreamID: uint16(r.UintN(1 << 16)), OriginalNetworkID: uint16(r.UintN(1 << 16))}
+		for j := uint(0); j < r.UintN(4); j++ {
+			bit.Broadcasters = append(bit.Broadcasters, BITBroadcaster{BroadcasterID: uint8(r.UintN(256)), Descriptors: randDescriptors(r)})
+			info := NBITInformation{
+				InformationID: uint16(r.UintN(1 << 16)), InformationType: uint8(r.UintN(16)), DescriptionBodyLocation: uint8(r.UintN(4)),
+				UserDefined: uint8(r.UintN(256)), Descriptors: randDescriptors(r),
+			}
+			for k := uint(0); k < r.UintN(3); k++ {
+				info.KeyIDs = append(info.KeyIDs, uint16(r.UintN(1<<16)))
+			}
+			nbit.Informations = append(nbit.Informations, info)
+			ldt.Descriptions = append(ldt.Descriptions, LDTDescription{DescriptionID: uint16(r.UintN(1 << 16)), Descriptors: randDescriptors(r)})
+		}
+
 		iso := &ISO14496Section{}
 		for j := uint(0); j < 1+r.UintN(10); j++ {
 			iso.Data = append(iso.Data, uint8(r.UintN(256)))
@@ -204,6 +296,11 @@ func TestRoundtripPSITables(t *testing.T) {
 			{TableIDBAT, bat},
 			{TableIDSIT, sit},
 			{TableIDISO14496, iso},
+			{TableIDATSCEIT, atscEIT},
+			{TableIDRRT, rrt},
+			{TableIDBIT, bit},
+			{TableIDNBITBody, nbit},
+			{TableIDLDT, ldt},
 		}
 		for _, tc := range cases {
 			sec := randSection(r, tc.tableID, tc.data, tc.data.(sectionBody).CalcSectionLength())
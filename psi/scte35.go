@@ -0,0 +1,678 @@
+package psi
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/internal/util"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// SpliceCommandType is an SCTE 35 splice_command_type (SCTE 35 Table 7).
+type SpliceCommandType uint8
+
+const (
+	SpliceCommandTypeSpliceNull           SpliceCommandType = 0x00
+	SpliceCommandTypeSpliceSchedule       SpliceCommandType = 0x04
+	SpliceCommandTypeSpliceInsert         SpliceCommandType = 0x05
+	SpliceCommandTypeTimeSignal           SpliceCommandType = 0x06
+	SpliceCommandTypeBandwidthReservation SpliceCommandType = 0x07
+	SpliceCommandTypePrivateCommand       SpliceCommandType = 0xff
+)
+
+var spliceCommandTypeNames = map[SpliceCommandType]string{
+	SpliceCommandTypeSpliceNull:           "splice_null",
+	SpliceCommandTypeSpliceSchedule:       "splice_schedule",
+	SpliceCommandTypeSpliceInsert:         "splice_insert",
+	SpliceCommandTypeTimeSignal:           "time_signal",
+	SpliceCommandTypeBandwidthReservation: "bandwidth_reservation",
+	SpliceCommandTypePrivateCommand:       "private_command",
+}
+
+func (t SpliceCommandType) String() (s string) {
+	var ok bool
+	if s, ok = spliceCommandTypeNames[t]; !ok {
+		s = fmt.Sprintf("0x%02x", uint8(t))
+	}
+	return
+}
+
+func (t SpliceCommandType) MarshalJSON() (b []byte, err error) {
+	return json.Marshal(t.String())
+}
+
+func (t *SpliceCommandType) UnmarshalJSON(b []byte) (err error) {
+	*t, err = util.UnmarshalEnum(b, spliceCommandTypeNames)
+	return
+}
+
+const (
+	// SpliceDescriptorIdentifierCUEI is the identifier of the splice
+	// descriptors SCTE 35 defines ("CUEI").
+	SpliceDescriptorIdentifierCUEI uint32 = 0x43554549
+	// SpliceDescriptorTagSegmentation is the segmentation_descriptor tag.
+	SpliceDescriptorTagSegmentation uint8 = 0x02
+)
+
+// SpliceInfo represents an SCTE 35 splice_info_section (table id 0xfc): a
+// splice command and its splice descriptors, carried on a PID of stream type
+// StreamTypeSCTE35. It has no syntax header but ends with a CRC32. Commands
+// other than splice_insert and time_signal keep their bytes in CommandData.
+// Encrypted sections are not decrypted: everything from the tier field to
+// the end of the section stays in Encrypted.
+// Link: https://www.scte.org/standards/library/catalog/scte-35-digital-program-insertion-cueing-message/
+type SpliceInfo struct {
+	SpliceInsert        *SpliceInsert      `json:"splice_insert,omitempty"`
+	TimeSignal          *SpliceTime        `json:"time_signal,omitempty"`
+	CommandData         []byte             `json:"splice_command,omitempty"`
+	Descriptors         []SpliceDescriptor `json:"_descriptors"`
+	Encrypted           []byte             `json:"encrypted,omitempty"`
+	PTSAdjustment       uint64             `json:"pts_adjustment"`
+	Tier                uint16             `json:"tier"`
+	CommandType         SpliceCommandType  `json:"splice_command_type"`
+	ProtocolVersion     uint8              `json:"protocol_version"`
+	CWIndex             uint8              `json:"cw_index"`
+	EncryptionAlgorithm uint8              `json:"encryption_algorithm"`
+	EncryptedPacket     bool               `json:"encrypted_packet"`
+}
+
+// SpliceTime is a splice_time(): a 33-bit PTS, absent for an immediate splice.
+type SpliceTime struct {
+	PTSTime       uint64 `json:"pts_time"`
+	TimeSpecified bool   `json:"time_specified_flag"`
+}
+
+// BreakDuration is a break_duration(): the break length in 90 kHz ticks.
+type BreakDuration struct {
+	Duration   uint64 `json:"duration"`
+	AutoReturn bool   `json:"auto_return"`
+}
+
+// SpliceInsert is a splice_insert() command. SpliceTime is set for a program
+// splice that is not immediate; Components replace it for a component splice.
+type SpliceInsert struct {
+	SpliceTime      *SpliceTime             `json:"splice_time,omitempty"`
+	BreakDuration   *BreakDuration          `json:"break_duration,omitempty"`
+	Components      []SpliceInsertComponent `json:"_components"`
+	SpliceEventID   uint32                  `json:"splice_event_id"`
+	UniqueProgramID uint16                  `json:"unique_program_id"`
+	AvailNum        uint8                   `json:"avail_num"`
+	AvailsExpected  uint8                   `json:"avails_expected"`
+	Cancel          bool                    `json:"splice_event_cancel_indicator"`
+	OutOfNetwork    bool                    `json:"out_of_network_indicator"`
+	ProgramSplice   bool                    `json:"program_splice_flag"`
+	SpliceImmediate bool                    `json:"splice_immediate_flag"`
+}
+
+// SpliceInsertComponent is a component of a component-level splice_insert;
+// SpliceTime is nil for an immediate splice.
+type SpliceInsertComponent struct {
+	SpliceTime   *SpliceTime `json:"splice_time,omitempty"`
+	ComponentTag uint8       `json:"component_tag"`
+}
+
+// SpliceDescriptor is a splice_descriptor(). Segmentation descriptors are
+// decoded into Segmentation; any other tag keeps its body (after the
+// identifier) in Data.
+type SpliceDescriptor struct {
+	Segmentation *SegmentationDescriptor `json:"segmentation,omitempty"`
+	Data         []byte                  `json:"data,omitempty"`
+	Identifier   uint32                  `json:"identifier"`
+	Tag          uint8                   `json:"splice_descriptor_tag"`
+}
+
+// SegmentationDescriptor is a segmentation_descriptor(). The restriction
+// flags are meaningful only without DeliveryNotRestricted; Duration only with
+// HasDuration; the sub-segment fields only with HasSubSegments.
+type SegmentationDescriptor struct {
+	Components            []SegmentationComponent `json:"_components"`
+	UPID                  []byte                  `json:"segmentation_upid"`
+	Duration              uint64                  `json:"segmentation_duration"`
+	EventID               uint32                  `json:"segmentation_event_id"`
+	UPIDType              uint8                   `json:"segmentation_upid_type"`
+	TypeID                uint8                   `json:"segmentation_type_id"`
+	SegmentNum            uint8                   `json:"segment_num"`
+	SegmentsExpected      uint8                   `json:"segments_expected"`
+	SubSegmentNum         uint8                   `json:"sub_segment_num"`
+	SubSegmentsExpected   uint8                   `json:"sub_segments_expected"`
+	DeviceRestrictions    uint8                   `json:"device_restrictions"`
+	Cancel                bool                    `json:"segmentation_event_cancel_indicator"`
+	ProgramSegmentation   bool                    `json:"program_segmentation_flag"`
+	HasDuration           bool                    `json:"segmentation_duration_flag"`
+	DeliveryNotRestricted bool                    `json:"delivery_not_restricted_flag"`
+	WebDeliveryAllowed    bool                    `json:"web_delivery_allowed_flag"`
+	NoRegionalBlackout    bool                    `json:"no_regional_blackout_flag"`
+	ArchiveAllowed        bool                    `json:"archive_allowed_flag"`
+	HasSubSegments        bool                    `json:"sub_segments_present"`
+}
+
+// SegmentationComponent is a component of a component-level segmentation.
+type SegmentationComponent struct {
+	PTSOffset    uint64 `json:"pts_offset"`
+	ComponentTag uint8  `json:"component_tag"`
+}
+
+// NewSpliceInsert returns a program-level splice_insert cue for eventID at
+// pts (90 kHz), with an auto-returning break of duration ticks when duration
+// is not 0. out marks the splice out of the network feed (start of a break).
+func NewSpliceInsert(eventID uint32, pts, duration uint64, out bool) *SpliceInfo {
+	si := &SpliceInsert{
+		SpliceEventID: eventID,
+		OutOfNetwork:  out,
+		ProgramSplice: true,
+		SpliceTime:    &SpliceTime{TimeSpecified: true, PTSTime: pts},
+	}
+	if duration > 0 {
+		si.BreakDuration = &BreakDuration{AutoReturn: true, Duration: duration}
+	}
+	return &SpliceInfo{CommandType: SpliceCommandTypeSpliceInsert, SpliceInsert: si, Tier: 0xfff}
+}
+
+// NewTimeSignal returns a time_signal cue at pts (90 kHz) carrying ds,
+// usually segmentation descriptors.
+func NewTimeSignal(pts uint64, ds ...SpliceDescriptor) *SpliceInfo {
+	return &SpliceInfo{
+		CommandType: SpliceCommandTypeTimeSignal,
+		TimeSignal:  &SpliceTime{TimeSpecified: true, PTSTime: pts},
+		Descriptors: ds,
+		Tier:        0xfff,
+	}
+}
+
+// NewSegmentationDescriptor wraps d in a CUEI splice descriptor.
+func NewSegmentationDescriptor(d *SegmentationDescriptor) SpliceDescriptor {
+	return SpliceDescriptor{Tag: SpliceDescriptorTagSegmentation, Identifier: SpliceDescriptorIdentifierCUEI, Segmentation: d}
+}
+
+// Data returns the cue as PSI data with a single splice_info_section, ready
+// for Data.Append or a muxer.
+func (d *SpliceInfo) Data() *Data {
+	return &Data{Sections: []Section{{
+		Header: SectionHeader{TableID: TableIDSCTE35},
+		Syntax: &SectionSyntax{Data: d},
+	}}}
+}
+
+// parseSpliceInfoSection parses an SCTE 35 splice_info_section
+func parseSpliceInfoSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *SpliceInfo, err error) {
+	d = &SpliceInfo{}
+
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(7); err != nil || len(bs) < 7 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.ProtocolVersion = bs[0]
+	d.EncryptedPacket = bs[1]&0x80 > 0
+	d.EncryptionAlgorithm = bs[1] >> 1 & 0x3f
+	d.PTSAdjustment = uint64(bs[1]&0x1)<<32 | uint64(binary.BigEndian.Uint32(bs[2:6]))
+	d.CWIndex = bs[6]
+
+	if d.EncryptedPacket {
+		if d.Encrypted, err = i.NextBytes(offsetSectionsEnd - i.Offset()); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		if len(d.Encrypted) >= 2 {
+			d.Tier = binary.BigEndian.Uint16(d.Encrypted) >> 4
+		}
+		return
+	}
+
+	if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.Tier = binary.BigEndian.Uint16(bs) >> 4
+	commandLength := int(binary.BigEndian.Uint16(bs[1:]) & 0xfff)
+	d.CommandType = SpliceCommandType(bs[3])
+
+	commandStart := i.Offset()
+	switch d.CommandType {
+	case SpliceCommandTypeSpliceNull:
+	case SpliceCommandTypeSpliceInsert:
+		if d.SpliceInsert, err = parseSpliceInsert(i); err != nil {
+			err = fmt.Errorf("astits: parsing splice_insert failed: %w", err)
+			return
+		}
+	case SpliceCommandTypeTimeSignal:
+		if d.TimeSignal, err = parseSpliceTime(i); err != nil {
+			err = fmt.Errorf("astits: parsing time_signal failed: %w", err)
+			return
+		}
+	default:
+		// 0xfff is the legacy "unknown" length: only self-delimiting commands
+		// can be parsed then.
+		if commandLength == 0xfff {
+			err = fmt.Errorf("astits: splice command %s of unspecified length: %w", d.CommandType, ts.ErrInvalidData)
+			return
+		}
+		if d.CommandData, err = i.NextBytes(commandLength); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+	}
+	if commandLength != 0xfff {
+		i.Seek(commandStart + commandLength)
+	}
+
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	offsetDescriptorsEnd := i.Offset() + int(binary.BigEndian.Uint16(bs))
+	for i.Offset() < offsetDescriptorsEnd {
+		var sd SpliceDescriptor
+		if sd, err = parseSpliceDescriptor(i); err != nil {
+			err = fmt.Errorf("astits: parsing splice descriptor failed: %w", err)
+			return
+		}
+		d.Descriptors = append(d.Descriptors, sd)
+	}
+	return
+}
+
+func parseSpliceTime(i *bytesiter.Iterator) (t *SpliceTime, err error) {
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	t = &SpliceTime{TimeSpecified: b&0x80 > 0}
+	if t.TimeSpecified {
+		var bs []byte
+		if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		t.PTSTime = uint64(b&0x1)<<32 | uint64(binary.BigEndian.Uint32(bs))
+	}
+	return
+}
+
+func parseSpliceInsert(i *bytesiter.Iterator) (d *SpliceInsert, err error) {
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d = &SpliceInsert{
+		SpliceEventID: binary.BigEndian.Uint32(bs),
+		Cancel:        bs[4]&0x80 > 0,
+	}
+	if d.Cancel {
+		return
+	}
+
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	d.OutOfNetwork = b&0x80 > 0
+	d.ProgramSplice = b&0x40 > 0
+	hasDuration := b&0x20 > 0
+	d.SpliceImmediate = b&0x10 > 0
+
+	if d.ProgramSplice {
+		if !d.SpliceImmediate {
+			if d.SpliceTime, err = parseSpliceTime(i); err != nil {
+				return
+			}
+		}
+	} else {
+		if b, err = i.NextByte(); err != nil {
+			err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+			return
+		}
+		d.Components = make([]SpliceInsertComponent, b)
+		for idx := range d.Components {
+			c := &d.Components[idx]
+			if c.ComponentTag, err = i.NextByte(); err != nil {
+				err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+				return
+			}
+			if !d.SpliceImmediate {
+				if c.SpliceTime, err = parseSpliceTime(i); err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	if hasDuration {
+		if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		d.BreakDuration = &BreakDuration{
+			AutoReturn: bs[0]&0x80 > 0,
+			Duration:   uint64(bs[0]&0x1)<<32 | uint64(binary.BigEndian.Uint32(bs[1:])),
+		}
+	}
+
+	if bs, err = i.NextBytesNoCopy(4); err != nil || len(bs) < 4 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.UniqueProgramID = binary.BigEndian.Uint16(bs)
+	d.AvailNum = bs[2]
+	d.AvailsExpected = bs[3]
+	return
+}
+
+func parseSpliceDescriptor(i *bytesiter.Iterator) (d SpliceDescriptor, err error) {
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(6); err != nil || len(bs) < 6 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.Tag = bs[0]
+	offsetEnd := i.Offset() - 4 + int(bs[1])
+	d.Identifier = binary.BigEndian.Uint32(bs[2:])
+	if offsetEnd < i.Offset() {
+		err = fmt.Errorf("astits: splice descriptor length %d is too short: %w", bs[1], ts.ErrInvalidData)
+		return
+	}
+
+	if d.Tag == SpliceDescriptorTagSegmentation && d.Identifier == SpliceDescriptorIdentifierCUEI {
+		if d.Segmentation, err = parseSegmentationDescriptor(i, offsetEnd); err != nil {
+			err = fmt.Errorf("astits: parsing segmentation descriptor failed: %w", err)
+			return
+		}
+	} else if d.Data, err = i.NextBytes(offsetEnd - i.Offset()); err != nil {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	i.Seek(offsetEnd)
+	return
+}
+
+// segmentationTypeHasSubSegments reports the segmentation_type_id values that
+// may carry sub_segment_num and sub_segments_expected.
+func segmentationTypeHasSubSegments(t uint8) bool {
+	switch t {
+	case 0x30, 0x32, 0x34, 0x36, 0x38, 0x3a, 0x44, 0x46:
+		return true
+	}
+	return false
+}
+
+func parseSegmentationDescriptor(i *bytesiter.Iterator, offsetEnd int) (d *SegmentationDescriptor, err error) {
+	var bs []byte
+	if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d = &SegmentationDescriptor{
+		EventID: binary.BigEndian.Uint32(bs),
+		Cancel:  bs[4]&0x80 > 0,
+	}
+	if d.Cancel {
+		return
+	}
+
+	var b byte
+	if b, err = i.NextByte(); err != nil {
+		err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+		return
+	}
+	d.ProgramSegmentation = b&0x80 > 0
+	d.HasDuration = b&0x40 > 0
+	d.DeliveryNotRestricted = b&0x20 > 0
+	if !d.DeliveryNotRestricted {
+		d.WebDeliveryAllowed = b&0x10 > 0
+		d.NoRegionalBlackout = b&0x08 > 0
+		d.ArchiveAllowed = b&0x04 > 0
+		d.DeviceRestrictions = b & 0x03
+	}
+
+	if !d.ProgramSegmentation {
+		if b, err = i.NextByte(); err != nil {
+			err = fmt.Errorf("astits: fetching next byte failed: %w", err)
+			return
+		}
+		d.Components = make([]SegmentationComponent, b)
+		for idx := range d.Components {
+			if bs, err = i.NextBytesNoCopy(6); err != nil || len(bs) < 6 {
+				err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+				return
+			}
+			d.Components[idx] = SegmentationComponent{
+				ComponentTag: bs[0],
+				PTSOffset:    uint64(bs[1]&0x1)<<32 | uint64(binary.BigEndian.Uint32(bs[2:])),
+			}
+		}
+	}
+
+	if d.HasDuration {
+		if bs, err = i.NextBytesNoCopy(5); err != nil || len(bs) < 5 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		d.Duration = uint64(bs[0])<<32 | uint64(binary.BigEndian.Uint32(bs[1:]))
+	}
+
+	if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.UPIDType = bs[0]
+	if upidLength := int(bs[1]); upidLength > 0 {
+		if d.UPID, err = i.NextBytes(upidLength); err != nil {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+	}
+
+	if bs, err = i.NextBytesNoCopy(3); err != nil || len(bs) < 3 {
+		err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+		return
+	}
+	d.TypeID = bs[0]
+	d.SegmentNum = bs[1]
+	d.SegmentsExpected = bs[2]
+
+	// Sub-segments were added in SCTE 35 2016: older writers omit them.
+	if segmentationTypeHasSubSegments(d.TypeID) && offsetEnd-i.Offset() >= 2 {
+		if bs, err = i.NextBytesNoCopy(2); err != nil || len(bs) < 2 {
+			err = fmt.Errorf("astits: fetching next bytes failed: %w", err)
+			return
+		}
+		d.HasSubSegments = true
+		d.SubSegmentNum = bs[0]
+		d.SubSegmentsExpected = bs[1]
+	}
+	return
+}
+
+func (t *SpliceTime) calcLength() int {
+	if t.TimeSpecified {
+		return 5
+	}
+	return 1
+}
+
+func (t *SpliceTime) append(dst []byte) []byte {
+	if !t.TimeSpecified {
+		return append(dst, 0x7f)
+	}
+	return append(dst, 0xfe|byte(t.PTSTime>>32)&0x1,
+		byte(t.PTSTime>>24), byte(t.PTSTime>>16), byte(t.PTSTime>>8), byte(t.PTSTime))
+}
+
+func (d *SpliceInsert) calcLength() (ret int) {
+	ret = 5
+	if d.Cancel {
+		return
+	}
+	ret++
+	if d.ProgramSplice {
+		if !d.SpliceImmediate && d.SpliceTime != nil {
+			ret += d.SpliceTime.calcLength()
+		}
+	} else {
+		ret++
+		for _, c := range d.Components {
+			ret++
+			if !d.SpliceImmediate && c.SpliceTime != nil {
+				ret += c.SpliceTime.calcLength()
+			}
+		}
+	}
+	if d.BreakDuration != nil {
+		ret += 5
+	}
+	return ret + 4
+}
+
+func (d *SpliceInsert) append(dst []byte) []byte {
+	dst = append(dst, byte(d.SpliceEventID>>24), byte(d.SpliceEventID>>16), byte(d.SpliceEventID>>8), byte(d.SpliceEventID),
+		util.B2U(d.Cancel)<<7|0x7f)
+	if d.Cancel {
+		return dst
+	}
+	// The trailing bits are event_id_compliance_flag and reserved, all 1.
+	dst = append(dst, util.B2U(d.OutOfNetwork)<<7|util.B2U(d.ProgramSplice)<<6|
+		util.B2U(d.BreakDuration != nil)<<5|util.B2U(d.SpliceImmediate)<<4|0x0f)
+	if d.ProgramSplice {
+		if !d.SpliceImmediate && d.SpliceTime != nil {
+			dst = d.SpliceTime.append(dst)
+		}
+	} else {
+		dst = append(dst, uint8(len(d.Components)))
+		for _, c := range d.Components {
+			dst = append(dst, c.ComponentTag)
+			if !d.SpliceImmediate && c.SpliceTime != nil {
+				dst = c.SpliceTime.append(dst)
+			}
+		}
+	}
+	if b := d.BreakDuration; b != nil {
+		dst = append(dst, util.B2U(b.AutoReturn)<<7|0x7e|byte(b.Duration>>32)&0x1,
+			byte(b.Duration>>24), byte(b.Duration>>16), byte(b.Duration>>8), byte(b.Duration))
+	}
+	return append(dst, byte(d.UniqueProgramID>>8), byte(d.UniqueProgramID), d.AvailNum, d.AvailsExpected)
+}
+
+func (d *SegmentationDescriptor) calcLength() (ret int) {
+	ret = 5
+	if d.Cancel {
+		return
+	}
+	ret++
+	if !d.ProgramSegmentation {
+		ret += 1 + 6*len(d.Components)
+	}
+	if d.HasDuration {
+		ret += 5
+	}
+	ret += 2 + len(d.UPID) + 3
+	if d.HasSubSegments {
+		ret += 2
+	}
+	return
+}
+
+func (d *SegmentationDescriptor) append(dst []byte) []byte {
+	// segmentation_event_id_compliance_indicator and reserved are written 1.
+	dst = append(dst, byte(d.EventID>>24), byte(d.EventID>>16), byte(d.EventID>>8), byte(d.EventID),
+		util.B2U(d.Cancel)<<7|0x7f)
+	if d.Cancel {
+		return dst
+	}
+	b := util.B2U(d.ProgramSegmentation)<<7 | util.B2U(d.HasDuration)<<6 | util.B2U(d.DeliveryNotRestricted)<<5
+	if d.DeliveryNotRestricted {
+		b |= 0x1f
+	} else {
+		b |= util.B2U(d.WebDeliveryAllowed)<<4 | util.B2U(d.NoRegionalBlackout)<<3 | util.B2U(d.ArchiveAllowed)<<2 | d.DeviceRestrictions&0x3
+	}
+	dst = append(dst, b)
+	if !d.ProgramSegmentation {
+		dst = append(dst, uint8(len(d.Components)))
+		for _, c := range d.Components {
+			dst = append(dst, c.ComponentTag, 0xfe|byte(c.PTSOffset>>32)&0x1,
+				byte(c.PTSOffset>>24), byte(c.PTSOffset>>16), byte(c.PTSOffset>>8), byte(c.PTSOffset))
+		}
+	}
+	if d.HasDuration {
+		dst = append(dst, byte(d.Duration>>32), byte(d.Duration>>24), byte(d.Duration>>16), byte(d.Duration>>8), byte(d.Duration))
+	}
+	dst = append(dst, d.UPIDType, uint8(len(d.UPID)))
+	dst = append(dst, d.UPID...)
+	dst = append(dst, d.TypeID, d.SegmentNum, d.SegmentsExpected)
+	if d.HasSubSegments {
+		dst = append(dst, d.SubSegmentNum, d.SubSegmentsExpected)
+	}
+	return dst
+}
+
+func (d *SpliceDescriptor) calcLength() int {
+	if d.Segmentation != nil {
+		return 4 + d.Segmentation.calcLength()
+	}
+	return 4 + len(d.Data)
+}
+
+func (d *SpliceDescriptor) append(dst []byte) []byte {
+	dst = append(dst, d.Tag, uint8(d.calcLength()),
+		byte(d.Identifier>>24), byte(d.Identifier>>16), byte(d.Identifier>>8), byte(d.Identifier))
+	if d.Segmentation != nil {
+		return d.Segmentation.append(dst)
+	}
+	return append(dst, d.Data...)
+}
+
+func (d *SpliceInfo) commandLength() int {
+	switch {
+	case d.SpliceInsert != nil:
+		return d.SpliceInsert.calcLength()
+	case d.TimeSignal != nil:
+		return d.TimeSignal.calcLength()
+	}
+	return len(d.CommandData)
+}
+
+func (d *SpliceInfo) descriptorsLength() (ret int) {
+	for i := range d.Descriptors {
+		ret += 2 + d.Descriptors[i].calcLength()
+	}
+	return
+}
+
+func (d *SpliceInfo) CalcSectionLength() int {
+	if d.EncryptedPacket {
+		return 7 + len(d.Encrypted)
+	}
+	return 11 + d.commandLength() + 2 + d.descriptorsLength()
+}
+
+func (d *SpliceInfo) appendSection(dst []byte) []byte {
+	dst = append(dst, d.ProtocolVersion,
+		util.B2U(d.EncryptedPacket)<<7|d.EncryptionAlgorithm&0x3f<<1|byte(d.PTSAdjustment>>32)&0x1,
+		byte(d.PTSAdjustment>>24), byte(d.PTSAdjustment>>16), byte(d.PTSAdjustment>>8), byte(d.PTSAdjustment),
+		d.CWIndex)
+	if d.EncryptedPacket {
+		return append(dst, d.Encrypted...)
+	}
+
+	l := d.commandLength()
+	dst = append(dst, byte(d.Tier>>4), byte(d.Tier<<4)|byte(l>>8)&0xf, byte(l), uint8(d.CommandType))
+	switch {
+	case d.SpliceInsert != nil:
+		dst = d.SpliceInsert.append(dst)
+	case d.TimeSignal != nil:
+		dst = d.TimeSignal.append(dst)
+	default:
+		dst = append(dst, d.CommandData...)
+	}
+
+	l = d.descriptorsLength()
+	dst = append(dst, byte(l>>8), byte(l))
+	for i := range d.Descriptors {
+		dst = d.Descriptors[i].append(dst)
+	}
+	return dst
+}
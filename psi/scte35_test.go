@@ -0,0 +1,102 @@
+package psi
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpliceInfoSection(t *testing.T) {
+	// SCTE 35 2022 §14.1 and §14.2 sample cues
+	spliceInsert, _ := base64.StdEncoding.DecodeString("/DAvAAAAAAAA///wFAVIAACPf+/+c2nALv4AUsz1AAAAAAAKAAhDVUVJAAABNWLbowo=")
+	timeSignal, _ := base64.StdEncoding.DecodeString("/DA0AAAAAAAA///wBQb+cr0AUAAeAhxDVUVJSAAAjn/PAAGlmbAICAAAAAAsoKGKNAIAmsnRfg==")
+
+	for _, tc := range []struct {
+		name string
+		bs   []byte
+		want *SpliceInfo
+	}{
+		{"splice_insert", spliceInsert, &SpliceInfo{
+			CommandType: SpliceCommandTypeSpliceInsert,
+			SpliceInsert: &SpliceInsert{
+				SpliceEventID: 0x4800008f,
+				OutOfNetwork:  true,
+				ProgramSplice: true,
+				SpliceTime:    &SpliceTime{TimeSpecified: true, PTSTime: 0x07369c02e},
+				BreakDuration: &BreakDuration{AutoReturn: true, Duration: 0x00052ccf5},
+			},
+			Descriptors: []SpliceDescriptor{{Tag: 0x00, Identifier: SpliceDescriptorIdentifierCUEI, Data: []byte{0x00, 0x00, 0x01, 0x35}}},
+			Tier:        0xfff,
+			CWIndex:     0xff,
+		}},
+		{"time_signal", timeSignal, &SpliceInfo{
+			CommandType: SpliceCommandTypeTimeSignal,
+			TimeSignal:  &SpliceTime{TimeSpecified: true, PTSTime: 0x072bd0050},
+			Descriptors: []SpliceDescriptor{NewSegmentationDescriptor(&SegmentationDescriptor{
+				EventID:             0x4800008e,
+				ProgramSegmentation: true,
+				HasDuration:         true,
+				NoRegionalBlackout:  true,
+				ArchiveAllowed:      true,
+				DeviceRestrictions:  3,
+				Duration:            0x0001a599b0,
+				UPIDType:            0x08,
+				UPID:                []byte{0x00, 0x00, 0x00, 0x00, 0x2c, 0xa0, 0xa1, 0x8a},
+				TypeID:              0x34,
+				SegmentNum:          2,
+			})},
+			Tier:    0xfff,
+			CWIndex: 0xff,
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := Parse(append([]byte{0x00}, tc.bs...))
+			require.NoError(t, err)
+			require.Len(t, d.Sections, 1)
+			assert.Equal(t, TableIDSCTE35, d.Sections[0].Header.TableID)
+			assert.Equal(t, tc.want, d.Sections[0].Syntax.Data)
+
+			// The writer reproduces the cue, CRC included.
+			bs, err := tc.want.Data().Append(nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.bs, bs[1:])
+		})
+	}
+}
+
+func TestWriteSpliceInfoSection(t *testing.T) {
+	for _, si := range []*SpliceInfo{
+		NewSpliceInsert(7, 0x1fffffffe, 30*90000, true),
+		NewSpliceInsert(8, 900000, 0, false),
+		{CommandType: SpliceCommandTypeSpliceInsert, Tier: 0xfff, SpliceInsert: &SpliceInsert{
+			SpliceEventID: 9, SpliceImmediate: true, UniqueProgramID: 0x1234, AvailNum: 1, AvailsExpected: 2,
+			Components: []SpliceInsertComponent{{ComponentTag: 1}, {ComponentTag: 2}},
+		}},
+		{CommandType: SpliceCommandTypeSpliceInsert, Tier: 0xfff, SpliceInsert: &SpliceInsert{SpliceEventID: 10, Cancel: true}},
+		{CommandType: SpliceCommandTypeSpliceNull, Tier: 0xfff, PTSAdjustment: 0x100000000},
+		{CommandType: SpliceCommandTypePrivateCommand, Tier: 0x123, CommandData: []byte{'C', 'U', 'E', 'I', 0x01}},
+		NewTimeSignal(45000, NewSegmentationDescriptor(&SegmentationDescriptor{
+			EventID:               1,
+			DeliveryNotRestricted: true,
+			Components:            []SegmentationComponent{{ComponentTag: 3, PTSOffset: 0x100000001}},
+			UPIDType:              0x0c,
+			UPID:                  []byte("MPU"),
+			TypeID:                0x30,
+			SegmentNum:            1,
+			SegmentsExpected:      1,
+			HasSubSegments:        true,
+			SubSegmentNum:         1,
+			SubSegmentsExpected:   4,
+		}), NewSegmentationDescriptor(&SegmentationDescriptor{EventID: 2, Cancel: true})),
+		{EncryptedPacket: true, EncryptionAlgorithm: 1, CWIndex: 3, Tier: 0xfff, Encrypted: []byte{0xff, 0xf0, 0x00, 0x00, 0x00, 0x00}},
+	} {
+		bs, err := si.Data().Append(nil)
+		require.NoError(t, err)
+		d, err := Parse(bs)
+		require.NoError(t, err)
+		require.Len(t, d.Sections, 1)
+		assert.Equal(t, si, d.Sections[0].Syntax.Data)
+	}
+}
@@ -68,7 +68,7 @@ type SDTService struct {
 }
 
 // parseSDTSection parses an SDT section
-func parseSDTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16) (d *SDT, err error) {
+func parseSDTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtension uint16, keepRaw bool) (d *SDT, err error) {
 	d = &SDT{TransportStreamID: tableIDExtension}
 
 	var bs []byte
@@ -115,7 +115,7 @@ func parseSDTSection(i *bytesiter.Iterator, offsetSectionsEnd int, tableIDExtens
 		i.Skip(-1)
 
 		var dn int
-		if s.Descriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if s.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
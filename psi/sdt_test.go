@@ -40,7 +40,7 @@ func sdtBytes() []byte {
 
 func TestParseSDTSection(t *testing.T) {
 	var b = sdtBytes()
-	d, err := parseSDTSection(bytesiter.New(b), len(b), uint16(1))
+	d, err := parseSDTSection(bytesiter.New(b), len(b), uint16(1), false)
 	assert.Equal(t, d, sdt)
 	assert.NoError(t, err)
 }
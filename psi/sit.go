@@ -25,11 +25,11 @@ type SITService struct {
 }
 
 // parseSITSection parses a SIT section
-func parseSITSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *SIT, err error) {
+func parseSITSection(i *bytesiter.Iterator, offsetSectionsEnd int, keepRaw bool) (d *SIT, err error) {
 	d = &SIT{}
 
 	var dn int
-	if d.TransmissionInfoDescriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+	if d.TransmissionInfoDescriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 		return
 	}
@@ -54,7 +54,7 @@ func parseSITSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *SIT, err
 		// The 2 bytes just read pack running_status over the descriptor-loop
 		// length; rewind so descriptor.Parse consumes them as its prefix.
 		i.Skip(-2)
-		if s.Descriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+		if s.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 			err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 			return
 		}
@@ -15,7 +15,7 @@ func TestParseSITSection(t *testing.T) {
 		0x00, 0x05, // service_id 5
 		0xc0, 0x00, // running_status 4, service_loop_length 0
 	}
-	d, err := parseSITSection(bytesiter.New(bs), len(bs))
+	d, err := parseSITSection(bytesiter.New(bs), len(bs), false)
 	require.NoError(t, err)
 
 	require.Len(t, d.TransmissionInfoDescriptors, 1)
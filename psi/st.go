@@ -1,19 +1,29 @@
 package psi
 
+import "github.com/k-danil/go-astits/v2/internal/bytesiter"
+
 // ST represents an ST: the stuffing table is pure filler and carries no
-// meaningful payload — it is surfaced only so the SI table set is exhaustive.
-// The stuffing bytes themselves are discarded.
+// meaningful payload — it is surfaced so the SI table set is exhaustive and so
+// stream replacement tooling can account for the space stuffed sections take.
+// The stuffing bytes themselves are discarded; only their count is kept.
 // Page: 39 | Chapter: 5.2.7 | Link: https://www.etsi.org/deliver/etsi_en/300400_300499/300468/01.15.01_60/en_300468v011501p.pdf
-type ST struct{}
+type ST struct {
+	Length int `json:"stuffing_length"` // number of data bytes in the section
+}
 
-// parseSTSection parses an ST section. The section body is stuffing bytes the
-// framework seeks past, so nothing is read here.
-func parseSTSection() (d *ST) {
-	return &ST{}
+// parseSTSection parses an ST section: the body up to the section end is
+// stuffing, counted and then sought past by the framework.
+func parseSTSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *ST) {
+	return &ST{Length: max(offsetSectionsEnd-i.Offset(), 0)}
 }
 
-func (d *ST) CalcSectionLength() int { return 0 }
+func (d *ST) CalcSectionLength() int { return d.Length }
 
 // appendSection appends the ST body: the stuffing bytes are meaningless (§5.2.8),
-// so a serialized ST is an empty section.
-func (d *ST) appendSection(dst []byte) []byte { return dst }
+// so Length 0xff bytes are written in their place.
+func (d *ST) appendSection(dst []byte) []byte {
+	for range d.Length {
+		dst = append(dst, 0xff)
+	}
+	return dst
+}
@@ -18,7 +18,7 @@ type TOT struct {
 }
 
 // parseTOTSection parses a TOT section
-func parseTOTSection(i *bytesiter.Iterator) (d *TOT, err error) {
+func parseTOTSection(i *bytesiter.Iterator, keepRaw bool) (d *TOT, err error) {
 	d = &TOT{}
 
 	if d.UTCTime, err = dvb.ParseTime(i); err != nil {
@@ -27,7 +27,7 @@ func parseTOTSection(i *bytesiter.Iterator) (d *TOT, err error) {
 	}
 
 	var dn int
-	if d.Descriptors, dn, err = descriptor.Parse(i.Bytes()); err != nil {
+	if d.Descriptors, dn, err = parseDescriptors(i.Bytes(), keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing descriptors failed: %w", err)
 		return
 	}
@@ -2,12 +2,17 @@ package psi
 
 import (
 	"bytes"
+	"encoding/binary"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/k-danil/go-astits/v2/descriptor"
 	"github.com/k-danil/go-astits/v2/internal/bitstest"
 	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+	"github.com/k-danil/go-astits/v2/ts"
 )
 
 var tot = &TOT{
@@ -25,7 +30,44 @@ func totBytes() []byte {
 }
 
 func TestParseTOTSection(t *testing.T) {
-	d, err := parseTOTSection(bytesiter.New(totBytes()))
+	d, err := parseTOTSection(bytesiter.New(totBytes()), false)
 	assert.Equal(t, d, tot)
 	assert.NoError(t, err)
 }
+
+func TestWriteTOTSection(t *testing.T) {
+	want := totBytes()
+	want[dvbTimeBytesSize] |= 0xf0 // reserved bits are written set
+	assert.Equal(t, want, tot.appendSection(nil))
+}
+
+func TestWriteTOT(t *testing.T) {
+	// A TOT has no syntax header but, unlike a TDT, ends with a CRC32.
+	d := &Data{Sections: []Section{{
+		// PrivateBit carries reserved_future_use, set to 1 in DVB SI.
+		Header: SectionHeader{TableID: TableIDTOT, PrivateBit: true},
+		Syntax: &SectionSyntax{Data: &TOT{
+			UTCTime: dvbTime,
+			Descriptors: []descriptor.Descriptor{&descriptor.LocalTimeOffset{
+				Header: descriptor.Header{Tag: descriptor.TagLocalTimeOffset, Length: 13},
+				Items: []descriptor.LocalTimeOffsetItem{{
+					CountryCode:     [3]byte{'d', 'e', 'u'},
+					LocalTimeOffset: time.Hour,
+					TimeOfChange:    dvbTime,
+					NextTimeOffset:  2 * time.Hour,
+				}},
+			}},
+		}},
+	}}}
+	bs, err := d.Append(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, uint8(TableIDTOT), 0x70, 5 + 2 + 15 + 4}, bs[:4])
+	crc := ts.UpdateCRC32(ts.CRC32Seed, bs[1:len(bs)-4])
+	assert.Equal(t, crc, binary.BigEndian.Uint32(bs[len(bs)-4:]))
+
+	got, err := Parse(bs)
+	require.NoError(t, err)
+	d.Sections[0].Header.SectionLength = 5 + 2 + 15 + 4
+	d.Sections[0].CRC32 = crc
+	assert.Equal(t, d.Sections, got.Sections)
+}
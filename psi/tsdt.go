@@ -16,14 +16,14 @@ type TSDT struct {
 }
 
 // parseTSDTSection parses a TSDT section
-func parseTSDTSection(i *bytesiter.Iterator, offsetSectionsEnd int) (d *TSDT, err error) {
+func parseTSDTSection(i *bytesiter.Iterator, offsetSectionsEnd int, keepRaw bool) (d *TSDT, err error) {
 	d = &TSDT{}
 	length := offsetSectionsEnd - i.Offset()
 	if length <= 0 {
 		return
 	}
 	var n int
-	if d.Descriptors, n, err = descriptor.ParseN(i.Bytes(), length); err != nil {
+	if d.Descriptors, n, err = parseDescriptorsN(i.Bytes(), length, keepRaw); err != nil {
 		err = fmt.Errorf("astits: parsing TSDT descriptors failed: %w", err)
 		return
 	}
@@ -12,7 +12,7 @@ import (
 func TestParseTSDTSection(t *testing.T) {
 	// one CA descriptor: tag 0x09, len 4, CA_system_id 0x1234, EMM PID 0x0BB8
 	bs := []byte{0x09, 0x04, 0x12, 0x34, 0xeb, 0xb8}
-	d, err := parseTSDTSection(bytesiter.New(bs), len(bs))
+	d, err := parseTSDTSection(bytesiter.New(bs), len(bs), false)
 	require.NoError(t, err)
 	require.Len(t, d.Descriptors, 1)
 
@@ -0,0 +1,101 @@
+package psi
+
+import (
+	"fmt"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/errclass"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// ErrDescriptorLength matches a DescriptorWarning reported as an error.
+var ErrDescriptorLength = errclass.New("astits: descriptor_length disagrees with the descriptor content", ts.ErrInvalidData)
+
+// DescriptorLoops returns the descriptor loops of a table in wire order:
+// table-level loops first, then one per item (stream, service, event).
+// Tables without descriptors return nil.
+func DescriptorLoops(data SectionSyntaxData) (loops [][]descriptor.Descriptor) {
+	switch d := data.(type) {
+	case *PMT:
+		loops = append(loops, d.ProgramDescriptors)
+		for _, es := range d.ElementaryStreams {
+			loops = append(loops, es.ElementaryStreamDescriptors)
+		}
+	case *CAT:
+		loops = append(loops, d.Descriptors)
+	case *TSDT:
+		loops = append(loops, d.Descriptors)
+	case *NIT:
+		loops = append(loops, d.NetworkDescriptors)
+		for _, ts := range d.TransportStreams {
+			loops = append(loops, ts.TransportDescriptors)
+		}
+	case *BAT:
+		loops = append(loops, d.BouquetDescriptors)
+		for _, ts := range d.TransportStreams {
+			loops = append(loops, ts.TransportDescriptors)
+		}
+	case *SDT:
+		for _, s := range d.Services {
+			loops = append(loops, s.Descriptors)
+		}
+	case *EIT:
+		for _, e := range d.Events {
+			loops = append(loops, e.Descriptors)
+		}
+	case *BIT:
+		loops = append(loops, d.Descriptors)
+		for _, b := range d.Broadcasters {
+			loops = append(loops, b.Descriptors)
+		}
+	case *NBIT:
+		for _, info := range d.Informations {
+			loops = append(loops, info.Descriptors)
+		}
+	case *LDT:
+		for _, desc := range d.Descriptions {
+			loops = append(loops, desc.Descriptors)
+		}
+	case *RRT:
+		loops = append(loops, d.Descriptors)
+	case *ATSCEIT:
+		for _, e := range d.Events {
+			loops = append(loops, e.Descriptors)
+		}
+	case *SIT:
+		loops = append(loops, d.TransmissionInfoDescriptors)
+		for _, s := range d.Services {
+			loops = append(loops, s.Descriptors)
+		}
+	case *TOT:
+		loops = append(loops, d.Descriptors)
+	}
+	return
+}
+
+// DescriptorWarning is a descriptor.Warning located in a section: Loop
+// indexes DescriptorLoops of the section's table.
+type DescriptorWarning struct {
+	descriptor.Warning
+	Loop int `json:"loop"`
+}
+
+func (w DescriptorWarning) Error() string {
+	return fmt.Sprintf("astits: descriptor loop %d: %s", w.Loop, w.Warning)
+}
+
+func (w DescriptorWarning) Unwrap() error { return ErrDescriptorLength }
+
+// ValidateDescriptors runs descriptor.Validate over every descriptor loop of
+// the section, so monitoring can flag malformed SI that parsed without error.
+func (s *Section) ValidateDescriptors() (ws []DescriptorWarning) {
+	if s.Syntax == nil {
+		return
+	}
+	for loop, ds := range DescriptorLoops(s.Syntax.Data) {
+		for _, w := range descriptor.Validate(ds) {
+			ws = append(ws, DescriptorWarning{Warning: w, Loop: loop})
+		}
+	}
+	return
+}
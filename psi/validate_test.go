@@ -0,0 +1,47 @@
+package psi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/descriptor"
+	"github.com/k-danil/go-astits/v2/internal/bitstest"
+	"github.com/k-danil/go-astits/v2/internal/bytesiter"
+)
+
+func TestValidateDescriptors(t *testing.T) {
+	s := Section{Syntax: &SectionSyntax{Data: pmt}}
+	assert.Empty(t, s.ValidateDescriptors())
+
+	buf := &bytes.Buffer{}
+	w := bitstest.NewWriter(buf)
+	_ = w.Write("111")                                 // Reserved bits
+	_ = w.Write("1010101010101")                       // PCR PID
+	_ = w.Write("1111")                                // Reserved
+	_ = w.Write("000000000000")                        // Program info length
+	_ = w.Write(uint8(StreamTypeMPEG1Audio))           // Stream #1 stream type
+	_ = w.Write("111")                                 // Stream #1 reserved
+	_ = w.Write("0101010101010")                       // Stream #1 PID
+	_ = w.Write("1111")                                // Stream #1 reserved
+	descriptorsBytes(w)                                // Stream #1 descriptors, valid
+	_ = w.Write(uint8(StreamTypeMPEG1Audio))           // Stream #2 stream type
+	_ = w.Write("111")                                 // Stream #2 reserved
+	_ = w.Write("0101010101011")                       // Stream #2 PID
+	_ = w.Write("1111")                                // Stream #2 reserved
+	_ = w.Write("000000000100")                        // ES info length
+	_ = w.Write(uint8(descriptor.TagStreamIdentifier)) // Tag
+	_ = w.Write(uint8(2))                              // Length, one byte too many
+	_ = w.Write(uint16(0x0700))                        // Component tag, garbage
+	b := buf.Bytes()
+
+	d, err := parsePMTSection(bytesiter.New(b), len(b), 1, false)
+	require.NoError(t, err)
+	s.Syntax.Data = d
+	assert.Equal(t, []DescriptorWarning{{
+		Warning: descriptor.Warning{Tag: descriptor.TagStreamIdentifier, Length: 2, Parsed: 1},
+		Loop:    2,
+	}}, s.ValidateDescriptors())
+}
@@ -0,0 +1,68 @@
+package teletext
+
+import "fmt"
+
+// Charset is a Latin G0 national option subset, selected by the C12-C14
+// control bits of the page header (EN 300 706 Table 32).
+type Charset uint8
+
+const (
+	CharsetEnglish Charset = iota
+	CharsetGerman
+	CharsetSwedish // Swedish, Finnish, Hungarian
+	CharsetItalian
+	CharsetFrench
+	CharsetPortuguese // Portuguese, Spanish
+	CharsetCzech      // Czech, Slovak
+	charsetCount
+)
+
+var charsetNames = [charsetCount]string{
+	CharsetEnglish:    "English",
+	CharsetGerman:     "German",
+	CharsetSwedish:    "Swedish/Finnish/Hungarian",
+	CharsetItalian:    "Italian",
+	CharsetFrench:     "French",
+	CharsetPortuguese: "Portuguese/Spanish",
+	CharsetCzech:      "Czech/Slovak",
+}
+
+func (c Charset) String() string {
+	if c < charsetCount {
+		return charsetNames[c]
+	}
+	return fmt.Sprintf("0x%02x", uint8(c))
+}
+
+// nationalPositions are the G0 codes a national option subset replaces.
+var nationalPositions = [13]byte{0x23, 0x24, 0x40, 0x5b, 0x5c, 0x5d, 0x5e, 0x5f, 0x60, 0x7b, 0x7c, 0x7d, 0x7e}
+
+// nationalSubsets are the characters at nationalPositions (EN 300 706 Table 36).
+var nationalSubsets = [charsetCount][13]rune{
+	CharsetEnglish:    {'£', '$', '@', '←', '½', '→', '↑', '#', '―', '¼', '‖', '¾', '÷'},
+	CharsetGerman:     {'#', '$', '§', 'Ä', 'Ö', 'Ü', '^', '_', '°', 'ä', 'ö', 'ü', 'ß'},
+	CharsetSwedish:    {'#', '¤', 'É', 'Ä', 'Ö', 'Å', 'Ü', '_', 'é', 'ä', 'ö', 'å', 'ü'},
+	CharsetItalian:    {'£', '$', 'é', '°', 'ç', '→', '↑', '#', 'ù', 'à', 'ò', 'è', 'ì'},
+	CharsetFrench:     {'é', 'ï', 'à', 'ë', 'ê', 'ù', 'î', '#', 'è', 'â', 'ô', 'û', 'ç'},
+	CharsetPortuguese: {'ç', '$', '¡', 'á', 'é', 'í', 'ó', 'ú', '¿', 'ü', 'ñ', 'è', 'à'},
+	CharsetCzech:      {'#', 'ů', 'č', 'ť', 'ž', 'ý', 'í', 'ř', 'é', 'á', 'ě', 'ú', 'š'},
+}
+
+// Rune maps a 7-bit G0 character code to its rune in the subset. Spacing
+// attributes and other control codes (below 0x20) display as spaces.
+func (c Charset) Rune(b byte) rune {
+	switch {
+	case b < 0x20:
+		return ' '
+	case b == 0x7f:
+		return '■'
+	}
+	if c < charsetCount {
+		for i, p := range nationalPositions {
+			if p == b {
+				return nationalSubsets[c][i]
+			}
+		}
+	}
+	return rune(b)
+}
@@ -0,0 +1,223 @@
+// Package teletext decodes EBU Teletext (ETSI EN 300 706) carried in PES
+// packets (ETSI EN 300 472): the data units of a PES payload are reassembled
+// into pages per magazine, and each completed page is returned with its text
+// rows decoded through the Latin G0 set and the national option subset of its
+// header.
+//
+// Feed a Decoder the PES units of one teletext PID, e.g. from the demuxer:
+//
+//	dec := teletext.NewDecoder()
+//	for {
+//		d, err := dmx.NextPES(pid)
+//		...
+//		pages, err := dec.Decode(d.Data.Data, d.Data.Header.OptionalHeader.PTS)
+//		d.Close()
+//		for _, p := range pages {
+//			if p.Subtitle { ... p.Lines ... }
+//		}
+//	}
+//
+// A page is complete when the next page header of its magazine arrives (of any
+// magazine in serial mode), so a subtitle is returned one header late; its PTS
+// is that of the unit carrying its own header, the time it goes on air. Only
+// the rows a page transmits are returned: enhancement packets (X/26-X/29) and
+// G0 sets other than Latin are not decoded.
+package teletext
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// EBU data unit ids (EN 300 472 Table 4)
+const (
+	dataUnitTeletext         = 0x02
+	dataUnitTeletextSubtitle = 0x03
+	dataUnitLength           = 44
+	framingCode              = 0xe4
+	packetSize               = 42
+	rows                     = 24 // the header row and the 23 display rows
+)
+
+// ErrNotTeletext reports a PES payload whose data_identifier is not EBU data.
+var ErrNotTeletext = errors.New("astits: data_identifier is not EBU teletext")
+
+// Line is one display row of a page.
+type Line struct {
+	Text string
+	Row  uint8 // 1-23
+}
+
+// Page is a decoded teletext page.
+type Page struct {
+	Lines   []Line
+	PTS     ts.ClockReference // of the PES unit carrying the page header
+	Subcode uint16
+	// Magazine (1-8) and Number (two hex digits, e.g. 0x88 for page 888) as
+	// in descriptor.TeletextItem.
+	Magazine  uint8
+	Number    uint8
+	Charset   Charset
+	Erase     bool // C4: the page replaces the previous one entirely
+	Newsflash bool // C5
+	Subtitle  bool // C6
+}
+
+// page is a page in reception.
+type page struct {
+	rows [rows][40]byte
+	sent uint32 // bitmap of the rows received
+	Page
+	active bool
+}
+
+// Decoder reassembles the teletext pages of one PID.
+type Decoder struct {
+	pages [8]page // by magazine
+}
+
+// NewDecoder returns a Decoder with no page in reception; the zero value is
+// equivalent.
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+// Decode consumes the PES payload of one teletext unit, pts being the PTS of
+// its header, and returns the pages completed by it. Packets with
+// uncorrectable Hamming errors are skipped; a malformed data unit fails the
+// call with an error matching ts.ErrInvalidData, after the pages completed up
+// to it.
+func (d *Decoder) Decode(payload []byte, pts ts.ClockReference) (pages []Page, err error) {
+	if len(payload) == 0 {
+		return
+	}
+	if id := payload[0]; id < 0x10 || id > 0x1f {
+		return nil, fmt.Errorf("%w: 0x%02x", ErrNotTeletext, id)
+	}
+
+	for bs := payload[1:]; len(bs) > 0; {
+		if len(bs) < 2 || len(bs) < 2+int(bs[1]) {
+			return pages, fmt.Errorf("astits: data unit truncated: %w", ts.ErrInvalidData)
+		}
+		id, unit := bs[0], bs[2:2+int(bs[1])]
+		bs = bs[2+len(unit):]
+
+		if id != dataUnitTeletext && id != dataUnitTeletextSubtitle {
+			continue // stuffing, or another kind of EBU data
+		}
+		if len(unit) != dataUnitLength {
+			return pages, fmt.Errorf("astits: teletext data unit of %d bytes: %w", len(unit), ts.ErrInvalidData)
+		}
+		if unit[1] != framingCode {
+			continue
+		}
+
+		var pkt [packetSize]byte
+		for i, b := range unit[2:] {
+			pkt[i] = bits.Reverse8(b)
+		}
+		pages = d.packet(&pkt, pts, pages)
+	}
+	return
+}
+
+// Flush returns the pages still in reception, e.g. at the end of the stream.
+func (d *Decoder) Flush() (pages []Page) {
+	for m := range d.pages {
+		pages = d.complete(m, pages)
+	}
+	return
+}
+
+// packet routes one X/Y packet to the page of its magazine.
+func (d *Decoder) packet(pkt *[packetSize]byte, pts ts.ClockReference, pages []Page) []Page {
+	addr, ok := unham(pkt[:2])
+	if !ok {
+		return pages
+	}
+	m, y := int(addr&0x7), addr>>3
+
+	switch {
+	case y == 0:
+		return d.header(m, pkt, pts, pages)
+	case y < rows:
+		p := &d.pages[m]
+		if !p.active {
+			return pages
+		}
+		copy(p.rows[y][:], pkt[2:])
+		p.sent |= 1 << y
+	}
+	return pages
+}
+
+// header completes the pages the header ends and starts its own.
+func (d *Decoder) header(m int, pkt *[packetSize]byte, pts ts.ClockReference, pages []Page) []Page {
+	h, ok := unham(pkt[2:10])
+	if !ok {
+		return pages
+	}
+
+	if h>>28&0x1 != 0 { // C11: serial mode, a header ends every magazine's page
+		for i := range d.pages {
+			pages = d.complete(i, pages)
+		}
+	} else {
+		pages = d.complete(m, pages)
+	}
+
+	units, tens := h&0xf, h>>4&0xf
+	if units > 9 || tens > 9 {
+		// Time filling header (page xFF): closes the page without opening one
+		return pages
+	}
+
+	p := &d.pages[m]
+	*p = page{active: true}
+	p.Magazine = uint8(m)
+	if m == 0 {
+		p.Magazine = 8
+	}
+	p.Number = uint8(tens<<4 | units)
+	p.Subcode = uint16(h>>8&0xf | h>>12&0x7<<4 | h>>16&0xf<<8 | h>>20&0x3<<12)
+	p.Erase = h>>15&0x1 != 0
+	p.Newsflash = h>>22&0x1 != 0
+	p.Subtitle = h>>23&0x1 != 0
+	// C12-C14, C12 the most significant bit
+	c := h >> 29 & 0x7
+	p.Charset = Charset(c&0x1<<2 | c&0x2 | c>>2)
+	p.PTS = pts
+	return pages
+}
+
+// complete renders the page of magazine m, if any, into pages.
+func (d *Decoder) complete(m int, pages []Page) []Page {
+	p := &d.pages[m]
+	if !p.active {
+		return pages
+	}
+	p.active = false
+
+	out := p.Page
+	for y := 1; y < rows; y++ {
+		if p.sent&(1<<y) == 0 {
+			continue
+		}
+		var sb strings.Builder
+		for _, b := range p.rows[y] {
+			c, ok := parity(b)
+			if !ok {
+				c = ' '
+			}
+			sb.WriteRune(p.Charset.Rune(c))
+		}
+		if text := strings.TrimSpace(sb.String()); text != "" {
+			out.Lines = append(out.Lines, Line{Row: uint8(y), Text: text})
+		}
+	}
+	return append(pages, out)
+}
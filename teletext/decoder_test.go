@@ -0,0 +1,90 @@
+package teletext
+
+import (
+	"math/bits"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// dataUnit builds an EBU teletext data unit carrying packet y of magazine m,
+// data being its 40 bytes after the address.
+func dataUnit(m, y uint8, data []byte) []byte {
+	pkt := []byte{hamming84Codewords[m&0x7|y&0x1<<3], hamming84Codewords[y>>1]}
+	pkt = append(pkt, data...)
+	unit := []byte{dataUnitTeletextSubtitle, dataUnitLength, 0x00, framingCode}
+	for _, b := range pkt {
+		unit = append(unit, bits.Reverse8(b))
+	}
+	return unit
+}
+
+// header builds the 40 bytes of a page header packet: page number, control
+// bits C4-C14 (bit 0 is C4) and a blank header row.
+func header(number uint8, control uint16) []byte {
+	h := uint32(number) | uint32(control&0x1)<<15 | uint32(control>>1&0x3)<<22 | uint32(control>>3)<<24
+	var bs []byte
+	for i := range 8 {
+		bs = append(bs, hamming84Codewords[h>>(4*i)&0xf])
+	}
+	return append(bs, text("")[:32]...)
+}
+
+// text encodes s as a 40-byte row with odd parity.
+func text(s string) []byte {
+	bs := make([]byte, 40)
+	for i := range bs {
+		c := byte(' ')
+		if i < len(s) {
+			c = s[i]
+		}
+		if bits.OnesCount8(c)%2 == 0 {
+			c |= 0x80
+		}
+		bs[i] = c
+	}
+	return bs
+}
+
+func TestDecoder(t *testing.T) {
+	const (
+		erase    = 1 << 0  // C4
+		subtitle = 1 << 2  // C6
+		german   = 1 << 10 // C14
+	)
+	row := text("\x0b\x0bGr\x7b\x7e!")
+	row[6] ^= 0x01 // parity error
+	addr := dataUnit(8, 22, text("unterwegs"))
+	addr[4] ^= 0x01 // single bit error, corrected
+
+	payload := []byte{0x10}
+	payload = append(payload, dataUnit(8, 0, header(0x88, erase|subtitle|german))...)
+	payload = append(payload, dataUnit(8, 20, row)...)
+	payload = append(payload, addr...)
+	payload = append(payload, 0xff, 0x02, 0xff, 0xff) // stuffing
+
+	d := NewDecoder()
+	pages, err := d.Decode(payload, ts.NewClockReference(90000, 0))
+	require.NoError(t, err)
+	assert.Empty(t, pages, "complete at the next header")
+
+	// Time filling header of magazine 8
+	pages, err = d.Decode(append([]byte{0x10}, dataUnit(0, 0, header(0xff, 0))...), ts.NewClockReference(180000, 0))
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.Equal(t, Page{
+		PTS:      ts.NewClockReference(90000, 0),
+		Magazine: 8, Number: 0x88,
+		Charset: CharsetGerman, Erase: true, Subtitle: true,
+		Lines: []Line{{Row: 20, Text: "Gräß"}, {Row: 22, Text: "unterwegs"}},
+	}, pages[0])
+	assert.Empty(t, d.Flush())
+
+	_, err = d.Decode([]byte{0x10, 0x02, 0x2c, 0x00}, 0)
+	assert.ErrorIs(t, err, ts.ErrInvalidData)
+	_, err = d.Decode([]byte{0x20}, 0)
+	assert.ErrorIs(t, err, ErrNotTeletext)
+}
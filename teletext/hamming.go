@@ -0,0 +1,44 @@
+package teletext
+
+import "math/bits"
+
+// hamming84Codewords are the Hamming 8/4 codewords of the nibbles 0-15 (EN 300
+// 706 §8.2), bit 0 being the first transmitted: P1 D1 P2 D2 P3 D3 P4 D4.
+var hamming84Codewords = [16]byte{
+	0x15, 0x02, 0x49, 0x5e, 0x64, 0x73, 0x38, 0x2f,
+	0xd0, 0xc7, 0x8c, 0x9b, 0xa1, 0xb6, 0xfd, 0xea,
+}
+
+// hamming84 decodes a transmitted byte to its nibble, correcting a single bit
+// error; 0xff marks a double error.
+var hamming84 = func() (t [256]byte) {
+	for b := range t {
+		t[b] = 0xff
+		for n, c := range hamming84Codewords {
+			if bits.OnesCount8(uint8(b)^c) <= 1 {
+				t[b] = byte(n)
+				break
+			}
+		}
+	}
+	return
+}()
+
+// unham decodes the Hamming 8/4 bytes of bs into one value, the first byte
+// being the least significant nibble.
+func unham(bs []byte) (v uint32, ok bool) {
+	for i := len(bs) - 1; i >= 0; i-- {
+		n := hamming84[bs[i]]
+		if n == 0xff {
+			return 0, false
+		}
+		v = v<<4 | uint32(n)
+	}
+	return v, true
+}
+
+// parity strips the odd parity bit of a text byte; ok is false on a parity
+// error.
+func parity(b byte) (c byte, ok bool) {
+	return b & 0x7f, bits.OnesCount8(b)%2 == 1
+}
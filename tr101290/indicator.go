@@ -0,0 +1,104 @@
+package tr101290
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Indicator identifies a TR 101 290 check.
+type Indicator uint8
+
+// First priority indicators (TR 101 290 §5.2.1): failing any of them makes the
+// stream undecodable.
+const (
+	TSSyncLoss           Indicator = iota // 1.1: sync lost, two or more consecutive corrupted sync bytes
+	SyncByteError                         // 1.2: a packet whose sync byte is not 0x47
+	PATError                              // 1.3: PAT missing for 0.5s, wrong table_id or scrambled on PID 0
+	ContinuityCountError                  // 1.4: packet lost, out of order or repeated more than once
+	PMTError                              // 1.5: PMT missing for 0.5s or scrambled on a PID referred to by the PAT
+	PIDError                              // 1.6: a PID referred to by a PMT missing for the PID timeout
+)
+
+// Second priority indicators (TR 101 290 §5.2.2): recommended for continuous
+// or periodic monitoring.
+const (
+	CRCError              Indicator = iota + PIDError + 1 // 2.2: a PSI/SI section with a wrong CRC32
+	PCRRepetitionError                                    // 2.3a: more than 40ms between two PCRs of a PID
+	PCRDiscontinuityError                                 // 2.3b: two PCRs outside 0-100ms apart, without discontinuity_indicator
+	PCRAccuracyError                                      // 2.4: a PCR off by more than 500ns from the transport rate
+	PTSError                                              // 2.5: more than 700ms between two PTSs of a PID
+	CATError                                              // 2.6: scrambled packets without a CAT, or another table on PID 1
+	indicatorCount
+)
+
+var indicatorNames = [indicatorCount]string{
+	TSSyncLoss:           "TS_sync_loss",
+	SyncByteError:        "Sync_byte_error",
+	PATError:             "PAT_error",
+	ContinuityCountError: "Continuity_count_error",
+	PMTError:             "PMT_error",
+	PIDError:             "PID_error",
+
+	CRCError:              "CRC_error",
+	PCRRepetitionError:    "PCR_repetition_error",
+	PCRDiscontinuityError: "PCR_discontinuity_indicator_error",
+	PCRAccuracyError:      "PCR_accuracy_error",
+	PTSError:              "PTS_error",
+	CATError:              "CAT_error",
+}
+
+// String returns the indicator name used by TR 101 290, e.g. "PAT_error".
+func (i Indicator) String() string {
+	if i < indicatorCount {
+		return indicatorNames[i]
+	}
+	return fmt.Sprintf("0x%02x", uint8(i))
+}
+
+// Priority returns the TR 101 290 priority of the indicator.
+func (i Indicator) Priority() int {
+	if i <= PIDError {
+		return 1
+	}
+	return 2
+}
+
+var (
+	// ErrSyncLost reports a TSSyncLoss.
+	ErrSyncLost = errors.New("astits: transport stream sync lost")
+	// ErrIntervalExceeded reports a table not repeated within its interval.
+	ErrIntervalExceeded = errors.New("astits: table repetition interval exceeded")
+	// ErrUnexpectedTableID reports a section of another table on a table PID.
+	ErrUnexpectedTableID = errors.New("astits: unexpected table id")
+	// ErrScrambled reports a scrambled packet on a table PID.
+	ErrScrambled = errors.New("astits: scrambled table packet")
+	// ErrPacketLost reports a continuity counter jump: a lost or reordered packet.
+	ErrPacketLost = errors.New("astits: continuity counter discontinuity")
+	// ErrPacketRepeated reports a packet sent more than twice.
+	ErrPacketRepeated = errors.New("astits: packet repeated more than once")
+	// ErrPIDMissing reports a referenced PID not seen within the PID timeout.
+	ErrPIDMissing = errors.New("astits: referenced PID missing")
+	// ErrPCRDiscontinuity reports an unsignalled PCR jump.
+	ErrPCRDiscontinuity = errors.New("astits: PCR discontinuity")
+	// ErrPCRInaccurate reports a PCR off the transport rate.
+	ErrPCRInaccurate = errors.New("astits: PCR inaccurate")
+	// ErrCATMissing reports a scrambled packet in a stream without a CAT.
+	ErrCATMissing = errors.New("astits: scrambled packet without CAT")
+)
+
+// Event is one failed check. Err is the failed condition — one of the Err
+// sentinels of this package, ts.ErrPacketMustStartWithASyncByte for a
+// SyncByteError or the parse error of a CRCError.
+type Event struct {
+	Err    error
+	Offset int64         // byte offset of the packet or damage the check failed at
+	Time   time.Duration // stream time, counted from the first PCR; 0 before it
+	PID    uint16        // ts.PIDUnset for stream-level indicators
+	// Indicator is the TR 101 290 check that failed.
+	Indicator Indicator
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s on PID %d at offset %d: %v", e.Indicator, e.PID, e.Offset, e.Err)
+}
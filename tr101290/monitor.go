@@ -0,0 +1,525 @@
+// Package tr101290 monitors a transport stream against the measurement
+// guidelines of ETSI TR 101 290.
+//
+// A Monitor is attached to a demux.Demuxer with demux.WithMonitor and checks
+// every packet the demuxer reads, reporting each failed check as an Event:
+//
+//	m := tr101290.New(tr101290.WithHandler(func(e tr101290.Event) { log.Println(e) }))
+//	dmx := demux.New(ctx, r, demux.WithMonitor(m))
+//
+// Both the first and second priority checks run; each failure is counted (see
+// Count) and passed to the handler of WithHandler and to those registered with
+// On, e.g. to feed an alerting pipeline per indicator.
+//
+// Timing checks run on stream time, read from the PCRs of the first PID seen
+// carrying one, so a file is checked as it would play; they are inactive in a
+// stream without PCR. PCR repetition and accuracy are measured against the
+// transport rate estimated from the bytes between the PCRs of each PID, which
+// assumes a constant rate stream; WithPCRFilter smooths the PCRs of a jittery
+// source before the accuracy check. PAT, PMT and CAT sections are reassembled
+// per PID, so a table spanning several packets is read once complete.
+package tr101290
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/internal/pidmap"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+const (
+	// sectionInterval is the longest gap allowed between two PAT or PMT
+	// sections.
+	sectionInterval = 500 * time.Millisecond
+	// defaultPIDTimeout is the longest gap allowed on a referenced PID.
+	defaultPIDTimeout = 5 * time.Second
+	// ptsInterval is the longest gap allowed between two PTSs of a PID.
+	ptsInterval = 700 * time.Millisecond
+
+	// pcrRepetition is the longest time allowed between two PCRs of a PID,
+	// pcrInterval the largest step between their values without a
+	// discontinuity_indicator, and pcrAccuracy the largest PCR error, in
+	// 27 MHz ticks: 40ms, 100ms and 500ns.
+	pcrRepetition = pcrTicksPerSecond / 25
+	pcrInterval   = pcrTicksPerSecond / 10
+	pcrAccuracy   = 13.5
+
+	pcrTicksPerSecond = 27000000
+	pcrWrap           = (1 << 33) * 300
+
+	// tableUnitMax bounds a table unit being reassembled: the pointer field
+	// and one section of the largest size.
+	tableUnitMax = 1 + 3 + psi.MaxPrivateSectionLength
+)
+
+// Monitor runs the TR 101 290 checks on the packets of a Demuxer; see
+// demux.WithMonitor. Like the demuxer, it is single-goroutine.
+type Monitor struct {
+	handler    func(Event)
+	on         [indicatorCount]func(Event)
+	pidTimeout time.Duration
+	pcrFilter  func() ts.PCRFilter
+	counts     [indicatorCount]uint64
+
+	clock    clock
+	syncErrs int  // consecutive sync byte errors
+	syncLost bool // TSSyncLoss reported, until the next good packet
+
+	cc   pidmap.Map[ccState]
+	pat  tableState
+	pmts pidmap.Map[tableState]
+	pids pidmap.Map[pidState] // PIDs referred to by a PMT
+	cat  tableState
+
+	pcrs       pidmap.Map[pcrState]
+	pts        pidmap.Map[time.Duration] // stream time of the last PTS per PID
+	catMissing bool                      // CATError reported for a scrambled packet
+}
+
+// ccState is the continuity counter state of a PID.
+type ccState struct {
+	cc      uint8
+	repeats uint8 // payload packets repeating cc
+	payload bool  // a payload packet carried cc
+}
+
+// tableState tracks the repetition of a PAT, PMT or CAT.
+type tableState struct {
+	last     time.Duration
+	sections [4]uint64 // section numbers read of the current version
+	pids     []uint16  // PIDs referred to by the current version
+	unit     []byte    // payload being reassembled, pointer field first; nil when none
+	version  uint8
+	seen     bool
+}
+
+// pidState tracks a PID referred to by a PMT.
+type pidState struct {
+	last time.Duration
+	refs int // referring PMTs
+}
+
+// pcrState tracks the PCRs of a PID: the last one, and the last accurate one
+// the transport rate is measured from.
+type pcrState struct {
+	ticks     int64
+	offset    int64
+	refTicks  int64
+	refOffset int64
+	rate      float64 // bytes per tick; 0 when unknown
+	missed    bool    // the last PCR was inaccurate
+
+	filter *ts.PCROffsetFilter // nil without WithPCRFilter
+}
+
+// clock unwraps the PCRs of one PID into stream time.
+type clock struct {
+	now     time.Duration
+	last    int64
+	pid     uint16
+	started bool
+}
+
+// New creates a monitor.
+func New(opts ...func(*Monitor)) (m *Monitor) {
+	m = &Monitor{pidTimeout: defaultPIDTimeout}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return
+}
+
+// WithHandler returns the option to receive every failed check as it is found.
+func WithHandler(fn func(Event)) func(*Monitor) {
+	return func(m *Monitor) {
+		m.handler = fn
+	}
+}
+
+// WithPIDTimeout returns the option to set how long a PID referred to by a PMT
+// may go missing before a PIDError; 5s by default.
+func WithPIDTimeout(d time.Duration) func(*Monitor) {
+	return func(m *Monitor) {
+		m.pidTimeout = d
+	}
+}
+
+// WithPCRFilter returns the option to pass the PCRs of each PID through a
+// filter from fn, e.g. ts.NewPCRMedianFilter, before they are checked for
+// accuracy and measure the transport rate. The filter's arrival clock is the
+// byte offset of the packets (ts.PCROffsetFilter). Repetition and
+// discontinuity are checked on the PCRs as sent.
+func WithPCRFilter(fn func() ts.PCRFilter) func(*Monitor) {
+	return func(m *Monitor) {
+		m.pcrFilter = fn
+	}
+}
+
+// On registers fn to receive the failed checks of i, along with the handler of
+// WithHandler. A later registration for the same indicator replaces fn.
+func (m *Monitor) On(i Indicator, fn func(Event)) {
+	if i < indicatorCount {
+		m.on[i] = fn
+	}
+}
+
+// Count returns the number of times the check of i failed.
+func (m *Monitor) Count(i Indicator) uint64 {
+	if i >= indicatorCount {
+		return 0
+	}
+	return m.counts[i]
+}
+
+// Time returns the stream time of the last packet checked, counted from the
+// first PCR.
+func (m *Monitor) Time() time.Duration {
+	return m.clock.now
+}
+
+func (m *Monitor) report(i Indicator, pid uint16, offset int64, err error) {
+	m.counts[i]++
+	if m.handler == nil && m.on[i] == nil {
+		return
+	}
+	e := Event{Indicator: i, PID: pid, Offset: offset, Time: m.clock.now, Err: err}
+	if m.handler != nil {
+		m.handler(e)
+	}
+	if m.on[i] != nil {
+		m.on[i](e)
+	}
+}
+
+// ObserveError implements demux.Monitor: a lost sync byte is a SyncByteError,
+// and a TSSyncLoss once the packet reader has to resync or two of them follow
+// each other; a section CRC32 mismatch is a CRCError.
+func (m *Monitor) ObserveError(e *ts.RecoverableError) {
+	if e.Kind == ts.ErrorKindCRC {
+		m.report(CRCError, e.PID, e.Offset, e.Err)
+		return
+	}
+	if !errors.Is(e.Err, ts.ErrPacketMustStartWithASyncByte) {
+		return
+	}
+	m.report(SyncByteError, ts.PIDUnset, e.Offset, e.Err)
+	m.syncErrs++
+	if !m.syncLost && (e.Kind == ts.ErrorKindSyncLoss || m.syncErrs >= 2) {
+		m.syncLost = true
+		m.report(TSSyncLoss, ts.PIDUnset, e.Offset, ErrSyncLost)
+	}
+}
+
+// ObservePacket implements demux.Monitor.
+func (m *Monitor) ObservePacket(p *ts.Packet) {
+	m.syncErrs, m.syncLost = 0, false
+	pid := p.Header.PID
+
+	if af := p.AdaptationField; af != nil && af.HasPCR {
+		moved := m.clock.update(pid, af.PCR, af.DiscontinuityIndicator)
+		m.checkPCR(p)
+		if moved {
+			m.checkIntervals(p.Offset)
+		}
+	}
+
+	if pid != ts.PIDNull {
+		m.checkContinuity(p)
+	}
+	if s := m.pids.Get(pid); s != nil {
+		s.last = m.clock.now
+	}
+	if p.Header.TransportScramblingControl != ts.ScramblingControlNotScrambled && !m.cat.seen && !m.catMissing {
+		m.catMissing = true
+		m.report(CATError, pid, p.Offset, ErrCATMissing)
+	}
+	if p.Header.PayloadUnitStartIndicator && hasPTS(p.Payload) {
+		m.pts.Set(pid, m.clock.now)
+	}
+
+	switch {
+	case pid == ts.PIDPAT:
+		m.checkTable(p, PATError, psi.TableIDPAT, &m.pat)
+	case pid == ts.PIDCAT:
+		m.checkTable(p, CATError, psi.TableIDCAT, &m.cat)
+	case m.pmts.Has(pid):
+		m.checkTable(p, PMTError, psi.TableIDPMT, m.pmts.Get(pid))
+	}
+}
+
+// hasPTS reports a payload starting a PES packet with a PTS.
+func hasPTS(bs []byte) bool {
+	// The optional header starts with '10', PTS_DTS_flags lead the next byte
+	return len(bs) > 7 && bs[0] == 0 && bs[1] == 0 && bs[2] == 1 && bs[6]&0xc0 == 0x80 && bs[7]&0x80 != 0
+}
+
+// checkPCR checks the PCR of p against the previous one of its PID, at most
+// 100ms earlier, and against the last accurate one, where the transport rate
+// puts it. A discontinuity indicator restarts the checks.
+func (m *Monitor) checkPCR(p *ts.Packet) {
+	af := p.AdaptationField
+	ticks := int64(af.PCR.Ticks())
+	s, seen := m.pcrs.Get(p.Header.PID), true
+	if s == nil {
+		s, seen = m.pcrs.GetOrAdd(p.Header.PID), false
+	}
+	if !seen && m.pcrFilter != nil {
+		s.filter = ts.NewPCROffsetFilter(m.pcrFilter())
+	}
+	n := p.Offset - s.offset
+	if !seen || af.DiscontinuityIndicator || n <= 0 {
+		*s = pcrState{ticks: ticks, offset: p.Offset, refTicks: ticks, refOffset: p.Offset, filter: s.filter}
+		s.restartFilter(af.PCR, p.Offset)
+		return
+	}
+
+	if s.rate > 0 && float64(n)/s.rate > pcrRepetition {
+		m.report(PCRRepetitionError, p.Header.PID, p.Offset, ErrIntervalExceeded)
+	}
+	d := pcrDiff(ticks, s.ticks)
+	s.ticks, s.offset = ticks, p.Offset
+	if d < 0 || d > pcrInterval {
+		m.report(PCRDiscontinuityError, p.Header.PID, p.Offset, ErrPCRDiscontinuity)
+		s.refTicks, s.refOffset, s.rate, s.missed = ticks, p.Offset, 0, false
+		s.restartFilter(af.PCR, p.Offset)
+		return
+	}
+
+	if s.filter != nil {
+		pcr := s.filter.Filter(af.PCR, p.Offset, false)
+		ticks = int64(pcr.Ticks())
+	}
+	dr, nr := pcrDiff(ticks, s.refTicks), p.Offset-s.refOffset
+	switch {
+	case s.rate > 0 && math.Abs(float64(dr)-float64(nr)/s.rate) > pcrAccuracy:
+		m.report(PCRAccuracyError, p.Header.PID, p.Offset, ErrPCRInaccurate)
+		// Keep measuring from the last accurate PCR, unless the rate changed
+		if s.missed = !s.missed; !s.missed {
+			s.refTicks, s.refOffset, s.rate = ticks, p.Offset, 0
+		}
+	default:
+		if dr > 0 {
+			s.rate = float64(nr) / float64(dr)
+		}
+		s.refTicks, s.refOffset, s.missed = ticks, p.Offset, false
+	}
+}
+
+// restartFilter restarts the PCR filter of s, if any, on pcr read at offset.
+func (s *pcrState) restartFilter(pcr ts.ClockReference, offset int64) {
+	if s.filter != nil {
+		s.filter.Filter(pcr, offset, true)
+	}
+}
+
+// pcrDiff returns the ticks from PCR b to PCR a, across a wrap.
+func pcrDiff(a, b int64) int64 {
+	d := a - b
+	if d < -pcrWrap/2 {
+		d += pcrWrap
+	}
+	return d
+}
+
+// update advances the clock to the PCR of pid and reports whether it moved. A
+// PCR discontinuity holds the clock instead of jumping it.
+func (c *clock) update(pid uint16, pcr ts.ClockReference, discontinuity bool) bool {
+	ticks := int64(pcr.Ticks())
+	if !c.started {
+		c.pid, c.last, c.started = pid, ticks, true
+		return false
+	}
+	if pid != c.pid {
+		return false
+	}
+	d := pcrDiff(ticks, c.last)
+	c.last = ticks
+	if discontinuity || d <= 0 {
+		return false
+	}
+	c.now += time.Duration(d/pcrTicksPerSecond)*time.Second + time.Duration(d%pcrTicksPerSecond)*time.Second/pcrTicksPerSecond
+	return true
+}
+
+// checkIntervals reports the PAT, PMTs and referenced PIDs overdue at the
+// current stream time; each is reported again after another interval.
+func (m *Monitor) checkIntervals(offset int64) {
+	now := m.clock.now
+	if now-m.pat.last > sectionInterval {
+		m.pat.last = now
+		m.report(PATError, ts.PIDPAT, offset, ErrIntervalExceeded)
+	}
+	for i := range m.pmts.Vals {
+		if s := &m.pmts.Vals[i]; now-s.last > sectionInterval {
+			s.last = now
+			m.report(PMTError, m.pmts.Keys[i], offset, ErrIntervalExceeded)
+		}
+	}
+	for i := range m.pids.Vals {
+		if s := &m.pids.Vals[i]; now-s.last > m.pidTimeout {
+			s.last = now
+			m.report(PIDError, m.pids.Keys[i], offset, ErrPIDMissing)
+		}
+	}
+	for i := range m.pts.Vals {
+		if last := &m.pts.Vals[i]; now-*last > ptsInterval {
+			*last = now
+			m.report(PTSError, m.pts.Keys[i], offset, ErrIntervalExceeded)
+		}
+	}
+}
+
+// checkContinuity checks the continuity counter of p: it increments on each
+// payload packet, a payload packet may be sent twice, and a discontinuity
+// indicator restarts the count.
+func (m *Monitor) checkContinuity(p *ts.Packet) {
+	cc, payload := p.Header.ContinuityCounter, p.Header.HasPayload
+	s, seen := m.cc.Get(p.Header.PID), true
+	if s == nil {
+		s, seen = m.cc.GetOrAdd(p.Header.PID), false
+	}
+	if !seen || (p.AdaptationField != nil && p.AdaptationField.DiscontinuityIndicator) {
+		*s = ccState{cc: cc, payload: payload}
+		return
+	}
+
+	switch {
+	case cc == s.cc && (!payload || s.payload):
+		if payload {
+			if s.repeats++; s.repeats == 2 {
+				m.report(ContinuityCountError, p.Header.PID, p.Offset, ErrPacketRepeated)
+			}
+		}
+		return
+	case payload && cc == (s.cc+1)&0xf:
+	default:
+		m.report(ContinuityCountError, p.Header.PID, p.Offset, ErrPacketLost)
+	}
+	*s = ccState{cc: cc, payload: payload}
+}
+
+// checkTable checks a packet of the PAT (or of a PMT, or of the CAT) and reads
+// the sections it completes.
+func (m *Monitor) checkTable(p *ts.Packet, ind Indicator, id psi.TableID, t *tableState) {
+	pid := p.Header.PID
+	if p.Header.TransportScramblingControl != ts.ScramblingControlNotScrambled {
+		m.report(ind, pid, p.Offset, ErrScrambled)
+		return
+	}
+	if !p.Header.HasPayload || len(p.Payload) == 0 {
+		return
+	}
+	switch {
+	case p.Header.PayloadUnitStartIndicator:
+		// The bytes before the pointer field end the pending unit
+		if t.unit != nil {
+			t.unit = append(t.unit, p.Payload[1:min(1+int(p.Payload[0]), len(p.Payload))]...)
+			if end, ok := tableEnd(t.unit); ok {
+				m.readTable(p, ind, id, t, t.unit[:end])
+			}
+		}
+		t.unit = append(t.unit[:0], p.Payload...)
+	case t.unit != nil:
+		t.unit = append(t.unit, p.Payload...)
+	default:
+		return
+	}
+	if end, ok := tableEnd(t.unit); ok {
+		m.readTable(p, ind, id, t, t.unit[:end])
+		t.unit = nil
+	} else if len(t.unit) > tableUnitMax {
+		t.unit = nil
+	}
+}
+
+// tableEnd walks the sections of a unit (pointer field first) and reports
+// where they end, once the unit holds all of them.
+func tableEnd(unit []byte) (end int, ok bool) {
+	o := 1 + int(unit[0])
+	for o < len(unit) && unit[o] != 0xff {
+		if o+3 > len(unit) {
+			return 0, false
+		}
+		o += 3 + int(binary.BigEndian.Uint16(unit[o+1:])&0xfff)
+	}
+	return o, o <= len(unit)
+}
+
+// readTable reads the sections of a complete unit of the table checked by
+// checkTable.
+func (m *Monitor) readTable(p *ts.Packet, ind Indicator, id psi.TableID, t *tableState, unit []byte) {
+	pid := p.Header.PID
+	d, err := psi.Parse(unit)
+	if err != nil {
+		return
+	}
+	for _, s := range d.Sections {
+		if s.Header.TableID != id {
+			// PIDs 0 and 1 carry nothing but the PAT and the CAT; a PMT PID
+			// may carry private sections.
+			if id != psi.TableIDPMT {
+				m.report(ind, pid, p.Offset, ErrUnexpectedTableID)
+			}
+			continue
+		}
+		t.last = m.clock.now
+		if s.Syntax == nil || !s.Syntax.Header.CurrentNextIndicator {
+			continue
+		}
+		h := s.Syntax.Header
+		if !t.seen || t.version != h.VersionNumber {
+			m.unrefer(t, id)
+			t.seen, t.version, t.sections = true, h.VersionNumber, [4]uint64{}
+		}
+		if bit := uint64(1) << (h.SectionNumber % 64); t.sections[h.SectionNumber/64]&bit == 0 {
+			t.sections[h.SectionNumber/64] |= bit
+			m.refer(t, s.Syntax.Data)
+		}
+	}
+}
+
+// refer records the PIDs a PAT or PMT section refers to.
+func (m *Monitor) refer(t *tableState, data psi.SectionSyntaxData) {
+	switch d := data.(type) {
+	case *psi.PAT:
+		for _, p := range d.Programs {
+			if p.ProgramNumber == 0 || m.pmts.Has(p.ProgramMapID) {
+				continue
+			}
+			m.pmts.Set(p.ProgramMapID, tableState{last: m.clock.now})
+			t.pids = append(t.pids, p.ProgramMapID)
+		}
+	case *psi.PMT:
+		for _, es := range d.ElementaryStreams {
+			s := m.pids.Get(es.ElementaryPID)
+			if s == nil {
+				s = m.pids.GetOrAdd(es.ElementaryPID)
+				s.last = m.clock.now
+			}
+			s.refs++
+			t.pids = append(t.pids, es.ElementaryPID)
+		}
+	}
+}
+
+// unrefer drops the PIDs the previous version of a PAT or PMT referred to,
+// along with those of the PMTs a PAT drops.
+func (m *Monitor) unrefer(t *tableState, id psi.TableID) {
+	for _, pid := range t.pids {
+		if id == psi.TableIDPAT {
+			if pmt := m.pmts.Get(pid); pmt != nil {
+				m.unrefer(pmt, psi.TableIDPMT)
+			}
+			m.pmts.Remove(pid)
+		} else if s := m.pids.Get(pid); s != nil {
+			if s.refs--; s.refs <= 0 {
+				m.pids.Remove(pid)
+			}
+		}
+	}
+	t.pids = t.pids[:0]
+}
@@ -0,0 +1,345 @@
+package tr101290
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k-danil/go-astits/v2/demux"
+	"github.com/k-danil/go-astits/v2/psi"
+	"github.com/k-danil/go-astits/v2/ts"
+)
+
+// tablePacket builds a packet on pid carrying one section of table id.
+func tablePacket(t *testing.T, pid uint16, cc uint8, id psi.TableID, ext uint16, data psi.SectionSyntaxData) []byte {
+	d := &psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: id, SectionSyntaxIndicator: true},
+		Syntax: &psi.SectionSyntax{Header: psi.SectionSyntaxHeader{TableIDExtension: ext, CurrentNextIndicator: true}, Data: data},
+	}}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+	return packet(t, ts.Packet{
+		Header:  ts.PacketHeader{PID: pid, ContinuityCounter: cc, HasPayload: true, PayloadUnitStartIndicator: true},
+		Payload: payload,
+	})
+}
+
+// pcrPacket builds a payload packet on pid carrying a PCR at stream time d.
+func pcrPacket(t *testing.T, pid uint16, cc uint8, d time.Duration) []byte {
+	return packet(t, ts.Packet{
+		Header: ts.PacketHeader{PID: pid, ContinuityCounter: cc, HasPayload: true, HasAdaptationField: true},
+		AdaptationField: &ts.PacketAdaptationField{
+			HasPCR: true,
+			PCR:    ts.NewClockReference(uint64(d/time.Microsecond)*90/1000, 0),
+		},
+		Payload: []byte{0xaa},
+	})
+}
+
+// packet serializes p into a full packet, stuffing the payload with 0xff.
+func packet(t *testing.T, p ts.Packet) []byte {
+	bs := make([]byte, ts.PacketSize)
+	for i := range bs {
+		bs[i] = 0xff
+	}
+	_, err := p.Put(bs)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestMonitor(t *testing.T) {
+	pat := &psi.PAT{TransportStreamID: 1, Programs: []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}}}
+	pmt := &psi.PMT{ProgramNumber: 1, PCRPID: 0x100, ElementaryStreams: []psi.ElementaryStream{
+		{ElementaryPID: 0x100, StreamType: psi.StreamTypeH264Video},
+		{ElementaryPID: 0x101, StreamType: psi.StreamTypeAACAudio},
+	}}
+
+	// 100ms steps: PAT and PMT until 1s, the audio PID until 0.5s
+	var stream []byte
+	for i := range uint8(20) {
+		if i <= 10 {
+			stream = append(stream, tablePacket(t, ts.PIDPAT, i, psi.TableIDPAT, 1, pat)...)
+			stream = append(stream, tablePacket(t, 0x1000, i, psi.TableIDPMT, 1, pmt)...)
+		}
+		if i <= 5 {
+			stream = append(stream, packet(t, ts.Packet{Header: ts.PacketHeader{PID: 0x101, ContinuityCounter: i, HasPayload: true}})...)
+		}
+		cc := i
+		switch i {
+		case 3:
+			cc = 7 // lost packets
+		case 4, 5:
+			cc = 7 // repeated twice
+		}
+		if i > 5 {
+			cc = i + 2
+		}
+		stream = append(stream, pcrPacket(t, 0x100, cc%16, time.Duration(i)*100*time.Millisecond)...)
+	}
+	// PMT on PID 0, then a corrupted sync byte
+	stream = append(stream, tablePacket(t, ts.PIDPAT, 11, psi.TableIDPMT, 1, pmt)...)
+	bad := pcrPacket(t, 0x100, 6, 2*time.Second)
+	bad[0] = 0x46
+	stream = append(stream, bad...)
+	stream = append(stream, pcrPacket(t, 0x100, 7, 2100*time.Millisecond)...)
+	stream = append(stream, pcrPacket(t, 0x100, 8, 2200*time.Millisecond)...)
+
+	var events []Event
+	m := New(WithPIDTimeout(time.Second), WithHandler(func(e Event) { events = append(events, e) }))
+	dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize),
+		demux.WithSyncLock(), demux.WithMonitor(m))
+	defer dmx.Close()
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	type check struct {
+		err       error
+		at        time.Duration
+		pid       uint16
+		indicator Indicator
+	}
+	var got []check
+	for _, e := range events {
+		if e.Indicator.Priority() == 1 {
+			got = append(got, check{indicator: e.Indicator, pid: e.PID, at: e.Time, err: e.Err})
+		}
+	}
+	assert.Equal(t, []check{
+		{indicator: ContinuityCountError, pid: 0x100, at: 300 * time.Millisecond, err: ErrPacketLost},
+		{indicator: ContinuityCountError, pid: 0x100, at: 500 * time.Millisecond, err: ErrPacketRepeated},
+		// Sections read at 0.9s, the audio PID at 0.4s
+		{indicator: PATError, pid: ts.PIDPAT, at: 1500 * time.Millisecond, err: ErrIntervalExceeded},
+		{indicator: PMTError, pid: 0x1000, at: 1500 * time.Millisecond, err: ErrIntervalExceeded},
+		{indicator: PIDError, pid: 0x101, at: 1500 * time.Millisecond, err: ErrPIDMissing},
+		{indicator: PATError, pid: ts.PIDPAT, at: 1900 * time.Millisecond, err: ErrUnexpectedTableID},
+		{indicator: SyncByteError, pid: ts.PIDUnset, at: 1900 * time.Millisecond, err: ts.ErrPacketMustStartWithASyncByte},
+		{indicator: TSSyncLoss, pid: ts.PIDUnset, at: 1900 * time.Millisecond, err: ErrSyncLost},
+		{indicator: PATError, pid: ts.PIDPAT, at: 2100 * time.Millisecond, err: ErrIntervalExceeded},
+		{indicator: PMTError, pid: 0x1000, at: 2100 * time.Millisecond, err: ErrIntervalExceeded},
+		// The packet lost to the sync loss
+		{indicator: ContinuityCountError, pid: 0x100, at: 2100 * time.Millisecond, err: ErrPacketLost},
+	}, got)
+	assert.Equal(t, uint64(3), m.Count(ContinuityCountError))
+	assert.Equal(t, uint64(3), m.Count(PATError))
+	assert.Equal(t, "PAT_error", PATError.String())
+}
+
+func TestMonitorMultiPacketTable(t *testing.T) {
+	pat := &psi.PAT{TransportStreamID: 1, Programs: []psi.PATProgram{{ProgramNumber: 1, ProgramMapID: 0x1000}}}
+	pmt := &psi.PMT{ProgramNumber: 1, PCRPID: 0x100}
+	for i := range uint16(40) {
+		pmt.ElementaryStreams = append(pmt.ElementaryStreams, psi.ElementaryStream{ElementaryPID: 0x100 + i, StreamType: psi.StreamTypeAACAudio})
+	}
+	d := &psi.Data{Sections: []psi.Section{{
+		Header: psi.SectionHeader{TableID: psi.TableIDPMT, SectionSyntaxIndicator: true},
+		Syntax: &psi.SectionSyntax{Header: psi.SectionSyntaxHeader{TableIDExtension: 1, CurrentNextIndicator: true}, Data: pmt},
+	}}}
+	payload, err := d.Append(nil)
+	require.NoError(t, err)
+	require.Greater(t, len(payload), ts.PacketSize-ts.HeaderSize)
+
+	// 100ms steps: the PAT, then the PMT over two packets
+	var stream []byte
+	for i := range uint8(12) {
+		stream = append(stream, tablePacket(t, ts.PIDPAT, i%16, psi.TableIDPAT, 1, pat)...)
+		split := ts.PacketSize - ts.HeaderSize
+		stream = append(stream, packet(t, ts.Packet{
+			Header:  ts.PacketHeader{PID: 0x1000, ContinuityCounter: 2 * i % 16, HasPayload: true, PayloadUnitStartIndicator: true},
+			Payload: payload[:split],
+		})...)
+		stream = append(stream, packet(t, ts.Packet{
+			Header:  ts.PacketHeader{PID: 0x1000, ContinuityCounter: (2*i + 1) % 16, HasPayload: true},
+			Payload: payload[split:],
+		})...)
+		stream = append(stream, pcrPacket(t, 0x100, i%16, time.Duration(i)*100*time.Millisecond)...)
+	}
+
+	m := New()
+	dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize), demux.WithMonitor(m))
+	defer dmx.Close()
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Zero(t, m.Count(PATError))
+	assert.Zero(t, m.Count(PMTError))
+}
+
+func TestMonitorPriority2(t *testing.T) {
+	null := packet(t, ts.Packet{Header: ts.PacketHeader{PID: ts.PIDNull, HasPayload: true}})
+	pes := packet(t, ts.Packet{
+		Header:  ts.PacketHeader{PID: 0x101, HasPayload: true, PayloadUnitStartIndicator: true},
+		Payload: []byte{0x00, 0x00, 0x01, 0xc0, 0x00, 0x00, 0x80, 0x80, 0x05, 0x21, 0x00, 0x01, 0x00, 0x01},
+	})
+	badPAT := tablePacket(t, ts.PIDPAT, 0, psi.TableIDPAT, 1, &psi.PAT{TransportStreamID: 1})
+	badPAT[ts.HeaderSize+1+8] ^= 0xff // CRC32
+	scrambled := packet(t, ts.Packet{Header: ts.PacketHeader{
+		PID: 0x102, HasPayload: true, TransportScramblingControl: ts.ScramblingControlScrambledWithEvenKey,
+	}})
+
+	// A PCR every 10 packets, 10ms apart at a constant rate
+	var stream []byte
+	for i := range 100 {
+		if i > 60 && i < 75 {
+			// PCRs missing for 150ms
+			for range 10 {
+				stream = append(stream, null...)
+			}
+			continue
+		}
+		at := time.Duration(i) * 10 * time.Millisecond
+		switch {
+		case i == 20:
+			at += 100 * time.Microsecond // off the rate
+		case i >= 40:
+			at += 5 * time.Second // signalled discontinuity
+		}
+		p := pcrPacket(t, 0x100, uint8(i%16), at)
+		if i == 40 {
+			p[5] |= 0x80 // discontinuity_indicator
+		}
+		stream = append(stream, p...)
+		for j := range 9 {
+			switch {
+			case i == 0 && j == 0:
+				stream = append(stream, pes...)
+			case i == 80 && j == 0:
+				stream = append(stream, badPAT...)
+			case i == 85 && j == 0:
+				stream = append(stream, scrambled...)
+			case i == 86 && j == 0:
+				stream = append(stream, tablePacket(t, ts.PIDCAT, 0, psi.TableIDPAT, 1, &psi.PAT{TransportStreamID: 1})...)
+			default:
+				stream = append(stream, null...)
+			}
+		}
+	}
+
+	var got []string
+	m := New(WithHandler(func(e Event) {
+		if e.Indicator.Priority() == 2 {
+			got = append(got, fmt.Sprintf("%s %#x %v", e.Indicator, e.PID, e.Time))
+		}
+	}))
+	var crcs int
+	m.On(CRCError, func(e Event) {
+		crcs++
+		assert.ErrorIs(t, e.Err, psi.ErrCRC32Mismatch)
+	})
+	dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize), demux.WithMonitor(m))
+	defer dmx.Close()
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{
+		"PCR_accuracy_error 0x100 200.1ms",
+		// Clock held at the discontinuity: 10ms behind from then on
+		"PCR_repetition_error 0x100 740ms",
+		"PCR_discontinuity_indicator_error 0x100 740ms",
+		"PTS_error 0x101 740ms",
+		"CRC_error 0x0 790ms",
+		"CAT_error 0x102 840ms",
+		"CAT_error 0x1 850ms",
+	}, got)
+	assert.Equal(t, 1, crcs)
+	assert.Equal(t, uint64(2), m.Count(CATError))
+	assert.Equal(t, 2, CATError.Priority())
+}
+
+func TestMonitorPCRRepetition(t *testing.T) {
+	// A PCR every 10 packets, 10ms apart, but for a 60ms gap: too long for
+	// 2.3a, not a discontinuity for 2.3b
+	null := packet(t, ts.Packet{Header: ts.PacketHeader{PID: ts.PIDNull, HasPayload: true}})
+	var stream []byte
+	for i := range 20 {
+		if i > 9 && i < 15 {
+			for range 10 {
+				stream = append(stream, null...)
+			}
+			continue
+		}
+		stream = append(stream, pcrPacket(t, 0x100, uint8(i%16), time.Duration(i)*10*time.Millisecond)...)
+		for range 9 {
+			stream = append(stream, null...)
+		}
+	}
+
+	var got []string
+	m := New(WithHandler(func(e Event) {
+		if e.Indicator.Priority() == 2 {
+			got = append(got, fmt.Sprintf("%s %#x %v", e.Indicator, e.PID, e.Time))
+		}
+	}))
+	dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize), demux.WithMonitor(m))
+	defer dmx.Close()
+	for {
+		_, err := dmx.Next()
+		if errors.Is(err, ts.ErrNoMorePackets) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"PCR_repetition_error 0x100 150ms"}, got)
+}
+
+func TestMonitorPCRFilter(t *testing.T) {
+	// A PCR every 10 packets, 10ms apart at a constant rate, every third one
+	// 2µs late
+	null := packet(t, ts.Packet{Header: ts.PacketHeader{PID: ts.PIDNull, HasPayload: true}})
+	var stream []byte
+	for i := range 50 {
+		ticks := uint64(i) * 270000
+		if i%3 == 2 {
+			ticks += 54
+		}
+		stream = append(stream, packet(t, ts.Packet{
+			Header: ts.PacketHeader{PID: 0x100, ContinuityCounter: uint8(i % 16), HasPayload: true, HasAdaptationField: true},
+			AdaptationField: &ts.PacketAdaptationField{
+				HasPCR: true,
+				PCR:    ts.NewClockReference(ticks/300, ticks%300),
+			},
+			Payload: []byte{0xaa},
+		})...)
+		for range 9 {
+			stream = append(stream, null...)
+		}
+	}
+
+	run := func(opts ...func(*Monitor)) *Monitor {
+		m := New(opts...)
+		dmx := demux.New(context.Background(), bytes.NewReader(stream), demux.WithPacketSize(ts.PacketSize), demux.WithMonitor(m))
+		defer dmx.Close()
+		for {
+			_, err := dmx.Next()
+			if errors.Is(err, ts.ErrNoMorePackets) {
+				break
+			}
+			require.NoError(t, err)
+		}
+		return m
+	}
+	assert.NotZero(t, run().Count(PCRAccuracyError))
+	m := run(WithPCRFilter(func() ts.PCRFilter { return ts.NewPCRMedianFilter(5) }))
+	assert.Zero(t, m.Count(PCRAccuracyError))
+	assert.Zero(t, m.Count(PCRDiscontinuityError))
+	assert.Zero(t, m.Count(PCRRepetitionError))
+}
@@ -0,0 +1,127 @@
+package ts
+
+import (
+	"encoding/binary"
+
+	"github.com/k-danil/go-astits/v2/internal/util"
+)
+
+// Tags of the adaptation field data items carried in the transport private
+// data (ETSI TS 101 154 Annex D, CableLabs OC-SP-EBP for the EBP).
+const (
+	AFDataTagAnnouncementSwitching uint8 = 0x01
+	AFDataTagAUInformation         uint8 = 0x02
+	AFDataTagPVRAssist             uint8 = 0x03
+	AFDataTagEBP                   uint8 = 0xdf
+)
+
+// ebpFormatIdentifier is the format_identifier opening an EBP item, "EBP0".
+const ebpFormatIdentifier = 0x45425030
+
+// PrivateDataItem is a tag, length, data item of the transport private data.
+type PrivateDataItem struct {
+	Data []byte
+	Tag  uint8
+}
+
+// SetTransportPrivateData makes data the transport private data of af, with
+// its flag and length.
+func (af *PacketAdaptationField) SetTransportPrivateData(data []byte) {
+	af.HasTransportPrivateData = true
+	af.TransportPrivateData = data
+	af.TransportPrivateDataLength = uint8(len(data))
+}
+
+// AppendPrivateDataItems appends items to dst as transport private data.
+func AppendPrivateDataItems(dst []byte, items ...PrivateDataItem) []byte {
+	for _, it := range items {
+		dst = append(dst, it.Tag, uint8(len(it.Data)))
+		dst = append(dst, it.Data...)
+	}
+	return dst
+}
+
+// ParsePrivateDataItems splits transport private data into its items, their
+// data viewing bs.
+func ParsePrivateDataItems(bs []byte) (items []PrivateDataItem, err error) {
+	for len(bs) > 0 {
+		if len(bs) < 2 || len(bs) < 2+int(bs[1]) {
+			return items, ErrShortPacket
+		}
+		items = append(items, PrivateDataItem{Tag: bs[0], Data: bs[2 : 2+int(bs[1])]})
+		bs = bs[2+int(bs[1]):]
+	}
+	return
+}
+
+// EBP is an encoder boundary point, marking a fragment or segment boundary
+// for packagers in the adaptation field of the packet starting the unit.
+type EBP struct {
+	AcquisitionTime uint64  // NTP time, when HasTime
+	Groups          []uint8 // 7-bit grouping ids
+	SAPType         uint8   // when HasSAP
+	Fragment        bool
+	Segment         bool
+	Concealment     bool
+	HasSAP          bool
+	HasTime         bool
+}
+
+// Item returns the EBP as a transport private data item.
+func (e *EBP) Item() PrivateDataItem {
+	bs := binary.BigEndian.AppendUint32(make([]byte, 0, 14+len(e.Groups)), ebpFormatIdentifier)
+	bs = append(bs, util.B2U(e.Fragment)<<7|util.B2U(e.Segment)<<6|util.B2U(e.HasSAP)<<5|
+		util.B2U(len(e.Groups) > 0)<<4|util.B2U(e.HasTime)<<3|util.B2U(e.Concealment)<<2|0x2)
+	if e.HasSAP {
+		bs = append(bs, e.SAPType<<5|0x1f)
+	}
+	for i, g := range e.Groups {
+		// the extension flag chains the next group
+		bs = append(bs, util.B2U(i < len(e.Groups)-1)<<7|g&0x7f)
+	}
+	if e.HasTime {
+		bs = binary.BigEndian.AppendUint64(bs, e.AcquisitionTime)
+	}
+	return PrivateDataItem{Tag: AFDataTagEBP, Data: bs}
+}
+
+// ParseEBP parses the data of an EBP item.
+func ParseEBP(bs []byte) (e *EBP, err error) {
+	if len(bs) < 5 || binary.BigEndian.Uint32(bs) != ebpFormatIdentifier {
+		return nil, ErrShortPacket
+	}
+	flags := bs[4]
+	e = &EBP{
+		Fragment:    flags&0x80 > 0,
+		Segment:     flags&0x40 > 0,
+		HasSAP:      flags&0x20 > 0,
+		HasTime:     flags&0x08 > 0,
+		Concealment: flags&0x04 > 0,
+	}
+	o := 5
+	if flags&0x01 > 0 {
+		// extension byte, not decoded
+		o++
+	}
+	if e.HasSAP {
+		if o >= len(bs) {
+			return nil, ErrShortPacket
+		}
+		e.SAPType = bs[o] >> 5
+		o++
+	}
+	for more := flags&0x10 > 0; more; o++ {
+		if o >= len(bs) {
+			return nil, ErrShortPacket
+		}
+		e.Groups = append(e.Groups, bs[o]&0x7f)
+		more = bs[o]&0x80 > 0
+	}
+	if e.HasTime {
+		if o+8 > len(bs) {
+			return nil, ErrShortPacket
+		}
+		e.AcquisitionTime = binary.BigEndian.Uint64(bs[o:])
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package ts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateDataItems(t *testing.T) {
+	items := []PrivateDataItem{
+		{Tag: AFDataTagAUInformation, Data: []byte{1, 2, 3}},
+		{Tag: AFDataTagPVRAssist, Data: []byte{}},
+	}
+	bs := AppendPrivateDataItems(nil, items...)
+	assert.Equal(t, []byte{0x02, 3, 1, 2, 3, 0x03, 0}, bs)
+	got, err := ParsePrivateDataItems(bs)
+	require.NoError(t, err)
+	assert.Equal(t, items, got)
+
+	_, err = ParsePrivateDataItems(bs[:3])
+	assert.ErrorIs(t, err, ErrShortPacket)
+}
+
+func TestEBP(t *testing.T) {
+	e := &EBP{
+		Fragment:        true,
+		Segment:         true,
+		HasSAP:          true,
+		SAPType:         1,
+		Groups:          []uint8{1, 2},
+		HasTime:         true,
+		AcquisitionTime: 0xe0000000_80000000,
+	}
+	it := e.Item()
+	assert.Equal(t, AFDataTagEBP, it.Tag)
+	assert.Equal(t, []byte{'E', 'B', 'P', '0', 0xfa, 0x3f, 0x81, 0x02, 0xe0, 0, 0, 0, 0x80, 0, 0, 0}, it.Data)
+	got, err := ParseEBP(it.Data)
+	require.NoError(t, err)
+	assert.Equal(t, e, got)
+
+	_, err = ParseEBP(it.Data[:9])
+	assert.ErrorIs(t, err, ErrShortPacket)
+}
+
+// The private data length written is that of the data, and the data items go
+// through Put and Parse with the extension field next to them.
+func TestAdaptationFieldPrivateDataWrite(t *testing.T) {
+	ebp := (&EBP{Segment: true}).Item()
+	var af PacketAdaptationField
+	af.SetTransportPrivateData(AppendPrivateDataItems(nil, ebp))
+	af.HasAdaptationExtensionField = true
+	af.AdaptationExtensionField = &PacketAdaptationExtensionField{HasPiecewiseRate: true, PiecewiseRate: 1000}
+	assert.Equal(t, 1+1+2+5+1+4, af.CalcLength())
+
+	af.TransportPrivateDataLength = 0
+	bs := make([]byte, PacketSize)
+	n, err := af.Put(bs)
+	require.NoError(t, err)
+	assert.Equal(t, 1+af.CalcLength(), n)
+
+	var parsed PacketAdaptationField
+	_, err = parsed.Parse(bs)
+	require.NoError(t, err)
+	items, err := ParsePrivateDataItems(parsed.TransportPrivateData)
+	require.NoError(t, err)
+	assert.Equal(t, []PrivateDataItem{ebp}, items)
+	assert.Equal(t, uint32(1000), parsed.AdaptationExtensionField.PiecewiseRate)
+
+	// flagged without a field: an empty extension
+	af = PacketAdaptationField{HasAdaptationExtensionField: true}
+	n, err = af.Put(bs)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0x01, 0}, bs[:n])
+}
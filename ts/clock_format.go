@@ -0,0 +1,123 @@
+package ts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/k-danil/go-astits/v2/internal/util"
+)
+
+// ClockFormat selects how ClockReference.Format and MarshalJSONFormat render
+// a clock reference.
+type ClockFormat uint8
+
+const (
+	// ClockFormat27MHz is the 27 MHz tick count (base*300 + extension):
+	// lossless, a JSON number.
+	ClockFormat27MHz ClockFormat = iota
+	// ClockFormat90kHz is the 90 kHz base; the extension is dropped. A JSON
+	// number.
+	ClockFormat90kHz
+	// ClockFormatSeconds is the value in seconds, a JSON number.
+	ClockFormatSeconds
+	// ClockFormatTimecode is "HH:MM:SS.mmm", a JSON string.
+	ClockFormatTimecode
+)
+
+var clockFormatNames = map[ClockFormat]string{
+	ClockFormat27MHz:    "27MHz",
+	ClockFormat90kHz:    "90kHz",
+	ClockFormatSeconds:  "seconds",
+	ClockFormatTimecode: "timecode",
+}
+
+func (f ClockFormat) String() (s string) {
+	var ok bool
+	if s, ok = clockFormatNames[f]; !ok {
+		s = fmt.Sprintf("0x%02x", uint8(f))
+	}
+	return
+}
+
+func (f ClockFormat) MarshalJSON() (b []byte, err error) {
+	return json.Marshal(f.String())
+}
+
+func (f *ClockFormat) UnmarshalJSON(b []byte) (err error) {
+	*f, err = util.UnmarshalEnum(b, clockFormatNames)
+	return
+}
+
+// Ticks returns the clock reference in 27 MHz ticks.
+func (cr *ClockReference) Ticks() uint64 {
+	return cr.Base()*300 + cr.Extension()
+}
+
+// Format renders the clock reference in the given representation.
+func (cr ClockReference) Format(f ClockFormat) string {
+	switch f {
+	case ClockFormat90kHz:
+		return strconv.FormatUint(cr.Base(), 10)
+	case ClockFormatSeconds:
+		return strconv.FormatFloat(cr.Duration().Seconds(), 'f', -1, 64)
+	case ClockFormatTimecode:
+		d := cr.Duration()
+		return fmt.Sprintf("%02d:%02d:%02d.%03d",
+			int(d/time.Hour), int(d/time.Minute%60), int(d/time.Second%60), int(d/time.Millisecond%1000))
+	default:
+		return strconv.FormatUint(cr.Ticks(), 10)
+	}
+}
+
+// MarshalJSONFormat renders the clock reference as JSON in the given
+// representation, for encoders choosing one per field; plain encoding/json
+// keeps the raw value.
+func (cr ClockReference) MarshalJSONFormat(f ClockFormat) ([]byte, error) {
+	s := cr.Format(f)
+	if f == ClockFormatTimecode {
+		return json.Marshal(s)
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalJSONFormat reads the representation MarshalJSONFormat writes for
+// f; a timecode string is accepted under any format.
+func (cr *ClockReference) UnmarshalJSONFormat(b []byte, f ClockFormat) (err error) {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err = json.Unmarshal(b, &s); err != nil {
+			return
+		}
+		var h, m, sec, ms uint64
+		if _, err = fmt.Sscanf(s, "%d:%d:%d.%d", &h, &m, &sec, &ms); err != nil {
+			return fmt.Errorf("astits: parsing clock reference timecode %q failed: %w", s, err)
+		}
+		*cr = clockReferenceFromTicks(((h*60+m)*60+sec)*pcrTicksPerSecond + ms*pcrTicksPerSecond/1000)
+		return
+	}
+
+	switch f {
+	case ClockFormat90kHz:
+		var base uint64
+		if err = json.Unmarshal(b, &base); err == nil {
+			*cr = NewClockReference(base, 0)
+		}
+	case ClockFormatSeconds:
+		var s float64
+		if err = json.Unmarshal(b, &s); err == nil {
+			*cr = clockReferenceFromTicks(uint64(s*pcrTicksPerSecond + 0.5))
+		}
+	default:
+		var ticks uint64
+		if err = json.Unmarshal(b, &ticks); err == nil {
+			*cr = clockReferenceFromTicks(ticks)
+		}
+	}
+	return
+}
+
+func clockReferenceFromTicks(ticks uint64) ClockReference {
+	return NewClockReference(ticks/300, ticks%300)
+}
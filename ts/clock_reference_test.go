@@ -1,10 +1,14 @@
 package ts
 
 import (
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var clockReference = NewClockReference(3271034319, 58)
@@ -13,3 +17,35 @@ func TestClockReference(t *testing.T) {
 	assert.Equal(t, 36344825768814*time.Nanosecond, clockReference.Duration())
 	assert.Equal(t, int64(36344), clockReference.Time().Unix())
 }
+
+func TestClockReferenceFormat(t *testing.T) {
+	cr := NewClockReference(90000*3723+45*90, 150) // 01:02:03.045 + 150/27 µs
+	assert.Equal(t, "100522215150", cr.Format(ClockFormat27MHz))
+	assert.Equal(t, "335074050", cr.Format(ClockFormat90kHz))
+	assert.Equal(t, "3723.045005555", cr.Format(ClockFormatSeconds))
+	assert.Equal(t, "01:02:03.045", cr.Format(ClockFormatTimecode))
+
+	for _, tc := range []struct {
+		f    ClockFormat
+		json string
+		back ClockReference
+	}{
+		{ClockFormat27MHz, `100522215150`, cr},
+		{ClockFormat90kHz, `335074050`, NewClockReference(cr.Base(), 0)},
+		{ClockFormatSeconds, `3723.045005555`, cr},
+		{ClockFormatTimecode, `"01:02:03.045"`, NewClockReference(cr.Base(), 0)},
+	} {
+		b, err := cr.MarshalJSONFormat(tc.f)
+		require.NoError(t, err)
+		assert.Equal(t, tc.json, string(b), tc.f.String())
+		var got ClockReference
+		require.NoError(t, got.UnmarshalJSONFormat(b, tc.f))
+		assert.Equal(t, tc.back, got, tc.f.String())
+	}
+
+	// plain encoding/json and fmt keep the raw value
+	b, err := json.Marshal(struct{ PCR ClockReference }{cr})
+	require.NoError(t, err)
+	assert.Equal(t, `{"PCR":`+strconv.FormatUint(uint64(cr), 10)+`}`, string(b))
+	assert.Equal(t, strconv.FormatUint(uint64(cr), 10), fmt.Sprint(cr))
+}
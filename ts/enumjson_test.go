@@ -20,4 +20,5 @@ func testEnumJSONRoundtrip[T interface{ ~uint8 }](t *testing.T) {
 
 func TestEnumJSONRoundtrip(t *testing.T) {
 	t.Run("ScramblingControl", testEnumJSONRoundtrip[ScramblingControl])
+	t.Run("ClockFormat", testEnumJSONRoundtrip[ClockFormat])
 }
@@ -14,4 +14,8 @@ var (
 	ErrNoMorePackets                = errors.New("astits: no more packets")
 	ErrPacketMustStartWithASyncByte = errclass.New("astits: packet must start with a sync byte", ErrInvalidData)
 	ErrShortPacket                  = errclass.New("astits: packet too short", ErrInvalidData)
+	ErrTransportError               = errclass.New("astits: transport error indicator set", ErrInvalidData)
+	// ErrAdaptationFieldOverflow reports an adaptation field that does not fit
+	// a packet (more than MaxAdaptationFieldLength bytes after the length byte).
+	ErrAdaptationFieldOverflow = errclass.New("astits: adaptation field does not fit a packet", ErrInvalidData)
 )
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/k-danil/go-astits/v2/internal/util"
 )
@@ -48,6 +49,10 @@ const (
 	M2TSPacketSize = 192 // 4-byte TP_extra_header prefix + 188
 	RSPacketSize   = 204 // 188 + 16-byte Reed-Solomon parity suffix
 	HeaderSize     = 4
+	// MaxAdaptationFieldLength is the largest adaptation_field_length: an
+	// adaptation-field-only packet, 1 length byte plus 183 filling the 184
+	// bytes after the header.
+	MaxAdaptationFieldLength = PacketSize - HeaderSize - 1
 )
 
 const syncByte byte = '\x47'
@@ -69,11 +74,19 @@ type Packet struct {
 	AdaptationField *PacketAdaptationField `json:"adaptation_field"`
 	Payload         []byte                 `json:"data_byte"` // This is only the payload content
 	Prefix          []byte                 `json:"_prefix"`   // the 192-byte M2TS TP_extra_header (4 bytes); empty otherwise. See ArrivalTimeStamp.
+	Suffix          []byte                 `json:"_suffix"`   // the 204-byte Reed-Solomon parity trailer (16 bytes); empty otherwise
 
 	// Offset is the byte offset of the raw packet start (including any M2TS prefix)
 	// within the demuxed stream, counted from the Demuxer's first packet. Packets
 	// dropped by a PacketSkipper advance it too, so it stays a valid byte map.
 	Offset int64 `json:"_offset"`
+	// Index is the number of packets read before this one, counted like
+	// Offset from the Demuxer's first packet (from PacketBufferConfig.StartIndex).
+	Index int64 `json:"_index"`
+
+	// CaptureTime is the time the packet was read, from the clock of
+	// PacketBufferConfig; zero without one.
+	CaptureTime time.Time `json:"_capture_time,omitzero"`
 }
 
 // UpdateHeader re-serializes Header into the packet bytes; call it after
@@ -190,6 +203,19 @@ func (p *Packet) SetAdaptationField(src *PacketAdaptationField) {
 	p.AdaptationField = &p.af
 }
 
+// CopyFrom makes p an owned copy of src, a packet read by a PacketBuffer in
+// either mode: the on-wire bytes are copied into p and parsed again, so p
+// outlives the buffer src views.
+func (p *Packet) CopyFrom(src *Packet) {
+	n := copy(p.bs[:], src.raw)
+	p.Reset()
+	p.raw = p.bs[:n]
+	_, _ = p.parse(p.raw, nil, nil)
+	p.Offset = src.Offset
+	p.Index = src.Index
+	p.CaptureTime = src.CaptureTime
+}
+
 // Close returns the packet to the pool. Do not use the packet afterwards.
 func (p *Packet) Close() {
 	poolOfPacket.Put(p)
@@ -203,7 +229,10 @@ func (p *Packet) Reset() {
 	p.AdaptationField = nil
 	p.Payload = nil
 	p.Prefix = nil
+	p.Suffix = nil
 	p.Offset = 0
+	p.Index = 0
+	p.CaptureTime = time.Time{}
 }
 
 // parse parses a packet from bs. Direct slice parsing: no BytesIterator on the hot
@@ -218,10 +247,13 @@ func (p *Packet) parse(bs []byte, s PacketSkipper, keep *PIDSet) (skip bool, err
 	// Any other extra bytes (e.g. the 204-byte Reed-Solomon parity) are a
 	// trailing suffix and the TS packet starts at bs[0].
 	prefixLen := 0
-	p.Prefix = nil
-	if len(bs) == M2TSPacketSize {
+	p.Prefix, p.Suffix = nil, nil
+	switch len(bs) {
+	case M2TSPacketSize:
 		prefixLen = M2TSPacketSize - PacketSize
 		p.Prefix = bs[:prefixLen]
+	case RSPacketSize:
+		p.Suffix = bs[PacketSize:]
 	}
 
 	// One big-endian 32-bit load covers the sync byte (top) and the 3 header bytes.
@@ -508,16 +540,18 @@ func (ph *PacketHeader) putBytes(bb []byte) {
 	binary.BigEndian.PutUint32(bb[:], val)
 }
 
+// CalcLength returns the value of the adaptation_field_length field: the
+// size without the length byte itself. It is not capped; Put rejects anything
+// above MaxAdaptationFieldLength.
 func (af *PacketAdaptationField) CalcLength() int {
-	var length uint8
-	length++
-	length += PCRSize * util.B2U(af.HasPCR)
-	length += PCRSize * util.B2U(af.HasOPCR)
-	length += util.B2U(af.HasSplicingCountdown)
-	length += (1 + uint8(len(af.TransportPrivateData))) * util.B2U(af.HasTransportPrivateData)
-	length += (1 + af.AdaptationExtensionField.calcLength()) * util.B2U(af.HasAdaptationExtensionField)
-	length += af.StuffingLength
-	return int(length)
+	length := 1
+	length += PCRSize * int(util.B2U(af.HasPCR))
+	length += PCRSize * int(util.B2U(af.HasOPCR))
+	length += int(util.B2U(af.HasSplicingCountdown))
+	length += (1 + len(af.TransportPrivateData)) * int(util.B2U(af.HasTransportPrivateData))
+	length += (1 + int(af.AdaptationExtensionField.calcLength())) * int(util.B2U(af.HasAdaptationExtensionField))
+	length += int(af.StuffingLength)
+	return length
 }
 
 // Put serializes the adaptation field directly into bs, mirroring the wire
@@ -529,6 +563,9 @@ func (af *PacketAdaptationField) Put(bs []byte) (n int, err error) {
 	}
 
 	length := af.CalcLength()
+	if length > MaxAdaptationFieldLength {
+		return 0, fmt.Errorf("astits: adaptation field length %d exceeds %d: %w", length, MaxAdaptationFieldLength, ErrAdaptationFieldOverflow)
+	}
 	if length+1 > len(bs) {
 		return 0, ErrShortPacket
 	}
@@ -560,7 +597,8 @@ func (af *PacketAdaptationField) Put(bs []byte) (n int, err error) {
 	}
 
 	if af.HasTransportPrivateData {
-		bs[n] = af.TransportPrivateDataLength
+		// the length is that of the data written, whatever the field says
+		bs[n] = uint8(len(af.TransportPrivateData))
 		n++
 		n += copy(bs[n:], af.TransportPrivateData)
 	}
@@ -592,6 +630,11 @@ func (afe *PacketAdaptationExtensionField) calcLength() (length uint8) {
 }
 
 func (afe *PacketAdaptationExtensionField) putBytes(bs []byte) (n int) {
+	if afe == nil {
+		// flagged without a field: an empty one
+		bs[0] = 0
+		return 1
+	}
 	bs[0] = afe.calcLength()
 	bs[1] = util.B2U(afe.HasLegalTimeWindow)<<7 | util.B2U(afe.HasPiecewiseRate)<<6 | util.B2U(afe.HasSeamlessSplice)<<5 | util.B2U(!afe.HasAFDescriptors)<<4 | 0x0f
 	n = 2
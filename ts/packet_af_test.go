@@ -61,3 +61,35 @@ func TestPacketEmbeddedAFReuse(t *testing.T) {
 	assert.False(t, p.AdaptationField.HasPCR)
 	assert.False(t, p.AdaptationField.RandomAccessIndicator)
 }
+
+// An adaptation-field-only packet fills all 184 bytes after the header; one
+// byte more no longer fits and must be rejected instead of truncated.
+func TestPacketAdaptationFieldMaxLength(t *testing.T) {
+	af := PacketAdaptationField{HasPCR: true, PCR: NewClockReference(1, 2)}
+	af.StuffingLength = uint8(MaxAdaptationFieldLength - af.CalcLength())
+	require.Equal(t, MaxAdaptationFieldLength, af.CalcLength())
+
+	p := Packet{Header: PacketHeader{HasAdaptationField: true, PID: 0x100}}
+	p.SetAdaptationField(&af)
+	bs := make([]byte, PacketSize)
+	n, err := p.Put(bs)
+	require.NoError(t, err)
+	assert.Equal(t, PacketSize, n)
+	assert.Equal(t, byte(MaxAdaptationFieldLength), bs[HeaderSize])
+
+	parsed := NewPacket()
+	defer parsed.Close()
+	parseInto(t, parsed, bs)
+	assert.Equal(t, af.PCR, parsed.AdaptationField.PCR)
+	assert.Empty(t, parsed.Payload)
+
+	af.StuffingLength++
+	p.SetAdaptationField(&af)
+	_, err = p.Put(bs)
+	assert.ErrorIs(t, err, ErrAdaptationFieldOverflow)
+	assert.ErrorIs(t, err, ErrInvalidData)
+
+	// Private data past 255 bytes used to wrap the uint8 length silently.
+	big := PacketAdaptationField{HasTransportPrivateData: true, TransportPrivateData: make([]byte, 300)}
+	assert.Equal(t, 302, big.CalcLength())
+}
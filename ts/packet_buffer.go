@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 )
 
 // packetBatch is the zero-copy read buffer: packets are returned as views into bs,
@@ -117,10 +118,28 @@ type PacketBufferConfig struct {
 	ZeroCopyBatch uint
 	SyncLock      bool
 	ResyncLimit   uint
+	// Redetect, under SyncLock, re-runs packet size detection when a resync
+	// scan window holds no boundary at the current size, so a capture that
+	// switches between 188, 192 and 204 byte packets keeps going.
+	Redetect bool
+	// StartOffset is the reader position the first packet is read at, the base
+	// of Packet.Offset (e.g. after SeekSync).
+	StartOffset int64
+	// StartIndex is the Packet.Index of the first packet read.
+	StartIndex int64
 	// OnRecover, when set, is called for each recovered damage event (sync loss,
 	// dropped packet); nil keeps the silent fast path. Only invoked on the cold
 	// error branches, never on a clean read.
 	OnRecover func(RecoverableError)
+	// Clock, when set, stamps Packet.CaptureTime on every packet read (e.g.
+	// time.Now); nil leaves it zero and the read path clock-free.
+	Clock func() time.Time
+	// Datagram reads the input as datagrams of whole packets, as delivered by
+	// a UDP socket: every Read is expected to return one datagram (at most
+	// MaxDatagramSize bytes), whose packet size is told by its length and sync
+	// bytes unless PacketSize pins it. It replaces the size detection and
+	// SyncLock; in zero-copy mode packets view the datagram buffer.
+	Datagram bool
 }
 
 // PacketBuffer represents a packet buffer
@@ -132,13 +151,18 @@ type PacketBuffer struct {
 	r              io.Reader
 	peeker         Peeker // non-nil ⇒ sync-lock mode
 	pos            int64
+	index          int64        // Packet.Index of the next packet
 	batch          *packetBatch // nil = copy mode
 	zeroCopy       bool
 	skipErrCounter uint
 	skipErrLimit   uint
 	resyncCounter  uint
 	resyncLimit    uint // 0 = unlimited
+	redetect       bool
 	onRecover      func(RecoverableError)
+	clock          func() time.Time
+	dgram          *datagram // non-nil ⇒ datagram mode
+	fixedSize      uint      // PacketSize of the config, datagram mode
 }
 
 // NewPacketBuffer creates a new packet buffer
@@ -151,7 +175,16 @@ func NewPacketBuffer(r io.Reader, cfg PacketBufferConfig) (pb *PacketBuffer, err
 		zeroCopy:     cfg.ZeroCopyBatch > 0,
 		skipErrLimit: cfg.SkipErrLimit,
 		resyncLimit:  cfg.ResyncLimit,
+		redetect:     cfg.Redetect,
 		onRecover:    cfg.OnRecover,
+		clock:        cfg.Clock,
+		pos:          cfg.StartOffset,
+		index:        cfg.StartIndex,
+	}
+	if cfg.Datagram {
+		pb.dgram = &datagram{bs: make([]byte, MaxDatagramSize)}
+		pb.fixedSize = cfg.PacketSize
+		return
 	}
 	if cfg.SyncLock {
 		if err = pb.initSyncLock(cfg); err != nil {
@@ -225,13 +258,15 @@ func (pb *PacketBuffer) initSyncLock(cfg PacketBufferConfig) (err error) {
 		return fmt.Errorf("astits: discarding %d bytes to unit boundary failed: %w", off, err)
 	}
 	pb.pos += int64(off)
-	pb.packetSize = size
-	if size == M2TSPacketSize {
-		pb.prefixLen = M2TSPacketSize - PacketSize
-	}
+	pb.setPacketSize(size)
 	return
 }
 
+func (pb *PacketBuffer) setPacketSize(size uint) {
+	pb.packetSize = size
+	pb.prefixLen = syncOffset(size)
+}
+
 func asPeeker(r io.Reader, bufSize int) Peeker {
 	if p, ok := r.(Peeker); ok {
 		return p
@@ -384,6 +419,65 @@ func Rewind(r io.Reader) (n int64, err error) {
 	return
 }
 
+// ErrNotSeekable reports a seek on a reader that is not an io.Seeker.
+var ErrNotSeekable = errors.New("astits: reader is not seekable")
+
+// SeekSync seeks r to offset, then forward to the next unit boundary: the
+// first sync byte that recurs at the packet period (packetSize, or any
+// supported size when 0). It returns the boundary offset.
+func SeekSync(r io.Reader, offset int64, packetSize uint) (n int64, err error) {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return 0, ErrNotSeekable
+	}
+	if n, err = s.Seek(offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("astits: seeking to %d failed: %w", offset, err)
+	}
+
+	buf := make([]byte, syncScanWindow)
+	for {
+		var l int
+		if l, err = io.ReadFull(r, buf); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			if errors.Is(err, io.EOF) {
+				err = ErrNoMorePackets
+			}
+			return
+		}
+		err = nil
+		if _, off, found := scanUnit(buf[:l], packetSize); found {
+			n += int64(off)
+			break
+		}
+		if l < len(buf) {
+			// A lone last packet has no recurrence to confirm it: accept a sync
+			// byte that starts a packet ending exactly at the end of the stream.
+			if k := l - int(packetSize); packetSize != 0 && k >= 0 && buf[k+syncOffset(packetSize)] == syncByte {
+				n += int64(k)
+				break
+			}
+			return n, ErrNoMorePackets
+		}
+		// Keep the tail a boundary needing lookahead may start in.
+		n += int64(l - (autoDetectSyncs-1)*RSPacketSize - (M2TSPacketSize - PacketSize))
+		if _, err = s.Seek(n, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("astits: seeking to %d failed: %w", n, err)
+		}
+	}
+
+	if _, err = s.Seek(n, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("astits: seeking to %d failed: %w", n, err)
+	}
+	return
+}
+
+// syncOffset is the offset of the sync byte within a unit of packetSize.
+func syncOffset(packetSize uint) int {
+	if packetSize == M2TSPacketSize {
+		return M2TSPacketSize - PacketSize
+	}
+	return 0
+}
+
 func (pb *PacketBuffer) PacketSize() uint {
 	return pb.packetSize
 }
@@ -396,6 +490,9 @@ func (pb *PacketBuffer) Next(p *Packet) (err error) {
 	if pb.peeker != nil {
 		return pb.nextSync(p)
 	}
+	if pb.dgram != nil {
+		return pb.nextDatagram(p)
+	}
 
 	ps := int(pb.packetSize)
 	for {
@@ -418,8 +515,13 @@ func (pb *PacketBuffer) Next(p *Packet) (err error) {
 		}
 
 		p.Offset = pb.pos
+		p.Index = pb.index
+		pb.index++
 		pb.pos += int64(ps)
 		p.raw = bs
+		if pb.clock != nil {
+			p.CaptureTime = pb.clock()
+		}
 
 		var skip bool
 		if skip, err = p.parse(bs, pb.s, pb.keepPIDs); err != nil {
@@ -457,12 +559,17 @@ func (pb *PacketBuffer) nextSync(p *Packet) (err error) {
 		}
 
 		if buf[pb.prefixLen] != syncByte {
+			// Reported once the scan is over, so the event carries how many
+			// bytes were skipped — up to EOF if sync is never regained.
+			lost := pb.pos
+			err = pb.resync(ps)
 			if pb.onRecover != nil {
-				pb.onRecover(RecoverableError{Kind: ErrorKindSyncLoss, PID: PIDUnset, Offset: pb.pos, Err: ErrPacketMustStartWithASyncByte})
+				pb.onRecover(RecoverableError{Kind: ErrorKindSyncLoss, PID: PIDUnset, Offset: lost, Discarded: pb.pos - lost, Err: ErrPacketMustStartWithASyncByte})
 			}
-			if err = pb.resync(ps); err != nil {
+			if err != nil {
 				return err
 			}
+			ps = int(pb.packetSize) // Redetect may have switched it
 			continue
 		}
 
@@ -474,6 +581,11 @@ func (pb *PacketBuffer) nextSync(p *Packet) (err error) {
 		p.raw = pkt
 
 		p.Offset = pb.pos
+		p.Index = pb.index
+		pb.index++
+		if pb.clock != nil {
+			p.CaptureTime = pb.clock()
+		}
 		var skip bool
 		if skip, err = p.parse(pkt, pb.s, pb.keepPIDs); err != nil {
 			// Sync was present, so scanning won't help: drop the damaged packet.
@@ -531,19 +643,23 @@ func (pb *PacketBuffer) resync(ps int) (err error) {
 			return ErrNoMorePackets
 		}
 
-		if k := pb.scanResync(buf, ps); k >= 0 {
-			if _, err = pb.peeker.Discard(k); err != nil {
-				return fmt.Errorf("astits: resync discard failed: %w", err)
+		if pb.redetect {
+			// The capture may have switched formats: lock onto whichever size
+			// recurs first, the current one included.
+			if size, k, ok := scanUnit(buf, 0); ok {
+				if err = pb.skip(k); err != nil {
+					return err
+				}
+				pb.setPacketSize(size)
+				return nil
 			}
-			pb.pos += int64(k)
-			return nil
+		} else if k := pb.scanResync(buf, ps); k >= 0 {
+			return pb.skip(k)
 		}
 
-		drop := max(len(buf)-(autoDetectSyncs-1)*ps, 1)
-		if _, err = pb.peeker.Discard(drop); err != nil {
-			return fmt.Errorf("astits: resync discard failed: %w", err)
+		if err = pb.skip(max(len(buf)-(autoDetectSyncs-1)*ps, 1)); err != nil {
+			return err
 		}
-		pb.pos += int64(drop)
 
 		if pb.noteRecovery() {
 			return fmt.Errorf("astits: resync exhausted after %d events: %w", pb.resyncCounter, ErrInvalidData)
@@ -551,6 +667,15 @@ func (pb *PacketBuffer) resync(ps int) (err error) {
 	}
 }
 
+// skip discards n bytes ahead of a unit boundary.
+func (pb *PacketBuffer) skip(n int) (err error) {
+	if _, err = pb.peeker.Discard(n); err != nil {
+		return fmt.Errorf("astits: resync discard failed: %w", err)
+	}
+	pb.pos += int64(n)
+	return
+}
+
 // scanResync returns the offset (≥1) of the next unit boundary in buf, or -1.
 // Scanning small offsets first, where the full window confirms the period,
 // finds a strong lock before any weak one a payload 0x47 could form near the end.
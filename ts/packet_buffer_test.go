@@ -3,6 +3,7 @@ package ts
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -53,3 +54,33 @@ func TestAutoDetectPacketSize(t *testing.T) {
 		})
 	}
 }
+
+func TestPacketBufferClock(t *testing.T) {
+	stream := syncStream(0, PacketSize, 3, 3*PacketSize)
+	for _, cfg := range []PacketBufferConfig{
+		{PacketSize: PacketSize},
+		{PacketSize: PacketSize, ZeroCopyBatch: 2},
+		{SyncLock: true},
+	} {
+		var ticks int64
+		cfg.Clock = func() time.Time {
+			ticks++
+			return time.Unix(ticks, 0)
+		}
+		pb, err := NewPacketBuffer(bytes.NewReader(stream), cfg)
+		require.NoError(t, err)
+		var p Packet
+		for i := range 3 {
+			require.NoError(t, pb.Next(&p))
+			assert.Equal(t, time.Unix(int64(i+1), 0), p.CaptureTime)
+		}
+		assert.ErrorIs(t, pb.Next(&p), ErrNoMorePackets)
+	}
+
+	// No clock, no stamp
+	pb, err := NewPacketBuffer(bytes.NewReader(stream), PacketBufferConfig{PacketSize: PacketSize})
+	require.NoError(t, err)
+	var p Packet
+	require.NoError(t, pb.Next(&p))
+	assert.True(t, p.CaptureTime.IsZero())
+}
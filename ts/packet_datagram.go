@@ -0,0 +1,117 @@
+package ts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxDatagramSize is the largest datagram a datagram-aligned PacketBuffer
+// reads: the largest UDP payload.
+const MaxDatagramSize = 65535
+
+// datagram is the read buffer of datagram mode: the last datagram read, with
+// the packets not handed out yet from off on.
+type datagram struct {
+	bs  []byte
+	len int
+	off int
+}
+
+// nextDatagram fetches the next packet in datagram mode: every Read returns
+// one datagram of whole packets, which are sliced out in order. The packet
+// size is told by the datagram length and its sync bytes, or pinned by
+// PacketBufferConfig.PacketSize; a datagram matching no size is dropped as a
+// sync loss, a packet that does not parse as a packet drop.
+func (pb *PacketBuffer) nextDatagram(p *Packet) (err error) {
+	d := pb.dgram
+	for {
+		if d.off >= d.len {
+			if err = pb.readDatagram(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ps := int(pb.packetSize)
+		pkt := d.bs[d.off : d.off+ps]
+		d.off += ps
+		if !pb.zeroCopy {
+			copy(p.bs[:ps], pkt)
+			pkt = p.bs[:ps]
+		}
+		p.raw = pkt
+
+		p.Offset = pb.pos
+		p.Index = pb.index
+		pb.index++
+		pb.pos += int64(ps)
+		if pb.clock != nil {
+			p.CaptureTime = pb.clock()
+		}
+		var skip bool
+		if skip, err = p.parse(pkt, pb.s, pb.keepPIDs); err != nil {
+			if pb.onRecover != nil {
+				pb.onRecover(RecoverableError{Kind: ErrorKindPacketDrop, PID: PIDUnset, Offset: p.Offset, Err: err})
+			}
+			continue
+		}
+		if !skip {
+			return nil
+		}
+	}
+}
+
+// readDatagram reads the next datagram and sets the packet size it holds.
+func (pb *PacketBuffer) readDatagram() (err error) {
+	d := pb.dgram
+	d.off, d.len = 0, 0
+	n, err := pb.r.Read(d.bs)
+	if n == 0 {
+		switch {
+		case err == nil:
+			return nil
+		case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+			return ErrNoMorePackets
+		}
+		return fmt.Errorf("astits: reading datagram failed: %w", err)
+	}
+	// An error coming with data is returned again by the next Read
+
+	size := datagramPacketSize(d.bs[:n], pb.fixedSize)
+	if size == 0 {
+		if pb.onRecover != nil {
+			pb.onRecover(RecoverableError{Kind: ErrorKindSyncLoss, PID: PIDUnset, Offset: pb.pos, Discarded: int64(n), Err: ErrPacketMustStartWithASyncByte})
+		}
+		pb.pos += int64(n)
+		return nil
+	}
+	pb.setPacketSize(size)
+	d.len = n
+	return nil
+}
+
+// datagramPacketSize returns the packet size of which bs holds a whole
+// number with a sync byte each: size when set, else the first candidate that
+// fits; 0 when none does.
+func datagramPacketSize(bs []byte, size uint) uint {
+	for _, c := range syncCandidates {
+		if size != 0 && uint(c.size) != size {
+			continue
+		}
+		if len(bs)%c.size != 0 {
+			continue
+		}
+		ok := true
+		for off := c.sync; off < len(bs); off += c.size {
+			if bs[off] != syncByte {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return uint(c.size)
+		}
+	}
+	return 0
+}
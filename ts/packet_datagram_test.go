@@ -0,0 +1,90 @@
+package ts
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// datagramReader returns one datagram per Read, as a UDP socket does.
+type datagramReader struct{ dgrams [][]byte }
+
+func (r *datagramReader) Read(p []byte) (int, error) {
+	if len(r.dgrams) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.dgrams[0])
+	r.dgrams = r.dgrams[1:]
+	return n, nil
+}
+
+func TestPacketBufferDatagram(t *testing.T) {
+	m2ts := func(n int) (b []byte) {
+		for range n {
+			b = append(b, 0, 0, 0, 0)
+			b = append(b, syncPacket()...)
+		}
+		return
+	}
+	rs := func(n int) (b []byte) {
+		for range n {
+			b = append(b, syncPacket()...)
+			b = append(b, make([]byte, RSPacketSize-PacketSize)...)
+		}
+		return
+	}
+	garbage := bytes.Repeat([]byte{0x47, 0}, 100)
+	dgrams := [][]byte{
+		syncPackets(7),
+		m2ts(3),
+		garbage,
+		// 48 × 188 is also 47 × 192: the sync bytes tell them apart
+		syncPackets(48),
+		rs(2),
+	}
+
+	for _, zeroCopy := range []uint{0, 1} {
+		var errs []RecoverableError
+		pb, err := NewPacketBuffer(&datagramReader{dgrams: dgrams}, PacketBufferConfig{
+			Datagram:      true,
+			ZeroCopyBatch: zeroCopy,
+			OnRecover:     func(e RecoverableError) { errs = append(errs, e) },
+		})
+		require.NoError(t, err)
+		p := NewPacket()
+		var sizes []uint
+		var offsets []int64
+		for {
+			if err = pb.Next(p); err != nil {
+				break
+			}
+			sizes = append(sizes, pb.PacketSize())
+			offsets = append(offsets, p.Offset)
+			assert.Len(t, p.Raw(), int(pb.PacketSize()))
+		}
+		require.ErrorIs(t, err, ErrNoMorePackets)
+		require.Len(t, sizes, 7+3+48+2)
+		assert.Equal(t, uint(188), sizes[0])
+		assert.Equal(t, uint(192), sizes[7])
+		assert.Equal(t, uint(188), sizes[10])
+		assert.Equal(t, uint(204), sizes[58])
+		assert.Equal(t, int64(7*188+3*192+len(garbage)), offsets[10])
+		require.Len(t, errs, 1)
+		assert.Equal(t, ErrorKindSyncLoss, errs[0].Kind)
+		assert.Equal(t, int64(7*188+3*192), errs[0].Offset)
+		assert.Equal(t, int64(len(garbage)), errs[0].Discarded)
+	}
+
+	// A pinned size drops the datagrams of other sizes
+	pb, err := NewPacketBuffer(&datagramReader{dgrams: dgrams}, PacketBufferConfig{Datagram: true, PacketSize: M2TSPacketSize})
+	require.NoError(t, err)
+	p := NewPacket()
+	var n int
+	for pb.Next(p) == nil {
+		n++
+	}
+	assert.Equal(t, 3, n)
+}
@@ -102,6 +102,46 @@ func TestSyncLockResyncLimit(t *testing.T) {
 	assert.Len(t, offsets, 3)
 }
 
+func TestSyncLockRedetect(t *testing.T) {
+	// 188, then 192 (M2TS), then 204 (Reed-Solomon) byte packets back to back
+	var stream []byte
+	stream = append(stream, syncPackets(3)...)
+	for range 3 {
+		stream = append(stream, 0, 0, 0, 0)
+		stream = append(stream, syncPacket()...)
+	}
+	for range 3 {
+		stream = append(stream, syncPacket()...)
+		stream = append(stream, make([]byte, RSPacketSize-PacketSize)...)
+	}
+
+	// Without Redetect only stray 188-byte recurrences lock after the switch
+	offsets, err := drainSync(t, bytes.NewReader(stream), PacketBufferConfig{SyncLock: true})
+	require.ErrorIs(t, err, ErrNoMorePackets)
+	assert.Less(t, len(offsets), 9)
+
+	var discarded int64
+	pb, err := NewPacketBuffer(bytes.NewReader(stream), PacketBufferConfig{SyncLock: true, Redetect: true, OnRecover: func(e RecoverableError) {
+		discarded += e.Discarded
+	}})
+	require.NoError(t, err)
+	p := NewPacket()
+	var sizes []uint
+	offsets = offsets[:0]
+	for {
+		if err = pb.Next(p); err != nil {
+			break
+		}
+		offsets = append(offsets, p.Offset)
+		sizes = append(sizes, pb.PacketSize())
+	}
+	require.ErrorIs(t, err, ErrNoMorePackets)
+	assert.Equal(t, []int64{0, 188, 376, 564, 756, 948, 1140, 1344, 1548}, offsets)
+	assert.Equal(t, []uint{188, 188, 188, 192, 192, 192, 204, 204, 204}, sizes)
+	assert.Len(t, p.Suffix, RSPacketSize-PacketSize)
+	assert.Zero(t, discarded, "a clean format switch loses no bytes")
+}
+
 func TestSyncLockOffThenOffsetFails(t *testing.T) {
 	const junk = 12
 	stream := append(make([]byte, junk), syncPackets(5)...)
@@ -188,3 +228,32 @@ func TestAsPeeker(t *testing.T) {
 	_, ok := asPeeker(bytes.NewReader(nil), 1024).(*bufio.Reader)
 	assert.True(t, ok, "a plain reader is wrapped in bufio")
 }
+
+func TestSeekSync(t *testing.T) {
+	const junk = 2000 // several scan windows without a sync byte
+	stream := append(make([]byte, junk), syncPackets(5)...)
+	r := bytes.NewReader(stream)
+
+	n, err := SeekSync(r, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(junk), n)
+
+	n, err = SeekSync(r, junk+1, PacketSize)
+	require.NoError(t, err)
+	assert.Equal(t, int64(junk+188), n)
+
+	offsets, err := drainSync(t, r, PacketBufferConfig{PacketSize: PacketSize, StartOffset: n})
+	require.ErrorIs(t, err, ErrNoMorePackets)
+	assert.Equal(t, []int64{junk + 188, junk + 376, junk + 564, junk + 752}, offsets)
+
+	// The last packet is accepted without a recurrence when its size is known.
+	n, err = SeekSync(r, junk+565, PacketSize)
+	require.NoError(t, err)
+	assert.Equal(t, int64(junk+752), n)
+
+	_, err = SeekSync(r, junk+753, 0)
+	assert.ErrorIs(t, err, ErrNoMorePackets)
+
+	_, err = SeekSync(nonSeekableReader{r}, 0, 0)
+	assert.ErrorIs(t, err, ErrNotSeekable)
+}
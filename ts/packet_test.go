@@ -48,6 +48,7 @@ func TestParsePacket204(t *testing.T) {
 	assert.True(t, p.Header.PayloadUnitStartIndicator)
 	assert.Nil(t, p.Prefix)
 	assert.Len(t, p.Payload, PacketSize-HeaderSize) // 184; the 16 RS parity bytes are excluded
+	assert.Equal(t, bytes.Repeat([]byte{0xaa}, RSPacketSize-PacketSize), p.Suffix)
 }
 
 func packetShort(h PacketHeader, payload []byte) ([]byte, *Packet) {
@@ -86,6 +87,25 @@ func TestParsePacket(t *testing.T) {
 	assert.Equal(t, skip, true)
 }
 
+func TestPacketCopyFrom(t *testing.T) {
+	b, ep := packet(packetHeader, packetAdaptationField, []byte("payload"), true)
+	src := new(Packet)
+	_, err := src.parse(b, nil, nil)
+	assert.NoError(t, err)
+	src.raw = b
+	src.Offset = 376
+
+	p := new(Packet)
+	p.CopyFrom(src)
+	b[len(b)-1] = 0xff // the copy does not view the source
+	assert.Equal(t, ep.Header, p.Header)
+	assert.Equal(t, ep.AdaptationField, p.AdaptationField)
+	assert.Equal(t, ep.Payload, p.Payload)
+	assert.Equal(t, ep.Prefix, p.Prefix)
+	assert.Equal(t, int64(376), p.Offset)
+	assert.Len(t, p.Raw(), M2TSPacketSize)
+}
+
 //func TestPayloadOffset(t *testing.T) {
 //	assert.Equal(t, 3, payloadOffset(0, PacketHeader{}, nil))
 //	assert.Equal(t, 7, payloadOffset(1, PacketHeader{HasAdaptationField: true}, &PacketAdaptationField{Length: 2}))
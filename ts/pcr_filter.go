@@ -0,0 +1,181 @@
+package ts
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// pcrTicksPerSecond is the 27 MHz system clock rate.
+	pcrTicksPerSecond = 27000000
+	// pcrWrap is the PCR period in 27 MHz ticks: the 33-bit base times 300.
+	pcrWrap = (1 << 33) * 300
+)
+
+// PCRFilter smooths PCR jitter before the values feed pacing or compliance
+// measurements. Filter takes a PCR and the local arrival time of the packet
+// carrying it (any monotonic clock: wall time, byte offset over bitrate) and
+// returns the smoothed PCR. Filters work on the offset between the two clocks,
+// so a steady drift passes through and only the jitter is removed. Call Reset
+// on a signalled discontinuity.
+type PCRFilter interface {
+	Filter(pcr ClockReference, arrival time.Duration) ClockReference
+	Reset()
+}
+
+// NewPCRMedianFilter returns a filter that replaces each offset by the median
+// of the last n; isolated outliers never reach the output. n below 1 is
+// treated as 1.
+func NewPCRMedianFilter(n int) PCRFilter {
+	return &pcrMedianFilter{window: make([]int64, 0, max(n, 1))}
+}
+
+// NewPCREWMAFilter returns a filter that smooths offsets with an exponentially
+// weighted moving average; alpha in (0, 1] is the weight of the newest sample,
+// out-of-range values are treated as 1 (no smoothing).
+func NewPCREWMAFilter(alpha float64) PCRFilter {
+	if alpha <= 0 || alpha > 1 {
+		alpha = 1
+	}
+	return &pcrEWMAFilter{alpha: alpha}
+}
+
+// pcrClock unwraps PCRs onto a monotonic 27 MHz timeline and converts
+// between that timeline and clock offsets.
+type pcrClock struct {
+	last    int64
+	wraps   int64
+	started bool
+}
+
+func (c *pcrClock) offset(pcr ClockReference, arrival time.Duration) int64 {
+	ticks := int64(pcr.Ticks())
+	if c.started && ticks < c.last-pcrWrap/2 {
+		c.wraps++
+	} else if c.started && ticks > c.last+pcrWrap/2 && c.wraps > 0 {
+		c.wraps--
+	}
+	c.last, c.started = ticks, true
+	return ticks + c.wraps*pcrWrap - arrivalTicks(arrival)
+}
+
+func (c *pcrClock) clockReference(offset int64, arrival time.Duration) ClockReference {
+	ticks := (offset + arrivalTicks(arrival)) % pcrWrap
+	if ticks < 0 {
+		ticks += pcrWrap
+	}
+	return clockReferenceFromTicks(uint64(ticks))
+}
+
+func (c *pcrClock) reset() {
+	*c = pcrClock{}
+}
+
+func arrivalTicks(d time.Duration) int64 {
+	return int64(d/time.Second)*pcrTicksPerSecond + int64(d%time.Second)*pcrTicksPerSecond/int64(time.Second)
+}
+
+type pcrMedianFilter struct {
+	clock  pcrClock
+	window []int64
+	next   int
+	sorted []int64
+}
+
+func (f *pcrMedianFilter) Filter(pcr ClockReference, arrival time.Duration) ClockReference {
+	o := f.clock.offset(pcr, arrival)
+	if len(f.window) < cap(f.window) {
+		f.window = append(f.window, o)
+	} else {
+		f.window[f.next] = o
+		f.next = (f.next + 1) % len(f.window)
+	}
+	f.sorted = append(f.sorted[:0], f.window...)
+	slices.Sort(f.sorted)
+	return f.clock.clockReference(f.sorted[len(f.sorted)/2], arrival)
+}
+
+func (f *pcrMedianFilter) Reset() {
+	f.clock.reset()
+	f.window = f.window[:0]
+	f.next = 0
+}
+
+type pcrEWMAFilter struct {
+	clock   pcrClock
+	alpha   float64
+	offset  float64
+	started bool
+}
+
+func (f *pcrEWMAFilter) Filter(pcr ClockReference, arrival time.Duration) ClockReference {
+	o := float64(f.clock.offset(pcr, arrival))
+	if f.started {
+		f.offset += f.alpha * (o - f.offset)
+	} else {
+		f.offset, f.started = o, true
+	}
+	return f.clock.clockReference(int64(f.offset), arrival)
+}
+
+func (f *pcrEWMAFilter) Reset() {
+	f.clock.reset()
+	f.started = false
+}
+
+// pcrOffsetGap is the largest PCR step a PCROffsetFilter takes as continuous:
+// 1 s of 27 MHz ticks.
+const pcrOffsetGap = pcrTicksPerSecond
+
+// PCROffsetFilter drives a PCRFilter from the byte offsets of the packets
+// carrying the PCRs, for a stream handled without arrival times: the arrival
+// clock advances by the bytes since the previous PCR at the average rate of
+// the smoothed PCRs since the filter started, so jitter does not skew it. A PCR flagged as a discontinuity, or
+// stepping back or by over 1 s, restarts the filter and passes as is.
+type PCROffsetFilter struct {
+	filter  PCRFilter
+	arrival float64 // 27 MHz ticks
+	ticks   uint64  // of the last PCR
+	out     uint64  // of the last smoothed PCR
+	offset  int64   // of the last PCR
+	span    uint64  // ticks since the start
+	bytes   int64   // since the start
+	started bool
+}
+
+// NewPCROffsetFilter returns a PCROffsetFilter feeding f.
+func NewPCROffsetFilter(f PCRFilter) *PCROffsetFilter {
+	return &PCROffsetFilter{filter: f}
+}
+
+// Filter returns pcr, carried by the packet at byte offset, smoothed.
+func (f *PCROffsetFilter) Filter(pcr ClockReference, offset int64, discontinuity bool) ClockReference {
+	ticks := pcr.Ticks()
+	d := (ticks + pcrWrap - f.ticks) % pcrWrap
+	n := offset - f.offset
+	if !f.started || discontinuity || d > pcrOffsetGap || n <= 0 {
+		f.Reset()
+		f.ticks, f.offset, f.out, f.started = ticks, offset, ticks, true
+		return f.filter.Filter(pcr, 0)
+	}
+
+	// Before a rate is measured, the arrival clock follows the PCRs
+	if f.bytes > 0 && f.span > 0 {
+		f.arrival += float64(n) * float64(f.span) / float64(f.bytes)
+	} else {
+		f.arrival += float64(d)
+	}
+	f.ticks, f.offset = ticks, offset
+	pcr = f.filter.Filter(pcr, time.Duration(f.arrival*1000/27))
+	out := pcr.Ticks()
+	f.span += (out + pcrWrap - f.out) % pcrWrap
+	f.bytes += n
+	f.out = out
+	return pcr
+}
+
+// Reset restarts the filter.
+func (f *PCROffsetFilter) Reset() {
+	f.filter.Reset()
+	*f = PCROffsetFilter{filter: f.filter}
+}
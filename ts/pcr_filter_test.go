@@ -0,0 +1,80 @@
+package ts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pcrAt(d time.Duration) ClockReference {
+	return clockReferenceFromTicks(uint64(arrivalTicks(d)) % pcrWrap)
+}
+
+func TestPCRMedianFilter(t *testing.T) {
+	f := NewPCRMedianFilter(5)
+	start := 10 * time.Second
+	for n := range 20 {
+		arrival := time.Duration(n) * 40 * time.Millisecond
+		pcr := pcrAt(start + arrival)
+		if n == 10 {
+			pcr = pcrAt(start + arrival + 5*time.Millisecond) // outlier
+		}
+		assert.Equal(t, pcrAt(start+arrival), f.Filter(pcr, arrival), "sample %d", n)
+	}
+}
+
+func TestPCRMedianFilterWrap(t *testing.T) {
+	f := NewPCRMedianFilter(3)
+	start := time.Duration(pcrWrap/pcrTicksPerSecond)*time.Second - 100*time.Millisecond
+	for n := range 10 {
+		arrival := time.Duration(n) * 40 * time.Millisecond
+		assert.Equal(t, pcrAt(start+arrival), f.Filter(pcrAt(start+arrival), arrival), "sample %d", n)
+	}
+}
+
+func TestPCREWMAFilter(t *testing.T) {
+	f := NewPCREWMAFilter(0.1)
+	start := 10 * time.Second
+	var maxErr time.Duration
+	for n := range 100 {
+		arrival := time.Duration(n) * 40 * time.Millisecond
+		jitter := time.Millisecond
+		if n%2 == 1 {
+			jitter = -jitter
+		}
+		got := f.Filter(pcrAt(start+arrival+jitter), arrival)
+		if n < 50 {
+			continue
+		}
+		diff := got.Duration() - (start + arrival)
+		maxErr = max(maxErr, diff, -diff)
+	}
+	assert.Less(t, maxErr, 200*time.Microsecond)
+
+	f.Reset()
+	assert.Equal(t, pcrAt(time.Second), f.Filter(pcrAt(time.Second), 0))
+}
+
+func TestPCROffsetFilter(t *testing.T) {
+	f := NewPCROffsetFilter(NewPCRMedianFilter(5))
+	start := 10 * time.Second
+	const bytesPerPCR = 188 * 40
+	for n := range 20 {
+		want := start + time.Duration(n)*40*time.Millisecond
+		pcr := pcrAt(want)
+		if n == 10 {
+			pcr = pcrAt(want + 3*time.Millisecond) // outlier
+		}
+		// the rate measured from the PCRs takes in a share of the outlier
+		got := f.Filter(pcr, int64(n)*bytesPerPCR, false)
+		diff := got.Duration() - want
+		assert.Less(t, max(diff, -diff), 500*time.Microsecond, "sample %d", n)
+	}
+
+	// a jump restarts the filter
+	pcr := pcrAt(start)
+	assert.Equal(t, pcr, f.Filter(pcr, 20*bytesPerPCR, false))
+	pcr = pcrAt(start + time.Hour)
+	assert.Equal(t, pcr, f.Filter(pcr, 21*bytesPerPCR, true))
+}
@@ -5,5 +5,9 @@ const (
 	PIDPAT  uint16 = 0x0    // Program Association Table (PAT) contains a directory listing of all Program Map Tables.
 	PIDCAT  uint16 = 0x1    // Conditional Access Table (CAT) contains a directory listing of all ITU-T Rec. H.222 entitlement management message streams used by Program Map Tables.
 	PIDTSDT uint16 = 0x2    // Transport Stream Description Table (TSDT) contains descriptors related to the overall transport stream
+	PIDNIT  uint16 = 0x10   // Network Information Table (NIT) describes the DVB network and the transport streams it carries.
+	PIDSDT  uint16 = 0x11   // Service Description Table (SDT) and Bouquet Association Table (BAT) describe the services and bouquets of the network.
+	PIDEIT  uint16 = 0x12   // Event Information Table (EIT) carries the DVB present/following and schedule event information.
+	PIDTDT  uint16 = 0x14   // Time and Date Table (TDT) and Time Offset Table (TOT) carry the UTC time and the local time offsets.
 	PIDNull uint16 = 0x1fff // Null Packet (used for fixed bandwidth padding)
 )
@@ -38,3 +38,20 @@ func (s *PIDSet) Has(pid uint16) bool {
 func (s *PIDSet) Clear() {
 	*s = PIDSet{}
 }
+
+// AddRange adds the PIDs from first through last, both included.
+func (s *PIDSet) AddRange(first, last uint16) {
+	first &= pidMask
+	last &= pidMask
+	for pid := first; pid <= last; pid++ {
+		s.Add(pid)
+	}
+}
+
+// Invert turns the set into its complement, e.g. a deny-list into the
+// allow-list the demuxer checks.
+func (s *PIDSet) Invert() {
+	for i := range s {
+		s[i] = ^s[i]
+	}
+}
@@ -34,4 +34,18 @@ func TestPIDSet(t *testing.T) {
 	for pid := 0; pid < 8192; pid++ {
 		assert.False(t, s.Has(uint16(pid)))
 	}
+
+	s.AddRange(0x20, 0x2f)
+	assert.True(t, s.Has(0x20))
+	assert.True(t, s.Has(0x2f))
+	assert.False(t, s.Has(0x1f))
+	assert.False(t, s.Has(0x30))
+	s.AddRange(0x1ff0, 0x1fff)
+	assert.True(t, s.Has(0x1fff))
+
+	s.Invert()
+	assert.False(t, s.Has(0x20))
+	assert.False(t, s.Has(0x1fff))
+	assert.True(t, s.Has(0x1f))
+	assert.True(t, s.Has(0))
 }
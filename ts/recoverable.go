@@ -17,6 +17,7 @@ const (
 	ErrorKindCRC
 	ErrorKindPSI
 	ErrorKindPES
+	ErrorKindDescriptor
 )
 
 func (k ErrorKind) String() (s string) {
@@ -31,6 +32,8 @@ func (k ErrorKind) String() (s string) {
 		s = "psi"
 	case ErrorKindPES:
 		s = "pes"
+	case ErrorKindDescriptor:
+		s = "descriptor"
 	default:
 		s = "unknown"
 	}
@@ -43,8 +46,11 @@ func (k ErrorKind) String() (s string) {
 type RecoverableError struct {
 	Err    error
 	Offset int64 // best-effort: stream byte offset where the failure was detected, not the unit start
-	Kind   ErrorKind
-	PID    uint16
+	// Discarded is the number of bytes skipped to regain sync after an
+	// ErrorKindSyncLoss, counted from Offset; zero for other kinds.
+	Discarded int64
+	Kind      ErrorKind
+	PID       uint16
 }
 
 func (e *RecoverableError) Error() (s string) {
@@ -53,6 +59,9 @@ func (e *RecoverableError) Error() (s string) {
 	} else {
 		s = fmt.Sprintf("astits: recoverable %s error on PID %d at offset %d: %v", e.Kind, e.PID, e.Offset, e.Err)
 	}
+	if e.Discarded > 0 {
+		s += fmt.Sprintf(" (%d bytes discarded)", e.Discarded)
+	}
 	return
 }
 
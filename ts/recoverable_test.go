@@ -56,6 +56,29 @@ func TestSyncLockReportsRecoverable(t *testing.T) {
 	}
 }
 
+func TestSyncLossReportsDiscarded(t *testing.T) {
+	const torn = 100
+
+	var stream []byte
+	stream = append(stream, syncPackets(3)...)
+	stream = append(stream, make([]byte, torn)...)
+	stream = append(stream, syncPackets(3)...)
+
+	var got []RecoverableError
+	cfg := PacketBufferConfig{SyncLock: true, OnRecover: func(e RecoverableError) {
+		if e.Kind == ErrorKindSyncLoss {
+			got = append(got, e)
+		}
+	}}
+	offsets, err := drainSync(t, bytes.NewReader(stream), cfg)
+	require.ErrorIs(t, err, ErrNoMorePackets)
+	require.Len(t, got, 1)
+	assert.Equal(t, int64(3*PacketSize), got[0].Offset)
+	assert.Equal(t, int64(torn), got[0].Discarded)
+	assert.Contains(t, got[0].Error(), "(100 bytes discarded)")
+	assert.Len(t, offsets, 6)
+}
+
 func TestNoRecoverWithoutHook(t *testing.T) {
 	var stream []byte
 	stream = append(stream, syncPackets(3)...)